@@ -25,6 +25,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -34,13 +35,19 @@ import (
 	"github.com/shopspring/decimal"
 	"golang.org/x/oauth2"
 
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/alerting"
 	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
 	"github.com/berezovskyivalerii/adsieve/internal/adapter/googleads"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/meta"
 	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/webhooks"
 	"github.com/berezovskyivalerii/adsieve/internal/delivery/rest"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
 	"github.com/berezovskyivalerii/adsieve/internal/domain/ports"
 	"github.com/berezovskyivalerii/adsieve/internal/domain/service"
 	"github.com/berezovskyivalerii/adsieve/internal/shared/googleoauth"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/jwtkeys"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ratelimit"
 
 	_ "github.com/lib/pq"
 )
@@ -65,7 +72,13 @@ func main() {
 		log.Printf(".env not found: %v (ignored)", err)
 	}
 	dsn := mustEnv("DB_DSN")
-	jwtSecret := []byte(mustEnv("JWT_SECRET"))
+	// JWT_KEYS + JWT_ACTIVE_KID (RS256/EdDSA) или прежний JWT_SECRET (HS256)
+	jwtKeys, err := jwtkeys.FromEnv()
+	if err != nil {
+		log.Fatalf("jwt keys: %v", err)
+	}
+	accessTTL := durationEnv("JWT_ACCESS_TTL", 0)
+	refreshTTL := durationEnv("JWT_REFRESH_TTL", 0)
 	httpPort := getenv("PORT", "8080")
 	bcryptCost := atoi(getenv("BCRYPT_COST", ""))
 
@@ -81,6 +94,7 @@ func main() {
 	devToken := getenv("GOOGLE_DEVELOPER_TOKEN", "")
 	loginCID := getenv("GOOGLE_LOGIN_CUSTOMER_ID", "") // MCC без дефисов (можно пусто)
 	useStub := getenv("ADSIEVE_GOOGLE_STUB", "") == "1"
+	useMetaStub := getenv("ADSIEVE_META_STUB", "") == "1"
 
 	// ===== 2) DB =====
 	db, err := sql.Open("postgres", dsn)
@@ -96,28 +110,43 @@ func main() {
 
 	// ===== 3) Repos / Services =====
 	// repos
-	userAdsRepo := postgres.NewUserAdsRepo(db)
 	metricsRepo := postgres.NewMetricsRepo(db)
 	convRepo := postgres.NewConversionRepo(db)
 	clkRepo := postgres.NewClicksRepo(db)
 	userRepo := postgres.NewUserRepo(db)
 	tokenRepo := postgres.NewTokensRepo(db)
+	denylistRepo := postgres.NewAccessDenylistRepo(db)
 	adsRepo := postgres.NewAdsRepo(db)
 	stateRepo := postgres.NewOAuthStateRepo(db, 10*time.Minute)
-	aead, err := crypto.NewAEADEncryptor(mustEnv("ENC_KEY"))
+	// ENC_BACKEND: local (ENC_KEY / ENC_KEYS), file или vault — см. crypto.FromEnv
+	aead, err := crypto.FromEnv()
 	if err != nil {
 		log.Fatalf("encryptor: %v", err)
 	}
 	vaultRepo := postgres.NewTokenVault(db, aead)
+	capiRepo := postgres.NewMetaCAPIRepo(db, aead)
 	adAccRepo := postgres.NewGoogleAdAccountsRepo(db)
+	gconvRepo := postgres.NewGoogleConversionUploadsRepo(db)
 
 	// domain services
 	hasher := crypto.NewBcryptHasher(bcryptCost)
-	authSvc := service.NewAuthService(userRepo, tokenRepo, hasher, jwtSecret)
+	authSvc := service.NewAuthService(userRepo, tokenRepo, denylistRepo, hasher, jwtKeys).
+		WithTTL(accessTTL, refreshTTL)
+	// исходящие webhooks: sync.failed / needs_consent публикуются сразу, click/conversion — через
+	// outbox (cmd/cron/relay_outbox); доставка — cmd/cron/deliver_webhooks
+	webhooksSvc := service.NewWebhooks(postgres.NewWebhooksRepo(db, aead), webhooks.NewSender(), 0)
 	clkSvc := service.NewClickService(clkRepo)
-	convSvc := service.NewConversionService(clkRepo, convRepo)
-	metricsSvc := service.NewMetricsService(metricsRepo, userAdsRepo)
+	metaCAPISvc := service.NewMetaCAPI(meta.New(), capiRepo, 0)
+	convSvc := service.NewConversionService(clkRepo, convRepo).WithGoogleUploads().WithMetaQueue(metaCAPISvc)
+	metricsSvc := service.NewMetricsService(metricsRepo, adsRepo)
 	adsSvc := service.NewAdsService(adsRepo)
+	if useMetaStub {
+		// пауза/включение объявлений Meta без живого Marketing API
+		adsSvc.WithMutator("facebook", meta.NewStub())
+		log.Printf("Meta ads: using STUB client")
+	} else {
+		adsSvc.WithMutator("facebook", meta.New())
+	}
 
 	// ===== 4) Google Ads wiring (ports) =====
 	var (
@@ -125,13 +154,18 @@ func main() {
 		tokenVault  ports.TokenVault      = vaultRepo
 		gadsClient  ports.GoogleAdsClient
 		googleSync  rest.GoogleSync
+		googleConv  rest.GoogleConversions
+		googleDisc  rest.GoogleDisconnect
 	)
 
 	if useStub {
 		// Мок-клиент для локальных тестов без живого Google Ads
 		stub := googleads.NewStub(adAccRepo)
 		gadsClient = stub
-		googleSync = service.NewGoogleSync(stub, adAccRepo, userAdsRepo)
+		googleSync = service.NewGoogleSync(stub, adAccRepo).WithEvents(webhooksSvc)
+		googleConv = service.NewGoogleConversionUpload(stub, gconvRepo, adAccRepo, 0)
+		googleDisc = service.NewGoogleDisconnect(stub, vaultRepo, adAccRepo)
+		adsSvc.WithMutator("google", stub)
 		log.Printf("Google Ads: using STUB client")
 	} else {
 		// Прод-вариант: требуются oauthCfg и devToken
@@ -139,8 +173,9 @@ func main() {
 			log.Fatal("Google Ads not configured: set GOOGLE_CLIENT_ID/SECRET/REDIRECT_URL and GOOGLE_DEVELOPER_TOKEN")
 		}
 		// Источник токенов (refresh -> access) и HTTP-клиент Google Ads v21
-		ts := googleads.NewTokenSource(vaultRepo, oauthCfgWrapper{cfg: oauthCfg})
-		gads := googleads.New(devToken, loginCID, ts)
+		ts := googleads.NewTokenSource(vaultRepo, oauthCfgWrapper{cfg: oauthCfg}).WithOwners(adAccRepo).WithEvents(webhooksSvc)
+		// GOOGLE_LOGIN_CUSTOMER_ID — только запасной MCC для аккаунтов без login_customer_id
+		gads := googleads.New(devToken, loginCID, ts).WithLoginResolver(adAccRepo)
 
 		// Адаптер под порт (List / Link)
 		gadsClient = &gadsPortsAdapter{
@@ -149,7 +184,61 @@ func main() {
 			repo:  adAccRepo,
 		}
 		// Сервис синка (использует стример из конкретного клиента)
-		googleSync = service.NewGoogleSync(gads, adAccRepo, userAdsRepo)
+		googleSync = service.NewGoogleSync(gads, adAccRepo).WithEvents(webhooksSvc)
+		googleConv = service.NewGoogleConversionUpload(gads, gconvRepo, adAccRepo, 0)
+		googleDisc = service.NewGoogleDisconnect(googleads.NewRevoker(), vaultRepo, adAccRepo)
+		adsSvc.WithMutator("google", gads)
+	}
+
+	// sieve rules: CRUD и dry-run в API, прогон — cmd/cron/evaluate_rules
+	rulesSvc := service.NewRules(postgres.NewRulesRepo(db), adsSvc)
+
+	// алерты: список и каналы в API, детектор и доставка — cmd/cron/detect_anomalies
+	alertSenders := map[string]service.AlertSender{
+		entity.AlertChannelWebhook: alerting.NewWebhook(),
+		entity.AlertChannelSlack:   alerting.NewSlack(),
+	}
+	email := alerting.EmailFromEnv()
+	if email != nil {
+		alertSenders[entity.AlertChannelEmail] = email
+	}
+	alertsSvc := service.NewAlerts(postgres.NewAlertsRepo(db), alertSenders, 0)
+
+	// бюджеты: CRUD и пейсинг в API, алерты о перерасходе — cmd/cron/detect_anomalies
+	budgetsSvc := service.NewBudgets(postgres.NewBudgetsRepo(db))
+
+	// рабочие пространства и роли; приглашения письмом — если настроен SMTP
+	workspacesSvc := service.NewWorkspaces(postgres.NewWorkspacesRepo(db)).
+		WithInviteTTL(durationEnv("WORKSPACE_INVITE_TTL", 7*24*time.Hour))
+	if email != nil {
+		workspacesSvc.WithMailer(email, getenv("WORKSPACE_INVITE_URL", "http://localhost:5173/invite?token="))
+	}
+
+	// клиенты агентства; публичные ссылки на отчёт — только с SHARE_LINK_SECRET
+	clientsSvc := service.NewClients(postgres.NewClientsRepo(db), metricsSvc, []byte(os.Getenv("SHARE_LINK_SECRET"))).
+		WithLinkURL(getenv("SHARE_LINK_BASE_URL", "http://localhost:5173/shared/"))
+
+	// API-ключи (X-API-Key): в БД только sha256, сам ключ показывается один раз при создании
+	apiKeysSvc := service.NewAPIKeys(postgres.NewAPIKeysRepo(db))
+
+	// ограничение частоты: RATE_LIMIT_STORE=memory (на инстанс) | postgres (общий для инстансов) | off
+	var rateStore ratelimit.Store
+	switch getenv("RATE_LIMIT_STORE", "memory") {
+	case "memory":
+		rateStore = ratelimit.NewMemory()
+	case "postgres":
+		rateStore = postgres.NewRateLimitRepo(db)
+	case "off":
+	default:
+		log.Fatalf("RATE_LIMIT_STORE: unknown store %q", os.Getenv("RATE_LIMIT_STORE"))
+	}
+	rateLimits := rest.RateLimits{
+		Click:  limitEnv("RATE_LIMIT_CLICK", "600/1m"),
+		Auth:   limitEnv("RATE_LIMIT_AUTH", "10/1m"),
+		SignIn: limitEnv("RATE_LIMIT_SIGN_IN", "5/1m"),
+		Shared: limitEnv("RATE_LIMIT_SHARED", "60/1m"),
+		API:    limitEnv("RATE_LIMIT_API", "600/1m"),
+		APIKey: limitEnv("RATE_LIMIT_API_KEY", "6000/1m"),
 	}
 
 	// ===== 5) HTTP =====
@@ -164,11 +253,19 @@ func main() {
 		gadsClient,
 		oauthCfg,
 		googleSync,
+		googleConv,
+		metaCAPISvc,
+		googleDisc,
+		service.NewIntegrationStatus(postgres.NewIntegrationStatusRepo(db)),
 	)
 
+	// TRUSTED_PROXIES пусто — X-Forwarded-For не доверяем, IP клиента — адрес соединения
+	router := handler.WithAccessDenylist(denylistRepo).WithRules(rulesSvc).WithAlerts(alertsSvc).WithBudgets(budgetsSvc).WithWebhooks(webhooksSvc).WithWorkspaces(workspacesSvc).WithClients(clientsSvc).WithAPIKeys(apiKeysSvc).
+		WithRateLimits(rateStore, rateLimits).WithTrustedProxies(listEnv("TRUSTED_PROXIES")).Router(jwtKeys)
+
 	srv := &http.Server{
 		Addr:         ":" + httpPort,
-		Handler:      handler.Router(jwtSecret),
+		Handler:      router,
 		ReadTimeout:  5 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -196,27 +293,42 @@ func main() {
 
 type gadsPortsAdapter struct {
 	core interface {
-		ListAccessibleCustomers(ctx context.Context, userID int64) ([]string, string, error)
+		ListAccessibleAccounts(ctx context.Context, userID int64) ([]entity.GoogleCustomer, error)
 	}
 	vault interface {
 		LoadRefreshToken(ctx context.Context, userID int64) (googleUserID, refreshTokenEnc, scope string, err error)
 	}
 	repo interface {
-		LinkGoogleAccounts(ctx context.Context, userID int64, tokenOwnerGoogleUserID string, customerIDs []string) error
+		LinkGoogleAccounts(ctx context.Context, workspaceID, userID int64, links []entity.GoogleAccountLink) error
 	}
 }
 
-func (a *gadsPortsAdapter) ListAccessibleCustomers(ctx context.Context, userID int64) ([]string, error) {
-	ids, _, err := a.core.ListAccessibleCustomers(ctx, userID) // игнорируем googleUID
-	return ids, err
+func (a *gadsPortsAdapter) ListAccessibleAccounts(ctx context.Context, userID int64) ([]entity.GoogleCustomer, error) {
+	return a.core.ListAccessibleAccounts(ctx, userID)
 }
 
-func (a *gadsPortsAdapter) LinkAccounts(ctx context.Context, userID int64, customerIDs []string) error {
-	googleUID, _, _, err := a.vault.LoadRefreshToken(ctx, userID)
+func (a *gadsPortsAdapter) LinkAccounts(ctx context.Context, workspaceID, userID int64, customerIDs []string) error {
+	// запоминаем, через какой MCC и чьим Google-логином достижим каждый аккаунт —
+	// дальше клиент шлёт этот MCC в login-customer-id и берёт токен этого логина
+	tree, err := a.core.ListAccessibleAccounts(ctx, userID)
 	if err != nil {
 		return err
 	}
-	return a.repo.LinkGoogleAccounts(ctx, userID, googleUID, customerIDs)
+	links := googleads.LinksFor(tree, customerIDs)
+	defaultOwner := ""
+	for i := range links {
+		if links[i].TokenOwner != "" {
+			continue
+		}
+		// аккаунта нет в дереве — по старинке записываем на логин по умолчанию
+		if defaultOwner == "" {
+			if defaultOwner, _, _, err = a.vault.LoadRefreshToken(ctx, userID); err != nil {
+				return err
+			}
+		}
+		links[i].TokenOwner = defaultOwner
+	}
+	return a.repo.LinkGoogleAccounts(ctx, workspaceID, userID, links)
 }
 
 func (w oauthCfgWrapper) ExchangeRefresh(ctx context.Context, refresh string) (*oauth2.Token, error) {
@@ -246,3 +358,41 @@ func atoi(s string) int {
 	n, _ := strconv.Atoi(s)
 	return n
 }
+
+// limitEnv — лимит в формате ratelimit.Parse (напр. 600/1m; off — без ограничения)
+func limitEnv(key, def string) ratelimit.Limit {
+	v := getenv(key, def)
+	l, err := ratelimit.Parse(v)
+	if err != nil {
+		log.Fatalf("%s: %v", key, err)
+	}
+	return l
+}
+
+// listEnv — список через запятую; пустая переменная — nil
+func listEnv(key string) []string {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// durationEnv — длительность в формате time.ParseDuration (напр. 15m, 720h)
+func durationEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("environment variable %s: %v", key, err)
+	}
+	return d
+}
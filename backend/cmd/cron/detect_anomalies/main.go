@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/alerting"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/service"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/anomaly"
+)
+
+const lockKey int64 = 1008 // ключ для pg_advisory_lock
+
+func main() {
+	dsn := getenv("DB_DSN", "postgres://user:pass@db:5432/adsieve?sslmode=disable")
+	baselineDays := getenvInt("ALERT_BASELINE_DAYS", 14)
+	maxAttempts := getenvInt("ALERT_MAX_ATTEMPTS", 5)
+	batch := getenvInt("ALERT_BATCH", 200)
+
+	// ALERT_DATE=YYYY-MM-DD — проверить конкретный день (по умолчанию вчера, UTC)
+	var day time.Time
+	if v := os.Getenv("ALERT_DATE"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			log.Fatalf("bad ALERT_DATE: %v", err)
+		}
+		day = d
+	}
+	thresholds, err := anomaly.ParseThresholds(os.Getenv("ALERT_THRESHOLDS"))
+	if err != nil {
+		log.Fatalf("bad ALERT_THRESHOLDS: %v", err)
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	senders := map[string]service.AlertSender{
+		entity.AlertChannelWebhook: alerting.NewWebhook(),
+		entity.AlertChannelSlack:   alerting.NewSlack(),
+	}
+	if email := alerting.EmailFromEnv(); email != nil {
+		senders[entity.AlertChannelEmail] = email
+	}
+	svc := service.NewAlerts(postgres.NewAlertsRepo(db), senders, maxAttempts).
+		WithThresholds(thresholds).
+		WithBaselineDays(baselineDays)
+	budgets := service.NewBudgets(postgres.NewBudgetsRepo(db)).WithNotifier(svc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if err := withLock(ctx, db, lockKey, func(ctx context.Context) error {
+		st, err := svc.Detect(ctx, day)
+		if err != nil {
+			return err
+		}
+		log.Printf("detect: ads=%d alerts=%d", st.Ads, st.Alerts)
+
+		bs, err := budgets.CheckAll(ctx)
+		if err != nil {
+			return err
+		}
+		log.Printf("budgets: checked=%d over_pace=%d alerts=%d", bs.Budgets, bs.Over, bs.Alerts)
+
+		// доставка: новые алерты и повторы ранее неудачных
+		for {
+			st, err := svc.Deliver(ctx, batch)
+			if err != nil {
+				return err
+			}
+			log.Printf("deliver: sent=%d retried=%d failed=%d", st.Sent, st.Retried, st.Failed)
+			if st.Sent+st.Retried+st.Failed < batch {
+				return nil
+			}
+		}
+	}); err != nil {
+		log.Fatalf("detect_anomalies failed: %v", err)
+	}
+
+	log.Printf("detect_anomalies OK")
+}
+
+// --- helpers ---
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func withLock(ctx context.Context, db *sql.DB, key int64, fn func(context.Context) error) error {
+	if _, err := db.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		return err
+	}
+	defer db.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+	return fn(ctx)
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
+
+func getenvInt(k string, def int) int {
+	if v := os.Getenv(k); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
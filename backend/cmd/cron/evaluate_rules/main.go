@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+	"golang.org/x/oauth2"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/alerting"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/googleads"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/meta"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/webhooks"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/service"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/googleoauth"
+)
+
+const lockKey int64 = 1007 // ключ для pg_advisory_lock
+
+type oauthCfgWrapper struct{ cfg *oauth2.Config }
+
+func (w oauthCfgWrapper) ExchangeRefresh(ctx context.Context, refresh string) (*oauth2.Token, error) {
+	t := &oauth2.Token{RefreshToken: refresh}
+	return w.cfg.TokenSource(ctx, t).Token()
+}
+
+func main() {
+	dsn := getenv("DB_DSN", "postgres://user:pass@db:5432/adsieve?sslmode=disable")
+	dryRun := getenv("RULES_DRY_RUN", "") == "1" // только журнал совпадений, без действий
+
+	db, err := openDB(dsn)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	// пауза объявлений — тем же AdsService, что и POST /api/ads/{ad_id}/pause
+	ads := service.NewAdsService(postgres.NewAdsRepo(db))
+	if getenv("ADSIEVE_META_STUB", "") == "1" {
+		ads.WithMutator("facebook", meta.NewStub())
+	} else {
+		ads.WithMutator("facebook", meta.New())
+	}
+	gcfg := googleoauth.Load()
+	switch {
+	case getenv("ADSIEVE_GOOGLE_STUB", "") == "1":
+		ads.WithMutator("google", googleads.NewStub(postgres.NewGoogleAdAccountsRepo(db)))
+	case gcfg.ClientID != "" && gcfg.ClientSecret != "" && gcfg.DeveloperTok != "":
+		aead, err := crypto.FromEnv()
+		if err != nil {
+			log.Fatalf("encryptor: %v", err)
+		}
+		accounts := postgres.NewGoogleAdAccountsRepo(db)
+		ts := googleads.NewTokenSource(postgres.NewTokenVault(db, aead), oauthCfgWrapper{cfg: googleoauth.OAuth2(gcfg)}).WithOwners(accounts).
+			WithEvents(service.NewWebhooks(postgres.NewWebhooksRepo(db, aead), webhooks.NewSender(), 0))
+		ads.WithMutator("google", googleads.New(gcfg.DeveloperTok, gcfg.LoginCID, ts).WithLoginResolver(accounts))
+	default:
+		log.Printf("Google Ads not configured: pause actions on google ads will fail")
+	}
+
+	// notify — алерт вида rule, доставляется по каналам владельца сразу после прогона
+	senders := map[string]service.AlertSender{
+		entity.AlertChannelWebhook: alerting.NewWebhook(),
+		entity.AlertChannelSlack:   alerting.NewSlack(),
+	}
+	if email := alerting.EmailFromEnv(); email != nil {
+		senders[entity.AlertChannelEmail] = email
+	}
+	alerts := service.NewAlerts(postgres.NewAlertsRepo(db), senders, 0)
+
+	svc := service.NewRules(postgres.NewRulesRepo(db), ads).WithNotifier(alerts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	if err := withLock(ctx, db, lockKey, func(ctx context.Context) error {
+		st, err := svc.RunOnce(ctx, dryRun)
+		if err != nil {
+			return err
+		}
+		log.Printf("dry_run=%t rules=%d matched=%d applied=%d skipped=%d failed=%d",
+			dryRun, st.Rules, st.Matched, st.Applied, st.Skipped, st.Failed)
+		if dryRun {
+			return nil
+		}
+		ds, err := alerts.Deliver(ctx, 200)
+		if err != nil {
+			return err
+		}
+		log.Printf("notifications: sent=%d retried=%d failed=%d", ds.Sent, ds.Retried, ds.Failed)
+		return nil
+	}); err != nil {
+		log.Fatalf("evaluate_rules failed: %v", err)
+	}
+
+	log.Printf("evaluate_rules OK")
+}
+
+// --- helpers ---
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func withLock(ctx context.Context, db *sql.DB, key int64, fn func(context.Context) error) error {
+	if _, err := db.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		return err
+	}
+	defer db.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+	return fn(ctx)
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/meta"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/service"
+)
+
+const lockKey int64 = 1005 // ключ для pg_advisory_lock
+
+func main() {
+	dsn := getenv("DB_DSN", "postgres://user:pass@db:5432/adsieve?sslmode=disable")
+	batch, _ := strconv.Atoi(getenv("CAPI_BATCH", "200"))
+	maxAttempts, _ := strconv.Atoi(getenv("CAPI_MAX_ATTEMPTS", "5"))
+	baseURL := getenv("META_GRAPH_BASE_URL", "") // локальный фейк Graph API
+
+	db, err := openDB(dsn)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	aead, err := crypto.FromEnv()
+	if err != nil {
+		log.Fatalf("encryptor: %v", err)
+	}
+	client := meta.New()
+	if baseURL != "" {
+		client.WithBaseURL(baseURL)
+	}
+	svc := service.NewMetaCAPI(client, postgres.NewMetaCAPIRepo(db, aead), maxAttempts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := withLock(ctx, db, lockKey, func(ctx context.Context) error {
+		st, err := svc.RunOnce(ctx, batch)
+		if err != nil {
+			return err
+		}
+		log.Printf("claimed=%d sent=%d retried=%d failed=%d", st.Claimed, st.Uploaded, st.Retried, st.Failed)
+		return nil
+	}); err != nil {
+		log.Fatalf("forward_meta_capi failed: %v", err)
+	}
+
+	log.Printf("forward_meta_capi OK")
+}
+
+// --- helpers ---
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func withLock(ctx context.Context, db *sql.DB, key int64, fn func(context.Context) error) error {
+	if _, err := db.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		return err
+	}
+	defer db.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+	return fn(ctx)
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
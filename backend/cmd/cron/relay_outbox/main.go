@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/eventsink"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/webhooks"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/service"
+)
+
+const lockKey int64 = 1010 // ключ для pg_advisory_lock
+
+func main() {
+	dsn := getenv("DB_DSN", "postgres://user:pass@db:5432/adsieve?sslmode=disable")
+	batch, _ := strconv.Atoi(getenv("OUTBOX_BATCH", "500"))
+	retentionDays, _ := strconv.Atoi(getenv("OUTBOX_RETENTION_DAYS", "7"))
+	maxAttempts, _ := strconv.Atoi(getenv("OUTBOX_MAX_ATTEMPTS", "10"))
+
+	db, err := openDB(dsn)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	// OUTBOX_SINKS=webhook,stdout,nats — событие опубликовано, когда его приняли все
+	var sinks []service.OutboxSink
+	for _, name := range strings.Split(getenv("OUTBOX_SINKS", "webhook"), ",") {
+		switch strings.TrimSpace(name) {
+		case "webhook":
+			aead, err := crypto.FromEnv()
+			if err != nil {
+				log.Fatalf("encryptor: %v", err)
+			}
+			wh := service.NewWebhooks(postgres.NewWebhooksRepo(db, aead), webhooks.NewSender(), 6)
+			sinks = append(sinks, wh.OutboxSink())
+		case "stdout":
+			sinks = append(sinks, eventsink.NewWriter(os.Stdout))
+		case "nats":
+			n, err := eventsink.NewNATS(getenv("NATS_URL", "nats://nats:4222"), getenv("OUTBOX_SUBJECT_PREFIX", "adsieve"))
+			if err != nil {
+				log.Fatalf("nats: %v", err)
+			}
+			defer n.Close()
+			sinks = append(sinks, n)
+		case "":
+		default:
+			log.Fatalf("unknown OUTBOX_SINKS entry %q", name)
+		}
+	}
+
+	relay := service.NewOutboxRelay(postgres.NewOutboxRepo(db), maxAttempts, sinks...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := withLock(ctx, db, lockKey, func(ctx context.Context) error {
+		for {
+			st, err := relay.RunOnce(ctx, batch)
+			if err != nil {
+				return err
+			}
+			log.Printf("published=%d failed=%d dead=%d", st.Published, st.Failed, st.Dead)
+			// за опубликованными головами могли открыться следующие события объявлений;
+			// ничего не опубликовано — остались только ждущие повтора, до следующего запуска
+			if st.Published+st.Dead == 0 {
+				break
+			}
+		}
+		n, err := relay.Purge(ctx, time.Duration(retentionDays)*24*time.Hour)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			log.Printf("purged=%d", n)
+		}
+		return nil
+	}); err != nil {
+		log.Fatalf("relay_outbox failed: %v", err)
+	}
+
+	log.Printf("relay_outbox OK")
+}
+
+// --- helpers ---
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func withLock(ctx context.Context, db *sql.DB, key int64, fn func(context.Context) error) error {
+	if _, err := db.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		return err
+	}
+	defer db.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+	return fn(ctx)
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
@@ -10,7 +10,7 @@ import (
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 const lockKey int64 = 1002 // ключ для pg_advisory_lock
@@ -58,7 +58,14 @@ func main() {
 			log.Printf("upsert insights: ad_id=%d date=%s spend=%.2f",
 				ad, day.Format("2006-01-02"), spend)
 		}
-		return nil
+		// отметка последнего синка для GET /integrations/status
+		_, err = db.ExecContext(ctx, `
+			UPDATE ad_accounts
+			SET last_synced_at = NOW(), last_sync_error = NULL, last_sync_error_at = NULL
+			WHERE platform = 'facebook'
+			  AND account_id IN (SELECT account_id FROM ads WHERE ad_id = ANY($1))
+		`, pq.Array(adIDs))
+		return err
 	}); err != nil {
 		log.Fatalf("sync_fb_insights failed: %v", err)
 	}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	_ "github.com/lib/pq"
+	"golang.org/x/oauth2"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/googleads"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/webhooks"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/service"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/googleoauth"
+)
+
+const lockKey int64 = 1004 // ключ для pg_advisory_lock
+
+type oauthCfgWrapper struct{ cfg *oauth2.Config }
+
+func (w oauthCfgWrapper) ExchangeRefresh(ctx context.Context, refresh string) (*oauth2.Token, error) {
+	t := &oauth2.Token{RefreshToken: refresh}
+	return w.cfg.TokenSource(ctx, t).Token()
+}
+
+func main() {
+	dsn := getenv("DB_DSN", "postgres://user:pass@db:5432/adsieve?sslmode=disable")
+	batch, _ := strconv.Atoi(getenv("UPLOAD_BATCH", "200"))
+	maxAttempts, _ := strconv.Atoi(getenv("UPLOAD_MAX_ATTEMPTS", "5"))
+	baseURL := getenv("GOOGLE_ADS_BASE_URL", "") // локальный фейк Google Ads API
+
+	gcfg := googleoauth.Load()
+	if gcfg.ClientID == "" || gcfg.ClientSecret == "" || gcfg.DeveloperTok == "" {
+		log.Fatalf("Google Ads not configured: set GOOGLE_CLIENT_ID/SECRET and GOOGLE_DEVELOPER_TOKEN")
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	aead, err := crypto.FromEnv()
+	if err != nil {
+		log.Fatalf("encryptor: %v", err)
+	}
+	vault := postgres.NewTokenVault(db, aead)
+	accounts := postgres.NewGoogleAdAccountsRepo(db)
+	ts := googleads.NewTokenSource(vault, oauthCfgWrapper{cfg: googleoauth.OAuth2(gcfg)}).WithOwners(accounts).
+		WithEvents(service.NewWebhooks(postgres.NewWebhooksRepo(db, aead), webhooks.NewSender(), 0))
+	gads := googleads.New(gcfg.DeveloperTok, gcfg.LoginCID, ts).WithLoginResolver(accounts)
+	if baseURL != "" {
+		gads.WithBaseURL(baseURL)
+	}
+
+	svc := service.NewGoogleConversionUpload(
+		gads,
+		postgres.NewGoogleConversionUploadsRepo(db),
+		accounts,
+		maxAttempts,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	if err := withLock(ctx, db, lockKey, func(ctx context.Context) error {
+		st, err := svc.RunOnce(ctx, batch)
+		if err != nil {
+			return err
+		}
+		log.Printf("claimed=%d uploaded=%d retried=%d failed=%d", st.Claimed, st.Uploaded, st.Retried, st.Failed)
+		return nil
+	}); err != nil {
+		log.Fatalf("upload_google_conversions failed: %v", err)
+	}
+
+	log.Printf("upload_google_conversions OK")
+}
+
+// --- helpers ---
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func withLock(ctx context.Context, db *sql.DB, key int64, fn func(context.Context) error) error {
+	if _, err := db.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		return err
+	}
+	defer db.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+	return fn(ctx)
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,133 @@
+package main
+
+// Перешифровка сохранённых токенов активным ключом: после ротации ENC_ACTIVE_KEY_ID или KEK
+// или при переводе записей в конверт (ENC_BACKEND=file|vault). Refresh-токены Google
+// заодно переводятся из v1 в v2 — с привязкой к (user_id, google_user_id); открытые секреты
+// webhook-подписок — в secret_enc с привязкой к subscription_id.
+// Идемпотентна: записи, уже зашифрованные активным ключом, пропускаются.
+//
+//	ENC_KEYS="k0:<old>,k1:<new>" ENC_ACTIVE_KEY_ID=k1 ./reencrypt -batch 500
+//	ENC_BACKEND=vault VAULT_ADDR=... VAULT_TRANSIT_KEY=adsieve ENC_KEY=<old> ./reencrypt
+//	ENC_BACKEND=file ENC_KEK_ID=f2 ENC_KEK_FILE=<new> ENC_KEK_PREVIOUS=f1:<old> ./reencrypt
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+)
+
+const lockKey int64 = 1006 // ключ для pg_advisory_lock
+
+func main() {
+	batch := flag.Int("batch", 500, "rows per batch")
+	pause := flag.Duration("pause", 100*time.Millisecond, "pause between batches")
+	flag.Parse()
+
+	dsn := getenv("DB_DSN", "postgres://user:pass@db:5432/adsieve?sslmode=disable")
+	db, err := openDB(dsn)
+	if err != nil {
+		log.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+
+	kr, err := crypto.FromEnv()
+	if err != nil {
+		log.Fatalf("keyring: %v", err)
+	}
+	re := postgres.NewReencryptor(db, kr)
+	log.Printf("re-encrypting under key %s", kr.ActiveKeyID())
+
+	ctx := context.Background()
+	if err := withLock(ctx, db, lockKey, func(ctx context.Context) error {
+		var total postgres.ReencryptStats
+		cur := postgres.GoogleTokenCursor{}
+		for {
+			var st postgres.ReencryptStats
+			if cur, st, err = re.GoogleTokensBatch(ctx, cur, *batch); err != nil {
+				return err
+			}
+			total.Scanned += st.Scanned
+			total.Rewritten += st.Rewritten
+			if st.Scanned < *batch {
+				break
+			}
+			time.Sleep(*pause)
+		}
+		log.Printf("google_user_tokens: scanned=%d rewritten=%d", total.Scanned, total.Rewritten)
+
+		total = postgres.ReencryptStats{}
+		var after int64
+		for {
+			var st postgres.ReencryptStats
+			if after, st, err = re.MetaSettingsBatch(ctx, after, *batch); err != nil {
+				return err
+			}
+			total.Scanned += st.Scanned
+			total.Rewritten += st.Rewritten
+			if st.Scanned < *batch {
+				break
+			}
+			time.Sleep(*pause)
+		}
+		log.Printf("meta_capi_settings: scanned=%d rewritten=%d", total.Scanned, total.Rewritten)
+
+		total = postgres.ReencryptStats{}
+		after = 0
+		for {
+			var st postgres.ReencryptStats
+			if after, st, err = re.WebhookSecretsBatch(ctx, after, *batch); err != nil {
+				return err
+			}
+			total.Scanned += st.Scanned
+			total.Rewritten += st.Rewritten
+			if st.Scanned < *batch {
+				break
+			}
+			time.Sleep(*pause)
+		}
+		log.Printf("webhook_subscriptions: scanned=%d rewritten=%d", total.Scanned, total.Rewritten)
+		return nil
+	}); err != nil {
+		log.Fatalf("reencrypt failed: %v", err)
+	}
+}
+
+func openDB(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(5)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func withLock(ctx context.Context, db *sql.DB, key int64, fn func(context.Context) error) error {
+	if _, err := db.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, key); err != nil {
+		return err
+	}
+	defer db.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, key)
+	return fn(ctx)
+}
+
+func getenv(k, def string) string {
+	if v := os.Getenv(k); v != "" {
+		return v
+	}
+	return def
+}
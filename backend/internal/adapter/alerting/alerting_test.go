@@ -0,0 +1,124 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/safehttp"
+)
+
+func testAlert() entity.Alert {
+	return entity.Alert{
+		AlertID: 5, AdID: 87, AdName: "Summer Sale", Kind: entity.AlertKindAnomaly,
+		Metric: "spend", Day: "2025-03-02", Value: 100, Baseline: 50, PctChange: 100, Direction: "up",
+		Message: `Spend of "Summer Sale" (87) on 2025-03-02 is up 100%`,
+	}
+}
+
+func TestWebhook_Send(t *testing.T) {
+	var got struct {
+		Alert entity.Alert `json:"alert"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, NewWebhook().WithPrivateNetworks().Send(context.Background(), srv.URL, testAlert()))
+	require.Equal(t, int64(5), got.Alert.AlertID)
+	require.Equal(t, "spend", got.Alert.Metric)
+	require.Equal(t, 100.0, got.Alert.PctChange)
+}
+
+func TestWebhook_Non2xxIsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := NewWebhook().WithPrivateNetworks().Send(context.Background(), srv.URL, testAlert())
+	require.ErrorContains(t, err, "502")
+	require.NotContains(t, err.Error(), "nope")
+}
+
+func TestWebhook_RejectsPrivateAddresses(t *testing.T) {
+	var hit bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+
+	for _, url := range []string{srv.URL, "http://169.254.169.254/latest/meta-data/"} {
+		require.ErrorIs(t, NewWebhook().Send(context.Background(), url, testAlert()), safehttp.ErrForbiddenAddress, url)
+		require.ErrorIs(t, NewSlack().Send(context.Background(), url, testAlert()), safehttp.ErrForbiddenAddress, url)
+	}
+	require.False(t, hit)
+}
+
+func TestWebhook_RedirectIsError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://vault:8200/v1/secret", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	err := NewWebhook().WithPrivateNetworks().Send(context.Background(), srv.URL, testAlert())
+	require.ErrorContains(t, err, "302")
+}
+
+func TestSlack_Send(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	require.NoError(t, NewSlack().WithPrivateNetworks().Send(context.Background(), srv.URL, testAlert()))
+	require.Contains(t, got["text"], `Spend of "Summer Sale"`)
+}
+
+func TestEmail_Send(t *testing.T) {
+	var (
+		gotTo  []string
+		gotMsg string
+	)
+	e := NewEmail("smtp.local:25", "alerts@adsieve.local", nil)
+	e.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		require.Equal(t, "smtp.local:25", addr)
+		require.Equal(t, "alerts@adsieve.local", from)
+		gotTo, gotMsg = to, string(msg)
+		return nil
+	}
+
+	require.NoError(t, e.Send(context.Background(), "owner@example.com", testAlert()))
+	require.Equal(t, []string{"owner@example.com"}, gotTo)
+	require.Contains(t, gotMsg, "Subject: [AdSieve] Summer Sale: spend\r\n")
+	require.True(t, strings.HasSuffix(gotMsg, testAlert().Message+"\r\n"))
+
+	require.Error(t, e.Send(context.Background(), "a@b.c\r\nBcc: x@y.z", testAlert()))
+}
+
+func TestEmail_SendInvitation(t *testing.T) {
+	var gotMsg string
+	e := NewEmail("smtp.local:25", "alerts@adsieve.local", nil)
+	e.send = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		require.Equal(t, []string{"new@example.com"}, to)
+		gotMsg = string(msg)
+		return nil
+	}
+
+	link := "https://app.adsieve.local/invite?token=abc"
+	require.NoError(t, e.SendInvitation(context.Background(), "new@example.com", link))
+	require.Contains(t, gotMsg, "Subject: [AdSieve] Invitation to a workspace\r\n")
+	require.Contains(t, gotMsg, link+"\r\n")
+
+	require.Error(t, e.SendInvitation(context.Background(), "a@b.c\r\nBcc: x@y.z", link))
+}
@@ -0,0 +1,66 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"os"
+	"strings"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// Email шлёт алерт письмом через SMTP.
+type Email struct {
+	addr string // host:port
+	from string
+	auth smtp.Auth
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewEmail(addr, from string, auth smtp.Auth) *Email {
+	return &Email{addr: addr, from: from, auth: auth, send: smtp.SendMail}
+}
+
+// EmailFromEnv — SMTP_ADDR (host:port), SMTP_FROM, SMTP_USER/SMTP_PASSWORD (PLAIN, необязательно).
+// Без SMTP_ADDR возвращает nil — канал email недоступен.
+func EmailFromEnv() *Email {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		return nil
+	}
+	from := os.Getenv("SMTP_FROM")
+	if from == "" {
+		from = "alerts@adsieve.local"
+	}
+	var auth smtp.Auth
+	if user := os.Getenv("SMTP_USER"); user != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", user, os.Getenv("SMTP_PASSWORD"), host)
+	}
+	return NewEmail(addr, from, auth)
+}
+
+func (e *Email) Send(ctx context.Context, target string, a entity.Alert) error {
+	if strings.ContainsAny(target, "\r\n") {
+		return fmt.Errorf("email: bad recipient %q", target)
+	}
+	subject := "[AdSieve] " + a.Metric
+	if a.AdName != "" {
+		subject = fmt.Sprintf("[AdSieve] %s: %s", a.AdName, a.Metric)
+	}
+	msg := "From: " + e.from + "\r\n" +
+		"To: " + target + "\r\n" +
+		"Subject: " + strings.NewReplacer("\r", " ", "\n", " ").Replace(subject) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		a.Message + "\r\n"
+	if err := e.send(e.addr, e.auth, e.from, []string{target}, []byte(msg)); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SendInvitation — письмо со ссылкой-приглашением в рабочее пространство (тот же SMTP, что и алерты).
+func (e *Email) SendInvitation(_ context.Context, to, acceptURL string) error {
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("email: bad recipient %q", to)
+	}
+	msg := "From: " + e.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: [AdSieve] Invitation to a workspace\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		"You have been invited to join a workspace on AdSieve.\r\n" +
+		"Sign in with this email and open the link to accept:\r\n\r\n" +
+		acceptURL + "\r\n"
+	if err := e.send(e.addr, e.auth, e.from, []string{to}, []byte(msg)); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
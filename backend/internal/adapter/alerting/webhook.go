@@ -0,0 +1,84 @@
+// Package alerting — каналы доставки алертов: webhook (JSON), Slack-совместимый
+// incoming webhook и email (SMTP).
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/safehttp"
+)
+
+// URL каналов задаёт пользователь, поэтому webhook и Slack ходят только на публичные
+// адреса и без редиректов (см. safehttp).
+const postTimeout = 10 * time.Second
+
+// Webhook шлёт алерт как есть: POST {"alert": {...}} на URL канала.
+type Webhook struct {
+	http *http.Client
+}
+
+func NewWebhook() *Webhook {
+	return &Webhook{http: safehttp.NewClient(postTimeout)}
+}
+
+// WithPrivateNetworks снимает фильтр адресов — для локальных получателей в разработке и тестах.
+func (w *Webhook) WithPrivateNetworks() *Webhook {
+	w.http = safehttp.NewLocalClient(postTimeout)
+	return w
+}
+
+func (w *Webhook) Send(ctx context.Context, target string, a entity.Alert) error {
+	return postJSON(ctx, w.http, target, struct {
+		Alert entity.Alert `json:"alert"`
+	}{a})
+}
+
+// Slack шлёт текст алерта в incoming webhook ({"text": ...}); формат понимают и Mattermost/Rocket.Chat.
+type Slack struct {
+	http *http.Client
+}
+
+func NewSlack() *Slack {
+	return &Slack{http: safehttp.NewClient(postTimeout)}
+}
+
+// WithPrivateNetworks снимает фильтр адресов — для локальных получателей в разработке и тестах.
+func (s *Slack) WithPrivateNetworks() *Slack {
+	s.http = safehttp.NewLocalClient(postTimeout)
+	return s
+}
+
+func (s *Slack) Send(ctx context.Context, target string, a entity.Alert) error {
+	return postJSON(ctx, s.http, target, map[string]string{"text": ":rotating_light: " + a.Message})
+}
+
+// postJSON — POST тела в JSON; любой ответ кроме 2xx (в т.ч. неисполненный редирект) —
+// ошибка с одним кодом, без тела ответа (доставка повторится).
+func postJSON(ctx context.Context, c *http.Client, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AdSieve-Alerts/1.0")
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("alert post: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alert post: HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
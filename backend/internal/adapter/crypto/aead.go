@@ -10,6 +10,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // AEADEncryptor реализует симметричное шифрование AES-256-GCM.
@@ -42,6 +43,44 @@ func NewAEADEncryptor(keyMaterial string) (*AEADEncryptor, error) {
 // EncryptString шифрует plain и возвращает base64url строку вида v1:<nonce+ciphertext>.
 // Nonce генерируется случайно (aead.NonceSize()).
 func (e *AEADEncryptor) EncryptString(_ context.Context, plain string) (string, error) {
+	payload, err := e.seal(plain, nil)
+	if err != nil {
+		return "", err
+	}
+	return "v1:" + payload, nil
+}
+
+// EncryptStringAAD шифрует plain с привязкой к aad (напр. владельцу записи): v2:<nonce+ciphertext>.
+// Расшифровать можно только с тем же aad — шифротекст, перенесённый в чужую строку, не откроется.
+func (e *AEADEncryptor) EncryptStringAAD(_ context.Context, plain string, aad []byte) (string, error) {
+	payload, err := e.seal(plain, aad)
+	if err != nil {
+		return "", err
+	}
+	return "v2:" + payload, nil
+}
+
+// DecryptString расшифровывает строку, сгенерированную EncryptString.
+func (e *AEADEncryptor) DecryptString(ctx context.Context, enc string) (string, error) {
+	if strings.HasPrefix(enc, "v2:") {
+		return "", errors.New("aead: bound ciphertext needs associated data")
+	}
+	return e.DecryptStringAAD(ctx, enc, nil)
+}
+
+// DecryptStringAAD расшифровывает v2 с данным aad; старый v1 (без привязки) — как есть.
+func (e *AEADEncryptor) DecryptStringAAD(_ context.Context, enc string, aad []byte) (string, error) {
+	switch {
+	case strings.HasPrefix(enc, "v1:"):
+		return e.open(enc[3:], nil)
+	case strings.HasPrefix(enc, "v2:"):
+		return e.open(enc[3:], aad)
+	default:
+		return "", errors.New("aead: bad prefix")
+	}
+}
+
+func (e *AEADEncryptor) seal(plain string, aad []byte) (string, error) {
 	if e == nil || e.aead == nil {
 		return "", errors.New("aead: not initialized")
 	}
@@ -49,26 +88,21 @@ func (e *AEADEncryptor) EncryptString(_ context.Context, plain string) (string,
 	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", fmt.Errorf("aead: nonce: %w", err)
 	}
-	ct := e.aead.Seal(nil, nonce, []byte(plain), nil)
+	ct := e.aead.Seal(nil, nonce, []byte(plain), aad)
 
 	out := make([]byte, 0, len(nonce)+len(ct))
 	out = append(out, nonce...)
 	out = append(out, ct...)
 
 	// base64 URL-safe без '='
-	return "v1:" + base64.RawURLEncoding.EncodeToString(out), nil
+	return base64.RawURLEncoding.EncodeToString(out), nil
 }
 
-// DecryptString расшифровывает строку, сгенерированную EncryptString.
-func (e *AEADEncryptor) DecryptString(_ context.Context, enc string) (string, error) {
+func (e *AEADEncryptor) open(payload string, aad []byte) (string, error) {
 	if e == nil || e.aead == nil {
 		return "", errors.New("aead: not initialized")
 	}
-	const prefix = "v1:"
-	if len(enc) < len(prefix) || enc[:len(prefix)] != prefix {
-		return "", errors.New("aead: bad prefix")
-	}
-	raw, err := base64.RawURLEncoding.DecodeString(enc[len(prefix):])
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
 	if err != nil {
 		return "", fmt.Errorf("aead: b64: %w", err)
 	}
@@ -77,7 +111,7 @@ func (e *AEADEncryptor) DecryptString(_ context.Context, enc string) (string, er
 		return "", errors.New("aead: short payload")
 	}
 	nonce, ct := raw[:ns], raw[ns:]
-	pt, err := e.aead.Open(nil, nonce, ct, nil)
+	pt, err := e.aead.Open(nil, nonce, ct, aad)
 	if err != nil {
 		return "", fmt.Errorf("aead: open: %w", err)
 	}
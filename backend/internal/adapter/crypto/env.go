@@ -0,0 +1,110 @@
+package crypto
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Encryptor — то, что нужно хранилищам секретов (postgres.Encryptor) и команде reencrypt.
+type Encryptor interface {
+	EncryptString(ctx context.Context, plain string) (string, error)
+	DecryptString(ctx context.Context, enc string) (string, error)
+	// с привязкой к владельцу записи (aad): формат v2/env2
+	EncryptStringAAD(ctx context.Context, plain string, aad []byte) (string, error)
+	DecryptStringAAD(ctx context.Context, enc string, aad []byte) (string, error)
+	NeedsReencrypt(enc string) bool
+	IsBound(enc string) bool
+	ActiveKeyID() string
+}
+
+// FromEnv выбирает бэкенд шифрования по ENC_BACKEND:
+//
+//	local (по умолчанию) — Keyring из ENC_KEY / ENC_KEYS + ENC_ACTIVE_KEY_ID;
+//	file  — конверт, KEK из файла ENC_KEK_FILE с id ENC_KEK_ID;
+//	        ENC_KEK_PREVIOUS="<id>:<path>,..." — прежние файловые KEK, только для чтения;
+//	vault — конверт, KEK в Vault transit: VAULT_ADDR, VAULT_TOKEN,
+//	        VAULT_TRANSIT_MOUNT (transit), VAULT_TRANSIT_KEY.
+//
+// Для конвертных бэкендов заданный ENC_KEY/ENC_KEYS используется только для чтения
+// старых записей; перевести их в конверт — командой reencrypt.
+//
+// ENC_REQUIRE_AAD=1 — строгий режим: записи, которые должны быть привязаны к владельцу,
+// в формате без привязки (v1/env1) не расшифровываются. Включать после reencrypt.
+func FromEnv() (Encryptor, error) {
+	strict := os.Getenv("ENC_REQUIRE_AAD") == "1"
+	var kek KEKProvider
+	switch b := os.Getenv("ENC_BACKEND"); b {
+	case "", "local":
+		kr, err := KeyringFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if strict {
+			kr.WithRequireAAD()
+		}
+		return kr, nil
+	case "file":
+		id := os.Getenv("ENC_KEK_ID")
+		if id == "" {
+			id = "file1"
+		}
+		f, err := NewFileKEK(id, os.Getenv("ENC_KEK_FILE"))
+		if err != nil {
+			return nil, err
+		}
+		kek = f
+	case "vault":
+		key := os.Getenv("VAULT_TRANSIT_KEY")
+		if os.Getenv("VAULT_ADDR") == "" || key == "" {
+			return nil, fmt.Errorf("vault kek: VAULT_ADDR and VAULT_TRANSIT_KEY are required")
+		}
+		kek = NewVaultTransitKEK(os.Getenv("VAULT_ADDR"), os.Getenv("VAULT_TOKEN"),
+			os.Getenv("VAULT_TRANSIT_MOUNT"), key)
+	default:
+		return nil, fmt.Errorf("unknown ENC_BACKEND %q", b)
+	}
+
+	env := NewEnvelopeEncryptor(kek)
+	prev, err := previousKEKsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	env.WithPrevious(prev...)
+	if os.Getenv("ENC_KEY") != "" || os.Getenv("ENC_KEYS") != "" {
+		legacy, err := KeyringFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		if strict {
+			legacy.WithRequireAAD()
+		}
+		env.WithLegacy(legacy)
+	}
+	if strict {
+		env.WithRequireAAD()
+	}
+	return env, nil
+}
+
+// previousKEKsFromEnv читает ENC_KEK_PREVIOUS="f1:/run/secrets/kek-f1,f0:/run/secrets/kek-f0".
+func previousKEKsFromEnv() ([]KEKProvider, error) {
+	var out []KEKProvider
+	for _, part := range strings.Split(os.Getenv("ENC_KEK_PREVIOUS"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, path, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("file kek: ENC_KEK_PREVIOUS entry must be <id>:<path>")
+		}
+		k, err := NewFileKEK(strings.TrimSpace(id), strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, nil
+}
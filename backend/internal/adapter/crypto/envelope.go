@@ -0,0 +1,190 @@
+package crypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// KEKProvider — внешний ключ шифрования ключей (KEK): оборачивает и разворачивает
+// одноразовые ключи данных. Сам KEK приложению не виден (файл, Vault transit, KMS).
+type KEKProvider interface {
+	// KeyID — идентификатор KEK, пишется в шифротекст (без ':')
+	KeyID() string
+	Wrap(ctx context.Context, dek []byte) (string, error)
+	Unwrap(ctx context.Context, wrapped string) ([]byte, error)
+}
+
+// Шифратор, который ещё умеет читать чужой формат (старые записи до перехода на конверт).
+type legacyDecryptor interface {
+	DecryptStringAAD(ctx context.Context, enc string, aad []byte) (string, error)
+}
+
+const (
+	envelopePrefix      = "env1:"
+	envelopeBoundPrefix = "env2:" // данные запечатаны с aad
+)
+
+// EnvelopeEncryptor — конвертное шифрование: на каждую запись свой случайный
+// AES-256-GCM ключ данных (DEK), который оборачивается KEK и хранится рядом с шифротекстом:
+//
+//	env1:<kek_id>:<base64url(wrapped DEK)>:<base64url(nonce+ciphertext)>
+//
+// env2 — тот же формат, но данные запечатаны с aad (см. EncryptStringAAD).
+//
+// Новые записи оборачиваются текущим KEK; прежние KEK (WithPrevious) только разворачивают
+// старые записи, пока reencrypt не переведёт их на текущий.
+// Записи в старом формате v1 расшифровываются через legacy (Keyring из ENC_KEY/ENC_KEYS).
+type EnvelopeEncryptor struct {
+	kek        KEKProvider
+	keks       map[string]KEKProvider // по KeyID, включая текущий
+	legacy     legacyDecryptor
+	requireAAD bool
+}
+
+func NewEnvelopeEncryptor(kek KEKProvider) *EnvelopeEncryptor {
+	return &EnvelopeEncryptor{kek: kek, keks: map[string]KEKProvider{kek.KeyID(): kek}}
+}
+
+// WithPrevious подключает прежние KEK для чтения записей, обёрнутых до ротации.
+// Текущий KEK не подменяется, даже если id совпадает.
+func (e *EnvelopeEncryptor) WithPrevious(keks ...KEKProvider) *EnvelopeEncryptor {
+	for _, k := range keks {
+		if _, ok := e.keks[k.KeyID()]; !ok {
+			e.keks[k.KeyID()] = k
+		}
+	}
+	return e
+}
+
+// WithLegacy подключает расшифровку записей, созданных до перехода на конверт.
+func (e *EnvelopeEncryptor) WithLegacy(d legacyDecryptor) *EnvelopeEncryptor {
+	e.legacy = d
+	return e
+}
+
+// WithRequireAAD — строгий режим: DecryptStringAAD с aad не читает env1
+// (v1 отклоняет legacy-кольцо, см. Keyring.WithRequireAAD).
+func (e *EnvelopeEncryptor) WithRequireAAD() *EnvelopeEncryptor {
+	e.requireAAD = true
+	return e
+}
+
+// ActiveKeyID — идентификатор KEK, которым оборачиваются новые записи.
+func (e *EnvelopeEncryptor) ActiveKeyID() string { return "env:" + e.kek.KeyID() }
+
+func (e *EnvelopeEncryptor) EncryptString(ctx context.Context, plain string) (string, error) {
+	return e.encrypt(ctx, envelopePrefix, plain, nil)
+}
+
+// EncryptStringAAD — конверт с привязкой данных к aad (env2).
+func (e *EnvelopeEncryptor) EncryptStringAAD(ctx context.Context, plain string, aad []byte) (string, error) {
+	return e.encrypt(ctx, envelopeBoundPrefix, plain, aad)
+}
+
+func (e *EnvelopeEncryptor) DecryptString(ctx context.Context, enc string) (string, error) {
+	if e.IsBound(enc) {
+		return "", errors.New("envelope: bound ciphertext needs associated data")
+	}
+	return e.DecryptStringAAD(ctx, enc, nil)
+}
+
+// DecryptStringAAD расшифровывает env2 с данным aad; env1 и старые форматы — без привязки.
+func (e *EnvelopeEncryptor) DecryptStringAAD(ctx context.Context, enc string, aad []byte) (string, error) {
+	rest, ok := strings.CutPrefix(enc, envelopeBoundPrefix)
+	if !ok {
+		if rest, ok = strings.CutPrefix(enc, envelopePrefix); !ok {
+			if e.legacy == nil {
+				return "", errors.New("envelope: bad prefix")
+			}
+			return e.legacy.DecryptStringAAD(ctx, enc, aad)
+		}
+		if e.requireAAD && aad != nil {
+			return "", ErrUnbound
+		}
+		aad = nil
+	}
+	parts := strings.Split(rest, ":")
+	if len(parts) != 3 {
+		return "", errors.New("envelope: malformed ciphertext")
+	}
+	kid, wrappedB64, payloadB64 := parts[0], parts[1], parts[2]
+	kek, ok := e.keks[kid]
+	if !ok {
+		return "", fmt.Errorf("envelope: unknown kek %q", kid)
+	}
+	wrapped, err := base64.RawURLEncoding.DecodeString(wrappedB64)
+	if err != nil {
+		return "", fmt.Errorf("envelope: b64: %w", err)
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payloadB64)
+	if err != nil {
+		return "", fmt.Errorf("envelope: b64: %w", err)
+	}
+	dek, err := kek.Unwrap(ctx, string(wrapped))
+	if err != nil {
+		return "", fmt.Errorf("envelope: unwrap dek: %w", err)
+	}
+	a, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	ns := a.NonceSize()
+	if len(raw) < ns {
+		return "", errors.New("envelope: short payload")
+	}
+	pt, err := a.Open(nil, raw[:ns], raw[ns:], aad)
+	if err != nil {
+		return "", fmt.Errorf("envelope: open: %w", err)
+	}
+	return string(pt), nil
+}
+
+// NeedsReencrypt — запись не в конверте текущего KEK (старый v1 или другой KEK).
+func (e *EnvelopeEncryptor) NeedsReencrypt(enc string) bool {
+	kid := e.kek.KeyID() + ":"
+	return !strings.HasPrefix(enc, envelopePrefix+kid) && !strings.HasPrefix(enc, envelopeBoundPrefix+kid)
+}
+
+// IsBound — запечатана ли запись с aad (env2).
+func (e *EnvelopeEncryptor) IsBound(enc string) bool {
+	return strings.HasPrefix(enc, envelopeBoundPrefix)
+}
+
+func (e *EnvelopeEncryptor) encrypt(ctx context.Context, prefix, plain string, aad []byte) (string, error) {
+	dek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", fmt.Errorf("envelope: dek: %w", err)
+	}
+	a, err := newGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, a.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("envelope: nonce: %w", err)
+	}
+	ct := a.Seal(nonce, nonce, []byte(plain), aad)
+
+	wrapped, err := e.kek.Wrap(ctx, dek)
+	if err != nil {
+		return "", fmt.Errorf("envelope: wrap dek: %w", err)
+	}
+	return prefix + e.kek.KeyID() + ":" +
+		base64.RawURLEncoding.EncodeToString([]byte(wrapped)) + ":" +
+		base64.RawURLEncoding.EncodeToString(ct), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("envelope: new cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,232 @@
+package crypto_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+)
+
+func newFileKEK(t *testing.T, id, key string) *crypto.FileKEK {
+	path := filepath.Join(t.TempDir(), "kek")
+	require.NoError(t, os.WriteFile(path, []byte(key+"\n"), 0o600))
+	kek, err := crypto.NewFileKEK(id, path)
+	require.NoError(t, err)
+	return kek
+}
+
+func TestEnvelope_FileKEKRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	env := crypto.NewEnvelopeEncryptor(newFileKEK(t, "f1", keyA))
+
+	a, err := env.EncryptString(ctx, "refresh")
+	require.NoError(t, err)
+	b, err := env.EncryptString(ctx, "refresh")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(a, "env1:f1:"))
+	require.NotEqual(t, a, b) // свой DEK и nonce на каждую запись
+	require.False(t, env.NeedsReencrypt(a))
+
+	pt, err := env.DecryptString(ctx, a)
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+
+	// другой KEK не развернёт чужой DEK
+	other := crypto.NewEnvelopeEncryptor(newFileKEK(t, "f1", keyB))
+	_, err = other.DecryptString(ctx, a)
+	require.Error(t, err)
+}
+
+func TestEnvelope_LegacyFallback(t *testing.T) {
+	ctx := context.Background()
+	ring, err := crypto.NewKeyring(map[string]string{"k0": keyA}, "k0")
+	require.NoError(t, err)
+	old, err := ring.EncryptString(ctx, "old-token")
+	require.NoError(t, err)
+
+	env := crypto.NewEnvelopeEncryptor(newFileKEK(t, "f1", keyB))
+	_, err = env.DecryptString(ctx, old)
+	require.Error(t, err)
+
+	env.WithLegacy(ring)
+	pt, err := env.DecryptString(ctx, old)
+	require.NoError(t, err)
+	require.Equal(t, "old-token", pt)
+	require.True(t, env.NeedsReencrypt(old))
+}
+
+// fakeTransit — минимальный transit API Vault: "шифрует" префиксом vault:v1:
+func fakeTransit(t *testing.T, token string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != token {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		var in map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&in))
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/adsieve":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{
+				"ciphertext": "vault:v1:" + in["plaintext"],
+			}})
+		case "/v1/transit/decrypt/adsieve":
+			_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]string{
+				"plaintext": strings.TrimPrefix(in["ciphertext"], "vault:v1:"),
+			}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestVaultTransitKEK(t *testing.T) {
+	srv := fakeTransit(t, "dev-root")
+	defer srv.Close()
+	ctx := context.Background()
+
+	kek := crypto.NewVaultTransitKEK(srv.URL, "dev-root", "", "adsieve")
+	wrapped, err := kek.Wrap(ctx, []byte("0123456789abcdef0123456789abcdef"))
+	require.NoError(t, err)
+	require.Equal(t, "vault:v1:"+base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")), wrapped)
+
+	env := crypto.NewEnvelopeEncryptor(kek)
+	enc, err := env.EncryptString(ctx, "refresh")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enc, "env1:vault-adsieve:"))
+	pt, err := env.DecryptString(ctx, enc)
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+
+	bad := crypto.NewVaultTransitKEK(srv.URL, "wrong", "transit", "adsieve")
+	_, err = bad.Wrap(ctx, []byte("x"))
+	require.ErrorContains(t, err, "status 403")
+}
+
+func TestFromEnv_FileBackend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kek")
+	require.NoError(t, os.WriteFile(path, []byte(keyB), 0o600))
+	t.Setenv("ENC_BACKEND", "file")
+	t.Setenv("ENC_KEK_FILE", path)
+	t.Setenv("ENC_KEK_ID", "f2")
+	t.Setenv("ENC_KEY", keyA)
+	t.Setenv("ENC_KEYS", "")
+
+	enc, err := crypto.FromEnv()
+	require.NoError(t, err)
+	require.Equal(t, "env:f2", enc.ActiveKeyID())
+
+	single, _ := crypto.NewAEADEncryptor(keyA)
+	legacy, _ := single.EncryptString(context.Background(), "x")
+	pt, err := enc.DecryptString(context.Background(), legacy)
+	require.NoError(t, err)
+	require.Equal(t, "x", pt)
+}
+
+func TestEnvelope_BoundCiphertext(t *testing.T) {
+	ctx := context.Background()
+	env := crypto.NewEnvelopeEncryptor(newFileKEK(t, "f1", keyA))
+
+	enc, err := env.EncryptStringAAD(ctx, "refresh", []byte("owner-1"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enc, "env2:f1:"))
+	require.True(t, env.IsBound(enc))
+	require.False(t, env.NeedsReencrypt(enc))
+
+	pt, err := env.DecryptStringAAD(ctx, enc, []byte("owner-1"))
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+	_, err = env.DecryptStringAAD(ctx, enc, []byte("owner-2"))
+	require.Error(t, err)
+}
+
+func TestEnvelope_RequireAAD_RejectsUnbound(t *testing.T) {
+	ctx := context.Background()
+	legacy, err := crypto.NewKeyring(map[string]string{"k0": keyB}, "k0")
+	require.NoError(t, err)
+	v1, err := legacy.EncryptString(ctx, "v1-token")
+	require.NoError(t, err)
+	env := crypto.NewEnvelopeEncryptor(newFileKEK(t, "f1", keyA)).WithLegacy(legacy.WithRequireAAD()).WithRequireAAD()
+	env1, err := env.EncryptString(ctx, "env1-token")
+	require.NoError(t, err)
+
+	_, err = env.DecryptStringAAD(ctx, env1, []byte("owner-1"))
+	require.ErrorIs(t, err, crypto.ErrUnbound)
+	_, err = env.DecryptStringAAD(ctx, v1, []byte("owner-1"))
+	require.ErrorIs(t, err, crypto.ErrUnbound)
+
+	bound, err := env.EncryptStringAAD(ctx, "refresh", []byte("owner-1"))
+	require.NoError(t, err)
+	pt, err := env.DecryptStringAAD(ctx, bound, []byte("owner-1"))
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+}
+
+func TestEnvelope_RotateFileKEK(t *testing.T) {
+	ctx := context.Background()
+	old := crypto.NewEnvelopeEncryptor(newFileKEK(t, "f1", keyA))
+	enc, err := old.EncryptStringAAD(ctx, "refresh", []byte("owner-1"))
+	require.NoError(t, err)
+
+	// новый файл с новым id, старый — только для чтения
+	rotated := crypto.NewEnvelopeEncryptor(newFileKEK(t, "f2", keyB)).WithPrevious(newFileKEK(t, "f1", keyA))
+	require.Equal(t, "env:f2", rotated.ActiveKeyID())
+	require.True(t, rotated.NeedsReencrypt(enc))
+	pt, err := rotated.DecryptStringAAD(ctx, enc, []byte("owner-1"))
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+
+	// reencrypt: запись переворачивается текущим KEK и больше не требует перешифровки
+	re, err := rotated.EncryptStringAAD(ctx, pt, []byte("owner-1"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(re, "env2:f2:"))
+	require.False(t, rotated.NeedsReencrypt(re))
+
+	// после reencrypt старый KEK можно убрать
+	fresh := crypto.NewEnvelopeEncryptor(newFileKEK(t, "f2", keyB))
+	pt, err = fresh.DecryptStringAAD(ctx, re, []byte("owner-1"))
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+	_, err = fresh.DecryptStringAAD(ctx, enc, []byte("owner-1"))
+	require.ErrorContains(t, err, `unknown kek "f1"`)
+}
+
+func TestFromEnv_PreviousFileKEKs(t *testing.T) {
+	dir := t.TempDir()
+	oldPath, newPath := filepath.Join(dir, "kek-f1"), filepath.Join(dir, "kek-f2")
+	require.NoError(t, os.WriteFile(oldPath, []byte(keyA), 0o600))
+	require.NoError(t, os.WriteFile(newPath, []byte(keyB), 0o600))
+	t.Setenv("ENC_BACKEND", "file")
+	t.Setenv("ENC_KEY", "")
+	t.Setenv("ENC_KEYS", "")
+
+	t.Setenv("ENC_KEK_ID", "f1")
+	t.Setenv("ENC_KEK_FILE", oldPath)
+	before, err := crypto.FromEnv()
+	require.NoError(t, err)
+	enc, err := before.EncryptString(context.Background(), "x")
+	require.NoError(t, err)
+
+	t.Setenv("ENC_KEK_ID", "f2")
+	t.Setenv("ENC_KEK_FILE", newPath)
+	t.Setenv("ENC_KEK_PREVIOUS", "f1:"+oldPath)
+	after, err := crypto.FromEnv()
+	require.NoError(t, err)
+	require.True(t, after.NeedsReencrypt(enc))
+	pt, err := after.DecryptString(context.Background(), enc)
+	require.NoError(t, err)
+	require.Equal(t, "x", pt)
+
+	t.Setenv("ENC_KEK_PREVIOUS", "f1")
+	_, err = crypto.FromEnv()
+	require.ErrorContains(t, err, "ENC_KEK_PREVIOUS")
+}
@@ -0,0 +1,47 @@
+package crypto
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// FileKEK — KEK из локального файла (32 байта в base64/hex/raw, как ENC_KEY).
+// Для dev-окружения и тестов; в проде — VaultTransitKEK.
+type FileKEK struct {
+	id   string
+	aead *AEADEncryptor
+}
+
+// NewFileKEK читает ключ из path. id попадает в шифротексты: при ротации новому файлу
+// дают новый id, а старый файл оставляют для чтения (ENC_KEK_PREVIOUS) до конца reencrypt.
+func NewFileKEK(id, path string) (*FileKEK, error) {
+	if id == "" || strings.Contains(id, ":") {
+		return nil, fmt.Errorf("file kek: bad key id %q", id)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("file kek: %w", err)
+	}
+	a, err := NewAEADEncryptor(strings.TrimSpace(string(b)))
+	if err != nil {
+		return nil, fmt.Errorf("file kek: %w", err)
+	}
+	return &FileKEK{id: id, aead: a}, nil
+}
+
+func (k *FileKEK) KeyID() string { return k.id }
+
+func (k *FileKEK) Wrap(ctx context.Context, dek []byte) (string, error) {
+	return k.aead.EncryptString(ctx, base64.RawStdEncoding.EncodeToString(dek))
+}
+
+func (k *FileKEK) Unwrap(ctx context.Context, wrapped string) ([]byte, error) {
+	s, err := k.aead.DecryptString(ctx, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	return base64.RawStdEncoding.DecodeString(s)
+}
@@ -0,0 +1,90 @@
+package crypto
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// VaultTransitKEK — KEK в HashiCorp Vault transit (или совместимом API, напр. OpenBao):
+// ключ не покидает Vault, DEK оборачивается через /v1/<mount>/encrypt/<key>.
+// Ротация KEK — средствами Vault (transit/keys/<key>/rotate); старые обёртки
+// вида vault:vN:... Vault разворачивает сам.
+type VaultTransitKEK struct {
+	http  *http.Client
+	addr  string
+	token string
+	mount string
+	key   string
+}
+
+func NewVaultTransitKEK(addr, token, mount, key string) *VaultTransitKEK {
+	if mount == "" {
+		mount = "transit"
+	}
+	return &VaultTransitKEK{
+		http:  &http.Client{Timeout: 10 * time.Second},
+		addr:  strings.TrimRight(addr, "/"),
+		token: token,
+		mount: strings.Trim(mount, "/"),
+		key:   key,
+	}
+}
+
+func (v *VaultTransitKEK) KeyID() string { return "vault-" + v.key }
+
+func (v *VaultTransitKEK) Wrap(ctx context.Context, dek []byte) (string, error) {
+	var out struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	in := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dek)}
+	if err := v.call(ctx, "encrypt", in, &out); err != nil {
+		return "", err
+	}
+	if out.Data.Ciphertext == "" {
+		return "", fmt.Errorf("vault transit: empty ciphertext")
+	}
+	return out.Data.Ciphertext, nil
+}
+
+func (v *VaultTransitKEK) Unwrap(ctx context.Context, wrapped string) ([]byte, error) {
+	var out struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := v.call(ctx, "decrypt", map[string]string{"ciphertext": wrapped}, &out); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(out.Data.Plaintext)
+}
+
+func (v *VaultTransitKEK) call(ctx context.Context, op string, in any, out any) error {
+	body, _ := json.Marshal(in)
+	url := fmt.Sprintf("%s/v1/%s/%s/%s", v.addr, v.mount, op, v.key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := v.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("vault transit %s: %w", op, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+		return fmt.Errorf("vault transit %s: status %d: %s", op, resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
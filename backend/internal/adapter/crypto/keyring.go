@@ -0,0 +1,170 @@
+package crypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// legacyKeyID — id, под которым в кольцо попадает одиночный ENC_KEY
+const legacyKeyID = "k0"
+
+// ErrUnbound — в строгом режиме (ENC_REQUIRE_AAD=1) запись без привязки к aad
+// там, где привязка ожидается: подмена шифротекста чужой записи не проходит.
+var ErrUnbound = errors.New("crypto: ciphertext is not bound to associated data")
+
+// Keyring — набор AES-256-GCM ключей с id, один из которых активный.
+// Шифрует активным ключом в формате v1:<key_id>:<nonce+ciphertext> (v2 — с привязкой к aad),
+// расшифровывает любым известным ключом. Старые шифротексты v1:<payload> без id
+// (от одиночного AEADEncryptor) пробуются всеми ключами кольца.
+type Keyring struct {
+	keys       map[string]*AEADEncryptor
+	activeID   string
+	requireAAD bool
+}
+
+// NewKeyring собирает кольцо из пар id → ключ (форматы ключа — как у NewAEADEncryptor).
+func NewKeyring(keys map[string]string, activeID string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring: no keys")
+	}
+	kr := &Keyring{keys: make(map[string]*AEADEncryptor, len(keys)), activeID: activeID}
+	for id, material := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("keyring: bad key id %q", id)
+		}
+		enc, err := NewAEADEncryptor(material)
+		if err != nil {
+			return nil, fmt.Errorf("keyring: key %s: %w", id, err)
+		}
+		kr.keys[id] = enc
+	}
+	if _, ok := kr.keys[activeID]; !ok {
+		return nil, fmt.Errorf("keyring: active key %q is not in the ring", activeID)
+	}
+	return kr, nil
+}
+
+// KeyringFromEnv читает ENC_KEYS="k1:<key>,k2:<key>" и ENC_ACTIVE_KEY_ID.
+// Без ENC_KEYS — кольцо из одного ENC_KEY с id k0 (прежняя конфигурация).
+// Если заданы оба, ENC_KEY добавляется в кольцо как k0, чтобы читать старые записи.
+func KeyringFromEnv() (*Keyring, error) {
+	keys := map[string]string{}
+	if legacy := os.Getenv("ENC_KEY"); legacy != "" {
+		keys[legacyKeyID] = legacy
+	}
+	for _, part := range strings.Split(os.Getenv("ENC_KEYS"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, material, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("keyring: ENC_KEYS entry must be <id>:<key>")
+		}
+		keys[strings.TrimSpace(id)] = strings.TrimSpace(material)
+	}
+	active := os.Getenv("ENC_ACTIVE_KEY_ID")
+	if active == "" {
+		active = legacyKeyID
+	}
+	return NewKeyring(keys, active)
+}
+
+// WithRequireAAD — строгий режим: DecryptStringAAD с aad не читает v1. Включать после того,
+// как reencrypt перевёл привязываемые записи в v2.
+func (k *Keyring) WithRequireAAD() *Keyring {
+	k.requireAAD = true
+	return k
+}
+
+// ActiveKeyID — id ключа, которым шифруются новые записи.
+func (k *Keyring) ActiveKeyID() string { return k.activeID }
+
+// EncryptString шифрует активным ключом: v1:<key_id>:<payload>.
+func (k *Keyring) EncryptString(ctx context.Context, plain string) (string, error) {
+	enc, err := k.keys[k.activeID].EncryptString(ctx, plain)
+	if err != nil {
+		return "", err
+	}
+	return "v1:" + k.activeID + ":" + strings.TrimPrefix(enc, "v1:"), nil
+}
+
+// EncryptStringAAD шифрует активным ключом с привязкой к aad: v2:<key_id>:<payload>.
+func (k *Keyring) EncryptStringAAD(ctx context.Context, plain string, aad []byte) (string, error) {
+	enc, err := k.keys[k.activeID].EncryptStringAAD(ctx, plain, aad)
+	if err != nil {
+		return "", err
+	}
+	return "v2:" + k.activeID + ":" + strings.TrimPrefix(enc, "v2:"), nil
+}
+
+// DecryptString расшифровывает v1:<key_id>:<payload> нужным ключом или
+// старый v1:<payload> — перебором ключей кольца.
+func (k *Keyring) DecryptString(ctx context.Context, enc string) (string, error) {
+	if strings.HasPrefix(enc, "v2:") {
+		return "", errors.New("keyring: bound ciphertext needs associated data")
+	}
+	return k.DecryptStringAAD(ctx, enc, nil)
+}
+
+// DecryptStringAAD — то же для v2 (с aad); v1 расшифровывается без привязки,
+// а в строгом режиме при непустом aad отклоняется.
+func (k *Keyring) DecryptStringAAD(ctx context.Context, enc string, aad []byte) (string, error) {
+	ver, id, payload, err := splitKeyID(enc)
+	if err != nil {
+		return "", err
+	}
+	if k.requireAAD && aad != nil && ver == "v1" {
+		return "", ErrUnbound
+	}
+	if id != "" {
+		key, ok := k.keys[id]
+		if !ok {
+			return "", fmt.Errorf("keyring: unknown key id %q", id)
+		}
+		return key.DecryptStringAAD(ctx, ver+":"+payload, aad)
+	}
+	for _, kid := range k.sortedIDs() {
+		if pt, err := k.keys[kid].DecryptString(ctx, "v1:"+payload); err == nil {
+			return pt, nil
+		}
+	}
+	return "", errors.New("keyring: no key can decrypt legacy ciphertext")
+}
+
+// NeedsReencrypt — зашифровано ли значение не активным ключом (или старым форматом без id).
+func (k *Keyring) NeedsReencrypt(enc string) bool {
+	_, id, _, err := splitKeyID(enc)
+	return err == nil && id != k.activeID
+}
+
+// IsBound — зашифровано ли значение с привязкой к aad (формат v2).
+func (k *Keyring) IsBound(enc string) bool { return strings.HasPrefix(enc, "v2:") }
+
+func splitKeyID(enc string) (ver, id, payload string, err error) {
+	ver, rest, ok := strings.Cut(enc, ":")
+	if !ok || (ver != "v1" && ver != "v2") {
+		return "", "", "", errors.New("keyring: bad prefix")
+	}
+	// base64url не содержит ':' — значит второй разделитель отделяет key id
+	if kid, p, ok := strings.Cut(rest, ":"); ok {
+		return ver, kid, p, nil
+	}
+	if ver == "v2" {
+		return "", "", "", errors.New("keyring: v2 ciphertext without key id")
+	}
+	return ver, "", rest, nil
+}
+
+func (k *Keyring) sortedIDs() []string {
+	ids := make([]string, 0, len(k.keys))
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,150 @@
+package crypto_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+)
+
+const (
+	keyA = "0000000000000000000000000000000000000000000000000000000000000001"
+	keyB = "0000000000000000000000000000000000000000000000000000000000000002"
+)
+
+func TestKeyring_RotateAndDecryptOld(t *testing.T) {
+	ctx := context.Background()
+	old, err := crypto.NewKeyring(map[string]string{"k1": keyA}, "k1")
+	require.NoError(t, err)
+	enc1, err := old.EncryptString(ctx, "refresh-1")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enc1, "v1:k1:"))
+
+	ring, err := crypto.NewKeyring(map[string]string{"k1": keyA, "k2": keyB}, "k2")
+	require.NoError(t, err)
+	enc2, err := ring.EncryptString(ctx, "refresh-2")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enc2, "v1:k2:"))
+
+	pt, err := ring.DecryptString(ctx, enc1)
+	require.NoError(t, err)
+	require.Equal(t, "refresh-1", pt)
+	require.True(t, ring.NeedsReencrypt(enc1))
+	require.False(t, ring.NeedsReencrypt(enc2))
+}
+
+func TestKeyring_LegacyCiphertext(t *testing.T) {
+	ctx := context.Background()
+	single, err := crypto.NewAEADEncryptor(keyA)
+	require.NoError(t, err)
+	legacy, err := single.EncryptString(ctx, "refresh")
+	require.NoError(t, err)
+
+	ring, err := crypto.NewKeyring(map[string]string{"k0": keyA, "k1": keyB}, "k1")
+	require.NoError(t, err)
+	pt, err := ring.DecryptString(ctx, legacy)
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+	require.True(t, ring.NeedsReencrypt(legacy))
+}
+
+func TestKeyring_UnknownKeyID(t *testing.T) {
+	ring, err := crypto.NewKeyring(map[string]string{"k1": keyA}, "k1")
+	require.NoError(t, err)
+	_, err = ring.DecryptString(context.Background(), "v1:k9:AAAA")
+	require.Error(t, err)
+
+	_, err = crypto.NewKeyring(map[string]string{"k1": keyA}, "k2")
+	require.Error(t, err)
+}
+
+func TestKeyringFromEnv(t *testing.T) {
+	t.Setenv("ENC_KEY", keyA)
+	t.Setenv("ENC_KEYS", "k1:"+keyB)
+	t.Setenv("ENC_ACTIVE_KEY_ID", "k1")
+	ring, err := crypto.KeyringFromEnv()
+	require.NoError(t, err)
+	require.Equal(t, "k1", ring.ActiveKeyID())
+
+	single, _ := crypto.NewAEADEncryptor(keyA)
+	legacy, _ := single.EncryptString(context.Background(), "x")
+	pt, err := ring.DecryptString(context.Background(), legacy)
+	require.NoError(t, err)
+	require.Equal(t, "x", pt)
+}
+
+func TestKeyring_BoundCiphertext(t *testing.T) {
+	ctx := context.Background()
+	ring, err := crypto.NewKeyring(map[string]string{"k1": keyA}, "k1")
+	require.NoError(t, err)
+
+	enc, err := ring.EncryptStringAAD(ctx, "refresh", []byte("owner-1"))
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enc, "v2:k1:"))
+	require.True(t, ring.IsBound(enc))
+	require.False(t, ring.NeedsReencrypt(enc))
+
+	pt, err := ring.DecryptStringAAD(ctx, enc, []byte("owner-1"))
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+
+	_, err = ring.DecryptStringAAD(ctx, enc, []byte("owner-2"))
+	require.Error(t, err)
+	_, err = ring.DecryptString(ctx, enc)
+	require.Error(t, err)
+
+	// v1 без привязки читается и через DecryptStringAAD
+	old, err := ring.EncryptString(ctx, "old")
+	require.NoError(t, err)
+	require.False(t, ring.IsBound(old))
+	pt, err = ring.DecryptStringAAD(ctx, old, []byte("owner-1"))
+	require.NoError(t, err)
+	require.Equal(t, "old", pt)
+}
+
+func TestKeyring_RequireAAD_RejectsV1(t *testing.T) {
+	ctx := context.Background()
+	ring, err := crypto.NewKeyring(map[string]string{"k1": keyA}, "k1")
+	require.NoError(t, err)
+	old, err := ring.EncryptString(ctx, "old")
+	require.NoError(t, err)
+	single, _ := crypto.NewAEADEncryptor(keyA)
+	legacy, _ := single.EncryptString(ctx, "older")
+	bound, err := ring.EncryptStringAAD(ctx, "refresh", []byte("owner-1"))
+	require.NoError(t, err)
+
+	ring.WithRequireAAD()
+
+	// v1 с id и без id там, где ожидается привязка, — отказ
+	_, err = ring.DecryptStringAAD(ctx, old, []byte("owner-1"))
+	require.ErrorIs(t, err, crypto.ErrUnbound)
+	_, err = ring.DecryptStringAAD(ctx, legacy, []byte("owner-1"))
+	require.ErrorIs(t, err, crypto.ErrUnbound)
+
+	// v2 читается; непривязанные по смыслу записи (DecryptString) — как раньше
+	pt, err := ring.DecryptStringAAD(ctx, bound, []byte("owner-1"))
+	require.NoError(t, err)
+	require.Equal(t, "refresh", pt)
+	pt, err = ring.DecryptString(ctx, old)
+	require.NoError(t, err)
+	require.Equal(t, "old", pt)
+}
+
+func TestFromEnv_RequireAAD(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("ENC_BACKEND", "")
+	t.Setenv("ENC_KEY", keyA)
+	t.Setenv("ENC_KEYS", "")
+	t.Setenv("ENC_ACTIVE_KEY_ID", "")
+	t.Setenv("ENC_REQUIRE_AAD", "1")
+
+	enc, err := crypto.FromEnv()
+	require.NoError(t, err)
+	old, err := enc.EncryptString(ctx, "old")
+	require.NoError(t, err)
+	_, err = enc.DecryptStringAAD(ctx, old, []byte("owner-1"))
+	require.ErrorIs(t, err, crypto.ErrUnbound)
+}
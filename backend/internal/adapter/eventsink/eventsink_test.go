@@ -0,0 +1,114 @@
+package eventsink_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/eventsink"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+func testEvent() entity.OutboxEvent {
+	return entity.OutboxEvent{
+		OutboxID:  1,
+		EventID:   "0b7f4c1e-2d7a-4d55-9a59-0d3c1b8e6f11",
+		EventType: "click.created",
+		AdID:      87,
+		Payload:   json.RawMessage(`{"click_id":5,"ad_id":87}`),
+		CreatedAt: time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWriter_JSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	s := eventsink.NewWriter(&buf)
+	require.NoError(t, s.Publish(context.Background(), testEvent()))
+	require.NoError(t, s.Publish(context.Background(), testEvent()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.JSONEq(t, `{"id":"0b7f4c1e-2d7a-4d55-9a59-0d3c1b8e6f11","type":"click.created",
+		"created_at":"2026-10-01T12:00:00Z","data":{"click_id":5,"ad_id":87}}`, lines[0])
+}
+
+// fakeNATS — минимальный сервер: INFO, ждёт CONNECT, принимает PUB и отвечает PONG.
+func fakeNATS(t *testing.T, errOnPub bool) (addr string, got chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	got = make(chan string, 4)
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rd := bufio.NewReader(conn)
+		conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+		for {
+			line, err := rd.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "PING":
+				conn.Write([]byte("PONG\r\n"))
+			case strings.HasPrefix(line, "PUB "):
+				f := strings.Fields(line)
+				n, _ := strconv.Atoi(f[2])
+				body := make([]byte, n+2)
+				if _, err := io.ReadFull(rd, body); err != nil {
+					return
+				}
+				if errOnPub {
+					conn.Write([]byte("-ERR 'Permissions Violation'\r\n"))
+					continue
+				}
+				got <- f[1] + " " + string(body[:n])
+			}
+		}
+	}()
+	return ln.Addr().String(), got
+}
+
+func TestNATS_Publish(t *testing.T) {
+	addr, got := fakeNATS(t, false)
+	s, err := eventsink.NewNATS("nats://"+addr, "adsieve.")
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Publish(context.Background(), testEvent()))
+	msg := <-got
+	subject, body, _ := strings.Cut(msg, " ")
+	require.Equal(t, "adsieve.click.created", subject)
+	require.Contains(t, body, `"id":"0b7f4c1e-2d7a-4d55-9a59-0d3c1b8e6f11"`)
+}
+
+func TestNATS_ServerError(t *testing.T) {
+	addr, _ := fakeNATS(t, true)
+	s, err := eventsink.NewNATS("nats://"+addr, "adsieve")
+	require.NoError(t, err)
+	defer s.Close()
+
+	err = s.Publish(context.Background(), testEvent())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Permissions Violation")
+}
+
+func TestNewNATS_InvalidURL(t *testing.T) {
+	_, err := eventsink.NewNATS("::bad", "adsieve")
+	require.Error(t, err)
+}
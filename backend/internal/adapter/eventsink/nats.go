@@ -0,0 +1,162 @@
+package eventsink
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// NATS публикует события в NATS core по текстовому протоколу (INFO/CONNECT/PUB/PING).
+// Subject — <prefix>.<event_type>, например adsieve.click.created.
+// После каждого PUB ждём PONG: сервер обработал команды по порядку, событие принято.
+type NATS struct {
+	addr    string
+	user    string
+	pass    string
+	prefix  string
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// NewNATS принимает URL вида nats://[user:pass@]host:4222.
+func NewNATS(rawURL, prefix string) (*NATS, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("nats url %q: invalid", rawURL)
+	}
+	n := &NATS{addr: u.Host, prefix: strings.TrimSuffix(prefix, "."), timeout: 5 * time.Second}
+	if u.Port() == "" {
+		n.addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil {
+		n.user = u.User.Username()
+		n.pass, _ = u.User.Password()
+	}
+	return n, nil
+}
+
+func (n *NATS) Subject(eventType string) string {
+	if n.prefix == "" {
+		return eventType
+	}
+	return n.prefix + "." + eventType
+}
+
+func (n *NATS) Publish(ctx context.Context, e entity.OutboxEvent) error {
+	body, err := e.Envelope()
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if err := n.pub(ctx, n.Subject(e.EventType), body); err != nil {
+		// соединение в неизвестном состоянии — переподключимся на следующей попытке
+		n.conn.Close()
+		n.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (n *NATS) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.conn == nil {
+		return nil
+	}
+	err := n.conn.Close()
+	n.conn = nil
+	return err
+}
+
+func (n *NATS) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: n.timeout}
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("nats dial: %w", err)
+	}
+	n.conn, n.rd = conn, bufio.NewReader(conn)
+	n.deadline(ctx)
+
+	line, err := n.rd.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		n.conn = nil
+		return fmt.Errorf("nats handshake: unexpected %q: %v", strings.TrimSpace(line), err)
+	}
+	opts := map[string]any{"verbose": false, "pedantic": false, "name": "adsieve-outbox", "lang": "go", "version": "1.0"}
+	if n.user != "" {
+		opts["user"], opts["pass"] = n.user, n.pass
+	}
+	raw, _ := json.Marshal(opts)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", raw); err != nil {
+		conn.Close()
+		n.conn = nil
+		return fmt.Errorf("nats connect: %w", err)
+	}
+	if err := n.awaitPong(); err != nil {
+		conn.Close()
+		n.conn = nil
+		return fmt.Errorf("nats connect: %w", err)
+	}
+	return nil
+}
+
+func (n *NATS) pub(ctx context.Context, subject string, body []byte) error {
+	n.deadline(ctx)
+	if _, err := fmt.Fprintf(n.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(body), body); err != nil {
+		return fmt.Errorf("nats pub: %w", err)
+	}
+	if err := n.awaitPong(); err != nil {
+		return fmt.Errorf("nats pub: %w", err)
+	}
+	return nil
+}
+
+// awaitPong читает ответы до PONG; на PING сервера отвечает, -ERR — ошибка.
+func (n *NATS) awaitPong() error {
+	for {
+		line, err := n.rd.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// +OK, INFO — пропускаем
+	}
+}
+
+func (n *NATS) deadline(ctx context.Context) {
+	dl := time.Now().Add(n.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(dl) {
+		dl = d
+	}
+	n.conn.SetDeadline(dl)
+}
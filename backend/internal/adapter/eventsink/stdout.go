@@ -0,0 +1,32 @@
+// Package eventsink — публикация событий outbox во внешние получатели (stdout, NATS).
+//
+// Каждое событие уходит как JSON-конверт {"id","type","created_at","data"} — тот же,
+// что и тело исходящего webhook. Доставка at-least-once: дедупликация по id.
+package eventsink
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// Writer пишет события JSON-строками (stdout, файл) — для отладки и лог-пайплайнов.
+type Writer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriter(w io.Writer) *Writer { return &Writer{w: w} }
+
+func (s *Writer) Publish(_ context.Context, e entity.OutboxEvent) error {
+	b, err := e.Envelope()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
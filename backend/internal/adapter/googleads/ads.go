@@ -0,0 +1,109 @@
+package googleads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// SetAdStatus ставит объявление на паузу / включает его (ad_group_ad.status = PAUSED | ENABLED).
+// В ads хранится только ad.id, поэтому resource name ad_group_ad сначала ищется через GAQL.
+func (c *Client) SetAdStatus(ctx context.Context, t entity.AdTarget, status string) error {
+	gStatus, err := googleAdStatus(status)
+	if err != nil {
+		return err
+	}
+	accessToken, googleUID, err := c.tokenSource.TokenForAccount(ctx, t.UserID, t.ExternalAccountID)
+	if err != nil {
+		return err
+	}
+	login, err := c.loginFor(ctx, t.UserID, t.ExternalAccountID)
+	if err != nil {
+		return err
+	}
+	customerID := strings.ReplaceAll(t.ExternalAccountID, "-", "")
+
+	q := `SELECT ad_group_ad.resource_name FROM ad_group_ad WHERE ad_group_ad.ad.id = ` + strconv.FormatInt(t.AdID, 10)
+	var rows []struct {
+		AdGroupAd struct {
+			ResourceName string `json:"resourceName"`
+		} `json:"adGroupAd"`
+	}
+	if err := c.search(ctx, accessToken, customerID, login, q, &rows); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.HTTPStatus == http.StatusUnauthorized {
+			_ = c.tokenSource.MarkNeedsConsent(ctx, t.UserID, googleUID)
+			return errs.ErrGoogleNeedsConsent
+		}
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%w: ad %d not found in customer %s", errs.ErrAdMutationRejected, t.AdID, customerID)
+	}
+
+	type update struct {
+		ResourceName string `json:"resourceName"`
+		Status       string `json:"status"`
+	}
+	type operation struct {
+		Update     update `json:"update"`
+		UpdateMask string `json:"updateMask"`
+	}
+	body := struct {
+		Operations []operation `json:"operations"`
+	}{Operations: []operation{{
+		Update:     update{ResourceName: rows[0].AdGroupAd.ResourceName, Status: gStatus},
+		UpdateMask: "status",
+	}}}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/customers/%s/adGroupAds:mutate", c.base, customerID)
+	req, _ := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(string(payload)))
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("developer-token", c.devToken)
+	if login != "" {
+		req.Header.Set("login-customer-id", login)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 {
+		_ = c.tokenSource.MarkNeedsConsent(ctx, t.UserID, googleUID)
+		return errs.ErrGoogleNeedsConsent
+	}
+	if resp.StatusCode != 200 {
+		err := apiError(resp)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.kind == nil && resp.StatusCode == http.StatusBadRequest {
+			// mutateError / policy и т.п. — Google отказал именно в этом изменении
+			return fmt.Errorf("%w: %v", errs.ErrAdMutationRejected, err)
+		}
+		return err
+	}
+	return nil
+}
+
+func googleAdStatus(status string) (string, error) {
+	switch status {
+	case entity.AdStatusActive:
+		return "ENABLED", nil
+	case entity.AdStatusPaused:
+		return "PAUSED", nil
+	}
+	return "", fmt.Errorf("googleads: unsupported ad status %q", status)
+}
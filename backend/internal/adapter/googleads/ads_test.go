@@ -0,0 +1,87 @@
+package googleads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+func TestSetAdStatus_SearchThenMutate(t *testing.T) {
+	var mutate struct {
+		Operations []struct {
+			Update struct {
+				ResourceName string `json:"resourceName"`
+				Status       string `json:"status"`
+			} `json:"update"`
+			UpdateMask string `json:"updateMask"`
+		} `json:"operations"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer access-1", r.Header.Get("Authorization"))
+		require.Equal(t, "9990002222", r.Header.Get("login-customer-id"))
+		switch r.URL.Path {
+		case "/customers/1234567890/googleAds:search":
+			var body struct {
+				Query string `json:"query"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Contains(t, body.Query, "ad_group_ad.ad.id = 555")
+			_, _ = w.Write([]byte(`{"results":[{"adGroupAd":{"resourceName":"customers/1234567890/adGroupAds/77~555"}}]}`))
+		case "/customers/1234567890/adGroupAds:mutate":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&mutate))
+			_, _ = w.Write([]byte(`{"results":[{"resourceName":"customers/1234567890/adGroupAds/77~555"}]}`))
+		default:
+			t.Fatalf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	c := New("dev-token", "999-000-2222", &fakeTS{}).WithBaseURL(srv.URL)
+	err := c.SetAdStatus(context.Background(),
+		entity.AdTarget{AdID: 555, UserID: 7, Platform: "google", ExternalAccountID: "123-456-7890"}, entity.AdStatusPaused)
+	require.NoError(t, err)
+	require.Len(t, mutate.Operations, 1)
+	require.Equal(t, "customers/1234567890/adGroupAds/77~555", mutate.Operations[0].Update.ResourceName)
+	require.Equal(t, "PAUSED", mutate.Operations[0].Update.Status)
+	require.Equal(t, "status", mutate.Operations[0].UpdateMask)
+}
+
+func TestSetAdStatus_Rejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/customers/1234567890/googleAds:search" {
+			_, _ = w.Write([]byte(`{"results":[{"adGroupAd":{"resourceName":"customers/1234567890/adGroupAds/77~555"}}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"code":400,"status":"INVALID_ARGUMENT","message":"Request contains an invalid argument.",
+			"details":[{"errors":[{"errorCode":{"adGroupAdError":"CANNOT_OPERATE_ON_REMOVED_ADGROUPAD"},"message":"Removed ad."}]}]}}`))
+	}))
+	defer srv.Close()
+
+	c := New("dev-token", "", &fakeTS{}).WithBaseURL(srv.URL)
+	err := c.SetAdStatus(context.Background(),
+		entity.AdTarget{AdID: 555, ExternalAccountID: "1234567890"}, entity.AdStatusActive)
+	require.True(t, errors.Is(err, errs.ErrAdMutationRejected))
+}
+
+func TestSetAdStatus_Unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	ts := &fakeTS{}
+	c := New("dev-token", "", ts).WithBaseURL(srv.URL)
+	err := c.SetAdStatus(context.Background(),
+		entity.AdTarget{AdID: 555, ExternalAccountID: "1234567890"}, entity.AdStatusPaused)
+	require.ErrorIs(t, err, errs.ErrGoogleNeedsConsent)
+	require.Equal(t, 1, ts.consent)
+}
@@ -1,6 +1,7 @@
 package googleads
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,25 +10,33 @@ import (
 	"net/http"
 	"strings"
 	"time"
-)
 
-type linkRepo interface {
-    LinkGoogleAccounts(ctx context.Context, userID int64, tokenOwnerGoogleUserID string, customerIDs []string) error
-}
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
 
 type TokenSource interface {
 	Token(ctx context.Context, userID int64) (accessToken string, googleUserID string, err error)
+	// токен конкретного Google-логина и токен логина-владельца аккаунта customerID
+	TokenFor(ctx context.Context, userID int64, googleUserID string) (accessToken string, err error)
+	TokenForAccount(ctx context.Context, userID int64, customerID string) (accessToken string, googleUserID string, err error)
+	Identities(ctx context.Context, userID int64) ([]string, error)
 	MarkNeedsConsent(ctx context.Context, userID int64, googleUserID string) error
 }
 
+// LoginResolver — откуда брать login-customer-id для запросов по конкретному аккаунту
+// (MCC, запомненный при привязке в ad_accounts.login_customer_id).
+type LoginResolver interface {
+	LoginCustomerID(ctx context.Context, userID int64, customerID string) (string, error)
+}
+
 type Client struct {
 	http        *http.Client
 	devToken    string
-	loginMCC    string 
+	loginMCC    string // запасной MCC для аккаунтов, привязанных без login_customer_id
 	tokenSource TokenSource
+	logins      LoginResolver
 	base        string 
 	retries     int
-	repo 		linkRepo
 }
 
 func New(devToken, loginMCC string, ts TokenSource) *Client {
@@ -41,24 +50,91 @@ func New(devToken, loginMCC string, ts TokenSource) *Client {
 	}
 }
 
+// WithLoginResolver включает выбор login-customer-id по аккаунту вместо единого loginMCC.
+func (c *Client) WithLoginResolver(r LoginResolver) *Client {
+	c.logins = r
+	return c
+}
+
+// setLoginCustomerID ставит заголовок login-customer-id для запроса по customerID:
+// MCC из привязки аккаунта, иначе — глобальный loginMCC (если задан).
+func (c *Client) setLoginCustomerID(ctx context.Context, req *http.Request, userID int64, customerID string) error {
+	login, err := c.loginFor(ctx, userID, customerID)
+	if err != nil {
+		return err
+	}
+	if login != "" {
+		req.Header.Set("login-customer-id", login)
+	}
+	return nil
+}
+
+// loginFor — login-customer-id (без дефисов) для запросов по customerID; "" — не слать.
+func (c *Client) loginFor(ctx context.Context, userID int64, customerID string) (string, error) {
+	login := c.loginMCC
+	if c.logins != nil {
+		l, err := c.logins.LoginCustomerID(ctx, userID, customerID)
+		if err != nil {
+			return "", err
+		}
+		if l != "" {
+			login = l
+		}
+	}
+	return strings.ReplaceAll(login, "-", ""), nil
+}
+
+// WithBaseURL переопределяет адрес Google Ads API (локальный фейк-сервер в тестах).
+func (c *Client) WithBaseURL(base string) *Client {
+	c.base = strings.TrimRight(base, "/")
+	return c
+}
+
+// Дольше этого внутри одного запроса не ждём: такие паузы (обычно квота) отдаём наверх
+// ошибкой с RetryAfter — повтор запланирует вызывающий (воркер, UI).
+const maxInlineRetryWait = 30 * time.Second
+
+// do выполняет запрос с повторами. Ответы 429/5xx разбираются в *APIError: повторяются только
+// временные ошибки, пауза — из retryDelay/Retry-After, иначе по фиксированной лестнице.
+// Остальные ответы (в т.ч. 4xx) возвращаются как есть — их разбирает вызывающий.
 func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
 	backoffs := []time.Duration{500 * time.Millisecond, 2 * time.Second, 5 * time.Second}
 	var last error
 	for attempt := 0; attempt < c.retries; attempt++ {
-		resp, err := c.http.Do(req.WithContext(ctx))
-		if err == nil && resp.StatusCode < 500 && resp.StatusCode != 429 {
-			return resp, nil
+		if attempt > 0 && req.GetBody != nil {
+			// тело уже вычитано предыдущей попыткой — берём свежую копию
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
 		}
-		if resp != nil {
+		var wait time.Duration
+		resp, err := c.http.Do(req.WithContext(ctx))
+		if err != nil {
+			last = err
+		} else {
+			if resp.StatusCode < 500 && resp.StatusCode != 429 {
+				return resp, nil
+			}
+			body, _ := io.ReadAll(resp.Body)
 			resp.Body.Close()
-		}
-		last = err
-		if attempt < len(backoffs) {
-			select {
-			case <-time.After(backoffs[attempt]):
-			case <-ctx.Done():
-				return nil, ctx.Err()
+			apiErr := parseAPIError(resp.StatusCode, resp.Header, body)
+			if !apiErr.Temporary() || apiErr.Retry > maxInlineRetryWait {
+				return nil, apiErr
 			}
+			last, wait = apiErr, apiErr.Retry
+		}
+		if attempt == c.retries-1 {
+			break
+		}
+		if wait == 0 && attempt < len(backoffs) {
+			wait = backoffs[attempt]
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		}
 	}
 	if last == nil {
@@ -67,38 +143,50 @@ func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, err
 	return nil, last
 }
 
+// apiError — ошибка из не-200 ответа (тело вычитывается).
+func apiError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return parseAPIError(resp.StatusCode, resp.Header, body)
+}
+
 func (c *Client) ListAccessibleCustomers(ctx context.Context, userID int64) ([]string, string, error) {
 	accessToken, googleUID, err := c.tokenSource.Token(ctx, userID)
 	if err != nil {
 		return nil, "", err
 	}
+	ids, err := c.listAccessible(ctx, userID, googleUID, accessToken)
+	if err != nil {
+		return nil, "", err
+	}
+	return ids, googleUID, nil
+}
+
+// listAccessible — customers:listAccessibleCustomers для токена логина googleUID.
+func (c *Client) listAccessible(ctx context.Context, userID int64, googleUID, accessToken string) ([]string, error) {
 	url := c.base + "/customers:listAccessibleCustomers"
 	req, _ := http.NewRequest(http.MethodGet, url, nil)
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("developer-token", c.devToken)
-	if c.loginMCC != "" {
-		req.Header.Set("login-customer-id", c.loginMCC)
-	}
+	// login-customer-id тут не нужен: список зависит только от токена
 
 	resp, err := c.do(ctx, req)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 401 {
 		_ = c.tokenSource.MarkNeedsConsent(ctx, userID, googleUID)
-		return nil, "", fmt.Errorf("unauthorized: re-consent required")
+		return nil, errs.ErrGoogleNeedsConsent
 	}
 	if resp.StatusCode != 200 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, "", fmt.Errorf("googleads listAccessible: %s", string(body))
+		return nil, apiError(resp)
 	}
 	var out struct {
 		ResourceNames []string `json:"resourceNames"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return nil, "", err
+		return nil, err
 	}
 	// resourceNames формата "customers/1234567890"
 	ids := make([]string, 0, len(out.ResourceNames))
@@ -107,12 +195,12 @@ func (c *Client) ListAccessibleCustomers(ctx context.Context, userID int64) ([]s
 			ids = append(ids, rn[i+1:])
 		}
 	}
-	return ids, googleUID, nil
+	return ids, nil
 }
 
 // Одноразовый синк трат за дату (включительно) по GAQL searchStream
 func (c *Client) SyncCostsForDate(ctx context.Context, userID int64, customerID, yyyymmdd string, sink func(adID int64, date string, micros int64) error) error {
-	accessToken, googleUID, err := c.tokenSource.Token(ctx, userID)
+	accessToken, googleUID, err := c.tokenSource.TokenForAccount(ctx, userID, customerID)
 	if err != nil {
 		return err
 	}
@@ -126,8 +214,8 @@ WHERE segments.date = '` + yyyymmdd + `'`
 	req, _ := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(payload))
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("developer-token", c.devToken)
-	if c.loginMCC != "" {
-		req.Header.Set("login-customer-id", c.loginMCC)
+	if err := c.setLoginCustomerID(ctx, req, userID, customerID); err != nil {
+		return err
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -139,37 +227,47 @@ WHERE segments.date = '` + yyyymmdd + `'`
 
 	if resp.StatusCode == 401 {
 		_ = c.tokenSource.MarkNeedsConsent(ctx, userID, googleUID)
-		return fmt.Errorf("unauthorized: re-consent required")
+		return errs.ErrGoogleNeedsConsent
 	}
 	if resp.StatusCode != 200 {
-		b, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("searchStream err: %s", string(b))
+		return apiError(resp)
 	}
 
-	dec := json.NewDecoder(resp.Body)
-	for {
-		var chunk struct {
-			Results []struct {
-				AdGroupAd struct {
-					Ad struct {
-						Id int64 `json:"id,string"`
-					} `json:"ad"`
-				} `json:"ad_group_ad"`
-				Segments struct {
-					Date string `json:"date"`
-				} `json:"segments"`
-				Metrics struct {
-					CostMicros int64 `json:"cost_micros,string"`
-				} `json:"metrics"`
-			} `json:"results"`
+	type chunk struct {
+		Results []struct {
+			AdGroupAd struct {
+				Ad struct {
+					Id int64 `json:"id,string"`
+				} `json:"ad"`
+			} `json:"adGroupAd"`
+			Segments struct {
+				Date string `json:"date"`
+			} `json:"segments"`
+			Metrics struct {
+				CostMicros int64 `json:"costMicros,string"`
+			} `json:"metrics"`
+		} `json:"results"`
+	}
+	// REST-вариант searchStream отдаёт JSON-массив пачек: [{"results":[...]}, ...];
+	// на всякий случай понимаем и поток отдельных объектов
+	br := bufio.NewReader(resp.Body)
+	dec := json.NewDecoder(br)
+	array := false
+	if b, err := peekNonSpace(br); err == nil && b == '[' {
+		if _, err := dec.Token(); err != nil {
+			return err
 		}
-		if err := dec.Decode(&chunk); err != nil {
+		array = true
+	}
+	for !array || dec.More() {
+		var ch chunk
+		if err := dec.Decode(&ch); err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
 			return err
 		}
-		for _, r := range chunk.Results {
+		for _, r := range ch.Results {
 			if err := sink(r.AdGroupAd.Ad.Id, r.Segments.Date, r.Metrics.CostMicros); err != nil {
 				return err
 			}
@@ -178,16 +276,19 @@ WHERE segments.date = '` + yyyymmdd + `'`
 	return nil
 }
 
-func (c *Client) LinkAccounts(ctx context.Context, userID int64, customerIDs []string) error {
-    if len(customerIDs) == 0 {
-        return nil
-    }
-    // Получим google_user_id владельца токена через TokenSource
-    _, googleUID, err := c.tokenSource.Token(ctx, userID)
-    if err != nil {
-        return err
-    }
-    return c.repo.LinkGoogleAccounts(ctx, userID, googleUID, customerIDs)
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\n', '\r', '\t':
+			_, _ = br.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
 }
 
 func jsonQuoted(s string) string {
@@ -0,0 +1,123 @@
+package googleads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// UploadClickConversions грузит пачку конверсий одного customer'а с partialFailure=true,
+// поэтому ошибки отдельных конверсий возвращаются поэлементно (nil ⇒ принята),
+// а общей ошибкой — только сбой запроса целиком.
+func (c *Client) UploadClickConversions(ctx context.Context, userID int64, customerID string, convs []entity.ClickConversion) ([]error, error) {
+	if len(convs) == 0 {
+		return nil, nil
+	}
+	accessToken, googleUID, err := c.tokenSource.TokenForAccount(ctx, userID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	type clickConversionJSON struct {
+		Gclid              string  `json:"gclid"`
+		ConversionAction   string  `json:"conversionAction"`
+		ConversionDateTime string  `json:"conversionDateTime"`
+		ConversionValue    float64 `json:"conversionValue"`
+		CurrencyCode       string  `json:"currencyCode,omitempty"`
+		OrderID            string  `json:"orderId,omitempty"`
+	}
+	body := struct {
+		Conversions    []clickConversionJSON `json:"conversions"`
+		PartialFailure bool                  `json:"partialFailure"`
+	}{PartialFailure: true}
+	for _, cv := range convs {
+		body.Conversions = append(body.Conversions, clickConversionJSON{
+			Gclid:              cv.Gclid,
+			ConversionAction:   cv.ConversionAction,
+			ConversionDateTime: cv.ConvertedAt.UTC().Format("2006-01-02 15:04:05-07:00"),
+			ConversionValue:    cv.Value,
+			CurrencyCode:       cv.CurrencyCode,
+			OrderID:            cv.OrderID,
+		})
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/customers/%s:uploadClickConversions", c.base, strings.ReplaceAll(customerID, "-", ""))
+	req, _ := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(string(payload)))
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("developer-token", c.devToken)
+	if err := c.setLoginCustomerID(ctx, req, userID, customerID); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 401 {
+		_ = c.tokenSource.MarkNeedsConsent(ctx, userID, googleUID)
+		return nil, errs.ErrGoogleNeedsConsent
+	}
+	if resp.StatusCode != 200 {
+		return nil, apiError(resp)
+	}
+
+	var out struct {
+		PartialFailureError *struct {
+			Message string `json:"message"`
+			Details []struct {
+				Errors []struct {
+					Message  string `json:"message"`
+					Location struct {
+						FieldPathElements []struct {
+							FieldName string `json:"fieldName"`
+							Index     *int   `json:"index"`
+						} `json:"fieldPathElements"`
+					} `json:"location"`
+				} `json:"errors"`
+			} `json:"details"`
+		} `json:"partialFailureError"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+
+	results := make([]error, len(convs))
+	if out.PartialFailureError == nil {
+		return results, nil
+	}
+	// Ошибки адресуются через location: conversions[<index>]
+	matched := false
+	for _, d := range out.PartialFailureError.Details {
+		for _, e := range d.Errors {
+			for _, el := range e.Location.FieldPathElements {
+				if el.FieldName != "conversions" || el.Index == nil {
+					continue
+				}
+				if i := *el.Index; i >= 0 && i < len(results) {
+					results[i] = fmt.Errorf("%w: %s", errs.ErrConversionRejected, e.Message)
+					matched = true
+				}
+				break
+			}
+		}
+	}
+	if !matched && out.PartialFailureError.Message != "" {
+		// не смогли сопоставить ошибки с конверсиями — считаем неудачной всю пачку
+		for i := range results {
+			results[i] = fmt.Errorf("%w: %s", errs.ErrConversionRejected, out.PartialFailureError.Message)
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,110 @@
+package googleads
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// fakeTS — один логин guid-1 (токен access-1), если не задан свой список логинов
+type fakeTS struct {
+	consent    int
+	identities []string
+}
+
+func (f *fakeTS) Token(ctx context.Context, userID int64) (string, string, error) {
+	return "access-1", "guid-1", nil
+}
+
+func (f *fakeTS) TokenFor(ctx context.Context, userID int64, googleUserID string) (string, error) {
+	if googleUserID == "guid-1" {
+		return "access-1", nil
+	}
+	return "access-" + googleUserID, nil
+}
+
+func (f *fakeTS) TokenForAccount(ctx context.Context, userID int64, customerID string) (string, string, error) {
+	return f.Token(ctx, userID)
+}
+
+func (f *fakeTS) Identities(ctx context.Context, userID int64) ([]string, error) {
+	if f.identities == nil {
+		return []string{"guid-1"}, nil
+	}
+	return f.identities, nil
+}
+
+func (f *fakeTS) MarkNeedsConsent(ctx context.Context, userID int64, googleUserID string) error {
+	f.consent++
+	return nil
+}
+
+func TestUploadClickConversions_PartialFailure(t *testing.T) {
+	var got struct {
+		Conversions []struct {
+			Gclid              string  `json:"gclid"`
+			ConversionAction   string  `json:"conversionAction"`
+			ConversionDateTime string  `json:"conversionDateTime"`
+			ConversionValue    float64 `json:"conversionValue"`
+			OrderID            string  `json:"orderId"`
+		} `json:"conversions"`
+		PartialFailure bool `json:"partialFailure"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/customers/1234567890:uploadClickConversions", r.URL.Path)
+		require.Equal(t, "Bearer access-1", r.Header.Get("Authorization"))
+		require.Equal(t, "dev-token", r.Header.Get("developer-token"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+
+		_, _ = w.Write([]byte(`{
+			"partialFailureError": {
+				"code": 3,
+				"message": "The click is too old.",
+				"details": [{"errors": [{
+					"message": "The click is too old.",
+					"location": {"fieldPathElements": [{"fieldName": "conversions", "index": 1}, {"fieldName": "gclid"}]}
+				}]}]
+			},
+			"results": [{"gclid": "g-1"}, {}]
+		}`))
+	}))
+	defer srv.Close()
+
+	c := New("dev-token", "", &fakeTS{}).WithBaseURL(srv.URL)
+	at := time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC)
+	res, err := c.UploadClickConversions(context.Background(), 7, "123-456-7890", []entity.ClickConversion{
+		{Gclid: "g-1", ConversionAction: "customers/1234567890/conversionActions/1", ConvertedAt: at, Value: 49.99, OrderID: "A1"},
+		{Gclid: "g-2", ConversionAction: "customers/1234567890/conversionActions/1", ConvertedAt: at, Value: 10},
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.NoError(t, res[0])
+	require.ErrorIs(t, res[1], errs.ErrConversionRejected)
+
+	require.True(t, got.PartialFailure)
+	require.Len(t, got.Conversions, 2)
+	require.Equal(t, "2025-03-01 10:30:00+00:00", got.Conversions[0].ConversionDateTime)
+	require.Equal(t, "A1", got.Conversions[0].OrderID)
+	require.InDelta(t, 49.99, got.Conversions[0].ConversionValue, 1e-9)
+}
+
+func TestUploadClickConversions_Unauthorized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	ts := &fakeTS{}
+	c := New("dev-token", "", ts).WithBaseURL(srv.URL)
+	_, err := c.UploadClickConversions(context.Background(), 7, "1234567890", []entity.ClickConversion{{Gclid: "g-1"}})
+	require.Error(t, err)
+	require.Equal(t, 1, ts.consent)
+}
@@ -0,0 +1,227 @@
+package googleads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// Глубина обхода иерархии MCC (менеджер → клиенты → клиенты вложенных менеджеров ...)
+const maxHierarchyDepth = 3
+
+// ListAccessibleAccounts возвращает аккаунты, доступные всем подключённым Google-логинам пользователя,
+// с названием, валютой, часовым поясом и признаком MCC; у менеджеров — дерево клиентских аккаунтов.
+// У каждого аккаунта проставлен google_user_id логина, через который он виден (первый по порядку).
+func (c *Client) ListAccessibleAccounts(ctx context.Context, userID int64) ([]entity.GoogleCustomer, error) {
+	identities, err := c.tokenSource.Identities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 {
+		// нет ни одного рабочего логина — пусть TokenSource вернёт свою ошибку
+		if _, _, err := c.tokenSource.Token(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	out := []entity.GoogleCustomer{}
+	seen := map[string]bool{}
+	var lastErr error
+	for _, googleUID := range identities {
+		accessToken, err := c.tokenSource.TokenFor(ctx, userID, googleUID)
+		if err == nil {
+			var ids []string
+			if ids, err = c.listAccessible(ctx, userID, googleUID, accessToken); err == nil {
+				for _, id := range ids {
+					if seen[id] {
+						continue
+					}
+					seen[id] = true
+					out = append(out, c.describeTree(ctx, accessToken, googleUID, id))
+				}
+				continue
+			}
+		}
+		// один сломанный логин не должен прятать аккаунты остальных
+		log.Printf("googleads: list accounts of %s: %v", googleUID, err)
+		lastErr = err
+	}
+	if len(out) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return out, nil
+}
+
+// describeTree — детали аккаунта и, если это MCC, его клиентов.
+func (c *Client) describeTree(ctx context.Context, accessToken, googleUID, id string) entity.GoogleCustomer {
+	cust, err := c.describeCustomer(ctx, accessToken, id)
+	if err != nil {
+		// отменённые/неактивные аккаунты тоже приходят в listAccessibleCustomers —
+		// показываем их без деталей, а не роняем весь список
+		log.Printf("googleads: describe customer %s: %v", id, err)
+		return entity.GoogleCustomer{CustomerID: id, GoogleUserID: googleUID}
+	}
+	cust.GoogleUserID = googleUID
+	if cust.Manager {
+		children, err := c.customerClients(ctx, accessToken, googleUID, id, id, 1)
+		if err != nil {
+			log.Printf("googleads: customer_client of %s: %v", id, err)
+		}
+		cust.Children = children
+	}
+	return cust
+}
+
+func (c *Client) describeCustomer(ctx context.Context, accessToken, customerID string) (entity.GoogleCustomer, error) {
+	const q = `SELECT customer.id, customer.descriptive_name, customer.currency_code, customer.time_zone, customer.manager FROM customer LIMIT 1`
+	var rows []struct {
+		Customer struct {
+			ID              string `json:"id"`
+			DescriptiveName string `json:"descriptiveName"`
+			CurrencyCode    string `json:"currencyCode"`
+			TimeZone        string `json:"timeZone"`
+			Manager         bool   `json:"manager"`
+		} `json:"customer"`
+	}
+	if err := c.search(ctx, accessToken, customerID, customerID, q, &rows); err != nil {
+		return entity.GoogleCustomer{}, err
+	}
+	if len(rows) == 0 {
+		return entity.GoogleCustomer{}, fmt.Errorf("customer %s: empty result", customerID)
+	}
+	r := rows[0].Customer
+	return entity.GoogleCustomer{
+		CustomerID:      customerID,
+		DescriptiveName: r.DescriptiveName,
+		CurrencyCode:    r.CurrencyCode,
+		TimeZone:        r.TimeZone,
+		Manager:         r.Manager,
+	}, nil
+}
+
+// customerClients — прямые клиенты менеджера managerID; запросы идут с login-customer-id = rootMCC.
+func (c *Client) customerClients(ctx context.Context, accessToken, googleUID, rootMCC, managerID string, depth int) ([]entity.GoogleCustomer, error) {
+	const q = `SELECT customer_client.id, customer_client.descriptive_name, customer_client.currency_code,
+       customer_client.time_zone, customer_client.manager, customer_client.level
+FROM customer_client
+WHERE customer_client.level = 1`
+	var rows []struct {
+		CustomerClient struct {
+			ID              string `json:"id"`
+			DescriptiveName string `json:"descriptiveName"`
+			CurrencyCode    string `json:"currencyCode"`
+			TimeZone        string `json:"timeZone"`
+			Manager         bool   `json:"manager"`
+		} `json:"customerClient"`
+	}
+	if err := c.search(ctx, accessToken, managerID, rootMCC, q, &rows); err != nil {
+		return nil, err
+	}
+
+	out := make([]entity.GoogleCustomer, 0, len(rows))
+	for _, r := range rows {
+		cc := r.CustomerClient
+		child := entity.GoogleCustomer{
+			CustomerID:      cc.ID,
+			DescriptiveName: cc.DescriptiveName,
+			CurrencyCode:    cc.CurrencyCode,
+			TimeZone:        cc.TimeZone,
+			Manager:         cc.Manager,
+			LoginCustomerID: rootMCC,
+			GoogleUserID:    googleUID,
+		}
+		if cc.Manager && depth < maxHierarchyDepth {
+			grand, err := c.customerClients(ctx, accessToken, googleUID, rootMCC, cc.ID, depth+1)
+			if err != nil {
+				log.Printf("googleads: customer_client of %s via %s: %v", cc.ID, rootMCC, err)
+			}
+			child.Children = grand
+		}
+		out = append(out, child)
+	}
+	return out, nil
+}
+
+// search выполняет GAQL через googleAds:search (с пагинацией) и складывает results в out.
+func (c *Client) search(ctx context.Context, accessToken, customerID, loginCID, query string, out any) error {
+	endpoint := fmt.Sprintf("%s/customers/%s/googleAds:search", c.base, strings.ReplaceAll(customerID, "-", ""))
+
+	var all []json.RawMessage
+	pageToken := ""
+	for {
+		body := map[string]string{"query": query}
+		if pageToken != "" {
+			body["pageToken"] = pageToken
+		}
+		payload, _ := json.Marshal(body)
+
+		req, _ := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(string(payload)))
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("developer-token", c.devToken)
+		if loginCID != "" {
+			req.Header.Set("login-customer-id", strings.ReplaceAll(loginCID, "-", ""))
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := c.do(ctx, req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != 200 {
+			err := apiError(resp)
+			resp.Body.Close()
+			return err
+		}
+		var page struct {
+			Results       []json.RawMessage `json:"results"`
+			NextPageToken string            `json:"nextPageToken"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		all = append(all, page.Results...)
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	raw, _ := json.Marshal(all)
+	return json.Unmarshal(raw, out)
+}
+
+// LinksFor сопоставляет выбранные customerIDs с деревом доступных аккаунтов и подставляет MCC,
+// через который к каждому из них ходить, и Google-логин, чьим токеном. Прямой доступ предпочтительнее доступа через менеджера;
+// аккаунты, которых нет в дереве, привязываются без MCC и без владельца токена.
+func LinksFor(tree []entity.GoogleCustomer, customerIDs []string) []entity.GoogleAccountLink {
+	found := map[string]entity.GoogleCustomer{}
+	var walk func(nodes []entity.GoogleCustomer)
+	walk = func(nodes []entity.GoogleCustomer) {
+		for _, n := range nodes {
+			id := strings.ReplaceAll(n.CustomerID, "-", "")
+			if cur, ok := found[id]; !ok || (cur.LoginCustomerID != "" && n.LoginCustomerID == "") {
+				found[id] = n
+			}
+			walk(n.Children)
+		}
+	}
+	walk(tree)
+
+	out := make([]entity.GoogleAccountLink, 0, len(customerIDs))
+	for _, cid := range customerIDs {
+		n := found[strings.ReplaceAll(cid, "-", "")]
+		out = append(out, entity.GoogleAccountLink{
+			CustomerID:      cid,
+			LoginCustomerID: n.LoginCustomerID,
+			TokenOwner:      n.GoogleUserID,
+		})
+	}
+	return out
+}
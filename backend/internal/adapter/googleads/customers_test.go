@@ -0,0 +1,135 @@
+package googleads
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+func TestListAccessibleAccounts_Hierarchy(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/customers:listAccessibleCustomers" {
+			_, _ = w.Write([]byte(`{"resourceNames":["customers/111","customers/900"]}`))
+			return
+		}
+		var body struct {
+			Query string `json:"query"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		login := r.Header.Get("login-customer-id")
+
+		switch {
+		case r.URL.Path == "/customers/111/googleAds:search" && strings.Contains(body.Query, "FROM customer "):
+			require.Equal(t, "111", login)
+			_, _ = w.Write([]byte(`{"results":[{"customer":{"id":"111","descriptiveName":"Shop","currencyCode":"USD","timeZone":"Europe/Kyiv","manager":false}}]}`))
+		case r.URL.Path == "/customers/900/googleAds:search" && strings.Contains(body.Query, "FROM customer "):
+			_, _ = w.Write([]byte(`{"results":[{"customer":{"id":"900","descriptiveName":"Agency MCC","currencyCode":"EUR","timeZone":"Europe/Berlin","manager":true}}]}`))
+		case r.URL.Path == "/customers/900/googleAds:search" && strings.Contains(body.Query, "FROM customer_client"):
+			require.Equal(t, "900", login)
+			_, _ = w.Write([]byte(`{"results":[{"customerClient":{"id":"222","descriptiveName":"Client A","currencyCode":"EUR","timeZone":"Europe/Berlin","manager":false}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := New("dev-token", "", &fakeTS{}).WithBaseURL(srv.URL)
+	accounts, err := c.ListAccessibleAccounts(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	require.Equal(t, "guid-1", accounts[0].GoogleUserID)
+
+	require.Equal(t, "Shop", accounts[0].DescriptiveName)
+	require.False(t, accounts[0].Manager)
+
+	mcc := accounts[1]
+	require.True(t, mcc.Manager)
+	require.Equal(t, "Agency MCC", mcc.DescriptiveName)
+	require.Len(t, mcc.Children, 1)
+	require.Equal(t, "222", mcc.Children[0].CustomerID)
+	require.Equal(t, "Client A", mcc.Children[0].DescriptiveName)
+	require.Equal(t, "900", mcc.Children[0].LoginCustomerID)
+	require.Equal(t, "guid-1", mcc.Children[0].GoogleUserID)
+}
+
+func TestListAccessibleAccounts_MultipleIdentities(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/customers:listAccessibleCustomers" {
+			switch r.Header.Get("Authorization") {
+			case "Bearer access-1":
+				_, _ = w.Write([]byte(`{"resourceNames":["customers/111"]}`))
+			case "Bearer access-guid-2":
+				_, _ = w.Write([]byte(`{"resourceNames":["customers/111","customers/333"]}`))
+			default:
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+			return
+		}
+		_, _ = w.Write([]byte(`{"results":[{"customer":{"descriptiveName":"Acc","manager":false}}]}`))
+	}))
+	defer srv.Close()
+
+	ts := &fakeTS{identities: []string{"guid-1", "guid-2", "guid-broken"}}
+	c := New("dev-token", "", ts).WithBaseURL(srv.URL)
+	accounts, err := c.ListAccessibleAccounts(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	// общий аккаунт закреплён за первым логином, второй — за тем, кто его видит
+	require.Equal(t, "111", accounts[0].CustomerID)
+	require.Equal(t, "guid-1", accounts[0].GoogleUserID)
+	require.Equal(t, "333", accounts[1].CustomerID)
+	require.Equal(t, "guid-2", accounts[1].GoogleUserID)
+	// сломанный логин помечен как требующий согласия, но список не уронил
+	require.Equal(t, 1, ts.consent)
+}
+
+func TestLinksFor_PrefersDirectAccess(t *testing.T) {
+	tree := []entity.GoogleCustomer{
+		{CustomerID: "900", Manager: true, GoogleUserID: "g-a", Children: []entity.GoogleCustomer{
+			{CustomerID: "111", LoginCustomerID: "900", GoogleUserID: "g-a"},
+			{CustomerID: "222", LoginCustomerID: "900", GoogleUserID: "g-a"},
+		}},
+		{CustomerID: "111", GoogleUserID: "g-b"},
+	}
+	links := LinksFor(tree, []string{"111", "222", "333"})
+	require.Equal(t, []entity.GoogleAccountLink{
+		{CustomerID: "111", TokenOwner: "g-b"},
+		{CustomerID: "222", LoginCustomerID: "900", TokenOwner: "g-a"},
+		{CustomerID: "333"},
+	}, links)
+}
+
+type fakeLogins map[string]string
+
+func (f fakeLogins) LoginCustomerID(ctx context.Context, userID int64, customerID string) (string, error) {
+	return f[customerID], nil
+}
+
+func TestLoginCustomerIDPerAccount(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("login-customer-id"))
+		_, _ = w.Write([]byte(`{"results":[]}`))
+	}))
+	defer srv.Close()
+
+	c := New("dev-token", "555", &fakeTS{}).
+		WithBaseURL(srv.URL).
+		WithLoginResolver(fakeLogins{"222": "900-000-0000"})
+
+	conv := []entity.ClickConversion{{Gclid: "g-1"}}
+	_, err := c.UploadClickConversions(context.Background(), 7, "222", conv)
+	require.NoError(t, err)
+	// аккаунт без привязанного MCC — запасной глобальный
+	_, err = c.UploadClickConversions(context.Background(), 7, "333", conv)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"9000000000", "555"}, got)
+}
@@ -0,0 +1,134 @@
+package googleads
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// APIError — разобранный ответ Google Ads API с ошибкой (google.rpc.Status + GoogleAdsFailure).
+// Через errors.Is сводится к доменным ошибкам errs.ErrGoogle*, через errors.As — отдаёт детали.
+type APIError struct {
+	HTTPStatus int
+	Status     string // google.rpc код: RESOURCE_EXHAUSTED, PERMISSION_DENIED, ...
+	Code       string // первый errorCode из GoogleAdsFailure: "quotaError.RESOURCE_EXHAUSTED"
+	Message    string
+	RequestID  string
+	Retry      time.Duration // сколько подождать до повтора (quota/Retry-After), 0 — не задано
+
+	kind      error
+	retryable bool
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if e.Code != "" {
+		msg = e.Code + ": " + msg
+	}
+	if e.RequestID != "" {
+		msg += " (request_id " + e.RequestID + ")"
+	}
+	return fmt.Sprintf("google ads %d: %s", e.HTTPStatus, msg)
+}
+
+func (e *APIError) Unwrap() error { return e.kind }
+
+// RetryAfter — рекомендованная пауза перед повтором (используется сервисами через интерфейс).
+func (e *APIError) RetryAfter() time.Duration { return e.Retry }
+
+// Temporary — имеет ли смысл повторять тот же запрос.
+func (e *APIError) Temporary() bool { return e.retryable }
+
+type failureJSON struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+		Details []struct {
+			Type   string `json:"@type"`
+			Errors []struct {
+				ErrorCode map[string]string `json:"errorCode"`
+				Message   string            `json:"message"`
+				Details   struct {
+					QuotaErrorDetails struct {
+						RetryDelay string `json:"retryDelay"`
+					} `json:"quotaErrorDetails"`
+				} `json:"details"`
+			} `json:"errors"`
+			RequestID  string `json:"requestId"`
+			RetryDelay string `json:"retryDelay"` // google.rpc.RetryInfo
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// parseAPIError разбирает тело ошибки. searchStream отдаёт ошибку массивом — берём первый элемент.
+func parseAPIError(status int, header http.Header, body []byte) *APIError {
+	e := &APIError{HTTPStatus: status}
+
+	var f failureJSON
+	raw := strings.TrimSpace(string(body))
+	if strings.HasPrefix(raw, "[") {
+		var arr []failureJSON
+		if json.Unmarshal(body, &arr) == nil && len(arr) > 0 {
+			f = arr[0]
+		}
+	} else {
+		_ = json.Unmarshal(body, &f)
+	}
+
+	e.Status = f.Error.Status
+	e.Message = f.Error.Message
+	if e.Message == "" {
+		e.Message = raw
+	}
+	for _, d := range f.Error.Details {
+		if d.RequestID != "" {
+			e.RequestID = d.RequestID
+		}
+		if d.RetryDelay != "" && e.Retry == 0 {
+			e.Retry, _ = time.ParseDuration(d.RetryDelay)
+		}
+		for _, ge := range d.Errors {
+			if e.Code == "" {
+				for group, val := range ge.ErrorCode {
+					e.Code = group + "." + val
+					break
+				}
+				if ge.Message != "" {
+					e.Message = ge.Message
+				}
+			}
+			if rd := ge.Details.QuotaErrorDetails.RetryDelay; rd != "" && e.Retry == 0 {
+				e.Retry, _ = time.ParseDuration(rd)
+			}
+		}
+	}
+	if e.Retry == 0 && header != nil {
+		if secs, err := strconv.Atoi(header.Get("Retry-After")); err == nil && secs > 0 {
+			e.Retry = time.Duration(secs) * time.Second
+		}
+	}
+	e.classify()
+	return e
+}
+
+func (e *APIError) classify() {
+	code := e.Code
+	switch {
+	case strings.HasPrefix(code, "quotaError.") || e.Status == "RESOURCE_EXHAUSTED" || e.HTTPStatus == http.StatusTooManyRequests:
+		e.kind, e.retryable = errs.ErrGoogleQuotaExceeded, true
+	case strings.Contains(code, "DEVELOPER_TOKEN"):
+		e.kind = errs.ErrGoogleInvalidDeveloperToken
+	case strings.HasSuffix(code, "CUSTOMER_NOT_ENABLED"):
+		e.kind = errs.ErrGoogleCustomerNotEnabled
+	case strings.HasPrefix(code, "authorizationError.") || e.Status == "PERMISSION_DENIED" || e.HTTPStatus == http.StatusForbidden:
+		e.kind = errs.ErrGooglePermissionDenied
+	case e.HTTPStatus >= 500 || e.Status == "UNAVAILABLE" || e.Status == "DEADLINE_EXCEEDED" || strings.HasPrefix(code, "internalError."):
+		e.retryable = true
+	}
+}
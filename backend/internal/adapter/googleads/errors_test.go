@@ -0,0 +1,115 @@
+package googleads
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+func failureBody(status, group, code, extra string) string {
+	return `{"error":{"code":400,"message":"Request contains an invalid argument.","status":"` + status + `",
+		"details":[{"@type":"type.googleapis.com/google.ads.googleads.v21.errors.GoogleAdsFailure",
+		"errors":[{"errorCode":{"` + group + `":"` + code + `"},"message":"` + code + ` happened"` + extra + `}],
+		"requestId":"req-1"}]}}`
+}
+
+func TestParseAPIError_Kinds(t *testing.T) {
+	cases := []struct {
+		name   string
+		status int
+		body   string
+		kind   error
+		retry  bool
+	}{
+		{"quota", 429, failureBody("RESOURCE_EXHAUSTED", "quotaError", "RESOURCE_EXHAUSTED",
+			`,"details":{"quotaErrorDetails":{"rateScope":"DEVELOPER","retryDelay":"42s"}}`), errs.ErrGoogleQuotaExceeded, true},
+		{"permission", 403, failureBody("PERMISSION_DENIED", "authorizationError", "USER_PERMISSION_DENIED", ""), errs.ErrGooglePermissionDenied, false},
+		{"not enabled", 403, failureBody("PERMISSION_DENIED", "authorizationError", "CUSTOMER_NOT_ENABLED", ""), errs.ErrGoogleCustomerNotEnabled, false},
+		{"dev token", 403, failureBody("PERMISSION_DENIED", "authorizationError", "DEVELOPER_TOKEN_NOT_APPROVED", ""), errs.ErrGoogleInvalidDeveloperToken, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := parseAPIError(tc.status, nil, []byte(tc.body))
+			require.ErrorIs(t, e, tc.kind)
+			require.Equal(t, tc.retry, e.Temporary())
+			require.Equal(t, "req-1", e.RequestID)
+		})
+	}
+
+	q := parseAPIError(429, nil, []byte(cases[0].body))
+	require.Equal(t, 42*time.Second, q.RetryAfter())
+	require.Equal(t, "quotaError.RESOURCE_EXHAUSTED", q.Code)
+}
+
+func TestParseAPIError_StreamArrayAndRetryAfterHeader(t *testing.T) {
+	body := `[` + failureBody("INTERNAL", "internalError", "INTERNAL_ERROR", "") + `]`
+	h := http.Header{}
+	h.Set("Retry-After", "3")
+	e := parseAPIError(500, h, []byte(body))
+	require.True(t, e.Temporary())
+	require.Equal(t, 3*time.Second, e.RetryAfter())
+	require.Equal(t, "internalError.INTERNAL_ERROR", e.Code)
+}
+
+func TestDo_LongQuotaIsNotRetriedInline(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(failureBody("RESOURCE_EXHAUSTED", "quotaError", "RESOURCE_EXHAUSTED",
+			`,"details":{"quotaErrorDetails":{"retryDelay":"600s"}}`)))
+	}))
+	defer srv.Close()
+
+	c := New("dev-token", "", &fakeTS{}).WithBaseURL(srv.URL)
+	err := c.SyncCostsForDate(context.Background(), 7, "111", "2025-03-01", func(int64, string, int64) error { return nil })
+	require.ErrorIs(t, err, errs.ErrGoogleQuotaExceeded)
+	require.Equal(t, 1, calls)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, 10*time.Minute, apiErr.RetryAfter())
+}
+
+func TestDo_PermissionDeniedIsNotRetried(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(failureBody("PERMISSION_DENIED", "authorizationError", "USER_PERMISSION_DENIED", "")))
+	}))
+	defer srv.Close()
+
+	c := New("dev-token", "", &fakeTS{}).WithBaseURL(srv.URL)
+	err := c.SyncCostsForDate(context.Background(), 7, "111", "2025-03-01", func(int64, string, int64) error { return nil })
+	require.ErrorIs(t, err, errs.ErrGooglePermissionDenied)
+	require.Equal(t, 1, calls)
+}
+
+func TestSyncCostsForDate_CamelCaseFields(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"results":[{"adGroupAd":{"ad":{"id":"555"}},"segments":{"date":"2025-03-01"},"metrics":{"costMicros":"1230000"}}]}]`))
+	}))
+	defer srv.Close()
+
+	type row struct {
+		ad     int64
+		date   string
+		micros int64
+	}
+	var got []row
+	c := New("dev-token", "", &fakeTS{}).WithBaseURL(srv.URL)
+	err := c.SyncCostsForDate(context.Background(), 7, "111", "2025-03-01", func(ad int64, d string, m int64) error {
+		got = append(got, row{ad, d, m})
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []row{{555, "2025-03-01", 1230000}}, got)
+}
@@ -0,0 +1,61 @@
+package googleads
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Revoker отзывает выданные Google токены (https://oauth2.googleapis.com/revoke).
+// Отзыв refresh-токена аннулирует и весь грант приложения для этого логина.
+type Revoker struct {
+	http     *http.Client
+	endpoint string
+}
+
+func NewRevoker() *Revoker {
+	return &Revoker{
+		http:     &http.Client{Timeout: 10 * time.Second},
+		endpoint: "https://oauth2.googleapis.com/revoke",
+	}
+}
+
+// WithEndpoint переопределяет адрес revoke (локальный фейк-сервер в тестах).
+func (r *Revoker) WithEndpoint(endpoint string) *Revoker {
+	r.endpoint = endpoint
+	return r
+}
+
+// Revoke отзывает токен. Уже отозванный или просроченный токен (invalid_token) — не ошибка.
+func (r *Revoker) Revoke(ctx context.Context, token string) error {
+	form := url.Values{"token": {token}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("revoke request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	raw, _ := io.ReadAll(resp.Body)
+	var fail struct {
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(raw, &fail)
+	if resp.StatusCode == http.StatusBadRequest && fail.Error == "invalid_token" {
+		return nil
+	}
+	return fmt.Errorf("revoke %d: %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+}
@@ -0,0 +1,32 @@
+package googleads
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevoke(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		switch r.PostForm.Get("token") {
+		case "good":
+			w.WriteHeader(http.StatusOK)
+		case "gone":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_token","error_description":"Token expired or revoked"}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	r := NewRevoker().WithEndpoint(srv.URL)
+	require.NoError(t, r.Revoke(context.Background(), "good"))
+	// уже отозванный токен — тоже успех
+	require.NoError(t, r.Revoke(context.Background(), "gone"))
+	require.Error(t, r.Revoke(context.Background(), "other"))
+}
@@ -4,27 +4,46 @@ import (
 	"context"
 	"math/rand"
 	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
 )
 
 // легкий мок: реализует и ports.GoogleAdsClient, и service.GoogleAdsCostStreamer
 type StubRepo interface {
-	LinkGoogleAccounts(ctx context.Context, userID int64, tokenOwnerGoogleUserID string, customerIDs []string) error
+	LinkGoogleAccounts(ctx context.Context, workspaceID, userID int64, links []entity.GoogleAccountLink) error
 }
 
+const stubGoogleUser = "stub-google-user"
+
 type Stub struct {
 	repo StubRepo
 }
 
 func NewStub(repo StubRepo) *Stub { return &Stub{repo: repo} }
 
-func (s *Stub) ListAccessibleCustomers(ctx context.Context, userID int64) ([]string, error) {
-	// стаб отдает один "клиентский" аккаунт
-	return []string{"999-000-1111"}, nil
+func (s *Stub) ListAccessibleAccounts(ctx context.Context, userID int64) ([]entity.GoogleCustomer, error) {
+	// стаб отдает один "клиентский" аккаунт и MCC с одним клиентом (всё под фиктивным логином)
+	return []entity.GoogleCustomer{
+		{CustomerID: "999-000-1111", DescriptiveName: "Stub Client", CurrencyCode: "USD", TimeZone: "Europe/Kyiv", GoogleUserID: stubGoogleUser},
+		{
+			CustomerID: "999-000-2222", DescriptiveName: "Stub Manager", CurrencyCode: "USD", TimeZone: "Europe/Kyiv", Manager: true, GoogleUserID: stubGoogleUser,
+			Children: []entity.GoogleCustomer{
+				{CustomerID: "999-000-3333", DescriptiveName: "Stub Sub-Account", CurrencyCode: "EUR", TimeZone: "Europe/Berlin", LoginCustomerID: "999-000-2222", GoogleUserID: stubGoogleUser},
+			},
+		},
+	}, nil
 }
 
-func (s *Stub) LinkAccounts(ctx context.Context, userID int64, customerIDs []string) error {
-	// владельца токена подставим фиктивно
-	return s.repo.LinkGoogleAccounts(ctx, userID, "stub-google-user", customerIDs)
+func (s *Stub) LinkAccounts(ctx context.Context, workspaceID, userID int64, customerIDs []string) error {
+	// владельца токена и MCC берём из стабового дерева
+	tree, _ := s.ListAccessibleAccounts(ctx, userID)
+	links := LinksFor(tree, customerIDs)
+	for i := range links {
+		if links[i].TokenOwner == "" {
+			links[i].TokenOwner = stubGoogleUser
+		}
+	}
+	return s.repo.LinkGoogleAccounts(ctx, workspaceID, userID, links)
 }
 
 // используется сервисом синка
@@ -44,3 +63,18 @@ func (s *Stub) SyncCostsForDate(
 	}
 	return nil
 }
+
+// стаб принимает любые конверсии
+func (s *Stub) UploadClickConversions(ctx context.Context, userID int64, customerID string, convs []entity.ClickConversion) ([]error, error) {
+	return make([]error, len(convs)), nil
+}
+
+// стаб ничего не отзывает
+func (s *Stub) Revoke(ctx context.Context, token string) error {
+	return nil
+}
+
+// стаб «применяет» любую смену статуса объявления
+func (s *Stub) SetAdStatus(ctx context.Context, t entity.AdTarget, status string) error {
+	return nil
+}
@@ -2,14 +2,26 @@ package googleads
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
 )
 
 type Vault interface {
 	LoadRefreshToken(ctx context.Context, userID int64) (googleUserID, refreshTokenEnc, scope string, err error)
-	Decrypt(s string) (string, error)
+	LoadRefreshTokenFor(ctx context.Context, userID int64, googleUserID string) (refreshTokenEnc, scope string, err error)
+	ListGoogleIdentities(ctx context.Context, userID int64) ([]entity.GoogleIdentity, error)
+	DecryptRefreshToken(ctx context.Context, userID int64, googleUserID, enc string) (string, error)
 	MarkNeedsConsent(ctx context.Context, userID int64, googleUserID string) error
 }
 
@@ -17,32 +29,216 @@ type OAuthCfg interface {
 	ExchangeRefresh(ctx context.Context, refresh string) (*oauth2.Token, error)
 }
 
+// AccountOwners — чей Google-логин владеет привязанным аккаунтом (ad_accounts.token_owner)
+type AccountOwners interface {
+	TokenOwner(ctx context.Context, userID int64, customerID string) (string, error)
+}
+
+// EventPublisher — исходящие события (integration.needs_consent) для webhook-подписок
+type EventPublisher interface {
+	Publish(ctx context.Context, e entity.WebhookEvent) error
+}
+
+// Запас до истечения access-токена: ближе к expiry токен считается протухшим и обновляется
+const defaultExpiryMargin = 2 * time.Minute
+
+type cachedToken struct {
+	access string
+	expiry time.Time
+}
+
 type TS struct {
-	v  Vault
-	oc OAuthCfg
+	v      Vault
+	oc     OAuthCfg
+	owners AccountOwners
+	events EventPublisher
+
+	// кэш access-токенов по (user_id, google_user_id); обмен refresh→access через singleflight,
+	// чтобы параллельные синки одного логина не долбили token endpoint
+	mu     sync.Mutex
+	cache  map[string]cachedToken
+	group  singleflight.Group
+	margin time.Duration
+	now    func() time.Time
+}
+
+func NewTokenSource(v Vault, oc OAuthCfg) *TS {
+	return &TS{
+		v:      v,
+		oc:     oc,
+		cache:  map[string]cachedToken{},
+		margin: defaultExpiryMargin,
+		now:    time.Now,
+	}
+}
+
+// WithOwners включает выбор токена по владельцу аккаунта (для пользователей с несколькими Google-логинами).
+func (t *TS) WithOwners(o AccountOwners) *TS {
+	t.owners = o
+	return t
 }
 
-func NewTokenSource(v Vault, oc OAuthCfg) *TS { return &TS{v: v, oc: oc} }
+// WithEvents публикует integration.needs_consent, когда логин требует повторного согласия.
+func (t *TS) WithEvents(p EventPublisher) *TS {
+	t.events = p
+	return t
+}
 
+// Token — access-токен последнего подключённого Google-логина пользователя.
 func (t *TS) Token(ctx context.Context, userID int64) (string, string, error) {
 	googleUID, refreshEnc, _, err := t.v.LoadRefreshToken(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", t.noTokenErr(ctx, userID, "")
+	}
 	if err != nil {
 		return "", "", err
 	}
-	refresh, err := t.v.Decrypt(refreshEnc)
+	access, err := t.exchange(ctx, userID, googleUID, refreshEnc)
 	if err != nil {
 		return "", "", err
 	}
-	tok, err := t.oc.ExchangeRefresh(ctx, refresh)
+	return access, googleUID, nil
+}
+
+// TokenFor — access-токен конкретного Google-логина.
+func (t *TS) TokenFor(ctx context.Context, userID int64, googleUserID string) (string, error) {
+	refreshEnc, _, err := t.v.LoadRefreshTokenFor(ctx, userID, googleUserID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", t.noTokenErr(ctx, userID, googleUserID)
+	}
 	if err != nil {
-		return "", "", err
+		return "", err
+	}
+	return t.exchange(ctx, userID, googleUserID, refreshEnc)
+}
+
+// TokenForAccount — access-токен логина, которым привязан аккаунт customerID.
+// Аккаунты без записанного владельца обслуживаются токеном по умолчанию.
+func (t *TS) TokenForAccount(ctx context.Context, userID int64, customerID string) (string, string, error) {
+	if t.owners != nil {
+		owner, err := t.owners.TokenOwner(ctx, userID, customerID)
+		if err != nil {
+			return "", "", err
+		}
+		if owner != "" {
+			access, err := t.TokenFor(ctx, userID, owner)
+			return access, owner, err
+		}
+	}
+	return t.Token(ctx, userID)
+}
+
+// Identities — google_user_id подключённых логинов, не требующих повторного согласия.
+func (t *TS) Identities(ctx context.Context, userID int64) ([]string, error) {
+	list, err := t.v.ListGoogleIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
-	if !tok.Expiry.IsZero() && time.Until(tok.Expiry) < time.Minute {
-		// форс обновление, если вдруг на грани (опционально)
+	out := make([]string, 0, len(list))
+	for _, gi := range list {
+		if !gi.NeedsConsent {
+			out = append(out, gi.GoogleUserID)
+		}
 	}
-	return tok.AccessToken, googleUID, nil
+	return out, nil
 }
 
+// MarkNeedsConsent вызывается клиентом на 401: закэшированный токен логина больше не годится.
 func (t *TS) MarkNeedsConsent(ctx context.Context, userID int64, googleUserID string) error {
-	return t.v.MarkNeedsConsent(ctx, userID, googleUserID)
+	t.Invalidate(userID, googleUserID)
+	if err := t.v.MarkNeedsConsent(ctx, userID, googleUserID); err != nil {
+		return err
+	}
+	if t.events != nil {
+		err := t.events.Publish(ctx, entity.WebhookEvent{
+			Type:   entity.EventIntegrationNeedsConsent,
+			UserID: userID,
+			Data:   map[string]any{"platform": "google", "google_user_id": googleUserID},
+		})
+		if err != nil {
+			log.Printf("publish %s (user %d): %v", entity.EventIntegrationNeedsConsent, userID, err)
+		}
+	}
+	return nil
+}
+
+// Invalidate выбрасывает закэшированный access-токен логина.
+func (t *TS) Invalidate(userID int64, googleUserID string) {
+	t.mu.Lock()
+	delete(t.cache, cacheKey(userID, googleUserID))
+	t.mu.Unlock()
+}
+
+func cacheKey(userID int64, googleUserID string) string {
+	return strconv.FormatInt(userID, 10) + "/" + googleUserID
+}
+
+func (t *TS) cached(key string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ct, ok := t.cache[key]
+	if !ok || !t.now().Add(t.margin).Before(ct.expiry) {
+		return "", false
+	}
+	return ct.access, true
+}
+
+// noTokenErr различает «логин не подключён» и «логин есть, но ждёт повторного согласия»
+// (LoadRefreshToken* в обоих случаях отвечают sql.ErrNoRows).
+func (t *TS) noTokenErr(ctx context.Context, userID int64, googleUserID string) error {
+	list, err := t.v.ListGoogleIdentities(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, gi := range list {
+		if gi.NeedsConsent && (googleUserID == "" || gi.GoogleUserID == googleUserID) {
+			return errs.ErrGoogleNeedsConsent
+		}
+	}
+	return errs.ErrGoogleNotConnected
+}
+
+// exchange отдаёт access-токен логина из кэша или меняет refresh-токен на новый.
+func (t *TS) exchange(ctx context.Context, userID int64, googleUserID, refreshEnc string) (string, error) {
+	key := cacheKey(userID, googleUserID)
+	if access, ok := t.cached(key); ok {
+		return access, nil
+	}
+	v, err, _ := t.group.Do(key, func() (any, error) {
+		// пока ждали своей очереди, токен мог обновить соседний вызов
+		if access, ok := t.cached(key); ok {
+			return access, nil
+		}
+		return t.refresh(ctx, userID, googleUserID, refreshEnc)
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (t *TS) refresh(ctx context.Context, userID int64, googleUserID, refreshEnc string) (string, error) {
+	refresh, err := t.v.DecryptRefreshToken(ctx, userID, googleUserID, refreshEnc)
+	if err != nil {
+		return "", err
+	}
+	tok, err := t.oc.ExchangeRefresh(ctx, refresh)
+	if err != nil {
+		var re *oauth2.RetrieveError
+		if errors.As(err, &re) && re.ErrorCode == "invalid_grant" {
+			// refresh-токен отозван или протух — без нового согласия дальше не поедем
+			if mErr := t.MarkNeedsConsent(ctx, userID, googleUserID); mErr != nil {
+				log.Printf("google mark needs consent (user %d, %s): %v", userID, googleUserID, mErr)
+			}
+			return "", fmt.Errorf("%w: %v", errs.ErrGoogleNeedsConsent, err)
+		}
+		return "", err
+	}
+	if !tok.Expiry.IsZero() {
+		// токен без expiry не кэшируем — не знаем, когда он протухнет
+		t.mu.Lock()
+		t.cache[cacheKey(userID, googleUserID)] = cachedToken{access: tok.AccessToken, expiry: tok.Expiry}
+		t.mu.Unlock()
+	}
+	return tok.AccessToken, nil
 }
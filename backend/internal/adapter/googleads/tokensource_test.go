@@ -0,0 +1,166 @@
+package googleads
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+type fakeVault struct {
+	identities []entity.GoogleIdentity
+	consent    []string
+}
+
+func (v *fakeVault) LoadRefreshToken(ctx context.Context, userID int64) (string, string, string, error) {
+	for _, gi := range v.identities {
+		if !gi.NeedsConsent {
+			return gi.GoogleUserID, "enc-" + gi.GoogleUserID, "adwords", nil
+		}
+	}
+	return "", "", "", sql.ErrNoRows
+}
+
+func (v *fakeVault) LoadRefreshTokenFor(ctx context.Context, userID int64, googleUserID string) (string, string, error) {
+	for _, gi := range v.identities {
+		if gi.GoogleUserID == googleUserID && !gi.NeedsConsent {
+			return "enc-" + googleUserID, "adwords", nil
+		}
+	}
+	return "", "", sql.ErrNoRows
+}
+
+func (v *fakeVault) ListGoogleIdentities(ctx context.Context, userID int64) ([]entity.GoogleIdentity, error) {
+	return v.identities, nil
+}
+
+func (v *fakeVault) DecryptRefreshToken(ctx context.Context, userID int64, googleUserID, s string) (string, error) {
+	return s, nil
+}
+
+func (v *fakeVault) MarkNeedsConsent(ctx context.Context, userID int64, googleUserID string) error {
+	v.consent = append(v.consent, googleUserID)
+	return nil
+}
+
+type fakeOAuth struct {
+	err    error
+	expiry time.Time
+	delay  time.Duration
+	calls  atomic.Int32
+}
+
+func (f *fakeOAuth) ExchangeRefresh(ctx context.Context, refresh string) (*oauth2.Token, error) {
+	f.calls.Add(1)
+	time.Sleep(f.delay)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &oauth2.Token{AccessToken: "access-for-" + refresh, Expiry: f.expiry}, nil
+}
+
+type fakeOwners map[string]string
+
+func (f fakeOwners) TokenOwner(ctx context.Context, userID int64, customerID string) (string, error) {
+	return f[customerID], nil
+}
+
+func TestTokenSource_TypedErrors(t *testing.T) {
+	ts := NewTokenSource(&fakeVault{}, &fakeOAuth{})
+	_, _, err := ts.Token(context.Background(), 7)
+	require.ErrorIs(t, err, errs.ErrGoogleNotConnected)
+
+	ts = NewTokenSource(&fakeVault{identities: []entity.GoogleIdentity{{GoogleUserID: "g-1", NeedsConsent: true}}}, &fakeOAuth{})
+	_, _, err = ts.Token(context.Background(), 7)
+	require.ErrorIs(t, err, errs.ErrGoogleNeedsConsent)
+}
+
+type fakeEvents []entity.WebhookEvent
+
+func (f *fakeEvents) Publish(ctx context.Context, ev entity.WebhookEvent) error {
+	*f = append(*f, ev)
+	return nil
+}
+
+func TestTokenSource_InvalidGrantMarksConsent(t *testing.T) {
+	v := &fakeVault{identities: []entity.GoogleIdentity{{GoogleUserID: "g-1"}}}
+	var events fakeEvents
+	ts := NewTokenSource(v, &fakeOAuth{err: &oauth2.RetrieveError{ErrorCode: "invalid_grant"}}).WithEvents(&events)
+	_, _, err := ts.Token(context.Background(), 7)
+	require.ErrorIs(t, err, errs.ErrGoogleNeedsConsent)
+	require.Equal(t, []string{"g-1"}, v.consent)
+	// тот же путь, что и на 401: событие integration.needs_consent
+	require.Len(t, events, 1)
+	require.Equal(t, entity.EventIntegrationNeedsConsent, events[0].Type)
+	require.Equal(t, int64(7), events[0].UserID)
+}
+
+func TestTokenSource_TokenForAccountUsesOwner(t *testing.T) {
+	v := &fakeVault{identities: []entity.GoogleIdentity{{GoogleUserID: "g-1"}, {GoogleUserID: "g-2"}}}
+	ts := NewTokenSource(v, &fakeOAuth{}).WithOwners(fakeOwners{"222": "g-2"})
+
+	access, owner, err := ts.TokenForAccount(context.Background(), 7, "222")
+	require.NoError(t, err)
+	require.Equal(t, "g-2", owner)
+	require.Equal(t, "access-for-enc-g-2", access)
+
+	// без записанного владельца — логин по умолчанию
+	_, owner, err = ts.TokenForAccount(context.Background(), 7, "111")
+	require.NoError(t, err)
+	require.Equal(t, "g-1", owner)
+}
+
+func TestTokenSource_CachesUntilMargin(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	oc := &fakeOAuth{expiry: now.Add(time.Hour)}
+	ts := NewTokenSource(&fakeVault{identities: []entity.GoogleIdentity{{GoogleUserID: "g-1"}}}, oc)
+	ts.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		_, _, err := ts.Token(context.Background(), 7)
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, 1, oc.calls.Load())
+
+	// за минуту до expiry токен уже считается протухшим (запас 2 минуты)
+	now = now.Add(59 * time.Minute)
+	_, _, err := ts.Token(context.Background(), 7)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, oc.calls.Load())
+}
+
+func TestTokenSource_InvalidateOn401(t *testing.T) {
+	oc := &fakeOAuth{expiry: time.Now().Add(time.Hour)}
+	v := &fakeVault{identities: []entity.GoogleIdentity{{GoogleUserID: "g-1"}}}
+	ts := NewTokenSource(v, oc)
+
+	_, _, err := ts.Token(context.Background(), 7)
+	require.NoError(t, err)
+	require.NoError(t, ts.MarkNeedsConsent(context.Background(), 7, "g-1"))
+	require.Empty(t, ts.cache)
+}
+
+func TestTokenSource_SingleflightRefresh(t *testing.T) {
+	oc := &fakeOAuth{expiry: time.Now().Add(time.Hour), delay: 50 * time.Millisecond}
+	ts := NewTokenSource(&fakeVault{identities: []entity.GoogleIdentity{{GoogleUserID: "g-1"}}}, oc)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ts.TokenFor(context.Background(), 7, "g-1")
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.EqualValues(t, 1, oc.calls.Load())
+}
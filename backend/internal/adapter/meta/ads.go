@@ -0,0 +1,75 @@
+package meta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// SetAdStatus ставит объявление на паузу / включает его через Marketing API (POST /{ad_id} status=...).
+// Токен — ad_accounts.access_token аккаунта объявления. Отказ Meta оборачивается в errs.ErrAdMutationRejected.
+func (c *Client) SetAdStatus(ctx context.Context, t entity.AdTarget, status string) error {
+	var mStatus string
+	switch status {
+	case entity.AdStatusActive:
+		mStatus = "ACTIVE"
+	case entity.AdStatusPaused:
+		mStatus = "PAUSED"
+	default:
+		return fmt.Errorf("meta: unsupported ad status %q", status)
+	}
+
+	form := url.Values{}
+	form.Set("status", mStatus)
+	form.Set("access_token", t.AccessToken)
+	endpoint := fmt.Sprintf("%s/%d", c.base, t.AdID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("ad status request: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusOK {
+		var ok struct {
+			Success bool `json:"success"`
+		}
+		if err := json.Unmarshal(raw, &ok); err != nil {
+			return fmt.Errorf("ad status decode: %w", err)
+		}
+		if !ok.Success {
+			return fmt.Errorf("%w: meta returned success=false", errs.ErrAdMutationRejected)
+		}
+		return nil
+	}
+
+	var fail struct {
+		Error struct {
+			Message     string `json:"message"`
+			Code        int    `json:"code"`
+			IsTransient bool   `json:"is_transient"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(raw, &fail)
+	msg := fail.Error.Message
+	if msg == "" {
+		msg = strings.TrimSpace(string(raw))
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || fail.Error.IsTransient {
+		return fmt.Errorf("meta ad status %d: %s", resp.StatusCode, msg)
+	}
+	return fmt.Errorf("%w: meta %d (code %d): %s", errs.ErrAdMutationRejected, resp.StatusCode, fail.Error.Code, msg)
+}
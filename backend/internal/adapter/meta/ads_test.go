@@ -0,0 +1,58 @@
+package meta_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/meta"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+func TestSetAdStatus_OK(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "/238500001", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "PAUSED", r.PostForm.Get("status"))
+		require.Equal(t, "tok", r.PostForm.Get("access_token"))
+		_, _ = w.Write([]byte(`{"success":true}`))
+	}))
+	defer srv.Close()
+
+	err := meta.New().WithBaseURL(srv.URL).SetAdStatus(context.Background(),
+		entity.AdTarget{AdID: 238500001, Platform: "facebook", AccessToken: "tok"}, entity.AdStatusPaused)
+	require.NoError(t, err)
+}
+
+func TestSetAdStatus_Errors(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		body     string
+		rejected bool
+	}{
+		{"permission", 400, `{"error":{"message":"Ad account has no permission","code":200}}`, true},
+		{"transient", 400, `{"error":{"message":"Temporary","code":2,"is_transient":true}}`, false},
+		{"server error", 502, `oops`, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			err := meta.New().WithBaseURL(srv.URL).SetAdStatus(context.Background(),
+				entity.AdTarget{AdID: 1, AccessToken: "tok"}, entity.AdStatusActive)
+			require.Error(t, err)
+			require.Equal(t, tc.rejected, errors.Is(err, errs.ErrAdMutationRejected))
+		})
+	}
+}
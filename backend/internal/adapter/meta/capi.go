@@ -0,0 +1,142 @@
+package meta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// Client — минимальный клиент Meta Conversions API (Graph API /{pixel_id}/events)
+type Client struct {
+	http *http.Client
+	base string
+}
+
+func New() *Client {
+	return &Client{
+		http: &http.Client{Timeout: 15 * time.Second},
+		base: "https://graph.facebook.com/v21.0",
+	}
+}
+
+// WithBaseURL переопределяет адрес Graph API (локальный фейк-сервер в тестах).
+func (c *Client) WithBaseURL(base string) *Client {
+	c.base = strings.TrimRight(base, "/")
+	return c
+}
+
+type userData struct {
+	Em  []string `json:"em,omitempty"`
+	Ph  []string `json:"ph,omitempty"`
+	Fbc string   `json:"fbc,omitempty"`
+	Fbp string   `json:"fbp,omitempty"`
+}
+
+type customData struct {
+	Value    float64 `json:"value"`
+	Currency string  `json:"currency"`
+	OrderID  string  `json:"order_id,omitempty"`
+}
+
+type serverEvent struct {
+	EventName    string     `json:"event_name"`
+	EventTime    int64      `json:"event_time"`
+	EventID      string     `json:"event_id"`
+	ActionSource string     `json:"action_source"`
+	UserData     userData   `json:"user_data"`
+	CustomData   customData `json:"custom_data"`
+}
+
+// SendPurchase отправляет конверсию как событие Purchase и возвращает fbtrace_id.
+// Отказ Meta по самому событию (невалидные параметры) оборачивается в errs.ErrConversionRejected —
+// такие ошибки повтором не лечатся; остальные (сеть, 5xx, is_transient) — временные.
+func (c *Client) SendPurchase(ctx context.Context, ev entity.MetaCAPIEvent) (string, error) {
+	se := serverEvent{
+		EventName:    "Purchase",
+		EventTime:    ev.ConvertedAt.Unix(),
+		EventID:      ev.EventID,
+		ActionSource: "website",
+		CustomData: customData{
+			Value:    ev.Revenue.InexactFloat64(),
+			Currency: ev.Settings.Currency,
+		},
+	}
+	if ev.EmHash != nil {
+		se.UserData.Em = []string{*ev.EmHash}
+	}
+	if ev.PhHash != nil {
+		se.UserData.Ph = []string{*ev.PhHash}
+	}
+	if ev.Fbc != nil {
+		se.UserData.Fbc = *ev.Fbc
+	}
+	if ev.Fbp != nil {
+		se.UserData.Fbp = *ev.Fbp
+	}
+	if ev.OrderID != nil {
+		se.CustomData.OrderID = *ev.OrderID
+	}
+
+	body := struct {
+		Data          []serverEvent `json:"data"`
+		TestEventCode string        `json:"test_event_code,omitempty"`
+	}{Data: []serverEvent{se}, TestEventCode: ev.Settings.TestEventCode}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/events?access_token=%s",
+		c.base, url.PathEscape(ev.Settings.PixelID), url.QueryEscape(ev.Settings.AccessToken))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("capi request: %w", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode == http.StatusOK {
+		var ok struct {
+			EventsReceived int    `json:"events_received"`
+			FbtraceID      string `json:"fbtrace_id"`
+		}
+		if err := json.Unmarshal(raw, &ok); err != nil {
+			return "", fmt.Errorf("capi decode: %w", err)
+		}
+		return ok.FbtraceID, nil
+	}
+
+	var fail struct {
+		Error struct {
+			Message     string `json:"message"`
+			Type        string `json:"type"`
+			Code        int    `json:"code"`
+			IsTransient bool   `json:"is_transient"`
+			FbtraceID   string `json:"fbtrace_id"`
+		} `json:"error"`
+	}
+	_ = json.Unmarshal(raw, &fail)
+	msg := fail.Error.Message
+	if msg == "" {
+		msg = strings.TrimSpace(string(raw))
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || fail.Error.IsTransient {
+		return fail.Error.FbtraceID, fmt.Errorf("capi %d: %s", resp.StatusCode, msg)
+	}
+	return fail.Error.FbtraceID, fmt.Errorf("%w: capi %d (code %d): %s", errs.ErrConversionRejected, resp.StatusCode, fail.Error.Code, msg)
+}
@@ -0,0 +1,109 @@
+package meta_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/meta"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+func testEvent() entity.MetaCAPIEvent {
+	em := "b4c9a289323b21a01c3e940f150eb9b8c542587f1abfd8f0e1cc1ffc5e475514"
+	fbc := "fb.1.1700000000000.AbCdEf"
+	order := "A1"
+	return entity.MetaCAPIEvent{
+		ConversionID: 15,
+		EventID:      "evt-15",
+		EmHash:       &em,
+		Fbc:          &fbc,
+		ConvertedAt:  time.Unix(1700000100, 0).UTC(),
+		Revenue:      decimal.RequireFromString("49.99"),
+		OrderID:      &order,
+		Settings: entity.MetaCAPISettings{
+			PixelID:       "1234567890",
+			AccessToken:   "tok",
+			Currency:      "EUR",
+			TestEventCode: "TEST123",
+		},
+	}
+}
+
+func TestSendPurchase_OK(t *testing.T) {
+	var got struct {
+		Data []struct {
+			EventName    string `json:"event_name"`
+			EventTime    int64  `json:"event_time"`
+			EventID      string `json:"event_id"`
+			ActionSource string `json:"action_source"`
+			UserData     struct {
+				Em  []string `json:"em"`
+				Fbc string   `json:"fbc"`
+			} `json:"user_data"`
+			CustomData struct {
+				Value    float64 `json:"value"`
+				Currency string  `json:"currency"`
+				OrderID  string  `json:"order_id"`
+			} `json:"custom_data"`
+		} `json:"data"`
+		TestEventCode string `json:"test_event_code"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/1234567890/events", r.URL.Path)
+		require.Equal(t, "tok", r.URL.Query().Get("access_token"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"events_received":1,"fbtrace_id":"trace-1"}`))
+	}))
+	defer srv.Close()
+
+	trace, err := meta.New().WithBaseURL(srv.URL).SendPurchase(context.Background(), testEvent())
+	require.NoError(t, err)
+	require.Equal(t, "trace-1", trace)
+
+	require.Len(t, got.Data, 1)
+	ev := got.Data[0]
+	require.Equal(t, "Purchase", ev.EventName)
+	require.Equal(t, int64(1700000100), ev.EventTime)
+	require.Equal(t, "evt-15", ev.EventID)
+	require.Equal(t, "website", ev.ActionSource)
+	require.Len(t, ev.UserData.Em, 1)
+	require.Equal(t, "fb.1.1700000000000.AbCdEf", ev.UserData.Fbc)
+	require.Equal(t, "EUR", ev.CustomData.Currency)
+	require.Equal(t, "A1", ev.CustomData.OrderID)
+	require.Equal(t, "TEST123", got.TestEventCode)
+}
+
+func TestSendPurchase_Errors(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		body      string
+		permanent bool
+	}{
+		{"invalid parameter", 400, `{"error":{"message":"Invalid parameter","type":"OAuthException","code":100,"fbtrace_id":"t"}}`, true},
+		{"transient", 400, `{"error":{"message":"Temporary","code":2,"is_transient":true}}`, false},
+		{"server error", 503, `oops`, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			_, err := meta.New().WithBaseURL(srv.URL).SendPurchase(context.Background(), testEvent())
+			require.Error(t, err)
+			require.Equal(t, tc.permanent, errors.Is(err, errs.ErrConversionRejected))
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package meta
+
+import (
+	"context"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// Stub — мок Marketing API для локальных тестов без живого Meta (ADSIEVE_META_STUB=1)
+type Stub struct{}
+
+func NewStub() *Stub { return &Stub{} }
+
+// стаб «применяет» любую смену статуса объявления
+func (s *Stub) SetAdStatus(ctx context.Context, t entity.AdTarget, status string) error {
+	return nil
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AccessDenylistRepo — отозванные access-токены. Записи живут не дольше самих токенов.
+type AccessDenylistRepo struct {
+	db *sql.DB
+}
+
+func NewAccessDenylistRepo(db *sql.DB) *AccessDenylistRepo { return &AccessDenylistRepo{db: db} }
+
+// Deny отзывает access-токен jti до его истечения; заодно чистит истёкшие записи.
+func (r *AccessDenylistRepo) Deny(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	const q = `
+INSERT INTO revoked_access_tokens (jti, user_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (jti) DO NOTHING`
+	if _, err := r.db.ExecContext(ctx, q, jti, userID, expiresAt); err != nil {
+		return fmt.Errorf("deny access token: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM revoked_access_tokens WHERE expires_at < NOW()`); err != nil {
+		return fmt.Errorf("purge denylist: %w", err)
+	}
+	return nil
+}
+
+// CutOff отзывает все access-токены пользователя, выданные до текущего момента.
+// ttl — время жизни access-токена: дольше отсечка не нужна.
+//
+// iat в JWT — целые секунды, поэтому и not_before хранится с точностью до секунды: токен,
+// выданный в ту же секунду после отсечки (новый вход), проходит. Токены той же секунды,
+// выданные до отсечки, отсекаются по отозванной сессии (SignOutEverywhere отзывает все).
+func (r *AccessDenylistRepo) CutOff(ctx context.Context, userID int64, ttl time.Duration) error {
+	const q = `
+INSERT INTO access_token_cutoffs (user_id, not_before, expires_at)
+VALUES ($1, date_trunc('second', NOW()), NOW() + make_interval(secs => $2))
+ON CONFLICT (user_id) DO UPDATE
+SET not_before = EXCLUDED.not_before, expires_at = EXCLUDED.expires_at`
+	if _, err := r.db.ExecContext(ctx, q, userID, ttl.Seconds()); err != nil {
+		return fmt.Errorf("cut off access tokens: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked — отозван ли access-токен: по jti, по отозванной сессии sid
+// или отсечкой «выйти отовсюду» (токен выдан раньше not_before).
+func (r *AccessDenylistRepo) IsRevoked(ctx context.Context, jti string, userID, sessionID int64, issuedAt time.Time) (bool, error) {
+	const q = `
+SELECT EXISTS (SELECT 1 FROM revoked_access_tokens WHERE jti = $1 AND $1 <> '')
+    OR EXISTS (SELECT 1 FROM auth_sessions WHERE session_id = $3 AND user_id = $2 AND revoked_at IS NOT NULL)
+    OR EXISTS (SELECT 1 FROM access_token_cutoffs
+               WHERE user_id = $2 AND expires_at > NOW() AND not_before > date_trunc('second', $4::timestamptz))`
+	var revoked bool
+	if err := r.db.QueryRowContext(ctx, q, jti, userID, sessionID, issuedAt).Scan(&revoked); err != nil {
+		return false, fmt.Errorf("check access token: %w", err)
+	}
+	return revoked, nil
+}
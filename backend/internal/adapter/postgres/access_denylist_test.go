@@ -0,0 +1,76 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+)
+
+func newDenylist(t *testing.T) (*postgres.AccessDenylistRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewAccessDenylistRepo(db), mock, func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+		_ = db.Close()
+	}
+}
+
+func TestAccessDenylist_Deny(t *testing.T) {
+	repo, mock, done := newDenylist(t)
+	defer done()
+
+	exp := time.Now().Add(time.Hour)
+	mock.ExpectExec(`INSERT INTO revoked_access_tokens \(jti, user_id, expires_at\)`).
+		WithArgs("jti-1", int64(7), exp).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM revoked_access_tokens WHERE expires_at < NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	require.NoError(t, repo.Deny(context.Background(), "jti-1", 7, exp))
+}
+
+func TestAccessDenylist_CutOff(t *testing.T) {
+	repo, mock, done := newDenylist(t)
+	defer done()
+
+	mock.ExpectExec(`INSERT INTO access_token_cutoffs .+VALUES \(\$1, date_trunc\('second', NOW\(\)\).+ON CONFLICT \(user_id\) DO UPDATE`).
+		WithArgs(int64(7), float64(86400)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.CutOff(context.Background(), 7, 24*time.Hour))
+}
+
+func TestAccessDenylist_IsRevoked(t *testing.T) {
+	repo, mock, done := newDenylist(t)
+	defer done()
+
+	iat := time.Now().Add(-time.Minute)
+	mock.ExpectQuery(`FROM revoked_access_tokens WHERE jti = \$1.+FROM auth_sessions WHERE session_id = \$3.+FROM access_token_cutoffs`).
+		WithArgs("jti-1", int64(7), int64(3), iat).
+		WillReturnRows(sqlmock.NewRows([]string{"revoked"}).AddRow(true))
+
+	revoked, err := repo.IsRevoked(context.Background(), "jti-1", 7, 3, iat)
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
+
+// Вход в ту же секунду, что и «выйти отовсюду»: iat токена (целые секунды) равен
+// not_before, а не меньше его, — токен не считается выданным до отсечки.
+func TestAccessDenylist_IsRevoked_SameSecondAsCutOff(t *testing.T) {
+	repo, mock, done := newDenylist(t)
+	defer done()
+
+	iat := time.Unix(1741000000, 0)
+	mock.ExpectQuery(`FROM access_token_cutoffs\s+WHERE user_id = \$2 AND expires_at > NOW\(\) AND not_before > date_trunc\('second', \$4::timestamptz\)`).
+		WithArgs("jti-2", int64(7), int64(4), iat).
+		WillReturnRows(sqlmock.NewRows([]string{"revoked"}).AddRow(false))
+
+	revoked, err := repo.IsRevoked(context.Background(), "jti-2", 7, 4, iat)
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
@@ -17,15 +17,18 @@ type AdsRepo struct {
 
 func NewAdsRepo(db *sql.DB) *AdsRepo { return &AdsRepo{db: db} }
 
-func (r *AdsRepo) ListByUser(ctx context.Context, userID int64, f entity.AdsFilter) ([]entity.Ad, int, error) {
-	orderBy := orderClause(f.Sort)
-
-	const baseFrom = `
+// Единственная модель доступа к объявлениям: объявление принадлежит пространству своего аккаунта.
+// Ею пользуются и список объявлений, и метрики.
+const baseFrom = `
 		FROM ads a
 		JOIN ad_accounts aa ON aa.account_id = a.account_id
 	`
-	conds := []string{"aa.user_id = $1"}
-	args := []any{userID}
+
+// ListByWorkspace — объявления аккаунтов рабочего пространства.
+func (r *AdsRepo) ListByWorkspace(ctx context.Context, workspaceID int64, f entity.AdsFilter) ([]entity.Ad, int, error) {
+	orderBy := orderClause(f.Sort)
+	conds := []string{"aa.workspace_id = $1"}
+	args := []any{workspaceID}
 	next := 2
 
 	if f.Status != nil && *f.Status != "" {
@@ -90,6 +93,25 @@ func (r *AdsRepo) ListByUser(ctx context.Context, userID int64, f entity.AdsFilt
 	return out, total, nil
 }
 
+// IDsByWorkspace — id объявлений аккаунтов рабочего пространства (scope для метрик).
+func (r *AdsRepo) IDsByWorkspace(ctx context.Context, workspaceID int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT a.ad_id "+baseFrom+" WHERE aa.workspace_id = $1 ORDER BY a.ad_id", workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, 8)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func itoa(v int) string { return strconv.FormatInt(int64(v), 10) }
 
 func orderClause(sort string) string {
@@ -109,3 +131,42 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+const targetSQL = `
+SELECT a.ad_id, a.account_id, aa.user_id, a.platform, aa.external_account_id, aa.access_token, a.status
+FROM ads a
+JOIN ad_accounts aa ON aa.account_id = a.account_id
+`
+
+// WorkspaceTarget — объявление рабочего пространства вместе с аккаунтом платформы (для изменения
+// статуса); UserID — кто привязал аккаунт (его токенами идёт запрос к платформе).
+// Чужое или несуществующее объявление — sql.ErrNoRows.
+func (r *AdsRepo) WorkspaceTarget(ctx context.Context, workspaceID, adID int64) (entity.AdTarget, error) {
+	var t entity.AdTarget
+	err := r.db.QueryRowContext(ctx, targetSQL+`WHERE a.ad_id = $1 AND aa.workspace_id = $2`, adID, workspaceID).
+		Scan(&t.AdID, &t.AccountID, &t.UserID, &t.Platform, &t.ExternalAccountID, &t.AccessToken, &t.Status)
+	return t, err
+}
+
+// SwapStatus меняет статус, только если он всё ещё from (оптимистичная блокировка).
+// false — статус успели поменять параллельно.
+func (r *AdsRepo) SwapStatus(ctx context.Context, adID int64, from, to string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE ads SET status = $3 WHERE ad_id = $1 AND status = $2`, adID, from, to)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// LogStatusChange пишет запись аудита смены статуса.
+func (r *AdsRepo) LogStatusChange(ctx context.Context, ch entity.AdStatusChange) error {
+	const q = `
+INSERT INTO ad_status_changes (ad_id, user_id, platform, from_status, to_status, result, error)
+VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))`
+	_, err := r.db.ExecContext(ctx, q, ch.AdID, ch.UserID, ch.Platform, ch.FromStatus, ch.ToStatus, ch.Result, ch.Error)
+	return err
+}
@@ -18,11 +18,11 @@ func newAdsRepo(t *testing.T) (*postgres.AdsRepo, sqlmock.Sqlmock, func()) {
 	return postgres.NewAdsRepo(db), mock, func() { _ = db.Close() }
 }
 
-func TestAdsRepo_ListByUser_HappyWithFilters(t *testing.T) {
+func TestAdsRepo_ListByWorkspace_HappyWithFilters(t *testing.T) {
 	repo, mock, done := newAdsRepo(t)
 	defer done()
 
-	userID := int64(42)
+	workspaceID := int64(42)
 	status := "active"
 	platform := "facebook"
 	query := "sale"
@@ -37,18 +37,18 @@ func TestAdsRepo_ListByUser_HappyWithFilters(t *testing.T) {
 	}
 
 	// COUNT(*)
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1\s+AND a\.status = \$2\s+AND a\.platform = \$3\s+AND a\.name ILIKE \$4\s+AND a\.ad_id = ANY\(\$5\)`).
-		WithArgs(userID, status, platform, "%"+query+"%", pq.Array([]int64{87, 112})).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1\s+AND a\.status = \$2\s+AND a\.platform = \$3\s+AND a\.name ILIKE \$4\s+AND a\.ad_id = ANY\(\$5\)`).
+		WithArgs(workspaceID, status, platform, "%"+query+"%", pq.Array([]int64{87, 112})).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 
 	// SELECT items — ждём сортировку по имени по убыванию
-	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1\s+AND a\.status = \$2\s+AND a\.platform = \$3\s+AND a\.name ILIKE \$4\s+AND a\.ad_id = ANY\(\$5\)\s+ORDER BY a\.name DESC\s+LIMIT \$6 OFFSET \$7`).
-		WithArgs(userID, status, platform, "%"+query+"%", pq.Array([]int64{87, 112}), 2, 0).
+	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1\s+AND a\.status = \$2\s+AND a\.platform = \$3\s+AND a\.name ILIKE \$4\s+AND a\.ad_id = ANY\(\$5\)\s+ORDER BY a\.name DESC\s+LIMIT \$6 OFFSET \$7`).
+		WithArgs(workspaceID, status, platform, "%"+query+"%", pq.Array([]int64{87, 112}), 2, 0).
 		WillReturnRows(sqlmock.NewRows([]string{"ad_id", "account_id", "name", "status", "platform"}).
 			AddRow(int64(87), int64(1001), "Summer Sale Shoes", "active", "facebook").
 			AddRow(int64(112), int64(1001), "Sale – Leads", "active", "facebook"))
 
-	items, total, err := repo.ListByUser(context.Background(), userID, f)
+	items, total, err := repo.ListByWorkspace(context.Background(), workspaceID, f)
 	require.NoError(t, err)
 	require.Equal(t, 2, total)
 	require.Len(t, items, 2)
@@ -57,142 +57,142 @@ func TestAdsRepo_ListByUser_HappyWithFilters(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestAdsRepo_ListByUser_Empty(t *testing.T) {
+func TestAdsRepo_ListByWorkspace_Empty(t *testing.T) {
 	repo, mock, done := newAdsRepo(t)
 	defer done()
 
-	userID := int64(42)
+	workspaceID := int64(42)
 	f := entity.AdsFilter{Limit: 10, Offset: 0}
 
 	// COUNT(*) → 0
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1`).
-		WithArgs(userID).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1`).
+		WithArgs(workspaceID).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 
-	items, total, err := repo.ListByUser(context.Background(), userID, f)
+	items, total, err := repo.ListByWorkspace(context.Background(), workspaceID, f)
 	require.NoError(t, err)
 	require.Equal(t, 0, total)
 	require.Len(t, items, 0)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestAdsRepo_ListByUser_CountError(t *testing.T) {
+func TestAdsRepo_ListByWorkspace_CountError(t *testing.T) {
 	repo, mock, done := newAdsRepo(t)
 	defer done()
 
-	userID := int64(42)
+	workspaceID := int64(42)
 	f := entity.AdsFilter{Limit: 10, Offset: 0}
 
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1`).
-		WithArgs(userID).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1`).
+		WithArgs(workspaceID).
 		WillReturnError(sqlmock.ErrCancelled)
 
-	_, _, err := repo.ListByUser(context.Background(), userID, f)
+	_, _, err := repo.ListByWorkspace(context.Background(), workspaceID, f)
 	require.Error(t, err)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestAdsRepo_ListByUser_SelectError(t *testing.T) {
+func TestAdsRepo_ListByWorkspace_SelectError(t *testing.T) {
 	repo, mock, done := newAdsRepo(t)
 	defer done()
 
-	userID := int64(42)
+	workspaceID := int64(42)
 	f := entity.AdsFilter{Limit: 10, Offset: 0, Sort: "name"}
 
 	// COUNT(*) → 2
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1`).
-		WithArgs(userID).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1`).
+		WithArgs(workspaceID).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 
 	// SELECT → ошибка
 	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform`).
-		WithArgs(userID, 10, 0).
+		WithArgs(workspaceID, 10, 0).
 		WillReturnError(sqlmock.ErrCancelled)
 
-	_, _, err := repo.ListByUser(context.Background(), userID, f)
+	_, _, err := repo.ListByWorkspace(context.Background(), workspaceID, f)
 	require.Error(t, err)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 // ---- ДОПОЛНЕНИЯ: сортировки, Scan-ошибка и rows.Err() ----
 
-func TestAdsRepo_ListByUser_SortByNameAsc(t *testing.T) {
+func TestAdsRepo_ListByWorkspace_SortByNameAsc(t *testing.T) {
 	repo, mock, done := newAdsRepo(t)
 	defer done()
 
-	userID := int64(7)
+	workspaceID := int64(7)
 	f := entity.AdsFilter{Limit: 2, Offset: 0, Sort: "name"}
 
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1`).
-		WithArgs(userID).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1`).
+		WithArgs(workspaceID).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 
-	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1\s+ORDER BY a\.name ASC\s+LIMIT \$2 OFFSET \$3`).
-		WithArgs(userID, 2, 0).
+	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1\s+ORDER BY a\.name ASC\s+LIMIT \$2 OFFSET \$3`).
+		WithArgs(workspaceID, 2, 0).
 		WillReturnRows(sqlmock.NewRows([]string{"ad_id", "account_id", "name", "status", "platform"}).
 			AddRow(int64(1), int64(10), "A", "active", "facebook"))
 
-	items, total, err := repo.ListByUser(context.Background(), userID, f)
+	items, total, err := repo.ListByWorkspace(context.Background(), workspaceID, f)
 	require.NoError(t, err)
 	require.Equal(t, 1, total)
 	require.Len(t, items, 1)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestAdsRepo_ListByUser_SortByNameDesc(t *testing.T) {
+func TestAdsRepo_ListByWorkspace_SortByNameDesc(t *testing.T) {
 	repo, mock, done := newAdsRepo(t)
 	defer done()
 
-	userID := int64(7)
+	workspaceID := int64(7)
 	f := entity.AdsFilter{Limit: 1, Offset: 0, Sort: "-name"}
 
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1`).
-		WithArgs(userID).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1`).
+		WithArgs(workspaceID).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 
-	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1\s+ORDER BY a\.name DESC\s+LIMIT \$2 OFFSET \$3`).
-		WithArgs(userID, 1, 0).
+	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1\s+ORDER BY a\.name DESC\s+LIMIT \$2 OFFSET \$3`).
+		WithArgs(workspaceID, 1, 0).
 		WillReturnRows(sqlmock.NewRows([]string{"ad_id", "account_id", "name", "status", "platform"}).
 			AddRow(int64(2), int64(10), "Z", "active", "facebook"))
 
-	_, _, err := repo.ListByUser(context.Background(), userID, f)
+	_, _, err := repo.ListByWorkspace(context.Background(), workspaceID, f)
 	require.NoError(t, err)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestAdsRepo_ListByUser_ScanError(t *testing.T) {
+func TestAdsRepo_ListByWorkspace_ScanError(t *testing.T) {
 	repo, mock, done := newAdsRepo(t)
 	defer done()
 
-	userID := int64(9)
+	workspaceID := int64(9)
 	f := entity.AdsFilter{Limit: 10, Offset: 0}
 
 	// COUNT(*) → 1
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1`).
-		WithArgs(userID).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1`).
+		WithArgs(workspaceID).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 
 	// SELECT → тип в первой колонке ломает Scan (строка вместо BIGINT)
 	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform`).
-		WithArgs(userID, 10, 0).
+		WithArgs(workspaceID, 10, 0).
 		WillReturnRows(sqlmock.NewRows([]string{"ad_id", "account_id", "name", "status", "platform"}).
 			AddRow("oops", int64(10), "Name", "active", "facebook"))
 
-	_, _, err := repo.ListByUser(context.Background(), userID, f)
+	_, _, err := repo.ListByWorkspace(context.Background(), workspaceID, f)
 	require.Error(t, err)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestAdsRepo_ListByUser_ScanError_SecondRow(t *testing.T) {
+func TestAdsRepo_ListByWorkspace_ScanError_SecondRow(t *testing.T) {
 	repo, mock, done := newAdsRepo(t)
 	defer done()
 
-	userID := int64(9)
+	workspaceID := int64(9)
 	f := entity.AdsFilter{Limit: 10, Offset: 0}
 
 	// COUNT(*) → 2 (будем читать 2 строки)
-	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.user_id = \$1`).
-		WithArgs(userID).
+	mock.ExpectQuery(`SELECT\s+COUNT\(\*\)\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE\s+aa\.workspace_id = \$1`).
+		WithArgs(workspaceID).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
 
 	// Первая строка валидна, вторая ломает Scan
@@ -201,10 +201,92 @@ func TestAdsRepo_ListByUser_ScanError_SecondRow(t *testing.T) {
 		AddRow("oops", int64(10), "B", "active", "facebook")
 
 	mock.ExpectQuery(`SELECT\s+a\.ad_id, a\.account_id, a\.name, a\.status, a\.platform`).
-		WithArgs(userID, 10, 0).
+		WithArgs(workspaceID, 10, 0).
 		WillReturnRows(rows)
 
-	_, _, err := repo.ListByUser(context.Background(), userID, f)
+	_, _, err := repo.ListByWorkspace(context.Background(), workspaceID, f)
 	require.Error(t, err)
 	require.NoError(t, mock.ExpectationsWereMet())
 }
+
+// ---- смена статуса ----
+
+func TestAdsRepo_SwapStatus(t *testing.T) {
+	repo, mock, done := newAdsRepo(t)
+	defer done()
+
+	mock.ExpectExec(`UPDATE ads SET status = \$3 WHERE ad_id = \$1 AND status = \$2`).
+		WithArgs(int64(87), "active", "paused").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE ads SET status = \$3 WHERE ad_id = \$1 AND status = \$2`).
+		WithArgs(int64(87), "active", "paused").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.SwapStatus(context.Background(), 87, "active", "paused")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// статус уже поменяли параллельно
+	ok, err = repo.SwapStatus(context.Background(), 87, "active", "paused")
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdsRepo_WorkspaceTarget_ScopedToWorkspace(t *testing.T) {
+	repo, mock, done := newAdsRepo(t)
+	defer done()
+
+	mock.ExpectQuery(`FROM ads a\s+JOIN ad_accounts aa ON aa\.account_id = a\.account_id\s+WHERE a\.ad_id = \$1 AND aa\.workspace_id = \$2`).
+		WithArgs(int64(87), int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id", "account_id", "user_id", "platform", "external_account_id", "access_token", "status"}).
+			AddRow(int64(87), int64(1001), int64(42), "facebook", "act_1", "tok", "active"))
+
+	tg, err := repo.WorkspaceTarget(context.Background(), 3, 87)
+	require.NoError(t, err)
+	require.Equal(t, int64(42), tg.UserID) // токены того, кто привязал аккаунт
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdsRepo_IDsByWorkspace(t *testing.T) {
+	repo, mock, done := newAdsRepo(t)
+	defer done()
+
+	// та же модель доступа, что у ListByWorkspace: через аккаунт объявления
+	mock.ExpectQuery(`SELECT a\.ad_id\s+FROM\s+ads a\s+JOIN\s+ad_accounts aa\s+ON aa\.account_id = a\.account_id\s+WHERE aa\.workspace_id = \$1\s+ORDER BY a\.ad_id`).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id"}).AddRow(int64(10)).AddRow(int64(11)).AddRow(int64(12)))
+
+	ids, err := repo.IDsByWorkspace(context.Background(), 5)
+	require.NoError(t, err)
+	require.Equal(t, []int64{10, 11, 12}, ids)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdsRepo_IDsByWorkspace_Empty(t *testing.T) {
+	repo, mock, done := newAdsRepo(t)
+	defer done()
+
+	mock.ExpectQuery(`SELECT a\.ad_id\s+FROM\s+ads a`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id"}))
+
+	ids, err := repo.IDsByWorkspace(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, ids, 0)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdsRepo_IDsByWorkspace_ScanError(t *testing.T) {
+	repo, mock, done := newAdsRepo(t)
+	defer done()
+
+	mock.ExpectQuery(`SELECT a\.ad_id\s+FROM\s+ads a`).
+		WithArgs(int64(77)).
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id"}).AddRow("oops"))
+
+	ids, err := repo.IDsByWorkspace(context.Background(), 77)
+	require.Error(t, err)
+	require.Nil(t, ids)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
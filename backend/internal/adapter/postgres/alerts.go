@@ -0,0 +1,209 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// AlertsRepo — алерты, каналы доставки и очередь alert_deliveries.
+type AlertsRepo struct {
+	db *sql.DB
+}
+
+func NewAlertsRepo(db *sql.DB) *AlertsRepo { return &AlertsRepo{db: db} }
+
+// MetricHistory — дневные метрики всех объявлений за [from, to] с пространством аккаунта (по ad_id, дням).
+func (r *AlertsRepo) MetricHistory(ctx context.Context, from, to time.Time) ([]entity.AdMetricDay, error) {
+	const q = `
+SELECT aa.workspace_id, m.ad_id, a.name, m.metric_date, m.clicks, m.conversions, m.revenue, m.spend
+FROM ad_daily_metrics m
+JOIN ads a          ON a.ad_id = m.ad_id
+JOIN ad_accounts aa ON aa.account_id = a.account_id
+WHERE m.metric_date BETWEEN $1::date AND $2::date
+ORDER BY m.ad_id, m.metric_date`
+	rows, err := r.db.QueryContext(ctx, q, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("metric history: %w", err)
+	}
+	defer rows.Close()
+
+	var out []entity.AdMetricDay
+	for rows.Next() {
+		var d entity.AdMetricDay
+		if err := rows.Scan(&d.WorkspaceID, &d.AdID, &d.Name, &d.Day, &d.Clicks, &d.Conversions, &d.Revenue, &d.Spend); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// CreateAlert сохраняет алерт и ставит его в очередь во все включённые каналы пространства.
+// Такой алерт уже есть (объявление/метрика/день) — created=false, ничего не меняется.
+// AdID = 0 — алерт без объявления (бюджет).
+func (r *AlertsRepo) CreateAlert(ctx context.Context, a entity.Alert) (int64, bool, error) {
+	const q = `
+WITH ins AS (
+	INSERT INTO alerts (workspace_id, ad_id, kind, metric, metric_date, value, baseline, z_score, pct_change, direction, message)
+	VALUES ($1, NULLIF($2::bigint, 0), $3, $4, $5::date, $6, $7, $8, $9, $10, $11)
+	ON CONFLICT DO NOTHING
+	RETURNING alert_id, workspace_id
+), enq AS (
+	INSERT INTO alert_deliveries (alert_id, channel_id)
+	SELECT ins.alert_id, c.channel_id
+	FROM ins JOIN alert_channels c ON c.workspace_id = ins.workspace_id AND c.enabled
+)
+SELECT alert_id FROM ins`
+	var id int64
+	err := r.db.QueryRowContext(ctx, q, a.WorkspaceID, a.AdID, a.Kind, a.Metric, a.Day,
+		a.Value, a.Baseline, a.ZScore, a.PctChange, a.Direction, a.Message).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("create alert: %w", err)
+	}
+	return id, true, nil
+}
+
+const alertColumns = `a.alert_id, a.workspace_id, COALESCE(a.ad_id, 0), COALESCE(ad.name, ''), a.kind, a.metric, to_char(a.metric_date, 'YYYY-MM-DD'),
+       a.value, a.baseline, a.z_score, a.pct_change, a.direction, a.message, a.created_at`
+
+func alertDest(a *entity.Alert) []any {
+	return []any{&a.AlertID, &a.WorkspaceID, &a.AdID, &a.AdName, &a.Kind, &a.Metric, &a.Day,
+		&a.Value, &a.Baseline, &a.ZScore, &a.PctChange, &a.Direction, &a.Message, &a.CreatedAt}
+}
+
+// ListAlerts — последние алерты пространства (новые сверху).
+func (r *AlertsRepo) ListAlerts(ctx context.Context, workspaceID int64, limit int) ([]entity.Alert, error) {
+	q := `SELECT ` + alertColumns + `
+FROM alerts a
+LEFT JOIN ads ad ON ad.ad_id = a.ad_id
+WHERE a.workspace_id = $1
+ORDER BY a.created_at DESC, a.alert_id DESC
+LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, q, workspaceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.Alert{}
+	for rows.Next() {
+		var a entity.Alert
+		if err := rows.Scan(alertDest(&a)...); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
+
+func (r *AlertsRepo) ListChannels(ctx context.Context, workspaceID int64) ([]entity.AlertChannel, error) {
+	rows, err := r.db.QueryContext(ctx, `
+SELECT channel_id, workspace_id, user_id, kind, target, enabled, created_at
+FROM alert_channels WHERE workspace_id = $1 ORDER BY channel_id`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.AlertChannel{}
+	for rows.Next() {
+		var ch entity.AlertChannel
+		if err := rows.Scan(&ch.ChannelID, &ch.WorkspaceID, &ch.UserID, &ch.Kind, &ch.Target, &ch.Enabled, &ch.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, ch)
+	}
+	return out, rows.Err()
+}
+
+// CreateChannel добавляет канал пространства; тот же kind+target повторно — включает существующий.
+func (r *AlertsRepo) CreateChannel(ctx context.Context, ch entity.AlertChannel) (entity.AlertChannel, error) {
+	const q = `
+INSERT INTO alert_channels (workspace_id, user_id, kind, target, enabled)
+VALUES ($1, $2, $3, $4, TRUE)
+ON CONFLICT (workspace_id, kind, target) DO UPDATE SET enabled = TRUE
+RETURNING channel_id, workspace_id, user_id, kind, target, enabled, created_at`
+	var out entity.AlertChannel
+	err := r.db.QueryRowContext(ctx, q, ch.WorkspaceID, ch.UserID, ch.Kind, ch.Target).
+		Scan(&out.ChannelID, &out.WorkspaceID, &out.UserID, &out.Kind, &out.Target, &out.Enabled, &out.CreatedAt)
+	return out, err
+}
+
+// DeleteChannel — false, если канала в пространстве нет.
+func (r *AlertsRepo) DeleteChannel(ctx context.Context, workspaceID, channelID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM alert_channels WHERE channel_id = $1 AND workspace_id = $2`, channelID, workspaceID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ClaimDeliveries забирает до limit готовых к отправке доставок: attempts+1 и next_attempt_at
+// сдвигается на lease — упавший воркер не держит доставку, её заберут после lease.
+func (r *AlertsRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.AlertDelivery, error) {
+	q := `
+WITH due AS (
+	SELECT alert_id, channel_id
+	FROM alert_deliveries
+	WHERE status = 'pending' AND next_attempt_at <= NOW()
+	ORDER BY next_attempt_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+), upd AS (
+	UPDATE alert_deliveries d
+	SET attempts = d.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+	FROM due
+	WHERE d.alert_id = due.alert_id AND d.channel_id = due.channel_id
+	RETURNING d.alert_id, d.channel_id, d.attempts
+)
+SELECT ` + alertColumns + `, c.channel_id, c.kind, c.target, upd.attempts
+FROM upd
+JOIN alerts a          ON a.alert_id = upd.alert_id
+LEFT JOIN ads ad       ON ad.ad_id = a.ad_id
+JOIN alert_channels c  ON c.channel_id = upd.channel_id`
+	rows, err := r.db.QueryContext(ctx, q, limit, int64(lease/time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("claim alert deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []entity.AlertDelivery
+	for rows.Next() {
+		var d entity.AlertDelivery
+		dest := append(alertDest(&d.Alert), &d.Channel.ChannelID, &d.Channel.Kind, &d.Channel.Target, &d.Attempts)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		d.Channel.WorkspaceID = d.Alert.WorkspaceID
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *AlertsRepo) MarkDelivered(ctx context.Context, alertID, channelID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+UPDATE alert_deliveries SET status = 'sent', sent_at = NOW(), last_error = NULL
+WHERE alert_id = $1 AND channel_id = $2`, alertID, channelID)
+	return err
+}
+
+// MarkDeliveryFailed — попытка не удалась: повтор в retryAt или, если final, статус failed.
+func (r *AlertsRepo) MarkDeliveryFailed(ctx context.Context, alertID, channelID int64, errText string, final bool, retryAt time.Time) error {
+	status := entity.DeliveryStatusPending
+	if final {
+		status = entity.DeliveryStatusFailed
+	}
+	_, err := r.db.ExecContext(ctx, `
+UPDATE alert_deliveries SET status = $3, last_error = $4, next_attempt_at = $5
+WHERE alert_id = $1 AND channel_id = $2`, alertID, channelID, status, errText, retryAt)
+	return err
+}
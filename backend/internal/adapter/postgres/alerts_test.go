@@ -0,0 +1,86 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+func newAlertsRepo(t *testing.T) (*postgres.AlertsRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewAlertsRepo(db), mock, func() { _ = db.Close() }
+}
+
+func TestAlertsRepo_CreateAlert(t *testing.T) {
+	repo, mock, done := newAlertsRepo(t)
+	defer done()
+
+	a := entity.Alert{
+		WorkspaceID: 3, AdID: 87, Kind: entity.AlertKindAnomaly, Metric: "spend", Day: "2025-03-02",
+		Value: 100, Baseline: 50, ZScore: 35.4, PctChange: 100, Direction: "up", Message: "spend is up 100%",
+	}
+	const q = `NULLIF\(\$2::bigint, 0\)[\s\S]+ON CONFLICT DO NOTHING[\s\S]+INSERT INTO alert_deliveries \(alert_id, channel_id\)[\s\S]+c\.workspace_id = ins\.workspace_id AND c\.enabled`
+
+	mock.ExpectQuery(q).WithArgs(int64(3), int64(87), "anomaly", "spend", "2025-03-02", 100.0, 50.0, 35.4, 100.0, "up", "spend is up 100%").
+		WillReturnRows(sqlmock.NewRows([]string{"alert_id"}).AddRow(int64(5)))
+	id, created, err := repo.CreateAlert(context.Background(), a)
+	require.NoError(t, err)
+	require.True(t, created)
+	require.Equal(t, int64(5), id)
+
+	// повторный прогон за тот же день
+	mock.ExpectQuery(q).WillReturnRows(sqlmock.NewRows([]string{"alert_id"}))
+	_, created, err = repo.CreateAlert(context.Background(), a)
+	require.NoError(t, err)
+	require.False(t, created)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAlertsRepo_ClaimDeliveries(t *testing.T) {
+	repo, mock, done := newAlertsRepo(t)
+	defer done()
+
+	created := time.Date(2025, 3, 3, 6, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FOR UPDATE SKIP LOCKED[\s\S]+SET attempts = d\.attempts \+ 1, next_attempt_at = NOW\(\) \+ \$2 \* INTERVAL '1 second'`).
+		WithArgs(50, int64(120)).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"alert_id", "workspace_id", "ad_id", "name", "kind", "metric", "day",
+			"value", "baseline", "z_score", "pct_change", "direction", "message", "created_at",
+			"channel_id", "kind", "target", "attempts",
+		}).AddRow(int64(5), int64(3), int64(87), "Summer Sale", "anomaly", "spend", "2025-03-02",
+			100.0, 50.0, 35.4, 100.0, "up", "spend is up 100%", created,
+			int64(3), "slack", "https://hooks.example/x", 2))
+
+	list, err := repo.ClaimDeliveries(context.Background(), 50, 2*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "Summer Sale", list[0].Alert.AdName)
+	require.Equal(t, int64(3), list[0].Channel.WorkspaceID)
+	require.Equal(t, "slack", list[0].Channel.Kind)
+	require.Equal(t, 2, list[0].Attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAlertsRepo_MarkDeliveryFailed(t *testing.T) {
+	repo, mock, done := newAlertsRepo(t)
+	defer done()
+
+	retry := time.Date(2025, 3, 3, 6, 5, 0, 0, time.UTC)
+	mock.ExpectExec(`UPDATE alert_deliveries SET status = \$3, last_error = \$4, next_attempt_at = \$5`).
+		WithArgs(int64(5), int64(3), "pending", "502", retry).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE alert_deliveries SET status = \$3`).
+		WithArgs(int64(5), int64(3), "failed", "502", retry).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkDeliveryFailed(context.Background(), 5, 3, "502", false, retry))
+	require.NoError(t, repo.MarkDeliveryFailed(context.Background(), 5, 3, "502", true, retry))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
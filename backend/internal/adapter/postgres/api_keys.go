@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// APIKeysRepo — API-ключи интеграций (api_keys). Ключ ищется по SHA-256, сам он не хранится.
+type APIKeysRepo struct {
+	db *sql.DB
+}
+
+func NewAPIKeysRepo(db *sql.DB) *APIKeysRepo { return &APIKeysRepo{db: db} }
+
+const apiKeyColumns = `key_id, workspace_id, user_id, name, prefix, created_at, revoked_at`
+
+func scanAPIKey(sc interface{ Scan(...any) error }) (entity.APIKey, error) {
+	var k entity.APIKey
+	err := sc.Scan(&k.KeyID, &k.WorkspaceID, &k.UserID, &k.Name, &k.Prefix, &k.CreatedAt, &k.RevokedAt)
+	return k, err
+}
+
+func (r *APIKeysRepo) CreateAPIKey(ctx context.Context, k entity.APIKey, keyHash string) (entity.APIKey, error) {
+	const q = `
+INSERT INTO api_keys (workspace_id, user_id, name, prefix, key_hash)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING ` + apiKeyColumns
+	out, err := scanAPIKey(r.db.QueryRowContext(ctx, q, k.WorkspaceID, k.UserID, k.Name, k.Prefix, keyHash))
+	if err != nil {
+		return entity.APIKey{}, fmt.Errorf("create api key: %w", err)
+	}
+	return out, nil
+}
+
+func (r *APIKeysRepo) ListAPIKeys(ctx context.Context, workspaceID int64) ([]entity.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE workspace_id = $1 ORDER BY key_id`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+func (r *APIKeysRepo) RevokeAPIKey(ctx context.Context, workspaceID, keyID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = NOW() WHERE workspace_id = $1 AND key_id = $2 AND revoked_at IS NULL`,
+		workspaceID, keyID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// APIKeyByHash — действующий ключ по хэшу; отозванный или неизвестный — sql.ErrNoRows.
+func (r *APIKeysRepo) APIKeyByHash(ctx context.Context, keyHash string) (entity.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`, keyHash))
+}
@@ -0,0 +1,66 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+func newAPIKeys(t *testing.T) (*postgres.APIKeysRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewAPIKeysRepo(db), mock, func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+		_ = db.Close()
+	}
+}
+
+var apiKeyCols = []string{"key_id", "workspace_id", "user_id", "name", "prefix", "created_at", "revoked_at"}
+
+func TestAPIKeys_Create(t *testing.T) {
+	repo, mock, done := newAPIKeys(t)
+	defer done()
+
+	now := time.Now()
+	mock.ExpectQuery(`INSERT INTO api_keys \(workspace_id, user_id, name, prefix, key_hash\)`).
+		WithArgs(int64(3), int64(7), "tracker", "ask_abcdef", "hash").
+		WillReturnRows(sqlmock.NewRows(apiKeyCols).AddRow(11, 3, 7, "tracker", "ask_abcdef", now, nil))
+
+	k, err := repo.CreateAPIKey(context.Background(),
+		entity.APIKey{WorkspaceID: 3, UserID: 7, Name: "tracker", Prefix: "ask_abcdef"}, "hash")
+	require.NoError(t, err)
+	require.Equal(t, int64(11), k.KeyID)
+	require.Nil(t, k.RevokedAt)
+}
+
+func TestAPIKeys_ByHash_SkipsRevoked(t *testing.T) {
+	repo, mock, done := newAPIKeys(t)
+	defer done()
+
+	mock.ExpectQuery(`FROM api_keys WHERE key_hash = \$1 AND revoked_at IS NULL`).
+		WithArgs("hash").
+		WillReturnRows(sqlmock.NewRows(apiKeyCols))
+
+	_, err := repo.APIKeyByHash(context.Background(), "hash")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestAPIKeys_Revoke_ScopedToWorkspace(t *testing.T) {
+	repo, mock, done := newAPIKeys(t)
+	defer done()
+
+	mock.ExpectExec(`UPDATE api_keys SET revoked_at = NOW\(\) WHERE workspace_id = \$1 AND key_id = \$2 AND revoked_at IS NULL`).
+		WithArgs(int64(3), int64(11)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.RevokeAPIKey(context.Background(), 3, 11)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
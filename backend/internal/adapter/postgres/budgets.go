@@ -0,0 +1,179 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// BudgetsRepo — бюджеты (budgets, budget_ads) и расход по ним из ads_insights.
+type BudgetsRepo struct {
+	db *sql.DB
+}
+
+func NewBudgetsRepo(db *sql.DB) *BudgetsRepo { return &BudgetsRepo{db: db} }
+
+const budgetColumns = `b.budget_id, b.workspace_id, b.user_id, b.account_id, b.name, b.period, b.amount, b.alert_pct,
+       COALESCE((SELECT array_agg(ba.ad_id ORDER BY ba.ad_id) FROM budget_ads ba WHERE ba.budget_id = b.budget_id), '{}'),
+       b.created_at, b.updated_at`
+
+func scanBudget(sc interface{ Scan(...any) error }) (entity.Budget, error) {
+	var b entity.Budget
+	var adIDs pq.Int64Array
+	err := sc.Scan(&b.BudgetID, &b.WorkspaceID, &b.UserID, &b.AccountID, &b.Name, &b.Period, &b.Amount, &b.AlertPct,
+		&adIDs, &b.CreatedAt, &b.UpdatedAt)
+	b.AdIDs = []int64(adIDs)
+	if b.AdIDs == nil {
+		b.AdIDs = []int64{}
+	}
+	return b, err
+}
+
+func (r *BudgetsRepo) queryBudgets(ctx context.Context, q string, args ...any) ([]entity.Budget, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.Budget{}
+	for rows.Next() {
+		b, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, rows.Err()
+}
+
+// Бюджет учитывается, пока его аккаунт в том же пространстве: после перепривязки аккаунта
+// в другое пространство расход по нему старому пространству не виден.
+const budgetFrom = `
+FROM budgets b
+JOIN ad_accounts aa ON aa.account_id = b.account_id AND aa.workspace_id = b.workspace_id`
+
+func (r *BudgetsRepo) ListBudgets(ctx context.Context, workspaceID int64) ([]entity.Budget, error) {
+	return r.queryBudgets(ctx, `SELECT `+budgetColumns+budgetFrom+` WHERE b.workspace_id = $1 ORDER BY b.budget_id`, workspaceID)
+}
+
+// AllBudgets — бюджеты всех пространств (для крона).
+func (r *BudgetsRepo) AllBudgets(ctx context.Context) ([]entity.Budget, error) {
+	return r.queryBudgets(ctx, `SELECT `+budgetColumns+budgetFrom+` ORDER BY b.workspace_id, b.budget_id`)
+}
+
+// CreateBudget — аккаунт не из пространства бюджета → sql.ErrNoRows;
+// объявление не из этого аккаунта → errs.ErrInvalidBudget.
+func (r *BudgetsRepo) CreateBudget(ctx context.Context, in entity.Budget) (entity.Budget, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Budget{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const q = `
+INSERT INTO budgets (workspace_id, user_id, account_id, name, period, amount, alert_pct)
+SELECT aa.workspace_id, $2, aa.account_id, $4, $5, $6, $7
+FROM ad_accounts aa
+WHERE aa.account_id = $3 AND aa.workspace_id = $1
+RETURNING budget_id`
+	var id int64
+	if err := tx.QueryRowContext(ctx, q, in.WorkspaceID, in.UserID, in.AccountID, in.Name, in.Period, in.Amount, in.AlertPct).Scan(&id); err != nil {
+		return entity.Budget{}, err
+	}
+	if err := setBudgetAds(ctx, tx, id, in.AccountID, in.AdIDs); err != nil {
+		return entity.Budget{}, err
+	}
+	out, err := scanBudget(tx.QueryRowContext(ctx, `SELECT `+budgetColumns+` FROM budgets b WHERE b.budget_id = $1`, id))
+	if err != nil {
+		return entity.Budget{}, err
+	}
+	return out, tx.Commit()
+}
+
+// UpdateBudget перезаписывает бюджет пространства (аккаунт не меняется); чужой или несуществующий — sql.ErrNoRows.
+func (r *BudgetsRepo) UpdateBudget(ctx context.Context, in entity.Budget) (entity.Budget, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Budget{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const q = `
+UPDATE budgets
+SET name = $3, period = $4, amount = $5, alert_pct = $6, updated_at = NOW()
+WHERE budget_id = $1 AND workspace_id = $2
+RETURNING account_id`
+	var accountID int64
+	if err := tx.QueryRowContext(ctx, q, in.BudgetID, in.WorkspaceID, in.Name, in.Period, in.Amount, in.AlertPct).Scan(&accountID); err != nil {
+		return entity.Budget{}, err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM budget_ads WHERE budget_id = $1`, in.BudgetID); err != nil {
+		return entity.Budget{}, err
+	}
+	if err := setBudgetAds(ctx, tx, in.BudgetID, accountID, in.AdIDs); err != nil {
+		return entity.Budget{}, err
+	}
+	out, err := scanBudget(tx.QueryRowContext(ctx, `SELECT `+budgetColumns+` FROM budgets b WHERE b.budget_id = $1`, in.BudgetID))
+	if err != nil {
+		return entity.Budget{}, err
+	}
+	return out, tx.Commit()
+}
+
+// setBudgetAds привязывает к бюджету объявления; все должны быть из аккаунта бюджета.
+func setBudgetAds(ctx context.Context, tx *sql.Tx, budgetID, accountID int64, adIDs []int64) error {
+	if len(adIDs) == 0 {
+		return nil
+	}
+	const q = `
+INSERT INTO budget_ads (budget_id, ad_id)
+SELECT $1, a.ad_id FROM ads a
+WHERE a.account_id = $2 AND a.ad_id = ANY($3)
+ON CONFLICT DO NOTHING`
+	res, err := tx.ExecContext(ctx, q, budgetID, accountID, pq.Array(adIDs))
+	if err != nil {
+		return fmt.Errorf("budget ads: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != int64(len(adIDs)) {
+		return fmt.Errorf("%w: ad_ids must belong to the budget's account", errs.ErrInvalidBudget)
+	}
+	return nil
+}
+
+// DeleteBudget — false, если бюджета в пространстве нет.
+func (r *BudgetsRepo) DeleteBudget(ctx context.Context, workspaceID, budgetID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM budgets WHERE budget_id = $1 AND workspace_id = $2`, budgetID, workspaceID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// BudgetSpend — расход по бюджету за [from, to] из ads_insights (весь аккаунт или группа объявлений).
+func (r *BudgetsRepo) BudgetSpend(ctx context.Context, b entity.Budget, from, to time.Time) (decimal.Decimal, error) {
+	const q = `
+SELECT COALESCE(SUM(ai.spend), 0)
+FROM ads_insights ai
+JOIN ads a ON a.ad_id = ai.ad_id
+WHERE a.account_id = $1
+  AND ai.insight_date BETWEEN $2::date AND $3::date
+  AND (cardinality($4::bigint[]) = 0 OR a.ad_id = ANY($4))`
+	var spend decimal.Decimal
+	if err := r.db.QueryRowContext(ctx, q, b.AccountID, from, to, pq.Array(b.AdIDs)).Scan(&spend); err != nil {
+		return decimal.Zero, fmt.Errorf("budget spend: %w", err)
+	}
+	return spend, nil
+}
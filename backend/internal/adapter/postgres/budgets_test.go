@@ -0,0 +1,104 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+func newBudgetsRepo(t *testing.T) (*postgres.BudgetsRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewBudgetsRepo(db), mock, func() { _ = db.Close() }
+}
+
+var budgetCols = []string{"budget_id", "workspace_id", "user_id", "account_id", "name", "period", "amount", "alert_pct", "ad_ids", "created_at", "updated_at"}
+
+func TestBudgetsRepo_CreateBudget(t *testing.T) {
+	repo, mock, done := newBudgetsRepo(t)
+	defer done()
+
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	in := entity.Budget{
+		WorkspaceID: 5, UserID: 42, AccountID: 3, Name: "Main", Period: entity.BudgetPeriodMonthly,
+		Amount: decimal.NewFromInt(3000), AlertPct: decimal.NewFromInt(10), AdIDs: []int64{87, 88},
+	}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO budgets \(workspace_id, user_id, account_id, name, period, amount, alert_pct\)\s+SELECT aa\.workspace_id, \$2, aa\.account_id[\s\S]+WHERE aa\.account_id = \$3 AND aa\.workspace_id = \$1`).
+		WithArgs(int64(5), int64(42), int64(3), "Main", "monthly", in.Amount, in.AlertPct).
+		WillReturnRows(sqlmock.NewRows([]string{"budget_id"}).AddRow(int64(9)))
+	mock.ExpectExec(`INSERT INTO budget_ads \(budget_id, ad_id\)[\s\S]+WHERE a\.account_id = \$2 AND a\.ad_id = ANY\(\$3\)`).
+		WithArgs(int64(9), int64(3), pq.Array([]int64{87, 88})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`FROM budgets b WHERE b\.budget_id = \$1`).
+		WithArgs(int64(9)).
+		WillReturnRows(sqlmock.NewRows(budgetCols).
+			AddRow(int64(9), int64(5), int64(42), int64(3), "Main", "monthly", "3000.00", "10.00", "{87,88}", now, now))
+	mock.ExpectCommit()
+
+	b, err := repo.CreateBudget(context.Background(), in)
+	require.NoError(t, err)
+	require.Equal(t, int64(9), b.BudgetID)
+	require.Equal(t, []int64{87, 88}, b.AdIDs)
+	require.Equal(t, "3000", b.Amount.String())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBudgetsRepo_CreateBudget_ForeignAd(t *testing.T) {
+	repo, mock, done := newBudgetsRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO budgets`).
+		WillReturnRows(sqlmock.NewRows([]string{"budget_id"}).AddRow(int64(9)))
+	mock.ExpectExec(`INSERT INTO budget_ads`).
+		WillReturnResult(sqlmock.NewResult(0, 1)) // 999 не из аккаунта
+	mock.ExpectRollback()
+
+	_, err := repo.CreateBudget(context.Background(), entity.Budget{
+		WorkspaceID: 5, UserID: 42, AccountID: 3, Name: "Main", Period: entity.BudgetPeriodMonthly,
+		Amount: decimal.NewFromInt(3000), AdIDs: []int64{87, 999},
+	})
+	require.ErrorIs(t, err, errs.ErrInvalidBudget)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBudgetsRepo_ListBudgets_AccountStillInWorkspace(t *testing.T) {
+	repo, mock, done := newBudgetsRepo(t)
+	defer done()
+
+	// аккаунт перепривязан в другое пространство — бюджет старого пространства не отдаётся
+	mock.ExpectQuery(`FROM budgets b\s+JOIN ad_accounts aa ON aa\.account_id = b\.account_id AND aa\.workspace_id = b\.workspace_id\s+WHERE b\.workspace_id = \$1`).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows(budgetCols))
+
+	list, err := repo.ListBudgets(context.Background(), 5)
+	require.NoError(t, err)
+	require.Empty(t, list)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBudgetsRepo_BudgetSpend(t *testing.T) {
+	repo, mock, done := newBudgetsRepo(t)
+	defer done()
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM ads_insights ai\s+JOIN ads a ON a\.ad_id = ai\.ad_id\s+WHERE a\.account_id = \$1\s+AND ai\.insight_date BETWEEN \$2::date AND \$3::date\s+AND \(cardinality\(\$4::bigint\[\]\) = 0 OR a\.ad_id = ANY\(\$4\)\)`).
+		WithArgs(int64(3), from, to, pq.Array([]int64{})).
+		WillReturnRows(sqlmock.NewRows([]string{"sum"}).AddRow("1234.50"))
+
+	spend, err := repo.BudgetSpend(context.Background(), entity.Budget{AccountID: 3, AdIDs: []int64{}}, from, to)
+	require.NoError(t, err)
+	require.Equal(t, "1234.5", spend.String())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
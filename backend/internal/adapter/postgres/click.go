@@ -17,14 +17,23 @@ type ClicksRepo struct {
 
 func NewClicksRepo(db *sql.DB) *ClicksRepo { return &ClicksRepo{db: db} }
 
-// Делает INSERT в таблицу clicks
+// Делает INSERT в таблицу clicks и тем же запросом (одна транзакция) пишет click.created в outbox
 func (r *ClicksRepo) Click(ctx context.Context, clk entity.Click) (int64, error) {
-	const q = `INSERT INTO clicks (click_id, ad_id, clicked_at, click_ref)
-			   VALUES ($1, $2, $3, $4)
-			   RETURNING id`
+	const q = `
+WITH ins AS (
+	INSERT INTO clicks (click_id, ad_id, clicked_at, click_ref, gclid, fbclid)
+	VALUES ($1, $2, $3, $4, $5, $6)
+	RETURNING id, click_id, ad_id, clicked_at, click_ref
+), ob AS (
+	INSERT INTO outbox_events (event_type, ad_id, payload)
+	SELECT 'click.created', ins.ad_id, jsonb_build_object(
+		'click_id', ins.click_id, 'ad_id', ins.ad_id, 'clicked_at', ins.clicked_at, 'click_ref', ins.click_ref)
+	FROM ins
+)
+SELECT id FROM ins`
 
 	var ID int64
-	if err := r.db.QueryRowContext(ctx, q, clk.ClickID, clk.AdID, clk.ClickedAt, clk.ClickRef).Scan(&ID); err != nil {
+	if err := r.db.QueryRowContext(ctx, q, clk.ClickID, clk.AdID, clk.ClickedAt, clk.ClickRef, clk.Gclid, clk.Fbclid).Scan(&ID); err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
 			return 0, errs.ErrDuplicateClick
 		}
@@ -35,13 +44,13 @@ func (r *ClicksRepo) Click(ctx context.Context, clk entity.Click) (int64, error)
 
 // этот метод существует только в репозитории
 func (r *ClicksRepo) ByClickID(ctx context.Context, id string) (entity.Click, error) {
-	const q = `SELECT id, click_id, ad_id, clicked_at, click_ref
+	const q = `SELECT id, click_id, ad_id, clicked_at, click_ref, gclid, fbclid
 	           FROM   clicks
 	           WHERE  click_id = $1`
 
 	var clk entity.Click
 	err := r.db.QueryRowContext(ctx, q, id).
-		Scan(&clk.ID, &clk.ClickID, &clk.AdID, &clk.ClickedAt, &clk.ClickRef)
+		Scan(&clk.ID, &clk.ClickID, &clk.AdID, &clk.ClickedAt, &clk.ClickRef, &clk.Gclid, &clk.Fbclid)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return entity.Click{}, errs.ErrClickNotFound
@@ -38,7 +38,7 @@ func TestClick(t *testing.T) {
 
 		// INSERT ... RETURNING id — возвращаем одну колонку "id"
 		mock.ExpectQuery(`INSERT\s+INTO\s+clicks`).
-			WithArgs(clk.ClickID, clk.AdID, clk.ClickedAt, clk.ClickRef).
+			WithArgs(clk.ClickID, clk.AdID, clk.ClickedAt, clk.ClickRef, clk.Gclid, clk.Fbclid).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(12345)))
 
 		gotID, err := repo.Click(context.Background(), clk)
@@ -62,7 +62,7 @@ func TestClick(t *testing.T) {
 		pgErr := &pq.Error{Code: "23505"}
 
 		mock.ExpectQuery(`INSERT\s+INTO\s+clicks`).
-			WithArgs(clk.ClickID, clk.AdID, clk.ClickedAt, clk.ClickRef).
+			WithArgs(clk.ClickID, clk.AdID, clk.ClickedAt, clk.ClickRef, clk.Gclid, clk.Fbclid).
 			WillReturnError(pgErr)
 
 		_, err := repo.Click(context.Background(), clk)
@@ -85,13 +85,14 @@ func TestById(t *testing.T) {
 		)
 		clickedAt := time.Now()
 		clickRef := uuid.New() // используем тот же в моке и в проверке
+		gclid := "Cj0KCQjw-gclid"
 
-		mock.ExpectQuery(`SELECT\s+id,\s+click_id,\s+ad_id,\s+clicked_at,\s+click_ref\s+FROM\s+clicks\s+WHERE\s+click_id\s*=\s*\$1`).
+		mock.ExpectQuery(`SELECT\s+id,\s+click_id,\s+ad_id,\s+clicked_at,\s+click_ref,\s+gclid,\s+fbclid\s+FROM\s+clicks\s+WHERE\s+click_id\s*=\s*\$1`).
 			WithArgs(clickID).
 			// Важно: значения должны быть совместимы с database/sql.
 			// Для UUID безопаснее отдавать строку (или []byte), Scanner из google/uuid это понимает.
-			WillReturnRows(sqlmock.NewRows([]string{"id", "click_id", "ad_id", "clicked_at", "click_ref"}).
-				AddRow(rowID, clickID, adID, clickedAt, clickRef.String()))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "click_id", "ad_id", "clicked_at", "click_ref", "gclid", "fbclid"}).
+				AddRow(rowID, clickID, adID, clickedAt, clickRef.String(), gclid, nil))
 
 		clk, err := repo.ByClickID(context.Background(), clickID)
 		require.NoError(t, err)
@@ -100,6 +101,9 @@ func TestById(t *testing.T) {
 		require.Equal(t, adID, clk.AdID)
 		require.WithinDuration(t, clickedAt, clk.ClickedAt, time.Second)
 		require.Equal(t, clickRef, clk.ClickRef) // сравниваем с тем, что положили в мок
+		require.NotNil(t, clk.Gclid)
+		require.Equal(t, gclid, *clk.Gclid)
+		require.Nil(t, clk.Fbclid)
 
 		require.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -108,7 +112,7 @@ func TestById(t *testing.T) {
 		repo, mock, done := newRepoClicks(t)
 		defer done()
 
-		mock.ExpectQuery(`SELECT\s+id,\s+click_id,\s+ad_id,\s+clicked_at,\s+click_ref\s+FROM\s+clicks`).
+		mock.ExpectQuery(`SELECT\s+id,\s+click_id,\s+ad_id,\s+clicked_at,\s+click_ref,\s+gclid,\s+fbclid\s+FROM\s+clicks`).
 			WithArgs("absent_click").
 			WillReturnError(sql.ErrNoRows)
 
@@ -0,0 +1,282 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// ClientsRepo — клиенты агентства (clients, ad_accounts.client_id), публичные ссылки на отчёт
+// и журнал обращений по ним.
+type ClientsRepo struct {
+	db *sql.DB
+}
+
+func NewClientsRepo(db *sql.DB) *ClientsRepo { return &ClientsRepo{db: db} }
+
+const clientColumns = `c.client_id, c.workspace_id, c.name,
+       COALESCE((SELECT array_agg(aa.account_id ORDER BY aa.account_id) FROM ad_accounts aa WHERE aa.client_id = c.client_id AND aa.workspace_id = c.workspace_id), '{}'),
+       c.created_at`
+
+func scanClient(sc interface{ Scan(...any) error }) (entity.Client, error) {
+	var c entity.Client
+	var accountIDs pq.Int64Array
+	err := sc.Scan(&c.ClientID, &c.WorkspaceID, &c.Name, &accountIDs, &c.CreatedAt)
+	c.AccountIDs = []int64(accountIDs)
+	if c.AccountIDs == nil {
+		c.AccountIDs = []int64{}
+	}
+	return c, err
+}
+
+func (r *ClientsRepo) ListClients(ctx context.Context, workspaceID int64) ([]entity.Client, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+clientColumns+` FROM clients c WHERE c.workspace_id = $1 ORDER BY c.name, c.client_id`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.Client{}
+	for rows.Next() {
+		c, err := scanClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// CreateClient — аккаунт не из пространства клиента → errs.ErrInvalidClient.
+func (r *ClientsRepo) CreateClient(ctx context.Context, in entity.Client) (entity.Client, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Client{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO clients (workspace_id, name) VALUES ($1, $2) RETURNING client_id`, in.WorkspaceID, in.Name).Scan(&id)
+	if err != nil {
+		return entity.Client{}, clientNameErr(err)
+	}
+	if err := setClientAccounts(ctx, tx, in.WorkspaceID, id, in.AccountIDs); err != nil {
+		return entity.Client{}, err
+	}
+	out, err := scanClient(tx.QueryRowContext(ctx, `SELECT `+clientColumns+` FROM clients c WHERE c.client_id = $1`, id))
+	if err != nil {
+		return entity.Client{}, err
+	}
+	return out, tx.Commit()
+}
+
+// UpdateClient перезаписывает имя и набор аккаунтов; чужой или несуществующий — sql.ErrNoRows.
+func (r *ClientsRepo) UpdateClient(ctx context.Context, in entity.Client) (entity.Client, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Client{}, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+	err = tx.QueryRowContext(ctx,
+		`UPDATE clients SET name = $3 WHERE client_id = $1 AND workspace_id = $2 RETURNING client_id`,
+		in.ClientID, in.WorkspaceID, in.Name).Scan(&id)
+	if err != nil {
+		return entity.Client{}, clientNameErr(err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE ad_accounts SET client_id = NULL WHERE client_id = $1`, id); err != nil {
+		return entity.Client{}, err
+	}
+	if err := setClientAccounts(ctx, tx, in.WorkspaceID, id, in.AccountIDs); err != nil {
+		return entity.Client{}, err
+	}
+	out, err := scanClient(tx.QueryRowContext(ctx, `SELECT `+clientColumns+` FROM clients c WHERE c.client_id = $1`, id))
+	if err != nil {
+		return entity.Client{}, err
+	}
+	return out, tx.Commit()
+}
+
+// setClientAccounts закрепляет аккаунты за клиентом (аккаунт принадлежит не больше чем одному клиенту);
+// все должны быть из пространства клиента.
+func setClientAccounts(ctx context.Context, tx *sql.Tx, workspaceID, clientID int64, accountIDs []int64) error {
+	if len(accountIDs) == 0 {
+		return nil
+	}
+	res, err := tx.ExecContext(ctx,
+		`UPDATE ad_accounts SET client_id = $1 WHERE workspace_id = $2 AND account_id = ANY($3)`,
+		clientID, workspaceID, pq.Array(accountIDs))
+	if err != nil {
+		return fmt.Errorf("client accounts: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != int64(len(accountIDs)) {
+		return fmt.Errorf("%w: account_ids must belong to the workspace", errs.ErrInvalidClient)
+	}
+	return nil
+}
+
+func clientNameErr(err error) error {
+	if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+		return fmt.Errorf("%w: name already used", errs.ErrInvalidClient)
+	}
+	return err
+}
+
+// DeleteClient — false, если клиента в пространстве нет. Аккаунты остаются (client_id → NULL).
+func (r *ClientsRepo) DeleteClient(ctx context.Context, workspaceID, clientID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM clients WHERE client_id = $1 AND workspace_id = $2`, clientID, workspaceID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ClientAdIDs — объявления аккаунтов клиента, которые всё ещё в пространстве клиента:
+// аккаунт, перепривязанный в другое пространство, по ссылке старого пространства не виден.
+func (r *ClientsRepo) ClientAdIDs(ctx context.Context, clientID int64) ([]int64, error) {
+	const q = `
+SELECT a.ad_id
+FROM ads a
+JOIN ad_accounts aa ON aa.account_id = a.account_id
+JOIN clients c      ON c.client_id = aa.client_id AND c.workspace_id = aa.workspace_id
+WHERE aa.client_id = $1
+ORDER BY a.ad_id`
+	rows, err := r.db.QueryContext(ctx, q, clientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, 8)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CreateLink — ссылка на клиента пространства; чужой клиент — sql.ErrNoRows.
+func (r *ClientsRepo) CreateLink(ctx context.Context, workspaceID int64, l entity.ShareLink) (entity.ShareLink, error) {
+	const q = `
+INSERT INTO share_links (client_id, created_by, expires_at)
+SELECT c.client_id, $3, $4 FROM clients c
+WHERE c.client_id = $1 AND c.workspace_id = $2
+RETURNING link_id, created_at`
+	err := r.db.QueryRowContext(ctx, q, l.ClientID, workspaceID, l.CreatedBy, l.ExpiresAt).Scan(&l.LinkID, &l.CreatedAt)
+	return l, err
+}
+
+const linkColumns = `l.link_id, l.client_id, c.name, COALESCE(l.created_by, 0), l.expires_at, l.revoked_at, l.created_at,
+       (SELECT COUNT(*) FROM share_link_access x WHERE x.link_id = l.link_id AND x.outcome = 'ok'),
+       (SELECT MAX(x.accessed_at) FROM share_link_access x WHERE x.link_id = l.link_id AND x.outcome = 'ok')`
+
+func scanLink(sc interface{ Scan(...any) error }) (entity.ShareLink, error) {
+	var l entity.ShareLink
+	var revoked, last sql.NullTime
+	err := sc.Scan(&l.LinkID, &l.ClientID, &l.ClientName, &l.CreatedBy, &l.ExpiresAt, &revoked, &l.CreatedAt, &l.Accesses, &last)
+	if revoked.Valid {
+		l.RevokedAt = &revoked.Time
+	}
+	if last.Valid {
+		l.LastAccessAt = &last.Time
+	}
+	return l, err
+}
+
+// Links — ссылки клиента пространства (включая отозванные и истёкшие) со счётчиком обращений.
+func (r *ClientsRepo) Links(ctx context.Context, workspaceID, clientID int64) ([]entity.ShareLink, error) {
+	rows, err := r.db.QueryContext(ctx, `
+SELECT `+linkColumns+`
+FROM share_links l
+JOIN clients c ON c.client_id = l.client_id
+WHERE l.client_id = $1 AND c.workspace_id = $2
+ORDER BY l.created_at DESC, l.link_id DESC`, clientID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.ShareLink{}
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, l)
+	}
+	return out, rows.Err()
+}
+
+// Link — ссылка по id (для публичного доступа по токену); нет — sql.ErrNoRows.
+func (r *ClientsRepo) Link(ctx context.Context, linkID int64) (entity.ShareLink, error) {
+	return scanLink(r.db.QueryRowContext(ctx, `
+SELECT `+linkColumns+`
+FROM share_links l
+JOIN clients c ON c.client_id = l.client_id
+WHERE l.link_id = $1`, linkID))
+}
+
+// RevokeLink — false, если действующей ссылки у клиента пространства нет.
+func (r *ClientsRepo) RevokeLink(ctx context.Context, workspaceID, clientID, linkID int64) (bool, error) {
+	const q = `
+UPDATE share_links l SET revoked_at = NOW()
+FROM clients c
+WHERE c.client_id = l.client_id AND c.workspace_id = $1
+  AND l.client_id = $2 AND l.link_id = $3 AND l.revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, workspaceID, clientID, linkID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *ClientsRepo) LogAccess(ctx context.Context, a entity.ShareAccess) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO share_link_access (link_id, ip, user_agent, outcome) VALUES ($1, $2, $3, $4)`,
+		a.LinkID, a.IP, a.UserAgent, a.Outcome)
+	return err
+}
+
+// AccessLog — последние обращения по ссылке клиента пространства.
+func (r *ClientsRepo) AccessLog(ctx context.Context, workspaceID, clientID, linkID int64, limit int) ([]entity.ShareAccess, error) {
+	const q = `
+SELECT x.link_id, x.accessed_at, x.ip, x.user_agent, x.outcome
+FROM share_link_access x
+JOIN share_links l ON l.link_id = x.link_id
+JOIN clients c ON c.client_id = l.client_id
+WHERE x.link_id = $3 AND l.client_id = $2 AND c.workspace_id = $1
+ORDER BY x.accessed_at DESC, x.access_id DESC
+LIMIT $4`
+	rows, err := r.db.QueryContext(ctx, q, workspaceID, clientID, linkID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.ShareAccess{}
+	for rows.Next() {
+		var a entity.ShareAccess
+		if err := rows.Scan(&a.LinkID, &a.AccessedAt, &a.IP, &a.UserAgent, &a.Outcome); err != nil {
+			return nil, err
+		}
+		out = append(out, a)
+	}
+	return out, rows.Err()
+}
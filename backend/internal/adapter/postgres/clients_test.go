@@ -0,0 +1,168 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+func newClientsRepo(t *testing.T) (*postgres.ClientsRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewClientsRepo(db), mock, func() { _ = db.Close() }
+}
+
+var clientCols = []string{"client_id", "workspace_id", "name", "account_ids", "created_at"}
+
+func TestClientsRepo_CreateClient(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO clients \(workspace_id, name\) VALUES \(\$1, \$2\) RETURNING client_id`).
+		WithArgs(int64(7), "Acme").
+		WillReturnRows(sqlmock.NewRows([]string{"client_id"}).AddRow(int64(4)))
+	mock.ExpectExec(`UPDATE ad_accounts SET client_id = \$1 WHERE workspace_id = \$2 AND account_id = ANY\(\$3\)`).
+		WithArgs(int64(4), int64(7), pq.Array([]int64{3, 5})).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`FROM clients c WHERE c\.client_id = \$1`).
+		WithArgs(int64(4)).
+		WillReturnRows(sqlmock.NewRows(clientCols).AddRow(int64(4), int64(7), "Acme", "{3,5}", now))
+	mock.ExpectCommit()
+
+	c, err := repo.CreateClient(context.Background(), entity.Client{WorkspaceID: 7, Name: "Acme", AccountIDs: []int64{3, 5}})
+	require.NoError(t, err)
+	require.Equal(t, int64(4), c.ClientID)
+	require.Equal(t, []int64{3, 5}, c.AccountIDs)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClientsRepo_CreateClient_ForeignAccount(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO clients`).
+		WillReturnRows(sqlmock.NewRows([]string{"client_id"}).AddRow(int64(4)))
+	mock.ExpectExec(`UPDATE ad_accounts SET client_id`).
+		WillReturnResult(sqlmock.NewResult(0, 1)) // 99 из другого пространства
+	mock.ExpectRollback()
+
+	_, err := repo.CreateClient(context.Background(), entity.Client{WorkspaceID: 7, Name: "Acme", AccountIDs: []int64{3, 99}})
+	require.ErrorIs(t, err, errs.ErrInvalidClient)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClientsRepo_CreateClient_DuplicateName(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO clients`).WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	_, err := repo.CreateClient(context.Background(), entity.Client{WorkspaceID: 7, Name: "Acme"})
+	require.ErrorIs(t, err, errs.ErrInvalidClient)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClientsRepo_UpdateClient_NotFound(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE clients SET name = \$3 WHERE client_id = \$1 AND workspace_id = \$2`).
+		WithArgs(int64(4), int64(8), "Acme").
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	_, err := repo.UpdateClient(context.Background(), entity.Client{ClientID: 4, WorkspaceID: 8, Name: "Acme"})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClientsRepo_ClientAdIDs_OnlyAccountsInClientWorkspace(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	// ссылка создана, затем аккаунт 1002 перепривязан в другое пространство —
+	// его объявления по ссылке больше не отдаются, даже если client_id остался
+	mock.ExpectQuery(`JOIN clients c\s+ON c\.client_id = aa\.client_id AND c\.workspace_id = aa\.workspace_id\s+WHERE aa\.client_id = \$1`).
+		WithArgs(int64(5)).
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id"}).AddRow(int64(87)))
+
+	ids, err := repo.ClientAdIDs(context.Background(), 5)
+	require.NoError(t, err)
+	require.Equal(t, []int64{87}, ids)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClientsRepo_CreateLink_ForeignClient(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	exp := time.Date(2025, 3, 8, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`INSERT INTO share_links \(client_id, created_by, expires_at\)[\s\S]+WHERE c\.client_id = \$1 AND c\.workspace_id = \$2`).
+		WithArgs(int64(4), int64(8), int64(42), exp).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.CreateLink(context.Background(), 8, entity.ShareLink{ClientID: 4, CreatedBy: 42, ExpiresAt: exp})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClientsRepo_Link(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM share_links l\s+JOIN clients c ON c\.client_id = l\.client_id\s+WHERE l\.link_id = \$1`).
+		WithArgs(int64(11)).
+		WillReturnRows(sqlmock.NewRows([]string{"link_id", "client_id", "name", "created_by", "expires_at", "revoked_at", "created_at", "accesses", "last_access"}).
+			AddRow(int64(11), int64(4), "Acme", int64(42), now.Add(7*24*time.Hour), now.Add(time.Hour), now, 3, now.Add(30*time.Minute)))
+
+	l, err := repo.Link(context.Background(), 11)
+	require.NoError(t, err)
+	require.Equal(t, "Acme", l.ClientName)
+	require.NotNil(t, l.RevokedAt)
+	require.Equal(t, 3, l.Accesses)
+	require.NotNil(t, l.LastAccessAt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClientsRepo_RevokeLink(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	mock.ExpectExec(`UPDATE share_links l SET revoked_at = NOW\(\)[\s\S]+c\.workspace_id = \$1[\s\S]+l\.revoked_at IS NULL`).
+		WithArgs(int64(8), int64(4), int64(11)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.RevokeLink(context.Background(), 8, 4, 11)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestClientsRepo_LogAccess(t *testing.T) {
+	repo, mock, done := newClientsRepo(t)
+	defer done()
+
+	mock.ExpectExec(`INSERT INTO share_link_access \(link_id, ip, user_agent, outcome\)`).
+		WithArgs(int64(11), "203.0.113.5", "curl/8", entity.ShareOutcomeExpired).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.LogAccess(context.Background(), entity.ShareAccess{LinkID: 11, IP: "203.0.113.5", UserAgent: "curl/8", Outcome: entity.ShareOutcomeExpired})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -19,20 +19,58 @@ func NewConversionRepo(db *sql.DB) *ConversionRepo {
 	return &ConversionRepo{db: db}
 }
 
-// Делает INSERT в таблицу conversions
-func (r *ConversionRepo) Create(ctx context.Context, conv entity.Conversion) (int64, error) {
+// Делает INSERT в таблицу conversions и тем же запросом (одна транзакция) пишет conversion.created в outbox
+// и ставит выгрузки в очереди Google и Meta CAPI. Google — только для привязанного аккаунта
+// с conversion_action, CAPI — только при включённом пикселе; иначе соответствующий INSERT пуст.
+func (r *ConversionRepo) Create(ctx context.Context, conv entity.Conversion, up entity.ConversionUploads) (int64, error) {
 	const q = `
-		INSERT INTO conversions (
-			ad_id,
-			converted_at,
-			revenue,
-			order_id,
-			click_ref
+		WITH ins AS (
+			INSERT INTO conversions (
+				ad_id,
+				converted_at,
+				revenue,
+				order_id,
+				click_ref
+			)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING conversion_id, ad_id, converted_at, revenue, order_id, click_ref
+		), ob AS (
+			INSERT INTO outbox_events (event_type, ad_id, payload)
+			SELECT 'conversion.created', ins.ad_id, jsonb_build_object(
+				'conversion_id', ins.conversion_id, 'ad_id', ins.ad_id, 'converted_at', ins.converted_at,
+				'revenue', ins.revenue::text, 'order_id', ins.order_id, 'click_ref', ins.click_ref)
+			FROM ins
+		), gu AS (
+			INSERT INTO google_conversion_uploads (conversion_id, user_id, customer_id, conversion_action, gclid, status, next_attempt_at)
+			SELECT ins.conversion_id, aa.user_id, aa.external_account_id, aa.conversion_action, $6, 'pending', NOW()
+			FROM ins
+			JOIN ads a          ON a.ad_id = ins.ad_id
+			JOIN ad_accounts aa ON aa.account_id = a.account_id
+			WHERE $6 <> ''
+			  AND aa.platform = 'google'
+			  AND aa.status = 'linked'
+			  AND aa.conversion_action IS NOT NULL
+			ON CONFLICT (conversion_id) DO NOTHING
+		), mc AS (
+			INSERT INTO meta_capi_events (conversion_id, account_id, user_id, event_id, em_hash, ph_hash, fbc, fbp, status, next_attempt_at)
+			SELECT ins.conversion_id, aa.account_id, aa.user_id, COALESCE(NULLIF($8, ''), 'conv-' || ins.conversion_id),
+			       $9, $10, $11, $12, 'pending', NOW()
+			FROM ins
+			JOIN ads a                 ON a.ad_id = ins.ad_id
+			JOIN ad_accounts aa        ON aa.account_id = a.account_id
+			JOIN meta_capi_settings ms ON ms.account_id = aa.account_id
+			WHERE $7
+			  AND aa.platform = 'facebook'
+			  AND ms.enabled
+			ON CONFLICT (conversion_id) DO NOTHING
 		)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING conversion_id
+		SELECT conversion_id FROM ins
 	`
 
+	meta := up.Meta
+	if meta == nil {
+		meta = &entity.MetaCAPIEvent{}
+	}
 	var id int64
 	err := r.db.QueryRowContext(
 		ctx,
@@ -42,6 +80,13 @@ func (r *ConversionRepo) Create(ctx context.Context, conv entity.Conversion) (in
 		conv.Revenue,
 		conv.OrderID,  // допускает NULL
 		conv.ClickRef, // допускает NULL
+		up.Gclid,
+		up.Meta != nil,
+		meta.EventID,
+		meta.EmHash, // допускает NULL
+		meta.PhHash,
+		meta.Fbc,
+		meta.Fbp,
 	).Scan(&id)
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
@@ -44,14 +44,35 @@ func TestConversionRepo_Create(t *testing.T) {
 
 		mock.ExpectQuery(`INSERT\s+INTO\s+conversions`).
 			// Для устойчивости к типам времени/decimal/uuid используем AnyArg
-			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), conv.OrderID, sqlmock.AnyArg()).
+			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), conv.OrderID, sqlmock.AnyArg(),
+				"", false, "", nil, nil, nil, nil).
 			WillReturnRows(sqlmock.NewRows([]string{"conversion_id"}).AddRow(int64(1)))
 
-		id, err := repo.Create(context.Background(), conv)
+		id, err := repo.Create(context.Background(), conv, entity.ConversionUploads{})
 		require.NoError(t, err)
 		require.Equal(t, int64(1), id)
 	})
 
+	t.Run("uploads are enqueued by the same statement", func(t *testing.T) {
+		repo, mock, done := newConversionRepo(t)
+		defer done()
+
+		conv := entity.Conversion{AdID: 87, ConvertedAt: time.Now().UTC(), Revenue: decimal.NewFromFloat(12)}
+		em := "7b52009b64fd0a2a49e6d8a939753077792b0554"
+		up := entity.ConversionUploads{Gclid: "gclid-1", Meta: &entity.MetaCAPIEvent{EmHash: &em}}
+
+		// одна команда: без очередей Google/CAPI конверсия не записывается, ошибка уходит трекеру
+		mock.ExpectQuery(`INSERT\s+INTO\s+conversions[\s\S]+INSERT INTO outbox_events[\s\S]+INSERT INTO google_conversion_uploads[\s\S]+WHERE \$6 <> ''[\s\S]+INSERT INTO meta_capi_events[\s\S]+COALESCE\(NULLIF\(\$8, ''\), 'conv-' \|\| ins\.conversion_id\)[\s\S]+WHERE \$7`).
+			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				"gclid-1", true, "", &em, nil, nil, nil).
+			WillReturnRows(sqlmock.NewRows([]string{"conversion_id"}).AddRow(int64(15)))
+
+		id, err := repo.Create(context.Background(), conv, up)
+		require.NoError(t, err)
+		require.Equal(t, int64(15), id)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
 	t.Run("duplicate (pq 23505) -> ErrConversionExists", func(t *testing.T) {
 		repo, mock, done := newConversionRepo(t)
 		defer done()
@@ -67,10 +88,11 @@ func TestConversionRepo_Create(t *testing.T) {
 		pgErr := &pq.Error{Code: "23505"} // unique_violation
 
 		mock.ExpectQuery(`INSERT\s+INTO\s+conversions`).
-			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), conv.OrderID, sqlmock.AnyArg()).
+			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), conv.OrderID, sqlmock.AnyArg(),
+				"", false, "", nil, nil, nil, nil).
 			WillReturnError(pgErr)
 
-		id, err := repo.Create(context.Background(), conv)
+		id, err := repo.Create(context.Background(), conv, entity.ConversionUploads{})
 		require.ErrorIs(t, err, errs.ErrConversionExists)
 		require.Zero(t, id)
 	})
@@ -89,10 +111,11 @@ func TestConversionRepo_Create(t *testing.T) {
 		pgErr := &pq.Error{Code: "23503"}
 
 		mock.ExpectQuery(`INSERT\s+INTO\s+conversions`).
-			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				"", false, "", nil, nil, nil, nil).
 			WillReturnError(pgErr)
 
-		id, err := repo.Create(context.Background(), conv)
+		id, err := repo.Create(context.Background(), conv, entity.ConversionUploads{})
 		require.Error(t, err)
 		require.Zero(t, id)
 
@@ -112,10 +135,11 @@ func TestConversionRepo_Create(t *testing.T) {
 		}
 
 		mock.ExpectQuery(`INSERT\s+INTO\s+conversions`).
-			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WithArgs(conv.AdID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(),
+				"", false, "", nil, nil, nil, nil).
 			WillReturnError(errors.New("boom"))
 
-		id, err := repo.Create(context.Background(), conv)
+		id, err := repo.Create(context.Background(), conv, entity.ConversionUploads{})
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "boom")
 		require.Zero(t, id)
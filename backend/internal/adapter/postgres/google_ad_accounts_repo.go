@@ -3,7 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
 )
 
 type GoogleAdAccountsRepo struct {
@@ -14,25 +17,31 @@ func NewGoogleAdAccountsRepo(db *sql.DB) *GoogleAdAccountsRepo {
 	return &GoogleAdAccountsRepo{db: db}
 }
 
-// LinkGoogleAccounts — массовый UPSERT выбранных customerIds для пользователя.
-// platform='google', external_account_id='<customerId>', status='linked'.
+// LinkGoogleAccounts — массовый UPSERT выбранных аккаунтов в рабочее пространство;
+// userID — кто привязывает (его токенами синкается аккаунт). При переезде аккаунта
+// в другое пространство он выходит из клиента старого пространства.
+// platform='google', external_account_id='<customerId>', status='linked';
+// token_owner — Google-логин, чьим токеном синкается аккаунт;
+// login_customer_id — MCC, через который аккаунт достижим (пусто ⇒ напрямую).
 func (r *GoogleAdAccountsRepo) LinkGoogleAccounts(
 	ctx context.Context,
-	userID int64,
-	tokenOwnerGoogleUserID string,
-	customerIDs []string,
+	workspaceID, userID int64,
+	links []entity.GoogleAccountLink,
 ) error {
-	if len(customerIDs) == 0 {
+	if len(links) == 0 {
 		return nil
 	}
 	const q = `
-	INSERT INTO ad_accounts (user_id, platform, external_account_id, token_owner, status, created_at, updated_at)
-	VALUES ($1, 'google', $2, $3, 'linked', NOW(), NOW())
+	INSERT INTO ad_accounts (user_id, workspace_id, platform, external_account_id, token_owner, login_customer_id, status, created_at, updated_at)
+	VALUES ($1, $5, 'google', $2, $3, NULLIF($4, ''), 'linked', NOW(), NOW())
 	ON CONFLICT (platform, external_account_id) DO UPDATE
-	SET user_id    = EXCLUDED.user_id,
-		token_owner= EXCLUDED.token_owner,
-		status     = 'linked',
-		updated_at = NOW()`
+	SET user_id          = EXCLUDED.user_id,
+		workspace_id     = EXCLUDED.workspace_id,
+		client_id        = CASE WHEN ad_accounts.workspace_id = EXCLUDED.workspace_id THEN ad_accounts.client_id END,
+		token_owner      = EXCLUDED.token_owner,
+		login_customer_id= EXCLUDED.login_customer_id,
+		status           = 'linked',
+		updated_at       = NOW()`
 
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -46,23 +55,146 @@ func (r *GoogleAdAccountsRepo) LinkGoogleAccounts(
 	}
 	defer stmt.Close()
 
-	for _, cid := range customerIDs {
-		if _, err := stmt.ExecContext(ctx, userID, cid, tokenOwnerGoogleUserID); err != nil {
+	for _, l := range links {
+		if _, err := stmt.ExecContext(ctx, userID, l.CustomerID, l.TokenOwner, l.LoginCustomerID, workspaceID); err != nil {
 			return err
 		}
 	}
 	return tx.Commit()
 }
 
+// LoginCustomerID — MCC, через который привязан аккаунт пользователя.
+// Пустая строка — аккаунт не найден или доступен напрямую.
+func (r *GoogleAdAccountsRepo) LoginCustomerID(ctx context.Context, userID int64, customerID string) (string, error) {
+	const q = `
+SELECT COALESCE(login_customer_id, '')
+FROM ad_accounts
+WHERE user_id = $1 AND platform = 'google' AND external_account_id = $2`
+	var login string
+	err := r.db.QueryRowContext(ctx, q, userID, customerID).Scan(&login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("login customer id: %w", err)
+	}
+	return login, nil
+}
+
+// TokenOwner — google_user_id, чьим токеном синкается привязанный аккаунт.
+// Пустая строка — аккаунт не найден или владелец не записан.
+func (r *GoogleAdAccountsRepo) TokenOwner(ctx context.Context, userID int64, customerID string) (string, error) {
+	const q = `
+SELECT COALESCE(token_owner, '')
+FROM ad_accounts
+WHERE user_id = $1 AND platform = 'google' AND external_account_id = $2`
+	var owner string
+	err := r.db.QueryRowContext(ctx, q, userID, customerID).Scan(&owner)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("token owner: %w", err)
+	}
+	return owner, nil
+}
+
+// UnlinkGoogleAccount — помечает аккаунт пространства unlinked и возвращает, кто его привязал
+// и чьим Google-логином (token_owner) он синкался.
+// Возвращает sql.ErrNoRows, если в пространстве нет такого привязанного аккаунта.
+func (r *GoogleAdAccountsRepo) UnlinkGoogleAccount(ctx context.Context, workspaceID int64, customerID string) (int64, string, error) {
+	const q = `
+UPDATE ad_accounts
+SET status = 'unlinked', updated_at = NOW()
+WHERE workspace_id = $1 AND platform = 'google' AND external_account_id = $2 AND status = 'linked'
+RETURNING user_id, COALESCE(token_owner, '')`
+	var (
+		userID int64
+		owner  string
+	)
+	if err := r.db.QueryRowContext(ctx, q, workspaceID, customerID).Scan(&userID, &owner); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, "", err
+		}
+		return 0, "", fmt.Errorf("unlink google account: %w", err)
+	}
+	return userID, owner, nil
+}
+
+// CountLinkedByOwner — сколько привязанных Google-аккаунтов пользователя ещё синкается токеном owner.
+func (r *GoogleAdAccountsRepo) CountLinkedByOwner(ctx context.Context, userID int64, owner string) (int, error) {
+	const q = `
+SELECT COUNT(*)
+FROM ad_accounts
+WHERE user_id = $1 AND platform = 'google' AND token_owner = $2 AND status = 'linked'`
+	var n int
+	if err := r.db.QueryRowContext(ctx, q, userID, owner).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count linked by owner: %w", err)
+	}
+	return n, nil
+}
+
+// PurgeGoogleSpend — удаляет синхронизированные траты Google-аккаунта пространства
+// (customerID = "" — всех Google-аккаунтов, привязанных в нём пользователем userID):
+// строки ads_insights и spend в ad_daily_metrics.
+// Клики/конверсии/выручка остаются — это наши собственные данные.
+func (r *GoogleAdAccountsRepo) PurgeGoogleSpend(ctx context.Context, workspaceID, userID int64, customerID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const accountAds = `
+SELECT a.ad_id
+FROM ads a
+JOIN ad_accounts aa ON aa.account_id = a.account_id
+WHERE aa.workspace_id = $1 AND aa.platform = 'google'
+  AND ($2::text = '' OR aa.external_account_id = $2)
+  AND ($2::text <> '' OR aa.user_id = $3)`
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ads_insights WHERE ad_id IN (`+accountAds+`)`, workspaceID, customerID, userID); err != nil {
+		return fmt.Errorf("purge ads_insights: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE ad_daily_metrics SET spend = 0 WHERE ad_id IN (`+accountAds+`)`, workspaceID, customerID, userID); err != nil {
+		return fmt.Errorf("purge ad_daily_metrics spend: %w", err)
+	}
+	return tx.Commit()
+}
+
+// RecordSyncResult — фиксирует итог синка аккаунта: пустой syncErr — успех.
+func (r *GoogleAdAccountsRepo) RecordSyncResult(ctx context.Context, accountID int64, syncErr string) error {
+	const ok = `
+UPDATE ad_accounts
+SET last_synced_at = NOW(), last_sync_error = NULL, last_sync_error_at = NULL
+WHERE account_id = $1`
+	const failed = `
+UPDATE ad_accounts
+SET last_sync_error = $2, last_sync_error_at = NOW()
+WHERE account_id = $1`
+	var err error
+	if syncErr == "" {
+		_, err = r.db.ExecContext(ctx, ok, accountID)
+	} else {
+		_, err = r.db.ExecContext(ctx, failed, accountID, syncErr)
+	}
+	if err != nil {
+		return fmt.Errorf("record sync result: %w", err)
+	}
+	return nil
+}
+
 // UpsertLinked — одиночный upsert с возвратом account_id и признака already.
+// Новый аккаунт попадает в личное пространство пользователя.
 func (r *GoogleAdAccountsRepo) UpsertLinked(
 	ctx context.Context,
 	userID int64,
 	platform, externalID, tokenOwner string,
 ) (accountID int64, already bool, err error) {
 	const q = `
-INSERT INTO ad_accounts (user_id, platform, external_account_id, token_owner, status, created_at, updated_at)
-VALUES ($1, $2, $3, $4, 'linked', NOW(), NOW())
+INSERT INTO ad_accounts (user_id, workspace_id, platform, external_account_id, token_owner, status, created_at, updated_at)
+SELECT $1, w.workspace_id, $2, $3, $4, 'linked', NOW(), NOW()
+FROM workspaces w WHERE w.personal_user_id = $1
 ON CONFLICT (platform, external_account_id) DO UPDATE
 SET user_id    = EXCLUDED.user_id,
     token_owner= EXCLUDED.token_owner,
@@ -76,19 +208,20 @@ RETURNING account_id, (xmax <> 0) AS updated`
 	return accountID, already, nil
 }
 
+// GetAccountID — привязанный аккаунт пространства и пользователь, который его привязал
+// (его токенами аккаунт синкается). Нет такого аккаунта — sql.ErrNoRows.
 func (r *GoogleAdAccountsRepo) GetAccountID(
 	ctx context.Context,
-	userID int64,
+	workspaceID int64,
 	platform, externalID string,
-) (int64, error) {
-	const q = `SELECT account_id FROM ad_accounts
-	           WHERE user_id=$1 AND platform=$2 AND external_account_id=$3
+) (accountID, userID int64, err error) {
+	const q = `SELECT account_id, user_id FROM ad_accounts
+	           WHERE workspace_id=$1 AND platform=$2 AND external_account_id=$3 AND status='linked'
 	           LIMIT 1`
-	var id int64
-	if err := r.db.QueryRowContext(ctx, q, userID, platform, externalID).Scan(&id); err != nil {
-		return 0, fmt.Errorf("get account id: %w", err)
+	if err := r.db.QueryRowContext(ctx, q, workspaceID, platform, externalID).Scan(&accountID, &userID); err != nil {
+		return 0, 0, fmt.Errorf("get account id: %w", err)
 	}
-	return id, nil
+	return accountID, userID, nil
 }
 
 func (r *GoogleAdAccountsRepo) UpsertAdIfMissing(
@@ -152,3 +285,24 @@ DO UPDATE SET spend = EXCLUDED.spend`
 	}
 	return nil
 }
+
+// SetConversionAction — задаёт conversion action, в который грузятся офлайн-конверсии аккаунта.
+// Пустая строка отключает загрузку. Возвращает sql.ErrNoRows, если аккаунт не привязан к пространству.
+func (r *GoogleAdAccountsRepo) SetConversionAction(
+	ctx context.Context,
+	workspaceID int64,
+	customerID, conversionAction string,
+) error {
+	const q = `
+UPDATE ad_accounts
+SET conversion_action = NULLIF($3, ''), updated_at = NOW()
+WHERE workspace_id = $1 AND platform = 'google' AND external_account_id = $2`
+	res, err := r.db.ExecContext(ctx, q, workspaceID, customerID, conversionAction)
+	if err != nil {
+		return fmt.Errorf("set conversion action: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+func newGoogleAdAccountsRepo(t *testing.T) (*postgres.GoogleAdAccountsRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewGoogleAdAccountsRepo(db), mock, func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+		_ = db.Close()
+	}
+}
+
+func TestGoogleAdAccounts_LinkStoresLoginCustomerID(t *testing.T) {
+	repo, mock, done := newGoogleAdAccountsRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(`INSERT\s+INTO\s+ad_accounts.+workspace_id.+login_customer_id.+ON\s+CONFLICT`)
+	prep.ExpectExec().WithArgs(int64(7), "1112223333", "guid-1", "", int64(3)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	prep.ExpectExec().WithArgs(int64(7), "4445556666", "guid-1", "9990001111", int64(3)).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	err := repo.LinkGoogleAccounts(context.Background(), 3, 7, []entity.GoogleAccountLink{
+		{CustomerID: "1112223333", TokenOwner: "guid-1"},
+		{CustomerID: "4445556666", LoginCustomerID: "9990001111", TokenOwner: "guid-1"},
+	})
+	require.NoError(t, err)
+}
+
+func TestGoogleAdAccounts_RelinkToOtherWorkspace_LeavesClient(t *testing.T) {
+	repo, mock, done := newGoogleAdAccountsRepo(t)
+	defer done()
+
+	// аккаунт уже в клиенте пространства 3 (и по нему есть ссылка) — перепривязка в 4 снимает client_id
+	mock.ExpectBegin()
+	prep := mock.ExpectPrepare(`ON\s+CONFLICT[\s\S]+workspace_id\s+= EXCLUDED\.workspace_id,\s+client_id\s+= CASE WHEN ad_accounts\.workspace_id = EXCLUDED\.workspace_id THEN ad_accounts\.client_id END`)
+	prep.ExpectExec().WithArgs(int64(8), "1112223333", "guid-2", "", int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := repo.LinkGoogleAccounts(context.Background(), 4, 8, []entity.GoogleAccountLink{
+		{CustomerID: "1112223333", TokenOwner: "guid-2"},
+	})
+	require.NoError(t, err)
+}
+
+func TestGoogleAdAccounts_LoginCustomerID(t *testing.T) {
+	repo, mock, done := newGoogleAdAccountsRepo(t)
+	defer done()
+
+	mock.ExpectQuery(`SELECT\s+COALESCE\(login_customer_id,\s*''\)\s+FROM\s+ad_accounts`).
+		WithArgs(int64(7), "4445556666").
+		WillReturnRows(sqlmock.NewRows([]string{"login_customer_id"}).AddRow("9990001111"))
+	login, err := repo.LoginCustomerID(context.Background(), 7, "4445556666")
+	require.NoError(t, err)
+	require.Equal(t, "9990001111", login)
+
+	// неизвестный аккаунт — не ошибка, просто без MCC
+	mock.ExpectQuery(`FROM\s+ad_accounts`).
+		WithArgs(int64(7), "0000000000").
+		WillReturnRows(sqlmock.NewRows([]string{"login_customer_id"}))
+	login, err = repo.LoginCustomerID(context.Background(), 7, "0000000000")
+	require.NoError(t, err)
+	require.Empty(t, login)
+}
+
+func TestGoogleAdAccounts_TokenOwner(t *testing.T) {
+	repo, mock, done := newGoogleAdAccountsRepo(t)
+	defer done()
+
+	mock.ExpectQuery(`SELECT\s+COALESCE\(token_owner,\s*''\)\s+FROM\s+ad_accounts`).
+		WithArgs(int64(7), "4445556666").
+		WillReturnRows(sqlmock.NewRows([]string{"token_owner"}).AddRow("guid-2"))
+	owner, err := repo.TokenOwner(context.Background(), 7, "4445556666")
+	require.NoError(t, err)
+	require.Equal(t, "guid-2", owner)
+}
+
+func TestGoogleAdAccounts_Unlink(t *testing.T) {
+	repo, mock, done := newGoogleAdAccountsRepo(t)
+	defer done()
+
+	mock.ExpectQuery(`UPDATE\s+ad_accounts\s+SET\s+status\s*=\s*'unlinked'.+WHERE\s+workspace_id\s*=\s*\$1.+RETURNING\s+user_id,\s*COALESCE\(token_owner`).
+		WithArgs(int64(3), "4445556666").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "token_owner"}).AddRow(7, "guid-1"))
+	linker, owner, err := repo.UnlinkGoogleAccount(context.Background(), 3, "4445556666")
+	require.NoError(t, err)
+	require.Equal(t, int64(7), linker)
+	require.Equal(t, "guid-1", owner)
+
+	// аккаунт другого пространства не найден
+	mock.ExpectQuery(`UPDATE\s+ad_accounts`).
+		WithArgs(int64(4), "4445556666").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "token_owner"}))
+	_, _, err = repo.UnlinkGoogleAccount(context.Background(), 4, "4445556666")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestGoogleAdAccounts_PurgeSpend(t *testing.T) {
+	repo, mock, done := newGoogleAdAccountsRepo(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE\s+FROM\s+ads_insights\s+WHERE\s+ad_id\s+IN.+aa\.workspace_id\s*=\s*\$1`).
+		WithArgs(int64(3), "4445556666", int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectExec(`UPDATE\s+ad_daily_metrics\s+SET\s+spend\s*=\s*0.+aa\.workspace_id\s*=\s*\$1`).
+		WithArgs(int64(3), "4445556666", int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 10))
+	mock.ExpectCommit()
+	require.NoError(t, repo.PurgeGoogleSpend(context.Background(), 3, 7, "4445556666"))
+}
+
+func TestGoogleAdAccounts_GetAccountID_ScopedToWorkspace(t *testing.T) {
+	repo, mock, done := newGoogleAdAccountsRepo(t)
+	defer done()
+
+	mock.ExpectQuery(`SELECT account_id, user_id FROM ad_accounts\s+WHERE workspace_id=\$1 AND platform=\$2 AND external_account_id=\$3 AND status='linked'`).
+		WithArgs(int64(3), "google", "4445556666").
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "user_id"}).AddRow(11, 7))
+	accountID, linker, err := repo.GetAccountID(context.Background(), 3, "google", "4445556666")
+	require.NoError(t, err)
+	require.Equal(t, int64(11), accountID)
+	require.Equal(t, int64(7), linker)
+}
+
+func TestGoogleAdAccounts_SetConversionAction_ScopedToWorkspace(t *testing.T) {
+	repo, mock, done := newGoogleAdAccountsRepo(t)
+	defer done()
+
+	mock.ExpectExec(`UPDATE ad_accounts\s+SET conversion_action = NULLIF\(\$3, ''\).+WHERE workspace_id = \$1`).
+		WithArgs(int64(4), "4445556666", "").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	err := repo.SetConversionAction(context.Background(), 4, "4445556666", "")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
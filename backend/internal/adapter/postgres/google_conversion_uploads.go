@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+type GoogleConversionUploadsRepo struct {
+	db *sql.DB
+}
+
+func NewGoogleConversionUploadsRepo(db *sql.DB) *GoogleConversionUploadsRepo {
+	return &GoogleConversionUploadsRepo{db: db}
+}
+
+// ClaimDue атомарно забирает до limit готовых к отправке записей и переводит их в processing.
+// Зависшие в processing дольше stuckAfter (упавший воркер) забираются повторно.
+func (r *GoogleConversionUploadsRepo) ClaimDue(ctx context.Context, limit int, stuckAfter time.Duration) ([]entity.GoogleConversionUpload, error) {
+	const q = `
+WITH due AS (
+	SELECT u.conversion_id
+	FROM google_conversion_uploads u
+	WHERE (u.status = 'pending' AND u.next_attempt_at <= NOW())
+	   OR (u.status = 'processing' AND u.updated_at < NOW() - $2 * INTERVAL '1 second')
+	ORDER BY u.next_attempt_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+UPDATE google_conversion_uploads u
+SET status = 'processing', attempts = u.attempts + 1, updated_at = NOW()
+FROM due, conversions cv
+WHERE u.conversion_id = due.conversion_id
+  AND cv.conversion_id = u.conversion_id
+RETURNING u.conversion_id, u.user_id, u.customer_id, u.conversion_action, u.gclid,
+          u.status, u.attempts, u.last_error, cv.converted_at, cv.revenue, cv.order_id`
+
+	rows, err := r.db.QueryContext(ctx, q, limit, int64(stuckAfter/time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("claim google uploads: %w", err)
+	}
+	defer rows.Close()
+
+	var out []entity.GoogleConversionUpload
+	for rows.Next() {
+		var u entity.GoogleConversionUpload
+		if err := rows.Scan(
+			&u.ConversionID, &u.UserID, &u.CustomerID, &u.ConversionAction, &u.Gclid,
+			&u.Status, &u.Attempts, &u.LastError, &u.ConvertedAt, &u.Revenue, &u.OrderID,
+		); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// MarkUploaded — конверсия принята Google.
+func (r *GoogleConversionUploadsRepo) MarkUploaded(ctx context.Context, conversionID int64) error {
+	const q = `
+UPDATE google_conversion_uploads
+SET status = 'uploaded', last_error = NULL, uploaded_at = NOW(), updated_at = NOW()
+WHERE conversion_id = $1`
+	if _, err := r.db.ExecContext(ctx, q, conversionID); err != nil {
+		return fmt.Errorf("mark uploaded: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry — неудачная попытка, повторим не раньше nextAttempt.
+func (r *GoogleConversionUploadsRepo) MarkRetry(ctx context.Context, conversionID int64, lastErr string, nextAttempt time.Time) error {
+	const q = `
+UPDATE google_conversion_uploads
+SET status = 'pending', last_error = $2, next_attempt_at = $3, updated_at = NOW()
+WHERE conversion_id = $1`
+	if _, err := r.db.ExecContext(ctx, q, conversionID, lastErr, nextAttempt); err != nil {
+		return fmt.Errorf("mark retry: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed — попытки исчерпаны или ошибка неисправима.
+func (r *GoogleConversionUploadsRepo) MarkFailed(ctx context.Context, conversionID int64, lastErr string) error {
+	const q = `
+UPDATE google_conversion_uploads
+SET status = 'failed', last_error = $2, updated_at = NOW()
+WHERE conversion_id = $1`
+	if _, err := r.db.ExecContext(ctx, q, conversionID, lastErr); err != nil {
+		return fmt.Errorf("mark failed: %w", err)
+	}
+	return nil
+}
+
+// ByConversionID — статус загрузки конкретной конверсии (в пределах пользователя).
+// Возвращает sql.ErrNoRows, если конверсия не ставилась в очередь.
+func (r *GoogleConversionUploadsRepo) ByConversionID(ctx context.Context, userID, conversionID int64) (entity.GoogleConversionUpload, error) {
+	const q = `
+SELECT u.conversion_id, u.user_id, u.customer_id, u.conversion_action, u.gclid,
+       u.status, u.attempts, u.last_error, cv.converted_at, cv.revenue, cv.order_id
+FROM google_conversion_uploads u
+JOIN conversions cv ON cv.conversion_id = u.conversion_id
+WHERE u.conversion_id = $1 AND u.user_id = $2`
+	var u entity.GoogleConversionUpload
+	err := r.db.QueryRowContext(ctx, q, conversionID, userID).Scan(
+		&u.ConversionID, &u.UserID, &u.CustomerID, &u.ConversionAction, &u.Gclid,
+		&u.Status, &u.Attempts, &u.LastError, &u.ConvertedAt, &u.Revenue, &u.OrderID,
+	)
+	return u, err
+}
@@ -0,0 +1,55 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+)
+
+func newGoogleConvUploadsRepo(t *testing.T) (*postgres.GoogleConversionUploadsRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewGoogleConversionUploadsRepo(db), mock, func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+		_ = db.Close()
+	}
+}
+
+func TestGoogleConversionUploads_ClaimDue(t *testing.T) {
+	repo, mock, done := newGoogleConvUploadsRepo(t)
+	defer done()
+
+	at := time.Now().UTC()
+	mock.ExpectQuery(`FOR\s+UPDATE\s+SKIP\s+LOCKED`).
+		WithArgs(50, int64(900)).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"conversion_id", "user_id", "customer_id", "conversion_action", "gclid",
+			"status", "attempts", "last_error", "converted_at", "revenue", "order_id",
+		}).AddRow(int64(15), int64(7), "1234567890", "customers/1234567890/conversionActions/1", "gclid-1",
+			"processing", 1, nil, at, "49.99", "A1"))
+
+	items, err := repo.ClaimDue(context.Background(), 50, 15*time.Minute)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "gclid-1", items[0].Gclid)
+	require.Equal(t, 1, items[0].Attempts)
+	require.Equal(t, "49.99", items[0].Revenue.StringFixed(2))
+	require.Equal(t, "A1", *items[0].OrderID)
+}
+
+func TestGoogleConversionUploads_MarkRetry(t *testing.T) {
+	repo, mock, done := newGoogleConvUploadsRepo(t)
+	defer done()
+
+	next := time.Now().Add(time.Minute)
+	mock.ExpectExec(`UPDATE\s+google_conversion_uploads\s+SET\s+status\s*=\s*'pending'`).
+		WithArgs(int64(15), "timeout", next).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkRetry(context.Background(), 15, "timeout", next))
+}
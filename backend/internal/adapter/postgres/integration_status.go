@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+type IntegrationStatusRepo struct {
+	db *sql.DB
+}
+
+func NewIntegrationStatusRepo(db *sql.DB) *IntegrationStatusRepo {
+	return &IntegrationStatusRepo{db: db}
+}
+
+// GoogleTokenCounts — сколько Google-логинов рабочие и сколько всего подключено: логины пользователя
+// и тех, кто привязал Google-аккаунты пространства (их токенами синкаются аккаунты).
+func (r *IntegrationStatusRepo) GoogleTokenCounts(ctx context.Context, workspaceID, userID int64) (active, total int, err error) {
+	const q = `
+SELECT COUNT(*) FILTER (WHERE NOT needs_consent), COUNT(*)
+FROM google_user_tokens
+WHERE user_id = $2
+   OR user_id IN (SELECT aa.user_id FROM ad_accounts aa WHERE aa.workspace_id = $1 AND aa.platform = 'google')`
+	if err = r.db.QueryRowContext(ctx, q, workspaceID, userID).Scan(&active, &total); err != nil {
+		return 0, 0, fmt.Errorf("google token counts: %w", err)
+	}
+	return active, total, nil
+}
+
+// AccountsSummary — число привязанных аккаунтов платформы в пространстве, время последнего
+// успешного синка и последняя ошибка синка среди них.
+func (r *IntegrationStatusRepo) AccountsSummary(ctx context.Context, workspaceID int64, platform string) (entity.AccountsSyncSummary, error) {
+	const q = `
+SELECT COUNT(*) FILTER (WHERE status = 'linked'),
+       MAX(last_synced_at),
+       (SELECT last_sync_error FROM ad_accounts
+         WHERE workspace_id = $1 AND platform = $2 AND last_sync_error IS NOT NULL
+         ORDER BY last_sync_error_at DESC LIMIT 1),
+       MAX(last_sync_error_at)
+FROM ad_accounts
+WHERE workspace_id = $1 AND platform = $2`
+	var s entity.AccountsSyncSummary
+	if err := r.db.QueryRowContext(ctx, q, workspaceID, platform).
+		Scan(&s.LinkedAccounts, &s.LastSyncAt, &s.LastError, &s.LastErrorAt); err != nil {
+		return s, fmt.Errorf("accounts summary: %w", err)
+	}
+	return s, nil
+}
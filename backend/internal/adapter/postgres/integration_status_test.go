@@ -0,0 +1,40 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+)
+
+func TestIntegrationStatus_Queries(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer db.Close()
+	repo := postgres.NewIntegrationStatusRepo(db)
+
+	mock.ExpectQuery(`FILTER\s+\(WHERE\s+NOT\s+needs_consent\).+FROM\s+google_user_tokens\s+WHERE user_id = \$2\s+OR user_id IN \(SELECT aa\.user_id FROM ad_accounts aa WHERE aa\.workspace_id = \$1`).
+		WithArgs(int64(3), int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"active", "total"}).AddRow(0, 2))
+	active, total, err := repo.GoogleTokenCounts(context.Background(), 3, 7)
+	require.NoError(t, err)
+	require.Equal(t, 0, active)
+	require.Equal(t, 2, total)
+
+	synced := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`MAX\(last_synced_at\).+FROM\s+ad_accounts\s+WHERE workspace_id = \$1 AND platform = \$2`).
+		WithArgs(int64(3), "google").
+		WillReturnRows(sqlmock.NewRows([]string{"linked", "last_synced_at", "last_sync_error", "last_sync_error_at"}).
+			AddRow(3, synced, "quota", synced.Add(time.Hour)))
+	sum, err := repo.AccountsSummary(context.Background(), 3, "google")
+	require.NoError(t, err)
+	require.Equal(t, 3, sum.LinkedAccounts)
+	require.Equal(t, synced, *sum.LastSyncAt)
+	require.Equal(t, "quota", *sum.LastError)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+type MetaCAPIRepo struct {
+	db  *sql.DB
+	enc Encryptor
+}
+
+func NewMetaCAPIRepo(db *sql.DB, enc Encryptor) *MetaCAPIRepo {
+	return &MetaCAPIRepo{db: db, enc: enc}
+}
+
+// SaveSettings — upsert настроек CAPI для facebook-аккаунта рабочего пространства (токен шифруется).
+// Возвращает sql.ErrNoRows, если такого аккаунта в пространстве нет.
+func (r *MetaCAPIRepo) SaveSettings(ctx context.Context, workspaceID int64, externalAccountID string, s entity.MetaCAPISettings) error {
+	encTok, err := r.enc.EncryptString(ctx, s.AccessToken)
+	if err != nil {
+		return fmt.Errorf("encrypt capi token: %w", err)
+	}
+
+	const q = `
+INSERT INTO meta_capi_settings (account_id, pixel_id, access_token_enc, currency, test_event_code, enabled, created_at, updated_at)
+SELECT aa.account_id, $3, $4, $5, NULLIF($6, ''), $7, NOW(), NOW()
+FROM ad_accounts aa
+WHERE aa.workspace_id = $1 AND aa.platform = 'facebook' AND aa.external_account_id = $2
+ON CONFLICT (account_id) DO UPDATE
+SET pixel_id         = EXCLUDED.pixel_id,
+    access_token_enc = EXCLUDED.access_token_enc,
+    currency         = EXCLUDED.currency,
+    test_event_code  = EXCLUDED.test_event_code,
+    enabled          = EXCLUDED.enabled,
+    updated_at       = NOW()`
+	res, err := r.db.ExecContext(ctx, q, workspaceID, externalAccountID, s.PixelID, encTok, s.Currency, s.TestEventCode, s.Enabled)
+	if err != nil {
+		return fmt.Errorf("save capi settings: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+const metaEventColumns = `
+       e.conversion_id, e.account_id, e.user_id, e.event_id, e.em_hash, e.ph_hash, e.fbc, e.fbp,
+       e.status, e.attempts, e.last_error, e.fbtrace_id, e.sent_at,
+       cv.converted_at, cv.revenue, cv.order_id`
+
+func scanMetaEvent(sc interface{ Scan(dest ...any) error }, ev *entity.MetaCAPIEvent) error {
+	return sc.Scan(
+		&ev.ConversionID, &ev.AccountID, &ev.UserID, &ev.EventID, &ev.EmHash, &ev.PhHash, &ev.Fbc, &ev.Fbp,
+		&ev.Status, &ev.Attempts, &ev.LastError, &ev.FbtraceID, &ev.SentAt,
+		&ev.ConvertedAt, &ev.Revenue, &ev.OrderID,
+	)
+}
+
+// ClaimDue атомарно забирает до limit готовых к отправке событий вместе с настройками пикселя.
+// Зависшие в processing дольше stuckAfter забираются повторно.
+func (r *MetaCAPIRepo) ClaimDue(ctx context.Context, limit int, stuckAfter time.Duration) ([]entity.MetaCAPIEvent, error) {
+	const q = `
+WITH due AS (
+	SELECT conversion_id
+	FROM meta_capi_events
+	WHERE (status = 'pending' AND next_attempt_at <= NOW())
+	   OR (status = 'processing' AND updated_at < NOW() - $2 * INTERVAL '1 second')
+	ORDER BY next_attempt_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+)
+UPDATE meta_capi_events e
+SET status = 'processing', attempts = e.attempts + 1, updated_at = NOW()
+FROM due, conversions cv, meta_capi_settings ms
+WHERE e.conversion_id = due.conversion_id
+  AND cv.conversion_id = e.conversion_id
+  AND ms.account_id = e.account_id
+RETURNING` + metaEventColumns + `,
+          ms.pixel_id, ms.access_token_enc, ms.currency, COALESCE(ms.test_event_code, ''), ms.enabled`
+
+	rows, err := r.db.QueryContext(ctx, q, limit, int64(stuckAfter/time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("claim capi events: %w", err)
+	}
+	defer rows.Close()
+
+	var out []entity.MetaCAPIEvent
+	for rows.Next() {
+		var ev entity.MetaCAPIEvent
+		var tokEnc string
+		if err := rows.Scan(
+			&ev.ConversionID, &ev.AccountID, &ev.UserID, &ev.EventID, &ev.EmHash, &ev.PhHash, &ev.Fbc, &ev.Fbp,
+			&ev.Status, &ev.Attempts, &ev.LastError, &ev.FbtraceID, &ev.SentAt,
+			&ev.ConvertedAt, &ev.Revenue, &ev.OrderID,
+			&ev.Settings.PixelID, &tokEnc, &ev.Settings.Currency, &ev.Settings.TestEventCode, &ev.Settings.Enabled,
+		); err != nil {
+			return nil, err
+		}
+		ev.Settings.AccountID = ev.AccountID
+		if ev.Settings.AccessToken, err = r.enc.DecryptString(ctx, tokEnc); err != nil {
+			return nil, fmt.Errorf("decrypt capi token (account %d): %w", ev.AccountID, err)
+		}
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
+
+// MarkSent — событие принято Meta.
+func (r *MetaCAPIRepo) MarkSent(ctx context.Context, conversionID int64, fbtraceID string) error {
+	const q = `
+UPDATE meta_capi_events
+SET status = 'uploaded', last_error = NULL, fbtrace_id = NULLIF($2, ''), sent_at = NOW(), updated_at = NOW()
+WHERE conversion_id = $1`
+	if _, err := r.db.ExecContext(ctx, q, conversionID, fbtraceID); err != nil {
+		return fmt.Errorf("mark capi sent: %w", err)
+	}
+	return nil
+}
+
+// MarkRetry — неудачная попытка, повторим не раньше nextAttempt.
+func (r *MetaCAPIRepo) MarkRetry(ctx context.Context, conversionID int64, lastErr, fbtraceID string, nextAttempt time.Time) error {
+	const q = `
+UPDATE meta_capi_events
+SET status = 'pending', last_error = $2, fbtrace_id = NULLIF($3, ''), next_attempt_at = $4, updated_at = NOW()
+WHERE conversion_id = $1`
+	if _, err := r.db.ExecContext(ctx, q, conversionID, lastErr, fbtraceID, nextAttempt); err != nil {
+		return fmt.Errorf("mark capi retry: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed — попытки исчерпаны или событие отклонено.
+func (r *MetaCAPIRepo) MarkFailed(ctx context.Context, conversionID int64, lastErr, fbtraceID string) error {
+	const q = `
+UPDATE meta_capi_events
+SET status = 'failed', last_error = $2, fbtrace_id = NULLIF($3, ''), updated_at = NOW()
+WHERE conversion_id = $1`
+	if _, err := r.db.ExecContext(ctx, q, conversionID, lastErr, fbtraceID); err != nil {
+		return fmt.Errorf("mark capi failed: %w", err)
+	}
+	return nil
+}
+
+// ByConversionID — статус пересылки конверсии (в пределах пространства её аккаунта).
+// Возвращает sql.ErrNoRows, если конверсия не ставилась в очередь.
+func (r *MetaCAPIRepo) ByConversionID(ctx context.Context, workspaceID, conversionID int64) (entity.MetaCAPIEvent, error) {
+	const q = `
+SELECT` + metaEventColumns + `
+FROM meta_capi_events e
+JOIN conversions cv  ON cv.conversion_id = e.conversion_id
+JOIN ad_accounts aa  ON aa.account_id = e.account_id
+WHERE e.conversion_id = $1 AND aa.workspace_id = $2`
+	var ev entity.MetaCAPIEvent
+	err := scanMetaEvent(r.db.QueryRowContext(ctx, q, conversionID, workspaceID), &ev)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return ev, fmt.Errorf("capi event by conversion: %w", err)
+	}
+	return ev, err
+}
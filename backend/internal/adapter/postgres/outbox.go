@@ -0,0 +1,77 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// OutboxRepo — чтение и отметки outbox_events для relay.
+// Relay работает в одном экземпляре (advisory lock), поэтому строки не блокируются.
+type OutboxRepo struct {
+	db *sql.DB
+}
+
+func NewOutboxRepo(db *sql.DB) *OutboxRepo { return &OutboxRepo{db: db} }
+
+// Pending — до limit голов очередей: самое раннее pending-событие каждого объявления,
+// если оно уже готово к (повторной) попытке. Голова, ждущая повтора, держит только своё
+// объявление — остальные объявления не простаивают, сколько бы событий за ней ни скопилось.
+func (r *OutboxRepo) Pending(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	const q = `
+SELECT outbox_id, event_id, event_type, ad_id, payload, created_at, attempts, next_attempt_at
+FROM (
+	SELECT DISTINCT ON (ad_id) outbox_id, event_id, event_type, ad_id, payload, created_at, attempts, next_attempt_at
+	FROM outbox_events
+	WHERE status = 'pending'
+	ORDER BY ad_id, outbox_id
+) head
+WHERE head.next_attempt_at <= NOW()
+ORDER BY outbox_id
+LIMIT $1`
+	rows, err := r.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("outbox pending: %w", err)
+	}
+	defer rows.Close()
+
+	var out []entity.OutboxEvent
+	for rows.Next() {
+		var e entity.OutboxEvent
+		if err := rows.Scan(&e.OutboxID, &e.EventID, &e.EventType, &e.AdID, &e.Payload,
+			&e.CreatedAt, &e.Attempts, &e.NextAttemptAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (r *OutboxRepo) MarkPublished(ctx context.Context, outboxID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+UPDATE outbox_events SET status = 'published', published_at = NOW(), attempts = attempts + 1, last_error = NULL
+WHERE outbox_id = $1`, outboxID)
+	return err
+}
+
+// MarkFailed: dead — попытки исчерпаны, событие уходит из очереди объявления.
+func (r *OutboxRepo) MarkFailed(ctx context.Context, outboxID int64, errText string, dead bool, retryAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+UPDATE outbox_events
+SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3,
+    status = CASE WHEN $4 THEN 'dead' ELSE status END
+WHERE outbox_id = $1`, outboxID, errText, retryAt, dead)
+	return err
+}
+
+// Purge удаляет опубликованные события старше before; возвращает число удалённых.
+func (r *OutboxRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM outbox_events WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
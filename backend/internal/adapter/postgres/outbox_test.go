@@ -0,0 +1,96 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+)
+
+func newOutboxRepo(t *testing.T) (*postgres.OutboxRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewOutboxRepo(db), mock, func() { _ = db.Close() }
+}
+
+func TestOutboxRepo_Pending(t *testing.T) {
+	repo, mock, done := newOutboxRepo(t)
+	defer done()
+
+	at := time.Date(2025, 3, 3, 6, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`DISTINCT ON \(ad_id\)[\s\S]+FROM outbox_events\s+WHERE status = 'pending'\s+ORDER BY ad_id, outbox_id\s+\) head\s+WHERE head\.next_attempt_at <= NOW\(\)\s+ORDER BY outbox_id\s+LIMIT \$1`).
+		WithArgs(100).
+		WillReturnRows(sqlmock.NewRows([]string{"outbox_id", "event_id", "event_type", "ad_id", "payload", "created_at", "attempts", "next_attempt_at"}).
+			AddRow(int64(1), "9a7e", "click.created", int64(87), []byte(`{"click_id":5}`), at, 0, at).
+			AddRow(int64(2), "9a7f", "conversion.created", int64(90), []byte(`{"conversion_id":3}`), at, 2, at))
+
+	events, err := repo.Pending(context.Background(), 100)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, "click.created", events[0].EventType)
+	require.JSONEq(t, `{"click_id":5}`, string(events[0].Payload))
+	require.Equal(t, 2, events[1].Attempts)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Голова очереди объявления 87 ждёт повтора, за ней batch более поздних событий того же
+// объявления. Раньше они занимали весь LIMIT и relay не видел готовых событий объявления 90;
+// теперь на объявление приходится одна строка, а не готовая голова отсекается после выбора.
+func TestOutboxRepo_Pending_StarvedHeadDoesNotBlockOtherAds(t *testing.T) {
+	repo, mock, done := newOutboxRepo(t)
+	defer done()
+
+	at := time.Date(2025, 3, 3, 6, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT DISTINCT ON \(ad_id\)[\s\S]+\) head\s+WHERE head\.next_attempt_at <= NOW\(\)`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"outbox_id", "event_id", "event_type", "ad_id", "payload", "created_at", "attempts", "next_attempt_at"}).
+			AddRow(int64(502), "9b01", "click.created", int64(90), []byte(`{"click_id":9}`), at, 0, at))
+
+	events, err := repo.Pending(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, int64(90), events[0].AdID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepo_MarkFailed(t *testing.T) {
+	repo, mock, done := newOutboxRepo(t)
+	defer done()
+
+	retry := time.Date(2025, 3, 3, 6, 5, 0, 0, time.UTC)
+	mock.ExpectExec(`UPDATE outbox_events\s+SET attempts = attempts \+ 1, last_error = \$2, next_attempt_at = \$3,\s+status = CASE WHEN \$4 THEN 'dead' ELSE status END`).
+		WithArgs(int64(7), "nats pub: timeout", retry, false).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkFailed(context.Background(), 7, "nats pub: timeout", false, retry))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepo_MarkFailed_Dead(t *testing.T) {
+	repo, mock, done := newOutboxRepo(t)
+	defer done()
+
+	retry := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`status = CASE WHEN \$4 THEN 'dead'`).
+		WithArgs(int64(7), "webhook sink: boom", retry, true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkFailed(context.Background(), 7, "webhook sink: boom", true, retry))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepo_MarkPublished(t *testing.T) {
+	repo, mock, done := newOutboxRepo(t)
+	defer done()
+
+	mock.ExpectExec(`UPDATE outbox_events SET status = 'published', published_at = NOW\(\)`).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkPublished(context.Background(), 7))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ratelimit"
+)
+
+// RateLimitRepo — вёдра token bucket в Postgres (ratelimit.Store), общие для всех инстансов API.
+type RateLimitRepo struct {
+	db      *sql.DB
+	idleTTL time.Duration
+	takes   atomic.Int64
+}
+
+// Вёдра без обращений дольше idleTTL удаляются (раз в purgeEvery списаний). Ведро политики
+// с окном длиннее idleTTL после такого простоя начнётся заново полным.
+const (
+	defaultRateIdleTTL = time.Hour
+	ratePurgeEvery     = 1000
+)
+
+func NewRateLimitRepo(db *sql.DB) *RateLimitRepo {
+	return &RateLimitRepo{db: db, idleTTL: defaultRateIdleTTL}
+}
+
+// Пополнение считается по старой строке (b.*): в SET ON CONFLICT все выражения видят её значения
+const rateRefill = `LEAST($2::float8, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4::timestamptz - b.updated_at)), 0) * $3::float8)`
+
+// Одним запросом: новое ведро — полное минус текущий запрос; иначе пополнение и списание, если есть токен
+const rateTakeSQL = `
+INSERT INTO rate_limit_buckets AS b (bucket_key, tokens, allowed, updated_at)
+VALUES ($1, $2::float8 - 1, TRUE, $4)
+ON CONFLICT (bucket_key) DO UPDATE SET
+  allowed    = ` + rateRefill + ` >= 1,
+  tokens     = CASE WHEN ` + rateRefill + ` >= 1 THEN ` + rateRefill + ` - 1 ELSE ` + rateRefill + ` END,
+  updated_at = GREATEST(b.updated_at, $4)
+RETURNING tokens, allowed`
+
+func (r *RateLimitRepo) Take(ctx context.Context, key string, l ratelimit.Limit, now time.Time) (ratelimit.Result, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := r.db.QueryRowContext(ctx, rateTakeSQL, key, float64(l.Burst), l.Rate(), now).Scan(&tokens, &allowed)
+	if err != nil {
+		return ratelimit.Result{}, fmt.Errorf("rate limit take: %w", err)
+	}
+	if r.takes.Add(1)%ratePurgeEvery == 0 {
+		if _, err := r.Purge(ctx, now.Add(-r.idleTTL)); err != nil {
+			return ratelimit.Result{}, err
+		}
+	}
+	return ratelimit.ResultOf(l, tokens, allowed), nil
+}
+
+// Purge удаляет вёдра без обращений с before.
+func (r *RateLimitRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("purge rate limit buckets: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,69 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ratelimit"
+)
+
+func newRateLimitRepo(t *testing.T) (*postgres.RateLimitRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewRateLimitRepo(db), mock, func() { _ = db.Close() }
+}
+
+func TestRateLimitRepo_Take(t *testing.T) {
+	repo, mock, done := newRateLimitRepo(t)
+	defer done()
+
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	l := ratelimit.Limit{Burst: 10, Per: time.Minute}
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets AS b \(bucket_key, tokens, allowed, updated_at\)[\s\S]+ON CONFLICT \(bucket_key\) DO UPDATE[\s\S]+RETURNING tokens, allowed`).
+		WithArgs("auth:ip:203.0.113.5", 10.0, l.Rate(), now).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(6.5, true))
+
+	r, err := repo.Take(context.Background(), "auth:ip:203.0.113.5", l, now)
+	require.NoError(t, err)
+	require.True(t, r.Allowed)
+	require.Equal(t, 10, r.Limit)
+	require.Equal(t, 6, r.Remaining)
+	require.Equal(t, 21*time.Second, r.Reset) // 3.5 токена по 1/6 в секунду
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitRepo_Take_Denied(t *testing.T) {
+	repo, mock, done := newRateLimitRepo(t)
+	defer done()
+
+	l := ratelimit.Limit{Burst: 10, Per: 10 * time.Second}
+	mock.ExpectQuery(`INSERT INTO rate_limit_buckets`).
+		WillReturnRows(sqlmock.NewRows([]string{"tokens", "allowed"}).AddRow(0.25, false))
+
+	r, err := repo.Take(context.Background(), "auth:ip:203.0.113.5", l, time.Now())
+	require.NoError(t, err)
+	require.False(t, r.Allowed)
+	require.Equal(t, 0, r.Remaining)
+	require.Equal(t, 750*time.Millisecond, r.RetryAfter)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitRepo_Purge(t *testing.T) {
+	repo, mock, done := newRateLimitRepo(t)
+	defer done()
+
+	before := time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`DELETE FROM rate_limit_buckets WHERE updated_at < \$1`).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+
+	n, err := repo.Purge(context.Background(), before)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
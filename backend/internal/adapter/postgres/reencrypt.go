@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// RotatingEncryptor — шифратор с ротацией ключей (crypto.Keyring, crypto.EnvelopeEncryptor)
+type RotatingEncryptor interface {
+	BoundEncryptor
+	NeedsReencrypt(cipher string) bool
+	IsBound(cipher string) bool
+}
+
+// ReencryptStats — итог одной пачки перешифровки
+type ReencryptStats struct {
+	Scanned   int
+	Rewritten int
+}
+
+// Reencryptor перешифровывает сохранённые секреты активным ключом, пачками по первичному ключу.
+type Reencryptor struct {
+	db  *sql.DB
+	enc RotatingEncryptor
+}
+
+func NewReencryptor(db *sql.DB, enc RotatingEncryptor) *Reencryptor {
+	return &Reencryptor{db: db, enc: enc}
+}
+
+// GoogleTokenCursor — позиция keyset-пагинации по google_user_tokens
+type GoogleTokenCursor struct {
+	UserID       int64
+	GoogleUserID string
+}
+
+// GoogleTokensBatch перешифровывает до limit строк google_user_tokens после курсора;
+// заодно переводит старые записи без привязки (v1) в v2, привязанный к (user_id, google_user_id).
+// Запись обновляется, только если её шифротекст не поменялся с момента чтения
+// (параллельный SaveGoogleRefreshToken выигрывает). Возвращает курсор для следующей пачки.
+func (r *Reencryptor) GoogleTokensBatch(ctx context.Context, after GoogleTokenCursor, limit int) (GoogleTokenCursor, ReencryptStats, error) {
+	const q = `
+SELECT user_id, google_user_id, refresh_token_enc
+FROM google_user_tokens
+WHERE (user_id, google_user_id) > ($1, $2)
+ORDER BY user_id, google_user_id
+LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, q, after.UserID, after.GoogleUserID, limit)
+	if err != nil {
+		return after, ReencryptStats{}, fmt.Errorf("scan google tokens: %w", err)
+	}
+	type row struct {
+		cur GoogleTokenCursor
+		enc string
+	}
+	var batch []row
+	for rows.Next() {
+		var rw row
+		if err := rows.Scan(&rw.cur.UserID, &rw.cur.GoogleUserID, &rw.enc); err != nil {
+			rows.Close()
+			return after, ReencryptStats{}, err
+		}
+		batch = append(batch, rw)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return after, ReencryptStats{}, err
+	}
+
+	st := ReencryptStats{Scanned: len(batch)}
+	const upd = `
+UPDATE google_user_tokens
+SET refresh_token_enc = $4, updated_at = NOW()
+WHERE user_id = $1 AND google_user_id = $2 AND refresh_token_enc = $3`
+	for _, rw := range batch {
+		after = rw.cur
+		newEnc, changed, err := r.rewrapBound(ctx, rw.enc, googleTokenAAD(rw.cur.UserID, rw.cur.GoogleUserID))
+		if err != nil {
+			return after, st, fmt.Errorf("google token user=%d guid=%s: %w", rw.cur.UserID, rw.cur.GoogleUserID, err)
+		}
+		if !changed {
+			continue
+		}
+		res, err := r.db.ExecContext(ctx, upd, rw.cur.UserID, rw.cur.GoogleUserID, rw.enc, newEnc)
+		if err != nil {
+			return after, st, fmt.Errorf("update google token: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			st.Rewritten++
+		}
+	}
+	return after, st, nil
+}
+
+// MetaSettingsBatch — то же для meta_capi_settings.access_token_enc (курсор — account_id).
+func (r *Reencryptor) MetaSettingsBatch(ctx context.Context, afterAccountID int64, limit int) (int64, ReencryptStats, error) {
+	const q = `
+SELECT account_id, access_token_enc
+FROM meta_capi_settings
+WHERE account_id > $1
+ORDER BY account_id
+LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, q, afterAccountID, limit)
+	if err != nil {
+		return afterAccountID, ReencryptStats{}, fmt.Errorf("scan capi settings: %w", err)
+	}
+	type row struct {
+		id  int64
+		enc string
+	}
+	var batch []row
+	for rows.Next() {
+		var rw row
+		if err := rows.Scan(&rw.id, &rw.enc); err != nil {
+			rows.Close()
+			return afterAccountID, ReencryptStats{}, err
+		}
+		batch = append(batch, rw)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return afterAccountID, ReencryptStats{}, err
+	}
+
+	st := ReencryptStats{Scanned: len(batch)}
+	const upd = `
+UPDATE meta_capi_settings
+SET access_token_enc = $3, updated_at = NOW()
+WHERE account_id = $1 AND access_token_enc = $2`
+	for _, rw := range batch {
+		afterAccountID = rw.id
+		newEnc, changed, err := r.rewrap(ctx, rw.enc)
+		if err != nil {
+			return afterAccountID, st, fmt.Errorf("capi settings account=%d: %w", rw.id, err)
+		}
+		if !changed {
+			continue
+		}
+		res, err := r.db.ExecContext(ctx, upd, rw.id, rw.enc, newEnc)
+		if err != nil {
+			return afterAccountID, st, fmt.Errorf("update capi settings: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			st.Rewritten++
+		}
+	}
+	return afterAccountID, st, nil
+}
+
+// WebhookSecretsBatch — то же для webhook_subscriptions (курсор — subscription_id): секреты,
+// записанные до шифрования открытым текстом (secret), переносятся в secret_enc, привязанный
+// к подписке; открытая колонка обнуляется.
+func (r *Reencryptor) WebhookSecretsBatch(ctx context.Context, afterID int64, limit int) (int64, ReencryptStats, error) {
+	const q = `
+SELECT subscription_id, COALESCE(secret_enc, ''), COALESCE(secret, '')
+FROM webhook_subscriptions
+WHERE subscription_id > $1
+ORDER BY subscription_id
+LIMIT $2`
+	rows, err := r.db.QueryContext(ctx, q, afterID, limit)
+	if err != nil {
+		return afterID, ReencryptStats{}, fmt.Errorf("scan webhook secrets: %w", err)
+	}
+	type row struct {
+		id    int64
+		enc   string
+		plain string
+	}
+	var batch []row
+	for rows.Next() {
+		var rw row
+		if err := rows.Scan(&rw.id, &rw.enc, &rw.plain); err != nil {
+			rows.Close()
+			return afterID, ReencryptStats{}, err
+		}
+		batch = append(batch, rw)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return afterID, ReencryptStats{}, err
+	}
+
+	st := ReencryptStats{Scanned: len(batch)}
+	const upd = `
+UPDATE webhook_subscriptions
+SET secret_enc = $4, secret = NULL
+WHERE subscription_id = $1 AND COALESCE(secret_enc, '') = $2 AND COALESCE(secret, '') = $3`
+	for _, rw := range batch {
+		afterID = rw.id
+		var (
+			newEnc  string
+			changed bool
+		)
+		if rw.plain != "" {
+			newEnc, err = r.enc.EncryptStringAAD(ctx, rw.plain, webhookSecretAAD(rw.id))
+			changed = true
+		} else {
+			newEnc, changed, err = r.rewrapBound(ctx, rw.enc, webhookSecretAAD(rw.id))
+		}
+		if err != nil {
+			return afterID, st, fmt.Errorf("webhook secret subscription=%d: %w", rw.id, err)
+		}
+		if !changed {
+			continue
+		}
+		res, err := r.db.ExecContext(ctx, upd, rw.id, rw.enc, rw.plain, newEnc)
+		if err != nil {
+			return afterID, st, fmt.Errorf("update webhook secret: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			st.Rewritten++
+		}
+	}
+	return afterID, st, nil
+}
+
+func (r *Reencryptor) rewrapBound(ctx context.Context, enc string, aad []byte) (string, bool, error) {
+	if !r.enc.NeedsReencrypt(enc) && r.enc.IsBound(enc) {
+		return enc, false, nil
+	}
+	// непривязанную запись читаем как непривязанную: строгий режим (ENC_REQUIRE_AAD)
+	// не мешает переводить её в v2/env2
+	var plain string
+	var err error
+	if r.enc.IsBound(enc) {
+		plain, err = r.enc.DecryptStringAAD(ctx, enc, aad)
+	} else {
+		plain, err = r.enc.DecryptString(ctx, enc)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	out, err := r.enc.EncryptStringAAD(ctx, plain, aad)
+	if err != nil {
+		return "", false, err
+	}
+	return out, true, nil
+}
+
+func (r *Reencryptor) rewrap(ctx context.Context, enc string) (string, bool, error) {
+	if !r.enc.NeedsReencrypt(enc) {
+		return enc, false, nil
+	}
+	plain, err := r.enc.DecryptString(ctx, enc)
+	if err != nil {
+		return "", false, err
+	}
+	out, err := r.enc.EncryptString(ctx, plain)
+	if err != nil {
+		return "", false, err
+	}
+	return out, true, nil
+}
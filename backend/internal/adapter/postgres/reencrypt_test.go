@@ -0,0 +1,172 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+)
+
+// fakeRing: "old:<x>" — старый ключ, "new:<x>" — активный, "new:<aad>|<x>" — с привязкой
+type fakeRing struct{}
+
+func (fakeRing) EncryptString(_ context.Context, p string) (string, error) { return "new:" + p, nil }
+func (fakeRing) DecryptString(_ context.Context, c string) (string, error) {
+	_, p, _ := strings.Cut(c, ":")
+	return p, nil
+}
+func (fakeRing) EncryptStringAAD(_ context.Context, p string, aad []byte) (string, error) {
+	return "new:" + string(aad) + "|" + p, nil
+}
+func (fakeRing) DecryptStringAAD(_ context.Context, c string, aad []byte) (string, error) {
+	_, p, _ := strings.Cut(c, ":")
+	if i := strings.LastIndex(p, "|"); i >= 0 {
+		return p[i+1:], nil
+	}
+	return p, nil
+}
+func (fakeRing) NeedsReencrypt(c string) bool { return strings.HasPrefix(c, "old:") }
+func (fakeRing) IsBound(c string) bool        { return strings.Contains(c, "|") }
+
+func TestReencryptor_GoogleTokensBatch(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer db.Close()
+	re := postgres.NewReencryptor(db, fakeRing{})
+
+	mock.ExpectQuery(`FROM\s+google_user_tokens\s+WHERE\s+\(user_id,\s*google_user_id\)\s*>\s*\(\$1,\s*\$2\)`).
+		WithArgs(int64(0), "", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "google_user_id", "refresh_token_enc"}).
+			AddRow(int64(1), "g1", "old:t1").
+			AddRow(int64(1), "g2", "new:google_user_tokens:1:g2|t2").
+			AddRow(int64(2), "g1", "new:t3"))
+	mock.ExpectExec(`UPDATE\s+google_user_tokens\s+SET\s+refresh_token_enc\s*=\s*\$4`).
+		WithArgs(int64(1), "g1", "old:t1", "new:google_user_tokens:1:g1|t1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// активный ключ, но без привязки — переводим в v2; строку успели перезаписать,
+	// оптимистичный UPDATE ничего не трогает
+	mock.ExpectExec(`UPDATE\s+google_user_tokens`).
+		WithArgs(int64(2), "g1", "new:t3", "new:google_user_tokens:2:g1|t3").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	cur, st, err := re.GoogleTokensBatch(context.Background(), postgres.GoogleTokenCursor{}, 10)
+	require.NoError(t, err)
+	require.Equal(t, postgres.GoogleTokenCursor{UserID: 2, GoogleUserID: "g1"}, cur)
+	require.Equal(t, 3, st.Scanned)
+	require.Equal(t, 1, st.Rewritten)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReencryptor_MetaSettingsBatch(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer db.Close()
+	re := postgres.NewReencryptor(db, fakeRing{})
+
+	mock.ExpectQuery(`FROM\s+meta_capi_settings\s+WHERE\s+account_id\s*>\s*\$1`).
+		WithArgs(int64(5), 2).
+		WillReturnRows(sqlmock.NewRows([]string{"account_id", "access_token_enc"}).
+			AddRow(int64(6), "old:a").
+			AddRow(int64(9), "new:b"))
+	mock.ExpectExec(`UPDATE\s+meta_capi_settings\s+SET\s+access_token_enc\s*=\s*\$3`).
+		WithArgs(int64(6), "old:a", "new:a").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	after, st, err := re.MetaSettingsBatch(context.Background(), 5, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(9), after)
+	require.Equal(t, postgres.ReencryptStats{Scanned: 2, Rewritten: 1}, st)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func fileKEK(t *testing.T, id, key string) *crypto.FileKEK {
+	path := filepath.Join(t.TempDir(), "kek-"+id)
+	require.NoError(t, os.WriteFile(path, []byte(key), 0o600))
+	k, err := crypto.NewFileKEK(id, path)
+	require.NoError(t, err)
+	return k
+}
+
+// wrappedBy — аргумент UPDATE: конверт под KEK id, который разворачивается в want
+type wrappedBy struct {
+	env  *crypto.EnvelopeEncryptor
+	id   string
+	aad  []byte
+	want string
+}
+
+func (m wrappedBy) Match(v driver.Value) bool {
+	enc, _ := v.(string)
+	if !strings.HasPrefix(enc, "env2:"+m.id+":") {
+		return false
+	}
+	pt, err := m.env.DecryptStringAAD(context.Background(), enc, m.aad)
+	return err == nil && pt == m.want
+}
+
+func TestReencryptor_RotatedFileKEK(t *testing.T) {
+	const (
+		oldKey = "0000000000000000000000000000000000000000000000000000000000000001"
+		newKey = "0000000000000000000000000000000000000000000000000000000000000002"
+	)
+	ctx := context.Background()
+	aad := []byte("google_user_tokens:1:g1")
+	old, err := crypto.NewEnvelopeEncryptor(fileKEK(t, "f1", oldKey)).EncryptStringAAD(ctx, "t1", aad)
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer db.Close()
+	rotated := crypto.NewEnvelopeEncryptor(fileKEK(t, "f2", newKey)).WithPrevious(fileKEK(t, "f1", oldKey))
+	re := postgres.NewReencryptor(db, rotated)
+
+	mock.ExpectQuery(`FROM\s+google_user_tokens`).
+		WithArgs(int64(0), "", 10).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "google_user_id", "refresh_token_enc"}).
+			AddRow(int64(1), "g1", old))
+	// новая запись читается без старого KEK
+	onlyNew := crypto.NewEnvelopeEncryptor(fileKEK(t, "f2", newKey))
+	mock.ExpectExec(`UPDATE\s+google_user_tokens`).
+		WithArgs(int64(1), "g1", old, wrappedBy{env: onlyNew, id: "f2", aad: aad, want: "t1"}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, st, err := re.GoogleTokensBatch(ctx, postgres.GoogleTokenCursor{}, 10)
+	require.NoError(t, err)
+	require.Equal(t, postgres.ReencryptStats{Scanned: 1, Rewritten: 1}, st)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReencryptor_WebhookSecretsBatch(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer db.Close()
+	re := postgres.NewReencryptor(db, fakeRing{})
+
+	mock.ExpectQuery(`FROM\s+webhook_subscriptions\s+WHERE\s+subscription_id\s*>\s*\$1`).
+		WithArgs(int64(0), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "secret_enc", "secret"}).
+			AddRow(int64(4), "", "whsec_plain").
+			AddRow(int64(5), "new:webhook_subscriptions:5|s5", "").
+			AddRow(int64(6), "old:webhook_subscriptions:6|s6", ""))
+	// открытый секрет переносится в secret_enc, колонка secret обнуляется
+	mock.ExpectExec(`UPDATE\s+webhook_subscriptions\s+SET\s+secret_enc\s*=\s*\$4,\s*secret\s*=\s*NULL`).
+		WithArgs(int64(4), "", "whsec_plain", "new:webhook_subscriptions:4|whsec_plain").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE\s+webhook_subscriptions`).
+		WithArgs(int64(6), "old:webhook_subscriptions:6|s6", "", "new:webhook_subscriptions:6|s6").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	after, st, err := re.WebhookSecretsBatch(context.Background(), 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), after)
+	require.Equal(t, postgres.ReencryptStats{Scanned: 3, Rewritten: 2}, st)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,221 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// RulesRepo — sieve_rules, журнал rule_executions, теги объявлений и метрики за окно правила.
+type RulesRepo struct {
+	db *sql.DB
+}
+
+func NewRulesRepo(db *sql.DB) *RulesRepo { return &RulesRepo{db: db} }
+
+const ruleColumns = `rule_id, workspace_id, user_id, name, condition, window_days, action, action_param, enabled, created_at, updated_at`
+
+func scanRule(sc interface{ Scan(...any) error }, extra ...any) (entity.Rule, error) {
+	var r entity.Rule
+	err := sc.Scan(append([]any{&r.RuleID, &r.WorkspaceID, &r.UserID, &r.Name, &r.Condition, &r.WindowDays,
+		&r.Action, &r.ActionParam, &r.Enabled, &r.CreatedAt, &r.UpdatedAt}, extra...)...)
+	return r, err
+}
+
+func (r *RulesRepo) queryRules(ctx context.Context, q string, args ...any) ([]entity.Rule, error) {
+	rows, err := r.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.Rule{}
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rule)
+	}
+	return out, rows.Err()
+}
+
+func (r *RulesRepo) ListRules(ctx context.Context, workspaceID int64) ([]entity.Rule, error) {
+	return r.queryRules(ctx, `SELECT `+ruleColumns+` FROM sieve_rules WHERE workspace_id = $1 ORDER BY rule_id`, workspaceID)
+}
+
+// EnabledRules — включённые правила всех пространств (для крона) с текущей ролью автора.
+// Правила авторов, которых удалили из пространства или понизили до read_only, не выполняются.
+func (r *RulesRepo) EnabledRules(ctx context.Context) ([]entity.Rule, error) {
+	const q = `
+SELECT r.rule_id, r.workspace_id, r.user_id, r.name, r.condition, r.window_days, r.action, r.action_param,
+       r.enabled, r.created_at, r.updated_at, m.role
+FROM sieve_rules r
+JOIN workspace_members m ON m.workspace_id = r.workspace_id AND m.user_id = r.user_id
+WHERE r.enabled AND m.role IN ('owner', 'admin', 'analyst')
+ORDER BY r.workspace_id, r.rule_id`
+	rows, err := r.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.Rule{}
+	for rows.Next() {
+		var role string
+		rule, err := scanRule(rows, &role)
+		if err != nil {
+			return nil, err
+		}
+		rule.AuthorRole = role
+		out = append(out, rule)
+	}
+	return out, rows.Err()
+}
+
+// Rule — правило пространства; чужое или несуществующее — sql.ErrNoRows.
+func (r *RulesRepo) Rule(ctx context.Context, workspaceID, ruleID int64) (entity.Rule, error) {
+	return scanRule(r.db.QueryRowContext(ctx,
+		`SELECT `+ruleColumns+` FROM sieve_rules WHERE rule_id = $1 AND workspace_id = $2`, ruleID, workspaceID))
+}
+
+func (r *RulesRepo) CreateRule(ctx context.Context, in entity.Rule) (entity.Rule, error) {
+	const q = `
+INSERT INTO sieve_rules (workspace_id, user_id, name, condition, window_days, action, action_param, enabled)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING ` + ruleColumns
+	return scanRule(r.db.QueryRowContext(ctx, q,
+		in.WorkspaceID, in.UserID, in.Name, in.Condition, in.WindowDays, in.Action, in.ActionParam, in.Enabled))
+}
+
+// UpdateRule перезаписывает правило пространства; автором становится тот, кто правит.
+// Чужое или несуществующее — sql.ErrNoRows.
+func (r *RulesRepo) UpdateRule(ctx context.Context, in entity.Rule) (entity.Rule, error) {
+	const q = `
+UPDATE sieve_rules
+SET user_id = $3, name = $4, condition = $5, window_days = $6, action = $7, action_param = $8, enabled = $9, updated_at = NOW()
+WHERE rule_id = $1 AND workspace_id = $2
+RETURNING ` + ruleColumns
+	return scanRule(r.db.QueryRowContext(ctx, q,
+		in.RuleID, in.WorkspaceID, in.UserID, in.Name, in.Condition, in.WindowDays, in.Action, in.ActionParam, in.Enabled))
+}
+
+// DeleteRule — false, если правила в пространстве нет.
+func (r *RulesRepo) DeleteRule(ctx context.Context, workspaceID, ruleID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM sieve_rules WHERE rule_id = $1 AND workspace_id = $2`, ruleID, workspaceID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AdWindowStats — суммы ad_daily_metrics за [from, to] по каждому объявлению пространства
+// (объявления без метрик в окне — с нулями).
+func (r *RulesRepo) AdWindowStats(ctx context.Context, workspaceID int64, from, to time.Time) ([]entity.AdWindowStats, error) {
+	const q = `
+SELECT a.ad_id, a.name, a.status, a.platform,
+       COALESCE(SUM(m.clicks), 0), COALESCE(SUM(m.conversions), 0),
+       COALESCE(SUM(m.revenue), 0), COALESCE(SUM(m.spend), 0)
+FROM ads a
+JOIN ad_accounts aa ON aa.account_id = a.account_id
+LEFT JOIN ad_daily_metrics m
+       ON m.ad_id = a.ad_id AND m.metric_date BETWEEN $2::date AND $3::date
+WHERE aa.workspace_id = $1
+GROUP BY a.ad_id, a.name, a.status, a.platform
+ORDER BY a.ad_id`
+	rows, err := r.db.QueryContext(ctx, q, workspaceID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("ad window stats: %w", err)
+	}
+	defer rows.Close()
+
+	var out []entity.AdWindowStats
+	for rows.Next() {
+		var s entity.AdWindowStats
+		if err := rows.Scan(&s.AdID, &s.Name, &s.Status, &s.Platform,
+			&s.Clicks, &s.Conversions, &s.Revenue, &s.Spend); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// FiredSince — объявления, по которым правило реально (не dry-run) сработало начиная с since.
+func (r *RulesRepo) FiredSince(ctx context.Context, ruleID int64, since time.Time) (map[int64]bool, error) {
+	const q = `
+SELECT DISTINCT ad_id FROM rule_executions
+WHERE rule_id = $1 AND NOT dry_run AND result = 'applied' AND executed_at >= $2`
+	rows, err := r.db.QueryContext(ctx, q, ruleID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out[id] = true
+	}
+	return out, rows.Err()
+}
+
+func (r *RulesRepo) LogExecution(ctx context.Context, e entity.RuleExecution) error {
+	metrics, err := json.Marshal(e.Metrics)
+	if err != nil {
+		return err
+	}
+	const q = `
+INSERT INTO rule_executions (rule_id, ad_id, action, dry_run, result, error, metrics)
+VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)`
+	_, err = r.db.ExecContext(ctx, q, e.RuleID, e.AdID, e.Action, e.DryRun, e.Result, e.Error, metrics)
+	return err
+}
+
+// Executions — последние записи журнала правила пространства (новые сверху).
+func (r *RulesRepo) Executions(ctx context.Context, workspaceID, ruleID int64, limit int) ([]entity.RuleExecution, error) {
+	const q = `
+SELECT e.execution_id, e.rule_id, e.ad_id, e.action, e.dry_run, e.result, COALESCE(e.error, ''), e.metrics, e.executed_at
+FROM rule_executions e
+JOIN sieve_rules r ON r.rule_id = e.rule_id
+WHERE e.rule_id = $1 AND r.workspace_id = $2
+ORDER BY e.executed_at DESC, e.execution_id DESC
+LIMIT $3`
+	rows, err := r.db.QueryContext(ctx, q, ruleID, workspaceID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.RuleExecution{}
+	for rows.Next() {
+		var (
+			e       entity.RuleExecution
+			metrics []byte
+		)
+		if err := rows.Scan(&e.ExecutionID, &e.RuleID, &e.AdID, &e.Action, &e.DryRun,
+			&e.Result, &e.Error, &metrics, &e.ExecutedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(metrics, &e.Metrics); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// TagAd вешает тег на объявление (повторно — без ошибки).
+func (r *RulesRepo) TagAd(ctx context.Context, adID int64, tag string) error {
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO ad_tags (ad_id, tag) VALUES ($1, $2) ON CONFLICT (ad_id, tag) DO NOTHING`, adID, tag)
+	return err
+}
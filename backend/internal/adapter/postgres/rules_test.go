@@ -0,0 +1,91 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+func newRulesRepo(t *testing.T) (*postgres.RulesRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewRulesRepo(db), mock, func() { _ = db.Close() }
+}
+
+func TestRulesRepo_AdWindowStats(t *testing.T) {
+	repo, mock, done := newRulesRepo(t)
+	defer done()
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`LEFT JOIN ad_daily_metrics m\s+ON m\.ad_id = a\.ad_id AND m\.metric_date BETWEEN \$2::date AND \$3::date\s+WHERE aa\.workspace_id = \$1`).
+		WithArgs(int64(42), from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id", "name", "status", "platform", "clicks", "conversions", "revenue", "spend"}).
+			AddRow(int64(87), "Summer Sale", "active", "facebook", 120, 5, "150.00", "250.00").
+			AddRow(int64(88), "Idle", "active", "google", 0, 0, "0", "0"))
+
+	stats, err := repo.AdWindowStats(context.Background(), 42, from, to)
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	require.Equal(t, "50", stats[0].CPA().String())
+	require.Equal(t, "0.6", stats[0].ROAS().String())
+	require.Nil(t, stats[1].CPA())
+	require.Nil(t, stats[1].ROAS())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRulesRepo_EnabledRules_OnlyCurrentMembers(t *testing.T) {
+	repo, mock, done := newRulesRepo(t)
+	defer done()
+
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`JOIN workspace_members m ON m\.workspace_id = r\.workspace_id AND m\.user_id = r\.user_id\s+WHERE r\.enabled AND m\.role IN \('owner', 'admin', 'analyst'\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"rule_id", "workspace_id", "user_id", "name", "condition", "window_days",
+			"action", "action_param", "enabled", "created_at", "updated_at", "role"}).
+			AddRow(int64(7), int64(3), int64(42), "High CPA", "cpa > 40", 3, "pause", "", true, now, now, "analyst"))
+
+	rules, err := repo.EnabledRules(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	require.Equal(t, int64(3), rules[0].WorkspaceID)
+	require.Equal(t, int64(42), rules[0].UserID)
+	require.Equal(t, entity.RoleAnalyst, rules[0].AuthorRole)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRulesRepo_FiredSince_IgnoresDryRun(t *testing.T) {
+	repo, mock, done := newRulesRepo(t)
+	defer done()
+
+	since := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT DISTINCT ad_id FROM rule_executions\s+WHERE rule_id = \$1 AND NOT dry_run AND result = 'applied' AND executed_at >= \$2`).
+		WithArgs(int64(7), since).
+		WillReturnRows(sqlmock.NewRows([]string{"ad_id"}).AddRow(int64(87)))
+
+	fired, err := repo.FiredSince(context.Background(), 7, since)
+	require.NoError(t, err)
+	require.Equal(t, map[int64]bool{87: true}, fired)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRulesRepo_LogExecution(t *testing.T) {
+	repo, mock, done := newRulesRepo(t)
+	defer done()
+
+	mock.ExpectExec(`INSERT INTO rule_executions \(rule_id, ad_id, action, dry_run, result, error, metrics\)`).
+		WithArgs(int64(7), int64(87), "pause", false, "failed", "boom", []byte(`{"cpa":"50.00"}`)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err := repo.LogExecution(context.Background(), entity.RuleExecution{
+		RuleID: 7, AdID: 87, Action: entity.RuleActionPause,
+		Result: entity.RuleResultFailed, Error: "boom", Metrics: map[string]string{"cpa": "50.00"},
+	})
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
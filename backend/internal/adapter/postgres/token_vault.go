@@ -5,6 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
 )
 
 type Encryptor interface {
@@ -12,15 +15,29 @@ type Encryptor interface {
     DecryptString(ctx context.Context, cipher string) (string, error)
 }
 
+// BoundEncryptor — шифрование с привязкой к владельцу записи через associated data:
+// шифротекст, скопированный в чужую строку, не расшифруется. Старые записи без
+// привязки (v1) DecryptStringAAD читает как есть.
+type BoundEncryptor interface {
+	Encryptor
+	EncryptStringAAD(ctx context.Context, plain string, aad []byte) (string, error)
+	DecryptStringAAD(ctx context.Context, cipher string, aad []byte) (string, error)
+}
+
 type TokenVaultRepo struct {
 	db  *sql.DB
-	enc Encryptor
+	enc BoundEncryptor
 }
 
-func NewTokenVault(db *sql.DB, enc Encryptor) *TokenVaultRepo {
+func NewTokenVault(db *sql.DB, enc BoundEncryptor) *TokenVaultRepo {
 	return &TokenVaultRepo{db: db, enc: enc}
 }
 
+// googleTokenAAD — к чему привязан refresh-токен: строка (user_id, google_user_id).
+func googleTokenAAD(userID int64, googleUserID string) []byte {
+	return []byte(fmt.Sprintf("google_user_tokens:%d:%s", userID, googleUserID))
+}
+
 // SaveGoogleRefreshToken — upsert шифрованного refresh-токена Google.
 func (r *TokenVaultRepo) SaveGoogleRefreshToken(
 	ctx context.Context,
@@ -29,7 +46,7 @@ func (r *TokenVaultRepo) SaveGoogleRefreshToken(
 	refreshToken string,
 	scope string,
 ) error {
-	encTok, err := r.enc.EncryptString(ctx, refreshToken)
+	encTok, err := r.enc.EncryptStringAAD(ctx, refreshToken, googleTokenAAD(userID, googleUserID))
 	if err != nil {
 		return fmt.Errorf("encrypt refresh: %w", err)
 	}
@@ -50,6 +67,7 @@ SET refresh_token_enc   = EXCLUDED.refresh_token_enc,
 }
 
 // LoadGoogleRefreshToken — получить (google_user_id, refresh_token_enc, scope) по user_id.
+// Если Google-логинов несколько, берётся последний подключённый.
 // Возвращает sql.ErrNoRows, если токена нет.
 func (r *TokenVaultRepo) LoadGoogleRefreshToken(
 	ctx context.Context,
@@ -59,6 +77,7 @@ func (r *TokenVaultRepo) LoadGoogleRefreshToken(
 SELECT google_user_id, refresh_token_enc, refresh_token_scope
 FROM google_user_tokens
 WHERE user_id = $1 AND needs_consent = FALSE
+ORDER BY updated_at DESC, google_user_id
 LIMIT 1`
 	err = r.db.QueryRowContext(ctx, q, userID).Scan(&googleUserID, &refreshTokenEnc, &scope)
 	if err != nil {
@@ -70,6 +89,133 @@ LIMIT 1`
 	return
 }
 
+// LoadGoogleRefreshTokenFor — refresh-токен конкретного Google-логина пользователя.
+// Возвращает sql.ErrNoRows, если логин не подключён или требует повторного согласия.
+func (r *TokenVaultRepo) LoadGoogleRefreshTokenFor(
+	ctx context.Context,
+	userID int64,
+	googleUserID string,
+) (refreshTokenEnc, scope string, err error) {
+	const q = `
+SELECT refresh_token_enc, refresh_token_scope
+FROM google_user_tokens
+WHERE user_id = $1 AND google_user_id = $2 AND needs_consent = FALSE`
+	err = r.db.QueryRowContext(ctx, q, userID, googleUserID).Scan(&refreshTokenEnc, &scope)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", err
+		}
+		return "", "", fmt.Errorf("load google refresh for identity: %w", err)
+	}
+	return
+}
+
+// ListGoogleIdentities — подключённые Google-логины пользователя с числом привязанных к ним аккаунтов.
+func (r *TokenVaultRepo) ListGoogleIdentities(ctx context.Context, userID int64) ([]entity.GoogleIdentity, error) {
+	const q = `
+SELECT t.google_user_id, t.refresh_token_scope, t.needs_consent, t.created_at, t.updated_at,
+       COUNT(aa.account_id) AS linked_accounts
+FROM google_user_tokens t
+LEFT JOIN ad_accounts aa
+       ON aa.user_id = t.user_id AND aa.platform = 'google'
+      AND aa.token_owner = t.google_user_id AND aa.status = 'linked'
+WHERE t.user_id = $1
+GROUP BY t.google_user_id, t.refresh_token_scope, t.needs_consent, t.created_at, t.updated_at
+ORDER BY t.created_at`
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list google identities: %w", err)
+	}
+	defer rows.Close()
+
+	out := []entity.GoogleIdentity{}
+	for rows.Next() {
+		var gi entity.GoogleIdentity
+		if err := rows.Scan(&gi.GoogleUserID, &gi.Scope, &gi.NeedsConsent, &gi.CreatedAt, &gi.UpdatedAt, &gi.LinkedAccounts); err != nil {
+			return nil, err
+		}
+		out = append(out, gi)
+	}
+	return out, rows.Err()
+}
+
+// DeleteGoogleIdentity — отключает Google-логин: удаляет его токен и отвязывает
+// аккаунты, которые через него синкались. Возвращает errs.ErrGoogleIdentityNotFound, если логина нет.
+func (r *TokenVaultRepo) DeleteGoogleIdentity(ctx context.Context, userID int64, googleUserID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx,
+		`DELETE FROM google_user_tokens WHERE user_id = $1 AND google_user_id = $2`, userID, googleUserID)
+	if err != nil {
+		return fmt.Errorf("delete google identity: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrGoogleIdentityNotFound
+	}
+
+	const unlink = `
+UPDATE ad_accounts
+SET status = 'unlinked', updated_at = NOW()
+WHERE user_id = $1 AND platform = 'google' AND token_owner = $2`
+	if _, err := tx.ExecContext(ctx, unlink, userID, googleUserID); err != nil {
+		return fmt.Errorf("unlink identity accounts: %w", err)
+	}
+	return tx.Commit()
+}
+
+// GoogleRefreshTokens — расшифрованные refresh-токены пользователя (включая требующие согласия),
+// чтобы отозвать их у Google. googleUserID = "" — все логины пользователя.
+func (r *TokenVaultRepo) GoogleRefreshTokens(ctx context.Context, userID int64, googleUserID string) ([]entity.GoogleRefreshToken, error) {
+	const q = `
+SELECT google_user_id, refresh_token_enc
+FROM google_user_tokens
+WHERE user_id = $1 AND ($2::text = '' OR google_user_id = $2)`
+	rows, err := r.db.QueryContext(ctx, q, userID, googleUserID)
+	if err != nil {
+		return nil, fmt.Errorf("google refresh tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var out []entity.GoogleRefreshToken
+	for rows.Next() {
+		var t entity.GoogleRefreshToken
+		var enc string
+		if err := rows.Scan(&t.GoogleUserID, &enc); err != nil {
+			return nil, err
+		}
+		if t.RefreshToken, err = r.enc.DecryptStringAAD(ctx, enc, googleTokenAAD(userID, t.GoogleUserID)); err != nil {
+			return nil, fmt.Errorf("decrypt refresh (%s): %w", t.GoogleUserID, err)
+		}
+		out = append(out, t)
+	}
+	return out, rows.Err()
+}
+
+// DeleteAllGoogleIdentities — удаляет все Google-токены пользователя и отвязывает все его Google-аккаунты.
+func (r *TokenVaultRepo) DeleteAllGoogleIdentities(ctx context.Context, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM google_user_tokens WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("delete google identities: %w", err)
+	}
+	const unlink = `
+UPDATE ad_accounts
+SET status = 'unlinked', updated_at = NOW()
+WHERE user_id = $1 AND platform = 'google'`
+	if _, err := tx.ExecContext(ctx, unlink, userID); err != nil {
+		return fmt.Errorf("unlink google accounts: %w", err)
+	}
+	return tx.Commit()
+}
+
 // MarkNeedsConsent — помечает запись как требующую повторного согласия.
 // Используется при 401 от Google.
 func (r *TokenVaultRepo) MarkNeedsConsent(
@@ -96,7 +242,11 @@ func (r *TokenVaultRepo) LoadRefreshToken(ctx context.Context, userID int64) (go
 	return r.LoadGoogleRefreshToken(ctx, userID)
 }
 
-func (r *TokenVaultRepo) Decrypt(cipher string) (string, error) {
-	// enc — твой AES-GCM, уже есть EncryptString; тут нужна обратная сторона
-	return r.enc.DecryptString(context.Background(), cipher)
+func (r *TokenVaultRepo) LoadRefreshTokenFor(ctx context.Context, userID int64, googleUserID string) (refreshTokenEnc, scope string, err error) {
+	return r.LoadGoogleRefreshTokenFor(ctx, userID, googleUserID)
+}
+
+// DecryptRefreshToken расшифровывает refresh-токен строки (userID, googleUserID).
+func (r *TokenVaultRepo) DecryptRefreshToken(ctx context.Context, userID int64, googleUserID, cipher string) (string, error) {
+	return r.enc.DecryptStringAAD(ctx, cipher, googleTokenAAD(userID, googleUserID))
 }
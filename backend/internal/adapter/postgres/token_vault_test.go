@@ -0,0 +1,156 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/crypto"
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+func newTokenVault(t *testing.T) (*postgres.TokenVaultRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewTokenVault(db, nil), mock, func() {
+		require.NoError(t, mock.ExpectationsWereMet())
+		_ = db.Close()
+	}
+}
+
+func TestTokenVault_LoadDefaultIsDeterministic(t *testing.T) {
+	repo, mock, done := newTokenVault(t)
+	defer done()
+
+	mock.ExpectQuery(`FROM\s+google_user_tokens\s+WHERE\s+user_id\s*=\s*\$1\s+AND\s+needs_consent\s*=\s*FALSE\s+ORDER\s+BY\s+updated_at\s+DESC`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"google_user_id", "refresh_token_enc", "refresh_token_scope"}).
+			AddRow("guid-2", "enc-2", "adwords"))
+	guid, enc, _, err := repo.LoadGoogleRefreshToken(context.Background(), 7)
+	require.NoError(t, err)
+	require.Equal(t, "guid-2", guid)
+	require.Equal(t, "enc-2", enc)
+}
+
+func TestTokenVault_LoadForIdentity(t *testing.T) {
+	repo, mock, done := newTokenVault(t)
+	defer done()
+
+	mock.ExpectQuery(`FROM\s+google_user_tokens\s+WHERE\s+user_id\s*=\s*\$1\s+AND\s+google_user_id\s*=\s*\$2`).
+		WithArgs(int64(7), "guid-1").
+		WillReturnRows(sqlmock.NewRows([]string{"refresh_token_enc", "refresh_token_scope"}).AddRow("enc-1", "adwords"))
+	enc, scope, err := repo.LoadGoogleRefreshTokenFor(context.Background(), 7, "guid-1")
+	require.NoError(t, err)
+	require.Equal(t, "enc-1", enc)
+	require.Equal(t, "adwords", scope)
+}
+
+func TestTokenVault_ListIdentities(t *testing.T) {
+	repo, mock, done := newTokenVault(t)
+	defer done()
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM\s+google_user_tokens\s+t\s+LEFT\s+JOIN\s+ad_accounts`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"google_user_id", "refresh_token_scope", "needs_consent", "created_at", "updated_at", "linked_accounts"}).
+			AddRow("guid-1", "adwords", false, now, now, 2).
+			AddRow("guid-2", "adwords", true, now, now, 0))
+	list, err := repo.ListGoogleIdentities(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, 2, list[0].LinkedAccounts)
+	require.True(t, list[1].NeedsConsent)
+}
+
+func TestTokenVault_DeleteIdentityUnlinksAccounts(t *testing.T) {
+	repo, mock, done := newTokenVault(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE\s+FROM\s+google_user_tokens`).
+		WithArgs(int64(7), "guid-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE\s+ad_accounts\s+SET\s+status\s*=\s*'unlinked'`).
+		WithArgs(int64(7), "guid-1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+	require.NoError(t, repo.DeleteGoogleIdentity(context.Background(), 7, "guid-1"))
+}
+
+func TestTokenVault_DeleteUnknownIdentity(t *testing.T) {
+	repo, mock, done := newTokenVault(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE\s+FROM\s+google_user_tokens`).
+		WithArgs(int64(7), "nope").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+	err := repo.DeleteGoogleIdentity(context.Background(), 7, "nope")
+	require.ErrorIs(t, err, errs.ErrGoogleIdentityNotFound)
+}
+
+func TestTokenVault_DeleteAllUnlinksGoogleAccounts(t *testing.T) {
+	repo, mock, done := newTokenVault(t)
+	defer done()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE\s+FROM\s+google_user_tokens\s+WHERE\s+user_id\s*=\s*\$1`).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE\s+ad_accounts\s+SET\s+status\s*=\s*'unlinked'.+platform\s*=\s*'google'`).
+		WithArgs(int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+	require.NoError(t, repo.DeleteAllGoogleIdentities(context.Background(), 7))
+}
+
+func TestTokenVault_CiphertextBoundToOwner(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	defer db.Close()
+	ring, err := crypto.NewKeyring(map[string]string{"k1": "0000000000000000000000000000000000000000000000000000000000000001"}, "k1")
+	require.NoError(t, err)
+	repo := postgres.NewTokenVault(db, ring)
+	ctx := context.Background()
+
+	enc := captureEnc{}
+	mock.ExpectExec(`INSERT\s+INTO\s+google_user_tokens`).
+		WithArgs(int64(7), "guid-1", &enc, "adwords").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	require.NoError(t, repo.SaveGoogleRefreshToken(ctx, 7, "guid-1", "refresh-7", "adwords"))
+	require.True(t, strings.HasPrefix(enc.v, "v2:k1:"))
+
+	pt, err := repo.DecryptRefreshToken(ctx, 7, "guid-1", enc.v)
+	require.NoError(t, err)
+	require.Equal(t, "refresh-7", pt)
+
+	// тот же шифротекст в строке другого пользователя/логина не расшифруется
+	_, err = repo.DecryptRefreshToken(ctx, 8, "guid-1", enc.v)
+	require.Error(t, err)
+	_, err = repo.DecryptRefreshToken(ctx, 7, "guid-2", enc.v)
+	require.Error(t, err)
+
+	// старые записи v1 без привязки читаются как раньше
+	legacy, err := ring.EncryptString(ctx, "refresh-old")
+	require.NoError(t, err)
+	pt, err = repo.DecryptRefreshToken(ctx, 7, "guid-1", legacy)
+	require.NoError(t, err)
+	require.Equal(t, "refresh-old", pt)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+// captureEnc — sqlmock-аргумент, запоминающий переданный шифротекст
+type captureEnc struct{ v string }
+
+func (c *captureEnc) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	c.v = s
+	return ok
+}
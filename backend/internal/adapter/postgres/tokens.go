@@ -4,8 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
 )
 
 type TokensRepo struct {
@@ -14,34 +17,53 @@ type TokensRepo struct {
 
 func NewTokensRepo(db *sql.DB) *TokensRepo { return &TokensRepo{db: db} }
 
-func (r *TokensRepo) Create(ctx context.Context, s entity.RefreshToken) error {
-	const q = `INSERT INTO refresh_tokens (user_id, refresh_token, expires_at)
-	           VALUES ($1, $2, $3)`
+// CreateSession — новая сессия (вход с устройства) и первый refresh-токен её семейства.
+func (r *TokensRepo) CreateSession(ctx context.Context, s entity.Session, tokenHash string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
 
-	_, err := r.db.ExecContext(ctx, q, s.UserID, s.RefreshToken, s.ExpiresAt)
-	return err
+	var sessionID int64
+	const insSession = `
+INSERT INTO auth_sessions (user_id, user_agent, ip, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING session_id`
+	if err := tx.QueryRowContext(ctx, insSession, s.UserID, s.UserAgent, s.IP, s.ExpiresAt).Scan(&sessionID); err != nil {
+		return 0, fmt.Errorf("create session: %w", err)
+	}
+	const insToken = `
+INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, insToken, s.UserID, sessionID, tokenHash, s.ExpiresAt); err != nil {
+		return 0, fmt.Errorf("create refresh token: %w", err)
+	}
+	return sessionID, tx.Commit()
 }
 
-// Get – читаем токен и сразу удаляем ВСЕ refresh-токены пользователя
-func (r *TokensRepo) Get(ctx context.Context, rawToken string) (entity.RefreshToken, error) {
+// Rotate меняет refresh-токен oldHash на newHash внутри его семейства.
+//   - токена нет или сессия отозвана → sql.ErrNoRows;
+//   - токен просрочен → errs.ErrRefreshTokenExpired;
+//   - токен уже был обменян (утёк и им воспользовались повторно) → сессия отзывается
+//     целиком, errs.ErrRefreshTokenReused. Остальные сессии пользователя не трогаем.
+func (r *TokensRepo) Rotate(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (entity.RefreshToken, error) {
 	var rt entity.RefreshToken
 
-	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return rt, err
 	}
-	defer func() {
-		if err != nil {
-			_ = tx.Rollback()
-		}
-	}()
-
-	// 1) Читаем запись
-	const selectQ = `SELECT token_id, user_id, refresh_token, expires_at
-	                 FROM   refresh_tokens
-	                 WHERE  refresh_token = $1`
-	err = tx.QueryRowContext(ctx, selectQ, rawToken).
-		Scan(&rt.TokenID, &rt.UserID, &rt.RefreshToken, &rt.ExpiresAt)
+	defer func() { _ = tx.Rollback() }()
+
+	const selectQ = `
+SELECT rt.token_id, rt.user_id, rt.session_id, rt.token_hash, rt.expires_at, rt.used_at
+FROM refresh_tokens rt
+JOIN auth_sessions s ON s.session_id = rt.session_id
+WHERE rt.token_hash = $1 AND s.revoked_at IS NULL
+FOR UPDATE OF rt, s`
+	err = tx.QueryRowContext(ctx, selectQ, oldHash).
+		Scan(&rt.TokenID, &rt.UserID, &rt.SessionID, &rt.TokenHash, &rt.ExpiresAt, &rt.UsedAt)
 	if errors.Is(err, sql.ErrNoRows) {
 		return rt, sql.ErrNoRows
 	}
@@ -49,12 +71,87 @@ func (r *TokensRepo) Get(ctx context.Context, rawToken string) (entity.RefreshTo
 		return rt, err
 	}
 
-	// 2) Удаляем все refresh-токены этого пользователя
-	const deleteQ = `DELETE FROM refresh_tokens WHERE user_id = $1`
-	if _, err = tx.ExecContext(ctx, deleteQ, rt.UserID); err != nil {
-		return rt, err
+	if rt.UsedAt != nil {
+		const revoke = `
+UPDATE auth_sessions SET revoked_at = NOW(), revoke_reason = 'refresh_reuse'
+WHERE session_id = $1`
+		if _, err := tx.ExecContext(ctx, revoke, rt.SessionID); err != nil {
+			return rt, fmt.Errorf("revoke session: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return rt, err
+		}
+		return rt, errs.ErrRefreshTokenReused
+	}
+	if time.Now().After(rt.ExpiresAt) {
+		return rt, errs.ErrRefreshTokenExpired
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE refresh_tokens SET used_at = NOW() WHERE token_id = $1`, rt.TokenID); err != nil {
+		return rt, fmt.Errorf("mark refresh used: %w", err)
+	}
+	const insToken = `
+INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at)
+VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, insToken, rt.UserID, rt.SessionID, newHash, expiresAt); err != nil {
+		return rt, fmt.Errorf("create refresh token: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE auth_sessions SET last_used_at = NOW(), expires_at = $2 WHERE session_id = $1`,
+		rt.SessionID, expiresAt); err != nil {
+		return rt, fmt.Errorf("touch session: %w", err)
 	}
+	return rt, tx.Commit()
+}
+
+// ListSessions — активные (не отозванные и не истёкшие) сессии пользователя, свежие сверху.
+func (r *TokensRepo) ListSessions(ctx context.Context, userID int64) ([]entity.Session, error) {
+	const q = `
+SELECT session_id, user_id, user_agent, ip, created_at, last_used_at, expires_at
+FROM auth_sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_used_at DESC`
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	out := []entity.Session{}
+	for rows.Next() {
+		var s entity.Session
+		if err := rows.Scan(&s.SessionID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
 
-	err = tx.Commit()
-	return rt, err
+// RevokeSession отзывает сессию пользователя: её refresh-токены больше не обмениваются.
+// Возвращает errs.ErrSessionNotFound, если активной сессии с таким id у пользователя нет.
+func (r *TokensRepo) RevokeSession(ctx context.Context, userID, sessionID int64, reason string) error {
+	const q = `
+UPDATE auth_sessions SET revoked_at = NOW(), revoke_reason = $3
+WHERE user_id = $1 AND session_id = $2 AND revoked_at IS NULL`
+	res, err := r.db.ExecContext(ctx, q, userID, sessionID, reason)
+	if err != nil {
+		return fmt.Errorf("revoke session: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errs.ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions — выход отовсюду: отзывает все активные сессии пользователя.
+func (r *TokensRepo) RevokeAllSessions(ctx context.Context, userID int64, reason string) error {
+	const q = `
+UPDATE auth_sessions SET revoked_at = NOW(), revoke_reason = $2
+WHERE user_id = $1 AND revoked_at IS NULL`
+	if _, err := r.db.ExecContext(ctx, q, userID, reason); err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+	return nil
 }
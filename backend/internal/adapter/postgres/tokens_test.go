@@ -12,6 +12,7 @@ import (
 
 	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
 	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
 )
 
 func newRepoTokens(t *testing.T) (*postgres.TokensRepo, sqlmock.Sqlmock, func()) {
@@ -24,109 +25,141 @@ func newRepoTokens(t *testing.T) (*postgres.TokensRepo, sqlmock.Sqlmock, func())
 	}
 }
 
-// Create
-func TestTokensRepo_Create(t *testing.T) {
+// CreateSession
+func TestTokensRepo_CreateSession(t *testing.T) {
+	s := entity.Session{
+		UserID:    7,
+		UserAgent: "curl/8",
+		IP:        "10.0.0.1",
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+
 	t.Run("happy path", func(t *testing.T) {
 		repo, mock, done := newRepoTokens(t)
 		defer done()
 
-		rs := entity.RefreshToken{
-			UserID:       7,
-			RefreshToken: "abc123",
-			ExpiresAt:    time.Now().Add(24 * time.Hour),
-		}
-
-		mock.ExpectExec(`INSERT INTO refresh_tokens`).
-			WithArgs(rs.UserID, rs.RefreshToken, rs.ExpiresAt).
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO auth_sessions`).
+			WithArgs(s.UserID, s.UserAgent, s.IP, s.ExpiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow(int64(11)))
+		mock.ExpectExec(`INSERT INTO refresh_tokens \(user_id, session_id, token_hash, expires_at\)`).
+			WithArgs(s.UserID, int64(11), "hash-1", s.ExpiresAt).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
 
-		err := repo.Create(context.Background(), rs)
+		id, err := repo.CreateSession(context.Background(), s, "hash-1")
 		require.NoError(t, err)
+		require.Equal(t, int64(11), id)
 	})
 
 	t.Run("db error bubbles up", func(t *testing.T) {
 		repo, mock, done := newRepoTokens(t)
 		defer done()
 
-		rs := entity.RefreshToken{
-			UserID:       7,
-			RefreshToken: "abc123",
-			ExpiresAt:    time.Now(),
-		}
-
-		mock.ExpectExec(`INSERT INTO refresh_tokens`).
-			WithArgs(rs.UserID, rs.RefreshToken, rs.ExpiresAt).
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO auth_sessions`).
 			WillReturnError(errors.New("boom"))
+		mock.ExpectRollback()
 
-		err := repo.Create(context.Background(), rs)
+		_, err := repo.CreateSession(context.Background(), s, "hash-1")
 		require.Error(t, err)
 	})
 }
 
-// Get (single-use → удаляем все токены этого user)
-func TestTokensRepo_Get(t *testing.T) {
-	const raw = "toktoktok"
-	const uid = int64(42)
-	const rid = int64(1)
+// Rotate (ротация внутри семейства, остальные сессии не трогаем)
+func TestTokensRepo_Rotate(t *testing.T) {
+	const uid, sid, tid = int64(42), int64(5), int64(1)
 	expires := time.Now().Add(30 * time.Minute)
-
-	happyRows := sqlmock.NewRows(
-		[]string{"token_id", "user_id", "token", "expires_at"},
-	).AddRow(rid, uid, raw, expires)
+	next := time.Now().Add(30 * 24 * time.Hour)
+	cols := []string{"token_id", "user_id", "session_id", "token_hash", "expires_at", "used_at"}
+	selectQ := `FROM refresh_tokens rt\s+JOIN auth_sessions s .+ WHERE rt.token_hash = \$1 AND s.revoked_at IS NULL`
 
 	t.Run("happy path", func(t *testing.T) {
 		repo, mock, done := newRepoTokens(t)
 		defer done()
 
 		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT token_id, user_id, refresh_token, expires_at FROM\s+refresh_tokens`).
-			WithArgs(raw).
-			WillReturnRows(happyRows)
-		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id =`).
-			WithArgs(uid).
-			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(selectQ).
+			WithArgs("old").
+			WillReturnRows(sqlmock.NewRows(cols).AddRow(tid, uid, sid, "old", expires, nil))
+		mock.ExpectExec(`UPDATE refresh_tokens SET used_at = NOW\(\) WHERE token_id = \$1`).
+			WithArgs(tid).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO refresh_tokens`).
+			WithArgs(uid, sid, "new", next).
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectExec(`UPDATE auth_sessions SET last_used_at = NOW\(\), expires_at = \$2 WHERE session_id = \$1`).
+			WithArgs(sid, next).
+			WillReturnResult(sqlmock.NewResult(0, 1))
 		mock.ExpectCommit()
 
-		rs, err := repo.Get(context.Background(), raw)
+		rt, err := repo.Rotate(context.Background(), "old", "new", next)
 		require.NoError(t, err)
-		require.Equal(t, rid, rs.TokenID)
-		require.Equal(t, uid, rs.UserID)
-		require.Equal(t, raw, rs.RefreshToken)
-		require.WithinDuration(t, expires, rs.ExpiresAt, time.Second)
+		require.Equal(t, uid, rt.UserID)
+		require.Equal(t, sid, rt.SessionID)
 	})
 
-	t.Run("token not found → sql.ErrNoRows (rollback)", func(t *testing.T) {
+	t.Run("reuse of rotated token → only this session revoked", func(t *testing.T) {
 		repo, mock, done := newRepoTokens(t)
 		defer done()
 
+		used := time.Now().Add(-time.Minute)
 		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT token_id, user_id, refresh_token, expires_at FROM\s+refresh_tokens`).
-			WithArgs(raw).
+		mock.ExpectQuery(selectQ).
+			WithArgs("old").
+			WillReturnRows(sqlmock.NewRows(cols).AddRow(tid, uid, sid, "old", expires, used))
+		mock.ExpectExec(`UPDATE auth_sessions SET revoked_at = NOW\(\), revoke_reason = 'refresh_reuse'\s+WHERE session_id = \$1`).
+			WithArgs(sid).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err := repo.Rotate(context.Background(), "old", "new", next)
+		require.ErrorIs(t, err, errs.ErrRefreshTokenReused)
+	})
+
+	t.Run("expired → no rotation", func(t *testing.T) {
+		repo, mock, done := newRepoTokens(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQ).
+			WithArgs("old").
+			WillReturnRows(sqlmock.NewRows(cols).AddRow(tid, uid, sid, "old", time.Now().Add(-time.Hour), nil))
+		mock.ExpectRollback()
+
+		_, err := repo.Rotate(context.Background(), "old", "new", next)
+		require.ErrorIs(t, err, errs.ErrRefreshTokenExpired)
+	})
+
+	t.Run("token not found or session revoked → sql.ErrNoRows", func(t *testing.T) {
+		repo, mock, done := newRepoTokens(t)
+		defer done()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectQ).
+			WithArgs("old").
 			WillReturnError(sql.ErrNoRows)
 		mock.ExpectRollback()
 
-		_, err := repo.Get(context.Background(), raw)
+		_, err := repo.Rotate(context.Background(), "old", "new", next)
 		require.ErrorIs(t, err, sql.ErrNoRows)
 	})
 
-	t.Run("delete fails → rollback", func(t *testing.T) {
+	t.Run("insert fails → rollback", func(t *testing.T) {
 		repo, mock, done := newRepoTokens(t)
 		defer done()
 
-		rows := sqlmock.NewRows(
-			[]string{"token_id", "user_id", "refresh_token", "expires_at"},
-		).AddRow(rid, uid, raw, expires)
-
 		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT token_id, user_id, refresh_token, expires_at FROM\s+refresh_tokens`).
-			WithArgs(raw).
-			WillReturnRows(rows)
-		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id =`).
-			WithArgs(uid).
-			WillReturnError(errors.New("delete failed"))
+		mock.ExpectQuery(selectQ).
+			WithArgs("old").
+			WillReturnRows(sqlmock.NewRows(cols).AddRow(tid, uid, sid, "old", expires, nil))
+		mock.ExpectExec(`UPDATE refresh_tokens SET used_at`).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO refresh_tokens`).
+			WillReturnError(errors.New("insert failed"))
 		mock.ExpectRollback()
 
-		_, err := repo.Get(context.Background(), raw)
+		_, err := repo.Rotate(context.Background(), "old", "new", next)
 		require.Error(t, err)
 	})
 
@@ -136,22 +169,49 @@ func TestTokensRepo_Get(t *testing.T) {
 
 		mock.ExpectBegin().WillReturnError(errors.New("begin failed"))
 
-		_, err := repo.Get(context.Background(), "whatever")
+		_, err := repo.Rotate(context.Background(), "old", "new", next)
 		require.Error(t, err)
 	})
+}
+
+func TestTokensRepo_ListSessions(t *testing.T) {
+	repo, mock, done := newRepoTokens(t)
+	defer done()
+
+	now := time.Now()
+	mock.ExpectQuery(`FROM auth_sessions\s+WHERE user_id = \$1 AND revoked_at IS NULL AND expires_at > NOW\(\)`).
+		WithArgs(int64(7)).
+		WillReturnRows(sqlmock.NewRows([]string{"session_id", "user_id", "user_agent", "ip", "created_at", "last_used_at", "expires_at"}).
+			AddRow(int64(2), int64(7), "Firefox", "10.0.0.2", now, now, now.Add(time.Hour)).
+			AddRow(int64(1), int64(7), "curl/8", "10.0.0.1", now, now, now.Add(time.Hour)))
+
+	list, err := repo.ListSessions(context.Background(), 7)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, "Firefox", list[0].UserAgent)
+}
 
-	t.Run("select returns non-no-rows error → rollback", func(t *testing.T) {
+func TestTokensRepo_RevokeSession(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
 		repo, mock, done := newRepoTokens(t)
 		defer done()
 
-		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT token_id, user_id, refresh_token, expires_at FROM\s+refresh_tokens`).
-			WithArgs("toktoktok").
-			WillReturnError(errors.New("select failed"))
-		mock.ExpectRollback()
+		mock.ExpectExec(`UPDATE auth_sessions SET revoked_at = NOW\(\), revoke_reason = \$3\s+WHERE user_id = \$1 AND session_id = \$2`).
+			WithArgs(int64(7), int64(2), "user_revoked").
+			WillReturnResult(sqlmock.NewResult(0, 1))
 
-		_, err := repo.Get(context.Background(), "toktoktok")
-		require.Error(t, err)
+		require.NoError(t, repo.RevokeSession(context.Background(), 7, 2, "user_revoked"))
 	})
 
+	t.Run("someone else's session → not found", func(t *testing.T) {
+		repo, mock, done := newRepoTokens(t)
+		defer done()
+
+		mock.ExpectExec(`UPDATE auth_sessions SET revoked_at`).
+			WithArgs(int64(7), int64(99), "user_revoked").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := repo.RevokeSession(context.Background(), 7, 99, "user_revoked")
+		require.ErrorIs(t, err, errs.ErrSessionNotFound)
+	})
 }
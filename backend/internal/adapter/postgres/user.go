@@ -18,9 +18,20 @@ type UserRepo struct {
 func NewUserRepo(db *sql.DB) *UserRepo { return &UserRepo{db: db} }
 
 func (r *UserRepo) CreateUser(ctx context.Context, u entity.User) (int64, error) {
-	const q = `INSERT INTO users (email, password_hash, registered_at)
-	           VALUES ($1, $2, NOW())
-	           RETURNING user_id`
+	// пользователь, его личное пространство и членство владельца — одним запросом
+	const q = `
+WITH u AS (
+  INSERT INTO users (email, password_hash, registered_at)
+  VALUES ($1, $2, NOW())
+  RETURNING user_id, email
+), w AS (
+  INSERT INTO workspaces (name, personal_user_id)
+  SELECT email, user_id FROM u
+  RETURNING workspace_id, personal_user_id
+)
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT workspace_id, personal_user_id, 'owner' FROM w
+RETURNING user_id`
 
 	var user_id int64
 	if err := r.db.QueryRowContext(ctx, q, u.Email, u.PassHash).Scan(&user_id); err != nil {
@@ -29,7 +29,8 @@ func TestCreateUser(t *testing.T) {
 
 		u := entity.User{Email: "me@example.com", PassHash: "hash123hash456"}
 
-		mock.ExpectQuery(`INSERT INTO users`).
+		// пользователь и его личное пространство — одним запросом
+		mock.ExpectQuery(`INSERT INTO users[\s\S]+INSERT INTO workspaces \(name, personal_user_id\)[\s\S]+INSERT INTO workspace_members[\s\S]+'owner'`).
 			WithArgs(u.Email, u.PassHash).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(int64(1)))
 
@@ -0,0 +1,228 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// WebhooksRepo — подписки на исходящие события и очередь webhook_deliveries.
+// Секрет подписи хранится зашифрованным (secret_enc) и расшифровывается только при доставке.
+type WebhooksRepo struct {
+	db  *sql.DB
+	enc BoundEncryptor
+}
+
+func NewWebhooksRepo(db *sql.DB, enc BoundEncryptor) *WebhooksRepo {
+	return &WebhooksRepo{db: db, enc: enc}
+}
+
+// webhookSecretAAD — к чему привязан секрет: строка подписки.
+func webhookSecretAAD(subscriptionID int64) []byte {
+	return []byte(fmt.Sprintf("webhook_subscriptions:%d", subscriptionID))
+}
+
+func (r *WebhooksRepo) ListSubscriptions(ctx context.Context, workspaceID int64) ([]entity.WebhookSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `
+SELECT subscription_id, workspace_id, user_id, url, event_types, enabled, created_at
+FROM webhook_subscriptions WHERE workspace_id = $1 ORDER BY subscription_id`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.WebhookSubscription{}
+	for rows.Next() {
+		var s entity.WebhookSubscription
+		if err := rows.Scan(&s.SubscriptionID, &s.WorkspaceID, &s.UserID, &s.URL, pq.Array(&s.EventTypes), &s.Enabled, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// CreateSubscription — id берётся из последовательности заранее: секрет шифруется с привязкой к нему.
+// Открытый секрет возвращается только здесь (для ответа на создание).
+func (r *WebhooksRepo) CreateSubscription(ctx context.Context, s entity.WebhookSubscription) (entity.WebhookSubscription, error) {
+	var id int64
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT nextval(pg_get_serial_sequence('webhook_subscriptions', 'subscription_id'))`).Scan(&id); err != nil {
+		return entity.WebhookSubscription{}, fmt.Errorf("next subscription id: %w", err)
+	}
+	secretEnc, err := r.enc.EncryptStringAAD(ctx, s.Secret, webhookSecretAAD(id))
+	if err != nil {
+		return entity.WebhookSubscription{}, fmt.Errorf("encrypt webhook secret: %w", err)
+	}
+
+	const q = `
+INSERT INTO webhook_subscriptions (subscription_id, workspace_id, user_id, url, secret_enc, event_types)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING subscription_id, workspace_id, user_id, url, event_types, enabled, created_at`
+	out := entity.WebhookSubscription{Secret: s.Secret}
+	err = r.db.QueryRowContext(ctx, q, id, s.WorkspaceID, s.UserID, s.URL, secretEnc, pq.Array(s.EventTypes)).
+		Scan(&out.SubscriptionID, &out.WorkspaceID, &out.UserID, &out.URL, pq.Array(&out.EventTypes), &out.Enabled, &out.CreatedAt)
+	if err != nil {
+		return entity.WebhookSubscription{}, err
+	}
+	return out, nil
+}
+
+// DeleteSubscription — false, если подписки в пространстве нет. Очередь подписки удаляется каскадом.
+func (r *WebhooksRepo) DeleteSubscription(ctx context.Context, workspaceID, subscriptionID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE subscription_id = $1 AND workspace_id = $2`,
+		subscriptionID, workspaceID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Enqueue ставит событие в очередь всех включённых подписок на этот тип в пространствах-получателях:
+// пространстве аккаунта объявления e.AdID, иначе аккаунта e.AccountID, иначе во всех пространствах
+// с аккаунтами, привязанными e.UserID (его Google-логин нужен им всем).
+func (r *WebhooksRepo) Enqueue(ctx context.Context, e entity.WebhookEvent, payload []byte) (int64, error) {
+	const q = `
+WITH ws AS (
+	SELECT DISTINCT aa.workspace_id
+	FROM ad_accounts aa
+	WHERE CASE
+		WHEN $2::bigint <> 0 THEN aa.account_id = (SELECT a.account_id FROM ads a WHERE a.ad_id = $2)
+		WHEN $3::bigint <> 0 THEN aa.account_id = $3
+		ELSE aa.user_id = $1
+	END
+)
+INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+SELECT s.subscription_id, $4, $5, $6
+FROM webhook_subscriptions s
+JOIN ws ON ws.workspace_id = s.workspace_id
+WHERE s.enabled AND $5 = ANY (s.event_types)
+ON CONFLICT (subscription_id, event_id) DO NOTHING`
+	res, err := r.db.ExecContext(ctx, q, e.UserID, e.AdID, e.AccountID, e.ID, e.Type, payload)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook %s: %w", e.Type, err)
+	}
+	return res.RowsAffected()
+}
+
+const webhookDeliveryColumns = `d.delivery_id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+       d.last_status_code, d.last_error, d.next_attempt_at, d.created_at, d.sent_at`
+
+func webhookDeliveryDest(d *entity.WebhookDelivery) []any {
+	return []any{&d.DeliveryID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+		&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.SentAt}
+}
+
+// ListDeliveries — последние доставки подписки пространства; status пустой — любые.
+func (r *WebhooksRepo) ListDeliveries(ctx context.Context, workspaceID, subscriptionID int64, status string, limit int) ([]entity.WebhookDelivery, error) {
+	q := `SELECT ` + webhookDeliveryColumns + `
+FROM webhook_deliveries d
+JOIN webhook_subscriptions s ON s.subscription_id = d.subscription_id
+WHERE s.workspace_id = $1 AND d.subscription_id = $2 AND ($3 = '' OR d.status = $3)
+ORDER BY d.created_at DESC, d.delivery_id DESC
+LIMIT $4`
+	rows, err := r.db.QueryContext(ctx, q, workspaceID, subscriptionID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []entity.WebhookDelivery{}
+	for rows.Next() {
+		var d entity.WebhookDelivery
+		if err := rows.Scan(webhookDeliveryDest(&d)...); err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// ClaimDeliveries забирает до limit готовых к отправке доставок (как ClaimDeliveries у алертов):
+// attempts+1, next_attempt_at сдвигается на lease на случай падения воркера.
+// Секрет подписки расшифровывается здесь; у подписок до reencrypt он ещё открытый (secret).
+func (r *WebhooksRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error) {
+	q := `
+WITH due AS (
+	SELECT delivery_id
+	FROM webhook_deliveries
+	WHERE status = 'pending' AND next_attempt_at <= NOW()
+	ORDER BY next_attempt_at
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED
+), d AS (
+	UPDATE webhook_deliveries w
+	SET attempts = w.attempts + 1, next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+	FROM due
+	WHERE w.delivery_id = due.delivery_id
+	RETURNING w.*
+)
+SELECT ` + webhookDeliveryColumns + `, s.url, COALESCE(s.secret_enc, ''), COALESCE(s.secret, '')
+FROM d
+JOIN webhook_subscriptions s ON s.subscription_id = d.subscription_id`
+	rows, err := r.db.QueryContext(ctx, q, limit, int64(lease/time.Second))
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var out []entity.WebhookDelivery
+	for rows.Next() {
+		var (
+			d         entity.WebhookDelivery
+			secretEnc string
+		)
+		if err := rows.Scan(append(webhookDeliveryDest(&d), &d.URL, &secretEnc, &d.Secret)...); err != nil {
+			return nil, err
+		}
+		if secretEnc != "" {
+			if d.Secret, err = r.enc.DecryptStringAAD(ctx, secretEnc, webhookSecretAAD(d.SubscriptionID)); err != nil {
+				return nil, fmt.Errorf("decrypt webhook secret (subscription %d): %w", d.SubscriptionID, err)
+			}
+		}
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+func (r *WebhooksRepo) MarkSent(ctx context.Context, deliveryID int64, statusCode int) error {
+	_, err := r.db.ExecContext(ctx, `
+UPDATE webhook_deliveries SET status = 'sent', sent_at = NOW(), last_status_code = $2, last_error = NULL
+WHERE delivery_id = $1`, deliveryID, statusCode)
+	return err
+}
+
+// MarkFailed — попытка не удалась (statusCode 0 — ответа не было): повтор в retryAt или, если dead, в dead-letter.
+func (r *WebhooksRepo) MarkFailed(ctx context.Context, deliveryID int64, statusCode int, errText string, dead bool, retryAt time.Time) error {
+	status := entity.WebhookStatusPending
+	if dead {
+		status = entity.WebhookStatusDead
+	}
+	_, err := r.db.ExecContext(ctx, `
+UPDATE webhook_deliveries SET status = $2, last_status_code = NULLIF($3, 0), last_error = $4, next_attempt_at = $5
+WHERE delivery_id = $1`, deliveryID, status, statusCode, errText, retryAt)
+	return err
+}
+
+// Replay возвращает dead-доставки пространства в очередь с обнулённым счётчиком попыток:
+// одну (deliveryID > 0) или все по подписке. Возвращает число переотправленных.
+func (r *WebhooksRepo) Replay(ctx context.Context, workspaceID, subscriptionID, deliveryID int64) (int64, error) {
+	const q = `
+UPDATE webhook_deliveries d
+SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+FROM webhook_subscriptions s
+WHERE s.subscription_id = d.subscription_id AND s.workspace_id = $1
+  AND d.subscription_id = $2 AND ($3 = 0 OR d.delivery_id = $3)
+  AND d.status = 'dead'`
+	res, err := r.db.ExecContext(ctx, q, workspaceID, subscriptionID, deliveryID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,106 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+func newWebhooksRepo(t *testing.T) (*postgres.WebhooksRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewWebhooksRepo(db, fakeRing{}), mock, func() { _ = db.Close() }
+}
+
+func TestWebhooksRepo_Enqueue_ByAdWorkspace(t *testing.T) {
+	repo, mock, done := newWebhooksRepo(t)
+	defer done()
+
+	payload := []byte(`{"id":"9a7e","type":"click.created"}`)
+	mock.ExpectExec(`WHEN \$2::bigint <> 0 THEN aa\.account_id = \(SELECT a\.account_id FROM ads a WHERE a\.ad_id = \$2\)[\s\S]+INSERT INTO webhook_deliveries[\s\S]+JOIN ws ON ws\.workspace_id = s\.workspace_id\s+WHERE s\.enabled AND \$5 = ANY \(s\.event_types\)\s+ON CONFLICT \(subscription_id, event_id\) DO NOTHING`).
+		WithArgs(int64(0), int64(87), int64(0), "9a7e", "click.created", payload).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	n, err := repo.Enqueue(context.Background(), entity.WebhookEvent{ID: "9a7e", Type: entity.EventClickCreated, AdID: 87}, payload)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhooksRepo_MarkFailed_DeadLetter(t *testing.T) {
+	repo, mock, done := newWebhooksRepo(t)
+	defer done()
+
+	retry := time.Date(2025, 3, 3, 6, 5, 0, 0, time.UTC)
+	mock.ExpectExec(`UPDATE webhook_deliveries SET status = \$2, last_status_code = NULLIF\(\$3, 0\), last_error = \$4, next_attempt_at = \$5`).
+		WithArgs(int64(11), "dead", 503, "webhook post 503: busy", retry).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	require.NoError(t, repo.MarkFailed(context.Background(), 11, 503, "webhook post 503: busy", true, retry))
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhooksRepo_Replay(t *testing.T) {
+	repo, mock, done := newWebhooksRepo(t)
+	defer done()
+
+	mock.ExpectExec(`SET status = 'pending', attempts = 0, next_attempt_at = NOW\(\)[\s\S]+s\.workspace_id = \$1[\s\S]+AND d\.status = 'dead'`).
+		WithArgs(int64(3), int64(5), int64(0)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	n, err := repo.Replay(context.Background(), 3, 5, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhooksRepo_CreateSubscription_EncryptsSecret(t *testing.T) {
+	repo, mock, done := newWebhooksRepo(t)
+	defer done()
+
+	now := time.Now()
+	mock.ExpectQuery(`SELECT nextval\(pg_get_serial_sequence\('webhook_subscriptions', 'subscription_id'\)\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"nextval"}).AddRow(5))
+	mock.ExpectQuery(`INSERT INTO webhook_subscriptions \(subscription_id, workspace_id, user_id, url, secret_enc, event_types\)`).
+		WithArgs(int64(5), int64(3), int64(7), "https://example.com/hook", "new:webhook_subscriptions:5|whsec_1", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "workspace_id", "user_id", "url", "event_types", "enabled", "created_at"}).
+			AddRow(5, 3, 7, "https://example.com/hook", "{click.created}", true, now))
+
+	sub, err := repo.CreateSubscription(context.Background(), entity.WebhookSubscription{
+		WorkspaceID: 3, UserID: 7, URL: "https://example.com/hook", Secret: "whsec_1", EventTypes: []string{entity.EventClickCreated},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(5), sub.SubscriptionID)
+	require.Equal(t, "whsec_1", sub.Secret) // открытый — только в ответе на создание
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhooksRepo_ClaimDeliveries_DecryptsSecret(t *testing.T) {
+	repo, mock, done := newWebhooksRepo(t)
+	defer done()
+
+	now := time.Now()
+	cols := []string{"delivery_id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts",
+		"last_status_code", "last_error", "next_attempt_at", "created_at", "sent_at", "url", "secret_enc", "secret"}
+	mock.ExpectQuery(`SELECT [\s\S]+, s\.url, COALESCE\(s\.secret_enc, ''\), COALESCE\(s\.secret, ''\)`).
+		WithArgs(10, int64(60)).
+		WillReturnRows(sqlmock.NewRows(cols).
+			AddRow(1, 5, "e1", "click.created", []byte(`{}`), "pending", 1, nil, nil, now, now, nil,
+				"https://a", "new:webhook_subscriptions:5|whsec_1", "").
+			// подписка до reencrypt: секрет ещё открытый
+			AddRow(2, 6, "e1", "click.created", []byte(`{}`), "pending", 1, nil, nil, now, now, nil,
+				"https://b", "", "whsec_legacy"))
+
+	ds, err := repo.ClaimDeliveries(context.Background(), 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, ds, 2)
+	require.Equal(t, "whsec_1", ds[0].Secret)
+	require.Equal(t, "whsec_legacy", ds[1].Secret)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+// WorkspacesRepo — рабочие пространства, участники и приглашения.
+// Не найдено / нет членства — sql.ErrNoRows.
+type WorkspacesRepo struct {
+	db *sql.DB
+}
+
+func NewWorkspacesRepo(db *sql.DB) *WorkspacesRepo { return &WorkspacesRepo{db: db} }
+
+// Membership — роль пользователя в пространстве.
+func (r *WorkspacesRepo) Membership(ctx context.Context, userID, workspaceID int64) (entity.WorkspaceMember, error) {
+	const q = `
+SELECT workspace_id, user_id, role, created_at
+FROM workspace_members
+WHERE workspace_id = $1 AND user_id = $2`
+	var m entity.WorkspaceMember
+	err := r.db.QueryRowContext(ctx, q, workspaceID, userID).Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.CreatedAt)
+	return m, err
+}
+
+// DefaultMembership — пространство по умолчанию: личное, иначе самое раннее членство.
+func (r *WorkspacesRepo) DefaultMembership(ctx context.Context, userID int64) (entity.WorkspaceMember, error) {
+	const q = `
+SELECT m.workspace_id, m.user_id, m.role, m.created_at
+FROM workspace_members m
+JOIN workspaces w ON w.workspace_id = m.workspace_id
+WHERE m.user_id = $1
+ORDER BY (w.personal_user_id IS NOT DISTINCT FROM m.user_id) DESC, m.created_at, m.workspace_id
+LIMIT 1`
+	var m entity.WorkspaceMember
+	err := r.db.QueryRowContext(ctx, q, userID).Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.CreatedAt)
+	return m, err
+}
+
+// ListForUser — пространства, где пользователь участник, с его ролью.
+func (r *WorkspacesRepo) ListForUser(ctx context.Context, userID int64) ([]entity.Workspace, error) {
+	const q = `
+SELECT w.workspace_id, w.name, w.personal_user_id IS NOT NULL, m.role, w.created_at
+FROM workspace_members m
+JOIN workspaces w ON w.workspace_id = m.workspace_id
+WHERE m.user_id = $1
+ORDER BY w.personal_user_id IS NULL, w.name, w.workspace_id`
+	rows, err := r.db.QueryContext(ctx, q, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	out := []entity.Workspace{}
+	for rows.Next() {
+		var w entity.Workspace
+		if err := rows.Scan(&w.WorkspaceID, &w.Name, &w.Personal, &w.Role, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, w)
+	}
+	return out, rows.Err()
+}
+
+// Create — командное пространство; создатель становится владельцем (одним запросом).
+func (r *WorkspacesRepo) Create(ctx context.Context, userID int64, name string) (entity.Workspace, error) {
+	const q = `
+WITH w AS (
+  INSERT INTO workspaces (name) VALUES ($2)
+  RETURNING workspace_id, name, created_at
+)
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT workspace_id, $1, 'owner' FROM w
+RETURNING workspace_id, (SELECT name FROM w), (SELECT created_at FROM w)`
+	w := entity.Workspace{Role: entity.RoleOwner}
+	if err := r.db.QueryRowContext(ctx, q, userID, name).Scan(&w.WorkspaceID, &w.Name, &w.CreatedAt); err != nil {
+		return w, fmt.Errorf("create workspace: %w", err)
+	}
+	return w, nil
+}
+
+func (r *WorkspacesRepo) Members(ctx context.Context, workspaceID int64) ([]entity.WorkspaceMember, error) {
+	const q = `
+SELECT m.workspace_id, m.user_id, u.email, m.role, m.created_at
+FROM workspace_members m
+JOIN users u ON u.user_id = m.user_id
+WHERE m.workspace_id = $1
+ORDER BY m.created_at, m.user_id`
+	rows, err := r.db.QueryContext(ctx, q, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list members: %w", err)
+	}
+	defer rows.Close()
+
+	out := []entity.WorkspaceMember{}
+	for rows.Next() {
+		var m entity.WorkspaceMember
+		if err := rows.Scan(&m.WorkspaceID, &m.UserID, &m.Email, &m.Role, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// CountOwners — сколько владельцев у пространства (последнего понижать/удалять нельзя).
+func (r *WorkspacesRepo) CountOwners(ctx context.Context, workspaceID int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM workspace_members WHERE workspace_id = $1 AND role = 'owner'`, workspaceID).Scan(&n)
+	return n, err
+}
+
+// SetRole — false, если такого участника нет.
+func (r *WorkspacesRepo) SetRole(ctx context.Context, workspaceID, userID int64, role string) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE workspace_members SET role = $3 WHERE workspace_id = $1 AND user_id = $2`, workspaceID, userID, role)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RemoveMember — false, если такого участника нет.
+func (r *WorkspacesRepo) RemoveMember(ctx context.Context, workspaceID, userID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2`, workspaceID, userID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// CreateInvitation — новое приглашение; открытое приглашение на тот же email перевыпускается.
+func (r *WorkspacesRepo) CreateInvitation(ctx context.Context, inv entity.WorkspaceInvitation, tokenHash string) (entity.WorkspaceInvitation, error) {
+	const q = `
+INSERT INTO workspace_invitations (workspace_id, email, role, token_hash, invited_by, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (workspace_id, lower(email)) WHERE accepted_at IS NULL DO UPDATE
+SET role       = EXCLUDED.role,
+    token_hash = EXCLUDED.token_hash,
+    invited_by = EXCLUDED.invited_by,
+    expires_at = EXCLUDED.expires_at,
+    created_at = NOW()
+RETURNING invitation_id, created_at`
+	err := r.db.QueryRowContext(ctx, q, inv.WorkspaceID, inv.Email, inv.Role, tokenHash, inv.InvitedBy, inv.ExpiresAt).
+		Scan(&inv.InvitationID, &inv.CreatedAt)
+	if err != nil {
+		return inv, fmt.Errorf("create invitation: %w", err)
+	}
+	return inv, nil
+}
+
+// Invitations — открытые (не принятые) приглашения пространства, включая истёкшие.
+func (r *WorkspacesRepo) Invitations(ctx context.Context, workspaceID int64) ([]entity.WorkspaceInvitation, error) {
+	const q = `
+SELECT invitation_id, workspace_id, email, role, COALESCE(invited_by, 0), expires_at, created_at
+FROM workspace_invitations
+WHERE workspace_id = $1 AND accepted_at IS NULL
+ORDER BY created_at DESC, invitation_id DESC`
+	rows, err := r.db.QueryContext(ctx, q, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list invitations: %w", err)
+	}
+	defer rows.Close()
+
+	out := []entity.WorkspaceInvitation{}
+	for rows.Next() {
+		var inv entity.WorkspaceInvitation
+		if err := rows.Scan(&inv.InvitationID, &inv.WorkspaceID, &inv.Email, &inv.Role, &inv.InvitedBy,
+			&inv.ExpiresAt, &inv.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, inv)
+	}
+	return out, rows.Err()
+}
+
+// DeleteInvitation — отзыв открытого приглашения; false, если его нет.
+func (r *WorkspacesRepo) DeleteInvitation(ctx context.Context, workspaceID, invitationID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM workspace_invitations WHERE workspace_id = $1 AND invitation_id = $2 AND accepted_at IS NULL`,
+		workspaceID, invitationID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AcceptInvitation — гасит действующее приглашение, выданное на email пользователя, и добавляет
+// его в пространство (одним запросом). Уже участник сохраняет свою роль.
+func (r *WorkspacesRepo) AcceptInvitation(ctx context.Context, tokenHash string, userID int64) (entity.WorkspaceMember, error) {
+	const q = `
+WITH inv AS (
+  UPDATE workspace_invitations i
+  SET accepted_at = NOW(), accepted_by = u.user_id
+  FROM users u
+  WHERE i.token_hash = $1 AND i.accepted_at IS NULL AND i.expires_at > NOW()
+    AND u.user_id = $2 AND lower(u.email) = lower(i.email)
+  RETURNING i.workspace_id, i.role
+)
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT workspace_id, $2, role FROM inv
+ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = workspace_members.role
+RETURNING workspace_id, user_id, role, created_at`
+	var m entity.WorkspaceMember
+	err := r.db.QueryRowContext(ctx, q, tokenHash, userID).Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.CreatedAt)
+	return m, err
+}
@@ -0,0 +1,112 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/postgres"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+func newWorkspacesRepo(t *testing.T) (*postgres.WorkspacesRepo, sqlmock.Sqlmock, func()) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	return postgres.NewWorkspacesRepo(db), mock, func() { _ = db.Close() }
+}
+
+func TestWorkspacesRepo_DefaultMembership_PrefersPersonal(t *testing.T) {
+	repo, mock, done := newWorkspacesRepo(t)
+	defer done()
+
+	at := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`FROM workspace_members m\s+JOIN workspaces w[\s\S]+WHERE m\.user_id = \$1\s+ORDER BY \(w\.personal_user_id IS NOT DISTINCT FROM m\.user_id\) DESC`).
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "user_id", "role", "created_at"}).
+			AddRow(int64(1), int64(42), "owner", at))
+
+	m, err := repo.DefaultMembership(context.Background(), 42)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), m.WorkspaceID)
+	require.Equal(t, entity.RoleOwner, m.Role)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkspacesRepo_Membership_NotMember(t *testing.T) {
+	repo, mock, done := newWorkspacesRepo(t)
+	defer done()
+
+	mock.ExpectQuery(`FROM workspace_members\s+WHERE workspace_id = \$1 AND user_id = \$2`).
+		WithArgs(int64(9), int64(42)).
+		WillReturnError(sql.ErrNoRows)
+
+	_, err := repo.Membership(context.Background(), 42, 9)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkspacesRepo_CreateInvitation_ReissuesPending(t *testing.T) {
+	repo, mock, done := newWorkspacesRepo(t)
+	defer done()
+
+	exp := time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`INSERT INTO workspace_invitations[\s\S]+ON CONFLICT \(workspace_id, lower\(email\)\) WHERE accepted_at IS NULL DO UPDATE[\s\S]+token_hash = EXCLUDED\.token_hash`).
+		WithArgs(int64(3), "new@example.com", "analyst", "hash", int64(42), exp).
+		WillReturnRows(sqlmock.NewRows([]string{"invitation_id", "created_at"}).AddRow(int64(11), exp.Add(-7*24*time.Hour)))
+
+	inv, err := repo.CreateInvitation(context.Background(), entity.WorkspaceInvitation{
+		WorkspaceID: 3, Email: "new@example.com", Role: "analyst", InvitedBy: 42, ExpiresAt: exp,
+	}, "hash")
+	require.NoError(t, err)
+	require.Equal(t, int64(11), inv.InvitationID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWorkspacesRepo_AcceptInvitation(t *testing.T) {
+	t.Run("joins workspace", func(t *testing.T) {
+		repo, mock, done := newWorkspacesRepo(t)
+		defer done()
+
+		mock.ExpectQuery(`UPDATE workspace_invitations i[\s\S]+i\.expires_at > NOW\(\)[\s\S]+lower\(u\.email\) = lower\(i\.email\)[\s\S]+INSERT INTO workspace_members[\s\S]+ON CONFLICT \(workspace_id, user_id\) DO UPDATE SET role = workspace_members\.role`).
+			WithArgs("hash", int64(77)).
+			WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "user_id", "role", "created_at"}).
+				AddRow(int64(3), int64(77), "analyst", time.Now()))
+
+		m, err := repo.AcceptInvitation(context.Background(), "hash", 77)
+		require.NoError(t, err)
+		require.Equal(t, int64(3), m.WorkspaceID)
+		require.Equal(t, "analyst", m.Role)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("expired or other email", func(t *testing.T) {
+		repo, mock, done := newWorkspacesRepo(t)
+		defer done()
+
+		mock.ExpectQuery(`UPDATE workspace_invitations i`).
+			WithArgs("hash", int64(77)).
+			WillReturnRows(sqlmock.NewRows([]string{"workspace_id", "user_id", "role", "created_at"}))
+
+		_, err := repo.AcceptInvitation(context.Background(), "hash", 77)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestWorkspacesRepo_RemoveMember_NotFound(t *testing.T) {
+	repo, mock, done := newWorkspacesRepo(t)
+	defer done()
+
+	mock.ExpectExec(`DELETE FROM workspace_members WHERE workspace_id = \$1 AND user_id = \$2`).
+		WithArgs(int64(3), int64(77)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	ok, err := repo.RemoveMember(context.Background(), 3, 77)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
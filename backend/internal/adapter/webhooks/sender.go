@@ -0,0 +1,84 @@
+// Package webhooks — доставка исходящих событий подписчикам с HMAC-подписью.
+//
+// Заголовки запроса:
+//
+//	X-AdSieve-Event:     click.created
+//	X-AdSieve-Delivery:  <delivery_id>
+//	X-AdSieve-Signature: t=<unix>,v1=<hex(HMAC-SHA256(secret, "<t>.<body>"))>
+//
+// Получатель пересчитывает v1 по сырому телу и отбрасывает запросы со старым t (replay).
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/safehttp"
+)
+
+const (
+	HeaderEvent     = "X-AdSieve-Event"
+	HeaderDelivery  = "X-AdSieve-Delivery"
+	HeaderSignature = "X-AdSieve-Signature"
+)
+
+const sendTimeout = 10 * time.Second
+
+// Sender шлёт payload доставки на URL подписки. Соединяется только с публичными адресами
+// и не ходит по редиректам (см. safehttp).
+type Sender struct {
+	http *http.Client
+	now  func() time.Time
+}
+
+func NewSender() *Sender {
+	return &Sender{http: safehttp.NewClient(sendTimeout), now: time.Now}
+}
+
+// WithPrivateNetworks снимает фильтр адресов — для локальных получателей в разработке и тестах.
+func (s *Sender) WithPrivateNetworks() *Sender {
+	s.http = safehttp.NewLocalClient(sendTimeout)
+	return s
+}
+
+// Send возвращает HTTP-код ответа (0 — ответа не было); не 2xx — ошибка, доставка повторится.
+// Тело ответа не читается и в ошибку не попадает: в last_error хранится только код.
+func (s *Sender) Send(ctx context.Context, d entity.WebhookDelivery) (int, error) {
+	ts := s.now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AdSieve-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, d.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(d.DeliveryID, 10))
+	req.Header.Set(HeaderSignature, fmt.Sprintf("t=%d,v1=%s", ts, Sign(d.Secret, ts, d.Payload)))
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("webhook post: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("webhook post: HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign — hex(HMAC-SHA256(secret, "<ts>.<body>")).
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(ts, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,101 @@
+package webhooks_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/adapter/webhooks"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/safehttp"
+)
+
+func TestSender_SignsPayload(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"e1","type":"click.created","data":{"ad_id":87}}`)
+
+	var verified bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "click.created", r.Header.Get(webhooks.HeaderEvent))
+		require.Equal(t, "42", r.Header.Get(webhooks.HeaderDelivery))
+		body, _ := io.ReadAll(r.Body)
+		require.Equal(t, payload, body)
+
+		// t=<unix>,v1=<hex>
+		var ts int64
+		var sig string
+		for _, part := range strings.Split(r.Header.Get(webhooks.HeaderSignature), ",") {
+			k, v, _ := strings.Cut(part, "=")
+			switch k {
+			case "t":
+				ts, _ = strconv.ParseInt(v, 10, 64)
+			case "v1":
+				sig = v
+			}
+		}
+		verified = hmac.Equal([]byte(sig), []byte(webhooks.Sign(secret, ts, body)))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	code, err := webhooks.NewSender().WithPrivateNetworks().Send(context.Background(), entity.WebhookDelivery{
+		DeliveryID: 42, EventType: entity.EventClickCreated, Payload: payload, URL: srv.URL, Secret: secret,
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, code)
+	require.True(t, verified)
+}
+
+func TestSender_Non2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	code, err := webhooks.NewSender().WithPrivateNetworks().Send(context.Background(), entity.WebhookDelivery{
+		DeliveryID: 1, EventType: entity.EventSyncFailed, Payload: []byte(`{}`), URL: srv.URL, Secret: "s",
+	})
+	require.Error(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.NotContains(t, err.Error(), "busy") // тело ответа в last_error не попадает
+}
+
+func TestSender_RejectsPrivateAddresses(t *testing.T) {
+	var hit bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+
+	for _, url := range []string{srv.URL, "http://169.254.169.254/latest/meta-data/"} {
+		code, err := webhooks.NewSender().Send(context.Background(), entity.WebhookDelivery{
+			DeliveryID: 1, EventType: entity.EventSyncFailed, Payload: []byte(`{}`), URL: url, Secret: "s",
+		})
+		require.ErrorIs(t, err, safehttp.ErrForbiddenAddress, url)
+		require.Zero(t, code)
+	}
+	require.False(t, hit)
+}
+
+func TestSender_RedirectIsFailure(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://vault:8200/v1/secret", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	code, err := webhooks.NewSender().WithPrivateNetworks().Send(context.Background(), entity.WebhookDelivery{
+		DeliveryID: 1, EventType: entity.EventSyncFailed, Payload: []byte(`{}`), URL: srv.URL, Secret: "s",
+	})
+	require.Error(t, err)
+	require.Equal(t, http.StatusTemporaryRedirect, code)
+}
+
+func TestSign_Stable(t *testing.T) {
+	require.Equal(t, webhooks.Sign("k", 1700000000, []byte("{}")), webhooks.Sign("k", 1700000000, []byte("{}")))
+	require.NotEqual(t, webhooks.Sign("k", 1700000000, []byte("{}")), webhooks.Sign("k2", 1700000000, []byte("{}")))
+}
@@ -2,6 +2,7 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
 )
 
 type AdsListMeta struct {
@@ -23,11 +25,12 @@ type AdsListResponse struct {
 	Meta  AdsListMeta    `json:"meta"`
 }
 
-// @Summary     Список объявлений пользователя
-// @Description Возвращает объявления текущего пользователя с фильтрами, пагинацией и сортировкой.
+// @Summary     Список объявлений рабочего пространства
+// @Description Возвращает объявления активного рабочего пространства с фильтрами, пагинацией и сортировкой.
 // @Tags        Ads
 // @Produce     json
 // @Security    BearerAuth
+// @Param       X-Workspace-ID  header  int64  false  "Рабочее пространство (по умолчанию — личное)"
 // @Param       status     query   string  false  "Фильтр по статусу"        Enums(active,paused,all) default(all)
 // @Param       platform   query   string  false  "Платформа"                Enums(facebook,google)
 // @Param       q          query   string  false  "Подстрока для поиска по названию"
@@ -41,9 +44,8 @@ type AdsListResponse struct {
 // @Failure     500        {object}  map[string]string  "internal error"
 // @Router      /ads [get]
 func (h *Handler) ads(c *gin.Context) {
-	userID, ok := getUserID(c) // ← единый способ извлечь ID из контекста
+	actor, ok := getActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
@@ -93,7 +95,7 @@ func (h *Handler) ads(c *gin.Context) {
 		Sort:     sort,
 	}
 
-	items, total, err := h.adsSvc.List(c.Request.Context(), userID, f)
+	items, total, err := h.adsSvc.List(c.Request.Context(), actor.WorkspaceID, f)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -111,6 +113,69 @@ func (h *Handler) ads(c *gin.Context) {
 	c.JSON(http.StatusOK, resp)
 }
 
+// @Summary     Поставить объявление на паузу
+// @Description Ставит объявление на паузу на рекламной платформе (Google Ads / Meta). Повторный вызов — changed=false.
+// @Tags        Ads
+// @Produce     json
+// @Security    BearerAuth
+// @Param       ad_id  path      int64  true  "ID объявления"
+// @Success     200    {object}  entity.AdStatusResult
+// @Failure     400    {object}  map[string]string  "invalid ad_id"
+// @Failure     401    {object}  map[string]string  "unauthorized"
+// @Failure     403    {object}  map[string]string  "workspace_forbidden (нужна роль admin)"
+// @Failure     404    {object}  map[string]string  "ad_not_found"
+// @Failure     409    {object}  map[string]string  "ad_status_conflict | google_needs_consent"
+// @Failure     422    {object}  map[string]string  "ad_platform_unsupported | ad_change_rejected"
+// @Failure     502    {object}  map[string]string  "ad platform error"
+// @Router      /ads/{ad_id}/pause [post]
+func (h *Handler) adPause(c *gin.Context) { h.setAdStatus(c, entity.AdStatusPaused) }
+
+// @Summary     Включить объявление
+// @Description Возобновляет показ объявления на рекламной платформе (Google Ads / Meta). Повторный вызов — changed=false.
+// @Tags        Ads
+// @Produce     json
+// @Security    BearerAuth
+// @Param       ad_id  path      int64  true  "ID объявления"
+// @Success     200    {object}  entity.AdStatusResult
+// @Failure     400    {object}  map[string]string  "invalid ad_id"
+// @Failure     401    {object}  map[string]string  "unauthorized"
+// @Failure     403    {object}  map[string]string  "workspace_forbidden (нужна роль admin)"
+// @Failure     404    {object}  map[string]string  "ad_not_found"
+// @Failure     409    {object}  map[string]string  "ad_status_conflict | google_needs_consent"
+// @Failure     422    {object}  map[string]string  "ad_platform_unsupported | ad_change_rejected"
+// @Failure     502    {object}  map[string]string  "ad platform error"
+// @Router      /ads/{ad_id}/resume [post]
+func (h *Handler) adResume(c *gin.Context) { h.setAdStatus(c, entity.AdStatusActive) }
+
+func (h *Handler) setAdStatus(c *gin.Context, status string) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	adID, err := strconv.ParseInt(c.Param("ad_id"), 10, 64)
+	if err != nil || adID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ad_id"})
+		return
+	}
+
+	res, err := h.adsSvc.SetWorkspaceStatus(c.Request.Context(), actor.WorkspaceID, actor.UserID, adID, status)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, res)
+	case errors.Is(err, errs.ErrAdNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "ad_not_found"})
+	case errors.Is(err, errs.ErrAdStatusConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "ad_status_conflict"})
+	case errors.Is(err, errs.ErrAdPlatformUnsupported):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "ad_platform_unsupported"})
+	case errors.Is(err, errs.ErrAdMutationRejected):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "ad_change_rejected", "detail": err.Error()})
+	default:
+		// ошибки Google (переподключение, квота, права) — общими кодами, прочие — 502
+		googleError(c, err)
+	}
+}
+
 /* ===== helpers ===== */
 
 const ctxUserKey = "userID"
@@ -0,0 +1,100 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// Тело POST /api/alerts/channels:
+// { "kind": "slack", "target": "https://hooks.slack.com/services/..." }
+// kind: webhook | slack | email; target — URL (webhook, slack) или адрес (email).
+type alertChannelReq struct {
+	Kind   string `json:"kind"   binding:"required"`
+	Target string `json:"target" binding:"required"`
+}
+
+// GET /api/alerts?limit=50
+func (h *Handler) listAlerts(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	limit := mustAtoiDefault(c.Query("limit"), 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	alerts, err := h.alerts.List(c.Request.Context(), actor.WorkspaceID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+}
+
+// GET /api/alerts/channels
+func (h *Handler) listAlertChannels(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	channels, err := h.alerts.Channels(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"channels": channels})
+}
+
+// POST /api/alerts/channels
+func (h *Handler) createAlertChannel(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	var req alertChannelReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ch, err := h.alerts.CreateChannel(c.Request.Context(), actor, entity.AlertChannel{Kind: req.Kind, Target: req.Target})
+	if err != nil {
+		alertError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, ch)
+}
+
+// DELETE /api/alerts/channels/:channel_id
+func (h *Handler) deleteAlertChannel(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	channelID, err := strconv.ParseInt(c.Param("channel_id"), 10, 64)
+	if err != nil || channelID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid channel_id"})
+		return
+	}
+	if err := h.alerts.DeleteChannel(c.Request.Context(), actor.WorkspaceID, channelID); err != nil {
+		alertError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func alertError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrInvalidAlertChannel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errs.ErrAlertChannelNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "alert_channel_not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
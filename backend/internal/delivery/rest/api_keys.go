@@ -0,0 +1,78 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// Тело POST /api/api-keys: { "name": "tracker prod" }. Ключ возвращается только в ответе на создание.
+type apiKeyReq struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// GET /api/api-keys
+func (h *Handler) listAPIKeys(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	keys, err := h.apiKeys.List(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// POST /api/api-keys
+func (h *Handler) createAPIKey(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	var req apiKeyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	k, err := h.apiKeys.Create(c.Request.Context(), actor, req.Name)
+	if err != nil {
+		apiKeyError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, k)
+}
+
+// DELETE /api/api-keys/:key_id — отзыв; запись остаётся в списке с revoked_at.
+func (h *Handler) revokeAPIKey(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	keyID, err := strconv.ParseInt(c.Param("key_id"), 10, 64)
+	if err != nil || keyID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid key_id"})
+		return
+	}
+	if err := h.apiKeys.Revoke(c.Request.Context(), actor.WorkspaceID, keyID); err != nil {
+		apiKeyError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func apiKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrInvalidAPIKey):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errs.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "api_key_not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
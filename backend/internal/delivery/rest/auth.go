@@ -1,7 +1,10 @@
 package rest
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -38,8 +41,10 @@ func (h *Handler) signUp(c *gin.Context) {
 
 	accessToken, refreshToken, err := h.userSvc.SignUp(c.Request.Context(),
 		entity.SignInput{
-			Email:    user.Email,
-			Password: user.Password,
+			Email:     user.Email,
+			Password:  user.Password,
+			UserAgent: c.Request.UserAgent(),
+			IP:        c.ClientIP(),
 		})
 
 	switch err {
@@ -74,7 +79,7 @@ func (h *Handler) signIn(c *gin.Context) {
 
 	acc, ref, err := h.userSvc.SignIn(
 		c.Request.Context(),
-		entity.SignInput{Email: req.Email, Password: req.Password},
+		entity.SignInput{Email: req.Email, Password: req.Password, UserAgent: c.Request.UserAgent(), IP: c.ClientIP()},
 	)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
@@ -88,7 +93,8 @@ func (h *Handler) signIn(c *gin.Context) {
 }
 
 // @Summary     Обновление access-токена по refresh-токену
-// @Description Принимает refresh_token и выдает новую пару токенов (access + refresh).
+// @Description Принимает refresh_token и выдает новую пару токенов (access + refresh) в рамках той же сессии.
+// @Description Старый refresh_token становится недействительным; его повторное предъявление отзывает сессию.
 // @Tags        Auth
 // @Accept      json
 // @Produce     json
@@ -115,3 +121,108 @@ func (h *Handler) refresh(c *gin.Context) {
 		"refresh_token": ref,
 	})
 }
+
+// @Summary     Активные сессии
+// @Description Входы пользователя с разных устройств (семейства refresh-токенов); current — текущая.
+// @Tags        Auth
+// @Produce     json
+// @Security    BearerAuth
+// @Success     200  {object}  map[string]interface{}  "sessions"
+// @Failure     401  {object}  map[string]string
+// @Router      /auth/sessions [get]
+func (h *Handler) sessions(c *gin.Context) {
+	uidVal, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return
+	}
+	userID := uidVal.(int64)
+	current := c.GetInt64("session_id")
+
+	list, err := h.userSvc.Sessions(c.Request.Context(), userID, current)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sessions": list})
+}
+
+// @Summary     Отозвать сессию
+// @Description Выход на конкретном устройстве: refresh-токены сессии больше не принимаются.
+// @Tags        Auth
+// @Security    BearerAuth
+// @Param       session_id  path  int  true  "ID сессии"
+// @Success     204
+// @Failure     400  {object}  map[string]string
+// @Failure     404  {object}  map[string]string  "session_not_found"
+// @Router      /auth/sessions/{session_id} [delete]
+func (h *Handler) revokeSession(c *gin.Context) {
+	uidVal, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return
+	}
+	userID := uidVal.(int64)
+
+	sessionID, err := strconv.ParseInt(c.Param("session_id"), 10, 64)
+	if err != nil || sessionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad session_id"})
+		return
+	}
+
+	err = h.userSvc.RevokeSession(c.Request.Context(), userID, sessionID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, errs.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "session_not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// @Summary     Выход
+// @Description Завершает текущую сессию: refresh-токен сессии и предъявленный access-токен отзываются.
+// @Tags        Auth
+// @Security    BearerAuth
+// @Success     204
+// @Failure     401  {object}  map[string]string
+// @Router      /auth/sign-out [post]
+func (h *Handler) signOut(c *gin.Context) {
+	uidVal, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return
+	}
+	userID := uidVal.(int64)
+	exp, _ := c.Get("token_expires_at")
+	expiresAt, _ := exp.(time.Time)
+
+	if err := h.userSvc.SignOut(c.Request.Context(), userID, c.GetInt64("session_id"), c.GetString("jti"), expiresAt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary     Выход на всех устройствах
+// @Description Отзывает все сессии пользователя и все выданные ему access-токены.
+// @Tags        Auth
+// @Security    BearerAuth
+// @Success     204
+// @Failure     401  {object}  map[string]string
+// @Router      /auth/sign-out-everywhere [post]
+func (h *Handler) signOutEverywhere(c *gin.Context) {
+	uidVal, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return
+	}
+	userID := uidVal.(int64)
+
+	if err := h.userSvc.SignOutEverywhere(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,150 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// Тело POST /api/budgets и PUT /api/budgets/:budget_id:
+// { "account_id": 3, "name": "Main", "period": "monthly", "amount": "3000", "alert_pct": "15", "ad_ids": [87, 88] }
+// period: monthly | daily (по умолчанию monthly); alert_pct по умолчанию 10;
+// ad_ids пусто — бюджет на весь аккаунт. account_id при обновлении не меняется.
+type budgetReq struct {
+	AccountID int64            `json:"account_id"`
+	Name      string           `json:"name"   binding:"required"`
+	Period    string           `json:"period"`
+	Amount    decimal.Decimal  `json:"amount" binding:"required"`
+	AlertPct  *decimal.Decimal `json:"alert_pct"`
+	AdIDs     []int64          `json:"ad_ids"`
+}
+
+func (r budgetReq) entity() entity.Budget {
+	alertPct := decimal.NewFromInt(10)
+	if r.AlertPct != nil {
+		alertPct = *r.AlertPct
+	}
+	return entity.Budget{
+		AccountID: r.AccountID,
+		Name:      r.Name,
+		Period:    r.Period,
+		Amount:    r.Amount,
+		AlertPct:  alertPct,
+		AdIDs:     r.AdIDs,
+	}
+}
+
+// GET /api/budgets
+func (h *Handler) listBudgets(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	budgets, err := h.budgets.List(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budgets": budgets})
+}
+
+// POST /api/budgets
+func (h *Handler) createBudget(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	var req budgetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.AccountID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "account_id is required"})
+		return
+	}
+	b, err := h.budgets.Create(c.Request.Context(), actor, req.entity())
+	if err != nil {
+		budgetError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, b)
+}
+
+// PUT /api/budgets/:budget_id
+func (h *Handler) updateBudget(c *gin.Context) {
+	actor, budgetID, ok := budgetParams(c)
+	if !ok {
+		return
+	}
+	var req budgetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	b, err := h.budgets.Update(c.Request.Context(), actor.WorkspaceID, budgetID, req.entity())
+	if err != nil {
+		budgetError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, b)
+}
+
+// DELETE /api/budgets/:budget_id
+func (h *Handler) deleteBudget(c *gin.Context) {
+	actor, budgetID, ok := budgetParams(c)
+	if !ok {
+		return
+	}
+	if err := h.budgets.Delete(c.Request.Context(), actor.WorkspaceID, budgetID); err != nil {
+		budgetError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/budgets/pacing
+// Расход с начала месяца, ожидаемый при ровном темпе и прогноз на конец месяца по каждому бюджету.
+func (h *Handler) budgetPacing(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	pacing, err := h.budgets.Pacing(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"budgets": pacing})
+}
+
+// budgetParams — актор и budget_id из пути; при ошибке ответ уже записан.
+func budgetParams(c *gin.Context) (entity.WorkspaceMember, int64, bool) {
+	actor, ok := getActor(c)
+	if !ok {
+		return actor, 0, false
+	}
+	budgetID, err := strconv.ParseInt(c.Param("budget_id"), 10, 64)
+	if err != nil || budgetID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid budget_id"})
+		return actor, 0, false
+	}
+	return actor, budgetID, true
+}
+
+func budgetError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrInvalidBudget):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errs.ErrBudgetNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "budget_not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -13,11 +13,15 @@ type clickReq struct {
 	ClickID   string `json:"click_id" binding:"required"`
 	AdID      int64  `json:"ad_id" binding:"required"`
 	ClickedAt int64  `json:"clicked_at" binding:"required"`
+	Gclid     string `json:"gclid,omitempty"`  // Google click id из посадочной ссылки
+	Fbclid    string `json:"fbclid,omitempty"` // Meta click id из посадочной ссылки
 }
 
 // @Summary     Регистрация клика
 // @Description Публичный эндпоинт. Принимает событие клика по объявлению и сохраняет в БД.
-// @Description Поля: click_id (уникально), ad_id (ID объявления), clicked_at (UNIX UTC).
+// @Description Поля: click_id (уникально), ad_id (ID объявления), clicked_at (UNIX UTC),
+// @Description gclid (optional) — Google click id для офлайн-загрузки конверсий в Google Ads,
+// @Description fbclid (optional) — Meta click id для пересылки конверсий в Conversions API.
 // @Tags        Tracking
 // @Accept      json
 // @Produce     json
@@ -38,6 +42,8 @@ func (h *Handler) click(c *gin.Context) {
 		ClickID:   req.ClickID,
 		AdID:      req.AdID,
 		ClickedAt: &req.ClickedAt,
+		Gclid:     stringPtrIf(req.Gclid != "", req.Gclid),
+		Fbclid:    stringPtrIf(req.Fbclid != "", req.Fbclid),
 	}
 
 	id, err := h.clickSvc.Click(c.Request.Context(), clk)
@@ -0,0 +1,233 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// Тело POST /api/clients и PUT /api/clients/:client_id:
+// { "name": "Acme", "account_ids": [3, 5] } — аккаунты рабочего пространства; при обновлении набор перезаписывается.
+type clientReq struct {
+	Name       string  `json:"name" binding:"required"`
+	AccountIDs []int64 `json:"account_ids"`
+}
+
+// Тело POST /api/clients/:client_id/share-links: { "ttl_hours": 168 } (по умолчанию 7 дней, максимум 90)
+type shareLinkReq struct {
+	TTLHours int `json:"ttl_hours"`
+}
+
+// GET /api/clients
+func (h *Handler) listClients(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	list, err := h.clients.List(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"clients": list})
+}
+
+// POST /api/clients
+func (h *Handler) createClient(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	var req clientReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	out, err := h.clients.Create(c.Request.Context(), actor.WorkspaceID, entity.Client{Name: req.Name, AccountIDs: req.AccountIDs})
+	if err != nil {
+		clientError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, out)
+}
+
+// PUT /api/clients/:client_id
+func (h *Handler) updateClient(c *gin.Context) {
+	actor, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+	var req clientReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	out, err := h.clients.Update(c.Request.Context(), actor.WorkspaceID, clientID, entity.Client{Name: req.Name, AccountIDs: req.AccountIDs})
+	if err != nil {
+		clientError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// DELETE /api/clients/:client_id — аккаунты остаются в пространстве, ссылки клиента удаляются
+func (h *Handler) deleteClient(c *gin.Context) {
+	actor, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+	if err := h.clients.Delete(c.Request.Context(), actor.WorkspaceID, clientID); err != nil {
+		clientError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/clients/:client_id/share-links
+func (h *Handler) listShareLinks(c *gin.Context) {
+	actor, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+	links, err := h.clients.Links(c.Request.Context(), actor.WorkspaceID, clientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"share_links": links})
+}
+
+// POST /api/clients/:client_id/share-links — токен и url в ответе показываются один раз
+func (h *Handler) createShareLink(c *gin.Context) {
+	actor, clientID, ok := clientParams(c)
+	if !ok {
+		return
+	}
+	var req shareLinkReq
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if req.TTLHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid ttl_hours"})
+		return
+	}
+	l, err := h.clients.CreateLink(c.Request.Context(), actor, clientID, time.Duration(req.TTLHours)*time.Hour)
+	if err != nil {
+		clientError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, l)
+}
+
+// DELETE /api/clients/:client_id/share-links/:link_id — отзыв; ссылка остаётся в списке и журнале
+func (h *Handler) revokeShareLink(c *gin.Context) {
+	actor, clientID, linkID, ok := shareLinkParams(c)
+	if !ok {
+		return
+	}
+	if err := h.clients.RevokeLink(c.Request.Context(), actor.WorkspaceID, clientID, linkID); err != nil {
+		clientError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/clients/:client_id/share-links/:link_id/access?limit=100
+func (h *Handler) shareLinkAccess(c *gin.Context) {
+	actor, clientID, linkID, ok := shareLinkParams(c)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	list, err := h.clients.AccessLog(c.Request.Context(), actor.WorkspaceID, clientID, linkID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"access": list})
+}
+
+// GET /api/shared/:token/metrics?from=&to=&ad_id= — публичный отчёт клиента (без логина),
+// ответ как у /api/metrics, но только по объявлениям аккаунтов клиента.
+func (h *Handler) sharedMetrics(c *gin.Context) {
+	from, to, err := parseDateRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_date_range"})
+		return
+	}
+	adIDs, err := parseAdIDs(c.Query("ad_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad_ad_id"})
+		return
+	}
+	visitor := entity.ShareAccess{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+	list, err := h.clients.SharedMetrics(c.Request.Context(), c.Param("token"),
+		entity.MetricsFilter{AdIDs: adIDs, From: from, To: to}, visitor)
+	switch {
+	case err == nil:
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, list)
+	case errors.Is(err, errs.ErrInvalidRange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_date_range"})
+	case errors.Is(err, errs.ErrNoAdAccess):
+		c.JSON(http.StatusNotFound, gin.H{"error": "ad_not_found"})
+	default:
+		clientError(c, err)
+	}
+}
+
+// clientParams — актор и client_id из пути; при ошибке ответ уже записан.
+func clientParams(c *gin.Context) (entity.WorkspaceMember, int64, bool) {
+	actor, ok := getActor(c)
+	if !ok {
+		return actor, 0, false
+	}
+	clientID, err := strconv.ParseInt(c.Param("client_id"), 10, 64)
+	if err != nil || clientID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid client_id"})
+		return actor, 0, false
+	}
+	return actor, clientID, true
+}
+
+// shareLinkParams — clientParams плюс link_id из пути.
+func shareLinkParams(c *gin.Context) (entity.WorkspaceMember, int64, int64, bool) {
+	actor, clientID, ok := clientParams(c)
+	if !ok {
+		return actor, 0, 0, false
+	}
+	linkID, err := strconv.ParseInt(c.Param("link_id"), 10, 64)
+	if err != nil || linkID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid link_id"})
+		return actor, 0, 0, false
+	}
+	return actor, clientID, linkID, true
+}
+
+func clientError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrInvalidClient):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errs.ErrClientNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "client_not_found"})
+	case errors.Is(err, errs.ErrShareLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "share_link_not_found"})
+	case errors.Is(err, errs.ErrShareLinkExpired):
+		c.JSON(http.StatusGone, gin.H{"error": "share_link_expired"})
+	case errors.Is(err, errs.ErrShareLinkRevoked):
+		c.JSON(http.StatusGone, gin.H{"error": "share_link_revoked"})
+	case errors.Is(err, errs.ErrShareLinksDisabled):
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "share_links_disabled"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
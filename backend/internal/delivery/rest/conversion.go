@@ -15,6 +15,13 @@ type conversionRequest struct {
 	Revenue     float64 `json:"revenue"       binding:"required,gt=0"` // Прибыль
 	OrderID     *string `json:"order_id,omitempty"`                    // ID заказа в системе магазина
 	ConvertedAt *int64  `json:"converted_at,omitempty"`                // Время совершения заказа
+
+	// Необязательные поля для Meta Conversions API
+	Email   string `json:"email,omitempty"`    // хэшируется SHA-256 перед сохранением
+	Phone   string `json:"phone,omitempty"`    // хэшируется SHA-256 перед сохранением
+	Fbc     string `json:"fbc,omitempty"`      // cookie _fbc
+	Fbp     string `json:"fbp,omitempty"`      // cookie _fbp
+	EventID string `json:"event_id,omitempty"` // тот же event_id, что у браузерного пикселя
 }
 
 // @Summary     Регистрация конверсии (заказа)
@@ -24,6 +31,7 @@ type conversionRequest struct {
 // @Description - revenue  (required, >0) — сумма заказа/ценность конверсии
 // @Description - order_id (optional) — ID заказа в магазине (для дедупликации)
 // @Description - converted_at (optional) — UNIX-таймстамп, когда произошла конверсия
+// @Description - email, phone, fbc, fbp, event_id (optional) — для пересылки в Meta Conversions API
 // @Tags        Tracking
 // @Accept      json
 // @Produce     json
@@ -46,6 +54,11 @@ func (h *Handler) conversion(c *gin.Context) {
 		Revenue:     decimal.NewFromFloat(req.Revenue),
 		OrderID:     req.OrderID,
 		ConvertedAt: req.ConvertedAt,
+		Email:       req.Email,
+		Phone:       req.Phone,
+		Fbc:         req.Fbc,
+		Fbp:         req.Fbp,
+		EventID:     req.EventID,
 	}
 
 	conversionID, err := h.convSvc.Create(c, in)
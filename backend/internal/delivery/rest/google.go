@@ -6,13 +6,19 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
 )
 
 func randBytes(n int) []byte { b := make([]byte, n); _, _ = rand.Read(b); return b }
@@ -127,13 +133,131 @@ func (h *Handler) googleAccounts(c *gin.Context) {
 	}
 	userID := uidVal.(int64)
 
-	ids, err := h.gadsClient.ListAccessibleCustomers(c, userID)
+	accounts, err := h.gadsClient.ListAccessibleAccounts(c, userID)
 	if err != nil {
+		googleError(c, err)
+		return
+	}
+	// accounts — дерево с названиями и MCC; customer_ids — плоский список (старый формат)
+	c.JSON(http.StatusOK, gin.H{
+		"accounts":     accounts,
+		"customer_ids": flattenCustomerIDs(accounts, map[string]bool{}, []string{}),
+	})
+}
+
+// googleError — ответ на ошибку обращения к Google Ads. Проблемы с подключением отдаём
+// отдельными кодами, чтобы UI мог предложить переподключить Google.
+func googleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrGoogleNeedsConsent):
+		c.JSON(http.StatusConflict, gin.H{"error": "google_needs_consent", "reconnect": true})
+	case errors.Is(err, errs.ErrGoogleNotConnected):
+		c.JSON(http.StatusConflict, gin.H{"error": "google_not_connected", "reconnect": true})
+	case errors.Is(err, errs.ErrGoogleQuotaExceeded):
+		var ra interface{ RetryAfter() time.Duration }
+		if errors.As(err, &ra) && ra.RetryAfter() > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(ra.RetryAfter().Seconds()))))
+		}
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "google_quota_exceeded"})
+	case errors.Is(err, errs.ErrGooglePermissionDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": "google_permission_denied"})
+	case errors.Is(err, errs.ErrGoogleCustomerNotEnabled):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "google_customer_not_enabled"})
+	case errors.Is(err, errs.ErrAccountNotLinked):
+		c.JSON(http.StatusNotFound, gin.H{"error": "account_not_linked"})
+	case errors.Is(err, errs.ErrGoogleInvalidDeveloperToken):
+		// проблема конфигурации сервера, а не пользователя
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "google_developer_token_invalid"})
+	default:
 		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
+
+// flattenCustomerIDs — все ID дерева без повторов (клиент может висеть под несколькими MCC)
+func flattenCustomerIDs(accounts []entity.GoogleCustomer, seen map[string]bool, out []string) []string {
+	for _, a := range accounts {
+		if !seen[a.CustomerID] {
+			seen[a.CustomerID] = true
+			out = append(out, a.CustomerID)
+		}
+		out = flattenCustomerIDs(a.Children, seen, out)
+	}
+	return out
+}
+
+// GET /integrations/google/identities
+func (h *Handler) googleIdentities(c *gin.Context) {
+	uidVal, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return
+	}
+	userID := uidVal.(int64)
+
+	list, err := h.tokenVault.ListGoogleIdentities(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"identities": list})
+}
+
+// DELETE /integrations/google/identities/:google_user_id
+// Отключает один Google-логин: токен отзывается у Google, его аккаунты становятся unlinked.
+func (h *Handler) googleDisconnectIdentity(c *gin.Context) {
+	uidVal, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return
+	}
+	userID := uidVal.(int64)
+
+	res, err := h.googleDisc.DisconnectIdentity(c.Request.Context(), userID, c.Param("google_user_id"))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"status": "disconnected", "result": res})
+	case errors.Is(err, errs.ErrGoogleIdentityNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "identity_not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// DELETE /integrations/google/accounts/:customer_id?purge_data=true
+// Отвязывает аккаунт; последний аккаунт логина отключает и сам логин (с отзывом токена).
+func (h *Handler) googleDisconnectAccount(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
 		return
 	}
-	// Минимальный формат (как обсуждали): { "customer_ids": [...] }
-	c.JSON(http.StatusOK, gin.H{"customer_ids": ids})
+
+	purge, _ := strconv.ParseBool(c.DefaultQuery("purge_data", "false"))
+	res, err := h.googleDisc.DisconnectAccount(c.Request.Context(), actor, c.Param("customer_id"), purge)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"status": "unlinked", "result": res})
+	case errors.Is(err, errs.ErrAccountNotLinked):
+		c.JSON(http.StatusNotFound, gin.H{"error": "account_not_linked"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// DELETE /integrations/google/connection?purge_data=true
+// Полностью отключает интеграцию Google: отзыв всех токенов, удаление, отвязка всех аккаунтов.
+func (h *Handler) googleDisconnectAll(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+
+	purge, _ := strconv.ParseBool(c.DefaultQuery("purge_data", "false"))
+	res, err := h.googleDisc.DisconnectAll(c.Request.Context(), actor, purge)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "disconnected", "result": res})
 }
 
 // POST /integrations/google/link-accounts
@@ -141,21 +265,20 @@ type linkReq struct {
 	CustomerIDs []string `json:"customer_ids"`
 }
 
+// Аккаунты привязываются к активному рабочему пространству, синк — токенами текущего пользователя.
 func (h *Handler) googleLinkAccounts(c *gin.Context) {
-	uidVal, ok := c.Get("user_id")
+	actor, ok := getActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
 		return
 	}
-	userID := uidVal.(int64)
 
 	var req linkReq
 	if err := c.ShouldBindJSON(&req); err != nil || len(req.CustomerIDs) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "customer_ids required"})
 		return
 	}
-	if err := h.gadsClient.LinkAccounts(c, userID, req.CustomerIDs); err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": "link failed"})
+	if err := h.gadsClient.LinkAccounts(c, actor.WorkspaceID, actor.UserID, req.CustomerIDs); err != nil {
+		googleError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "linked"})
@@ -169,12 +292,10 @@ type syncReq struct {
 }
 
 func (h *Handler) googleSyncCosts(c *gin.Context) {
-	uidVal, ok := c.Get("user_id")
+	actor, ok := getActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
 		return
 	}
-	userID := uidVal.(int64)
 
 	var req syncReq
 	if err := c.ShouldBindJSON(&req); err != nil || req.CustomerID == "" || req.Date == "" {
@@ -182,9 +303,72 @@ func (h *Handler) googleSyncCosts(c *gin.Context) {
 		return
 	}
 
-	if err := h.googleSync.SyncCostsForDate(c.Request.Context(), userID, req.CustomerID, req.Date); err != nil {
-		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	if err := h.googleSync.SyncCostsForDate(c.Request.Context(), actor.WorkspaceID, req.CustomerID, req.Date); err != nil {
+		googleError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"ok": true})
 }
+
+// PUT /integrations/google/accounts/:customer_id/conversion-action
+// Тело: { "conversion_action": "123456789" | "customers/<cid>/conversionActions/<id>" | "" }
+type conversionActionReq struct {
+	ConversionAction *string `json:"conversion_action"`
+}
+
+func (h *Handler) googleSetConversionAction(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+
+	var req conversionActionReq
+	if err := c.ShouldBindJSON(&req); err != nil || req.ConversionAction == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "conversion_action required"})
+		return
+	}
+
+	err := h.googleConv.SetConversionAction(c.Request.Context(), actor.WorkspaceID, c.Param("customer_id"), *req.ConversionAction)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	case errors.Is(err, errs.ErrInvalidConversionAction):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_conversion_action"})
+	case errors.Is(err, errs.ErrAccountNotLinked):
+		c.JSON(http.StatusNotFound, gin.H{"error": "account_not_linked"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GET /integrations/google/conversions/:conversion_id/upload
+func (h *Handler) googleConversionUpload(c *gin.Context) {
+	uidVal, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no user"})
+		return
+	}
+	userID := uidVal.(int64)
+
+	conversionID, err := strconv.ParseInt(c.Param("conversion_id"), 10, 64)
+	if err != nil || conversionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad conversion_id"})
+		return
+	}
+
+	u, err := h.googleConv.UploadStatus(c.Request.Context(), userID, conversionID)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{
+			"conversion_id": u.ConversionID,
+			"customer_id":   u.CustomerID,
+			"status":        u.Status,
+			"attempts":      u.Attempts,
+			"last_error":    u.LastError,
+		})
+	case errors.Is(err, errs.ErrConversionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "upload_not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
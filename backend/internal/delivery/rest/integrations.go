@@ -0,0 +1,24 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /integrations/status
+// Состояние подключения по платформам: connected | needs_consent | never_connected,
+// число привязанных аккаунтов, последний синк и последняя ошибка.
+func (h *Handler) integrationStatus(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+
+	list, err := h.integrations.Status(c.Request.Context(), actor)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"integrations": list})
+}
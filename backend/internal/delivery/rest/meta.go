@@ -0,0 +1,86 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// PUT /integrations/meta/accounts/:account_id/capi
+// Тело: { "pixel_id": "123", "access_token": "EAAB...", "currency": "USD", "test_event_code": "", "enabled": true }
+type capiSettingsReq struct {
+	PixelID       string `json:"pixel_id"     binding:"required"`
+	AccessToken   string `json:"access_token" binding:"required"`
+	Currency      string `json:"currency"`
+	TestEventCode string `json:"test_event_code"`
+	Enabled       *bool  `json:"enabled"`
+}
+
+func (h *Handler) metaSaveCAPISettings(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+
+	var req capiSettingsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	enabled := req.Enabled == nil || *req.Enabled
+
+	err := h.metaCAPI.SaveSettings(c.Request.Context(), actor.WorkspaceID, c.Param("account_id"), entity.MetaCAPISettings{
+		PixelID:       req.PixelID,
+		AccessToken:   req.AccessToken,
+		Currency:      req.Currency,
+		TestEventCode: req.TestEventCode,
+		Enabled:       enabled,
+	})
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "enabled": enabled})
+	case errors.Is(err, errs.ErrInvalidPixelSettings):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_pixel_settings"})
+	case errors.Is(err, errs.ErrAccountNotLinked):
+		c.JSON(http.StatusNotFound, gin.H{"error": "account_not_linked"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GET /integrations/meta/conversions/:conversion_id/delivery
+func (h *Handler) metaConversionDelivery(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+
+	conversionID, err := strconv.ParseInt(c.Param("conversion_id"), 10, 64)
+	if err != nil || conversionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bad conversion_id"})
+		return
+	}
+
+	ev, err := h.metaCAPI.DeliveryStatus(c.Request.Context(), actor.WorkspaceID, conversionID)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{
+			"conversion_id": ev.ConversionID,
+			"event_id":      ev.EventID,
+			"status":        ev.Status,
+			"attempts":      ev.Attempts,
+			"last_error":    ev.LastError,
+			"fbtrace_id":    ev.FbtraceID,
+			"sent_at":       ev.SentAt,
+		})
+	case errors.Is(err, errs.ErrConversionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "delivery_not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -14,10 +14,11 @@ import (
 )
 
 // @Summary     Получение агрегированных метрик по объявлениям
-// @Description Возвращает суточные метрики (clicks, conversions, revenue, spend, CPA, ROAS) только для объявлений текущего пользователя. Можно фильтровать по диапазону дат и по ad_id.
+// @Description Возвращает суточные метрики (clicks, conversions, revenue, spend, CPA, ROAS) только для объявлений активного рабочего пространства. Можно фильтровать по диапазону дат и по ad_id.
 // @Tags        Analytics
 // @Produce     json
 // @Security    BearerAuth
+// @Param       X-Workspace-ID  header  int64  false  "Рабочее пространство (по умолчанию — личное)"
 // @Param       from   query   string     false  "Дата начала (включительно), формат YYYY-MM-DD"
 // @Param       to     query   string     false  "Дата окончания (включительно), формат YYYY-MM-DD"
 // @Param       ad_id  query   []string   false  "Список ad_id для фильтрации (через запятую), напр. ad_id=123,456"
@@ -28,9 +29,8 @@ import (
 // @Failure     500    {object} map[string]string  "internal error"
 // @Router      /metrics [get]
 func (h *Handler) metrics(c *gin.Context) {
-	userID, ok := c.Get("userID")
+	actor, ok := getActor(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
@@ -57,7 +57,7 @@ func (h *Handler) metrics(c *gin.Context) {
 	}
 
 	// call service
-	list, err := h.metricsSvc.Get(c.Request.Context(), userID.(int64), filter)
+	list, err := h.metricsSvc.Get(c.Request.Context(), actor.WorkspaceID, filter)
 	switch err {
 	case nil:
 		c.JSON(http.StatusOK, list)
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// HeaderAPIKey — ключ серверной интеграции (напр. отправка кликов с бэкенда клиента)
+const HeaderAPIKey = "X-API-Key"
+
+// APIKeys — проверка предъявленного ключа; неизвестный или отозванный — errs.ErrInvalidAPIKey.
+type APIKeys interface {
+	Authenticate(ctx context.Context, key string) (entity.APIKey, error)
+}
+
+// APIKeyAuth — аутентификация по X-API-Key. Ключ действует от имени автора в пространстве ключа:
+// кладёт user_id автора, api_key_id и api_key_workspace_id (его берёт Authz вместо X-Workspace-ID).
+type APIKeyAuth struct {
+	keys APIKeys
+}
+
+func NewAPIKeyAuth(keys APIKeys) *APIKeyAuth { return &APIKeyAuth{keys: keys} }
+
+// Optional — ключ необязателен (POST /api/click), но предъявленный должен быть действителен:
+// вёдра по ключу (KeyAPIKey) заводятся только для проверенных ключей.
+func (a *APIKeyAuth) Optional() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(HeaderAPIKey) == "" {
+			c.Next()
+			return
+		}
+		if a.authenticate(c) {
+			c.Next()
+		}
+	}
+}
+
+// OrJWT — приватные маршруты: с X-API-Key запрос идёт от автора ключа, без него — проверка
+// access-токена middleware jwt.
+func (a *APIKeyAuth) OrJWT(jwt gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader(HeaderAPIKey) == "" {
+			jwt(c)
+			return
+		}
+		if a.authenticate(c) {
+			c.Next()
+		}
+	}
+}
+
+// authenticate — false: ответ уже записан.
+func (a *APIKeyAuth) authenticate(c *gin.Context) bool {
+	k, err := a.keys.Authenticate(c.Request.Context(), c.GetHeader(HeaderAPIKey))
+	if errors.Is(err, errs.ErrInvalidAPIKey) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid api key"})
+		return false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "api key check unavailable"})
+		return false
+	}
+	c.Set("userID", k.UserID)
+	c.Set("user_id", k.UserID)
+	c.Set("api_key_id", k.KeyID)
+	c.Set("api_key_workspace_id", k.WorkspaceID)
+	return true
+}
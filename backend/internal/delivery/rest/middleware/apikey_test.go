@@ -0,0 +1,112 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	mw "github.com/berezovskyivalerii/adsieve/internal/delivery/rest/middleware"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ratelimit"
+)
+
+type stubAPIKeys map[string]entity.APIKey
+
+func (s stubAPIKeys) Authenticate(_ context.Context, key string) (entity.APIKey, error) {
+	k, ok := s[key]
+	if !ok {
+		return entity.APIKey{}, errs.ErrInvalidAPIKey
+	}
+	return k, nil
+}
+
+func TestAPIKeyAuth_OptionalPerKeyBucket(t *testing.T) {
+	auth := mw.NewAPIKeyAuth(stubAPIKeys{
+		"ask_good": {KeyID: 5, WorkspaceID: 3, UserID: 7},
+		"ask_next": {KeyID: 6, WorkspaceID: 3, UserID: 7},
+	})
+	rl := mw.NewRateLimiter(ratelimit.NewMemory())
+
+	r := gin.New()
+	r.POST("/click", auth.Optional(),
+		rl.Limit(mw.RatePolicy{Name: "click_key", Limit: ratelimit.Limit{Burst: 1, Per: time.Minute}, Key: mw.KeyAPIKey}),
+		func(c *gin.Context) { c.Status(http.StatusAccepted) })
+
+	do := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/click", nil)
+		if key != "" {
+			req.Header.Set(mw.HeaderAPIKey, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusAccepted, do(""))
+	require.Equal(t, http.StatusAccepted, do(""))               // без ключа — только политики по IP
+	require.Equal(t, http.StatusUnauthorized, do("ask_forged")) // непроверенный ключ не получает ведра
+	require.Equal(t, http.StatusAccepted, do("ask_good"))       // ведро по key_id
+	require.Equal(t, http.StatusTooManyRequests, do("ask_good"))
+	require.Equal(t, http.StatusAccepted, do("ask_next")) // другой ключ — своё ведро
+}
+
+func TestAPIKeyAuth_OrJWT(t *testing.T) {
+	auth := mw.NewAPIKeyAuth(stubAPIKeys{"ask_good": {KeyID: 5, WorkspaceID: 3, UserID: 7}})
+	jwt := func(c *gin.Context) { c.AbortWithStatus(http.StatusTeapot) }
+
+	r := gin.New()
+	r.GET("/api", auth.OrJWT(jwt), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user_id": c.GetInt64("user_id"), "ws": c.GetInt64("api_key_workspace_id")})
+	})
+
+	do := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		if key != "" {
+			req.Header.Set(mw.HeaderAPIKey, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusTeapot, do("").Code)
+	require.Equal(t, http.StatusUnauthorized, do("ask_bad").Code)
+	w := do("ask_good")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"user_id":7,"ws":3}`, w.Body.String())
+}
+
+func TestKeyEmail_BucketPerAccount(t *testing.T) {
+	rl := mw.NewRateLimiter(ratelimit.NewMemory())
+
+	r := gin.New()
+	r.POST("/sign-in",
+		rl.Limit(mw.RatePolicy{Name: "sign_in", Limit: ratelimit.Limit{Burst: 1, Per: time.Minute}, Key: mw.KeyEmail}),
+		func(c *gin.Context) {
+			var body struct{ Email, Password string }
+			require.NoError(t, c.ShouldBindJSON(&body)) // тело восстановлено для хендлера
+			c.String(http.StatusOK, body.Password)
+		})
+
+	do := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/sign-in", strings.NewReader(body))
+		req.RemoteAddr = "203.0.113.5:5555"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(`{"email":"Ann@Example.com","password":"p1"}`)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "p1", w.Body.String())
+	// тот же аккаунт с другим регистром и пробелами — то же ведро
+	require.Equal(t, http.StatusTooManyRequests, do(`{"email":" ann@example.COM ","password":"p2"}`).Code)
+	require.Equal(t, http.StatusOK, do(`{"email":"bob@example.com","password":"p3"}`).Code)
+}
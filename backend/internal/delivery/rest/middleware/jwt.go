@@ -1,10 +1,12 @@
 package middleware
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -12,11 +14,41 @@ import (
 
 type Claims struct {
 	jwt.RegisteredClaims
+	SessionID int64 `json:"sid,omitempty"` // сессия, из которой выдан токен
 }
 
-type JWTAuth struct{ secret []byte }
+// Denylist — отозванные, но ещё не истёкшие access-токены (выход, выход отовсюду)
+type Denylist interface {
+	IsRevoked(ctx context.Context, jti string, userID, sessionID int64, issuedAt time.Time) (bool, error)
+}
+
+type JWTAuth struct {
+	keyfunc  jwt.Keyfunc
+	methods  []string
+	denylist Denylist
+}
 
-func NewJWTAuth(secret []byte) *JWTAuth { return &JWTAuth{secret: secret} }
+// NewJWTAuth — проверка HS256 общим секретом.
+func NewJWTAuth(secret []byte) *JWTAuth {
+	return NewJWTAuthWithKeys(func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return secret, nil
+	}, []string{jwt.SigningMethodHS256.Alg()})
+}
+
+// NewJWTAuthWithKeys — проверка набором ключей (выбор по kid, см. jwtkeys.KeySet);
+// methods — допустимые алгоритмы подписи.
+func NewJWTAuthWithKeys(keyfunc jwt.Keyfunc, methods []string) *JWTAuth {
+	return &JWTAuth{keyfunc: keyfunc, methods: methods}
+}
+
+// WithDenylist включает проверку отзыва токена на каждом запросе.
+func (a *JWTAuth) WithDenylist(d Denylist) *JWTAuth {
+	a.denylist = d
+	return a
+}
 
 func (a *JWTAuth) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -36,12 +68,7 @@ func (a *JWTAuth) Middleware() gin.HandlerFunc {
 
 		// 2. Парсим токен
 		claims := Claims{}
-		token, err := jwt.ParseWithClaims(raw, &claims, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return a.secret, nil
-		})
+		token, err := jwt.ParseWithClaims(raw, &claims, a.keyfunc, jwt.WithValidMethods(a.methods))
 		if err != nil {
 			// протухший токен приходит сюда как jwt.ErrTokenExpired
 			if errors.Is(err, jwt.ErrTokenExpired) {
@@ -62,9 +89,31 @@ func (a *JWTAuth) Middleware() gin.HandlerFunc {
 			return
 		}
 
-		// 3. Кладём userID в контекст и пропускаем дальше
+		// 3. Не отозван ли токен (sign-out / sign-out-everywhere)
+		if a.denylist != nil {
+			var issuedAt time.Time
+			if claims.IssuedAt != nil {
+				issuedAt = claims.IssuedAt.Time
+			}
+			revoked, err := a.denylist.IsRevoked(c.Request.Context(), claims.ID, userID, claims.SessionID, issuedAt)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "token check unavailable"})
+				return
+			}
+			if revoked {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				return
+			}
+		}
+
+		// 4. Кладём userID в контекст и пропускаем дальше
 		c.Set("userID", userID)
 		c.Set("user_id", userID)
+		c.Set("session_id", claims.SessionID)
+		c.Set("jti", claims.ID)
+		if claims.ExpiresAt != nil {
+			c.Set("token_expires_at", claims.ExpiresAt.Time)
+		}
 		c.Next()
 	}
 }
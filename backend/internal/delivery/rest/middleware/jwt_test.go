@@ -1,6 +1,7 @@
 package middleware_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -40,3 +41,48 @@ func TestJWTMiddleware_ValidToken(t *testing.T) {
 	require.Equal(t, http.StatusOK, w.Code)
 	require.Equal(t, "pong", w.Body.String())
 }
+
+type fakeDenylist struct {
+	revoked map[string]bool
+	gotSID  int64
+}
+
+func (d *fakeDenylist) IsRevoked(_ context.Context, jti string, _, sessionID int64, _ time.Time) (bool, error) {
+	d.gotSID = sessionID
+	return d.revoked[jti], nil
+}
+
+func TestJWTMiddleware_RevokedToken(t *testing.T) {
+	secret := []byte("test-secret")
+	dl := &fakeDenylist{revoked: map[string]bool{"jti-revoked": true}}
+	j := mw.NewJWTAuth(secret).WithDenylist(dl)
+
+	sign := func(jti string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, mw.Claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        jti,
+				Subject:   "42",
+				IssuedAt:  jwt.NewNumericDate(time.Now()),
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+			SessionID: 7,
+		})
+		raw, _ := token.SignedString(secret)
+		return raw
+	}
+
+	r := gin.New()
+	r.Use(j.Middleware())
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("jti"))
+	})
+
+	for jti, want := range map[string]int{"jti-ok": http.StatusOK, "jti-revoked": http.StatusUnauthorized} {
+		req := httptest.NewRequest("GET", "/ping", nil)
+		req.Header.Set("Authorization", "Bearer "+sign(jti))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, want, w.Code, jti)
+		require.Equal(t, int64(7), dl.gotSID)
+	}
+}
@@ -0,0 +1,100 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	mw "github.com/berezovskyivalerii/adsieve/internal/delivery/rest/middleware"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ratelimit"
+)
+
+func TestRateLimiter_Limit(t *testing.T) {
+	rl := mw.NewRateLimiter(ratelimit.NewMemory())
+
+	r := gin.New()
+	r.POST("/click",
+		rl.Limit(mw.RatePolicy{Name: "click", Limit: ratelimit.Limit{Burst: 2, Per: time.Minute}, Key: mw.KeyIP}),
+		func(c *gin.Context) { c.Status(http.StatusAccepted) })
+
+	do := func(ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/click", nil)
+		req.RemoteAddr = ip + ":5555"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("203.0.113.5")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, "2", w.Header().Get("RateLimit-Limit"))
+	require.Equal(t, "1", w.Header().Get("RateLimit-Remaining"))
+	require.Equal(t, "30", w.Header().Get("RateLimit-Reset"))
+	require.Equal(t, "2;w=60", w.Header().Get("RateLimit-Policy"))
+
+	require.Equal(t, http.StatusAccepted, do("203.0.113.5").Code)
+
+	w = do("203.0.113.5")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "30", w.Header().Get("Retry-After"))
+	require.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	require.JSONEq(t, `{"error":"rate_limited","policy":"click"}`, w.Body.String())
+
+	// у другого адреса своё ведро
+	require.Equal(t, http.StatusAccepted, do("198.51.100.7").Code)
+}
+
+func TestRateLimiter_PerPrincipal(t *testing.T) {
+	rl := mw.NewRateLimiter(ratelimit.NewMemory())
+	one := ratelimit.Limit{Burst: 1, Per: time.Minute}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if u := c.GetHeader("X-Test-User"); u != "" {
+			c.Set("user_id", int64(len(u)))
+		}
+	})
+	r.GET("/api",
+		rl.Limit(mw.RatePolicy{Name: "api", Limit: one, Key: mw.KeyUser}),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	do := func(user string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		if user != "" {
+			req.Header.Set("X-Test-User", user)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusOK, do("a"))
+	require.Equal(t, http.StatusTooManyRequests, do("a"))
+	require.Equal(t, http.StatusOK, do("bb")) // другой пользователь — своё ведро
+	require.Equal(t, http.StatusOK, do(""))   // без пользователя политика не применяется
+	require.Equal(t, http.StatusOK, do(""))
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit, time.Time) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("db down")
+}
+
+func TestRateLimiter_FailOpen(t *testing.T) {
+	rl := mw.NewRateLimiter(failingStore{})
+	r := gin.New()
+	r.GET("/x", rl.Limit(mw.RatePolicy{Name: "x", Limit: ratelimit.Limit{Burst: 1, Per: time.Second}, Key: mw.KeyIP}),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/x", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
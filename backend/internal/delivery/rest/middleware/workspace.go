@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// HeaderWorkspace — активное рабочее пространство запроса; без заголовка — пространство по умолчанию.
+const HeaderWorkspace = "X-Workspace-ID"
+
+// Workspaces — членство пользователя в пространстве (workspaceID = 0 — по умолчанию).
+// Не участник — errs.ErrWorkspaceNotFound.
+type Workspaces interface {
+	Resolve(ctx context.Context, userID, workspaceID int64) (entity.WorkspaceMember, error)
+}
+
+// Authz — проверка роли в рабочем пространстве. Ставится после JWT-middleware.
+type Authz struct {
+	workspaces Workspaces
+}
+
+func NewAuthz(ws Workspaces) *Authz { return &Authz{workspaces: ws} }
+
+// Require пропускает участника активного пространства с ролью не ниже min и кладёт
+// workspace_id / workspace_role в контекст. Членство определяется один раз за запрос.
+func (a *Authz) Require(min string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("workspace_role")
+		if role == "" {
+			m, ok := a.resolve(c)
+			if !ok {
+				return
+			}
+			role = m.Role
+		}
+		if !entity.RoleAtLeast(role, min) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "workspace_forbidden", "required_role": min})
+			return
+		}
+		c.Next()
+	}
+}
+
+func (a *Authz) resolve(c *gin.Context) (entity.WorkspaceMember, bool) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return entity.WorkspaceMember{}, false
+	}
+	var workspaceID int64
+	if v := c.GetHeader(HeaderWorkspace); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id <= 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid " + HeaderWorkspace})
+			return entity.WorkspaceMember{}, false
+		}
+		workspaceID = id
+	}
+	// API-ключ привязан к своему пространству: другое в заголовке — отказ
+	if keyWS := c.GetInt64("api_key_workspace_id"); keyWS > 0 {
+		if workspaceID != 0 && workspaceID != keyWS {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "workspace_forbidden"})
+			return entity.WorkspaceMember{}, false
+		}
+		workspaceID = keyWS
+	}
+
+	m, err := a.workspaces.Resolve(c.Request.Context(), userID.(int64), workspaceID)
+	if errors.Is(err, errs.ErrWorkspaceNotFound) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "workspace_forbidden"})
+		return m, false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "workspace check unavailable"})
+		return m, false
+	}
+	c.Set("workspace_id", m.WorkspaceID)
+	c.Set("workspace_role", m.Role)
+	return m, true
+}
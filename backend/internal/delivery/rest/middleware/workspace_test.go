@@ -0,0 +1,72 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+
+	mw "github.com/berezovskyivalerii/adsieve/internal/delivery/rest/middleware"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// fakeWorkspaces: пользователь 42 — владелец личного пространства 1 и аналитик в пространстве 2
+type fakeWorkspaces struct{ calls int }
+
+func (f *fakeWorkspaces) Resolve(_ context.Context, userID, workspaceID int64) (entity.WorkspaceMember, error) {
+	f.calls++
+	roles := map[int64]string{0: entity.RoleOwner, 1: entity.RoleOwner, 2: entity.RoleAnalyst}
+	role, ok := roles[workspaceID]
+	if userID != 42 || !ok {
+		return entity.WorkspaceMember{}, errs.ErrWorkspaceNotFound
+	}
+	if workspaceID == 0 {
+		workspaceID = 1
+	}
+	return entity.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+func TestAuthz_Require(t *testing.T) {
+	ws := &fakeWorkspaces{}
+	authz := mw.NewAuthz(ws)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", int64(42)) })
+	r.Use(authz.Require(entity.RoleReadOnly))
+	r.GET("/ads", func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.FormatInt(c.GetInt64("workspace_id"), 10))
+	})
+	r.POST("/ads/87/pause", authz.Require(entity.RoleAdmin), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		method, path, header string
+		want                 int
+		body                 string
+	}{
+		{"GET", "/ads", "", http.StatusOK, "1"},  // пространство по умолчанию
+		{"GET", "/ads", "2", http.StatusOK, "2"}, // аналитик может читать
+		{"POST", "/ads/87/pause", "2", http.StatusForbidden, ""},
+		{"POST", "/ads/87/pause", "1", http.StatusOK, ""},
+		{"GET", "/ads", "3", http.StatusForbidden, ""}, // не участник
+		{"GET", "/ads", "abc", http.StatusBadRequest, ""},
+	}
+	for _, tc := range cases {
+		ws.calls = 0
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		if tc.header != "" {
+			req.Header.Set(mw.HeaderWorkspace, tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, tc.want, w.Code, "%s %s ws=%s", tc.method, tc.path, tc.header)
+		if tc.body != "" {
+			require.Equal(t, tc.body, w.Body.String())
+		}
+		require.LessOrEqual(t, ws.calls, 1, "membership resolved once per request")
+	}
+}
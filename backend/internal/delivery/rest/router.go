@@ -2,7 +2,9 @@ package rest
 
 import (
 	"context"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -11,11 +13,108 @@ import (
 	"golang.org/x/oauth2"
 
 	mw "github.com/berezovskyivalerii/adsieve/internal/delivery/rest/middleware"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
 	"github.com/berezovskyivalerii/adsieve/internal/domain/ports"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/jwtkeys"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ratelimit"
 )
 
 type GoogleSync interface {
-	SyncCostsForDate(ctx context.Context, userID int64, customerID, date string) error
+	SyncCostsForDate(ctx context.Context, workspaceID int64, customerID, date string) error
+}
+
+type GoogleConversions interface {
+	SetConversionAction(ctx context.Context, workspaceID int64, customerID, conversionAction string) error
+	UploadStatus(ctx context.Context, userID, conversionID int64) (entity.GoogleConversionUpload, error)
+}
+
+type GoogleDisconnect interface {
+	DisconnectAccount(ctx context.Context, actor entity.WorkspaceMember, customerID string, purge bool) (entity.GoogleDisconnectResult, error)
+	DisconnectIdentity(ctx context.Context, userID int64, googleUserID string) (entity.GoogleDisconnectResult, error)
+	DisconnectAll(ctx context.Context, actor entity.WorkspaceMember, purge bool) (entity.GoogleDisconnectResult, error)
+}
+
+type IntegrationStatus interface {
+	Status(ctx context.Context, actor entity.WorkspaceMember) ([]entity.IntegrationStatus, error)
+}
+
+type MetaCAPI interface {
+	SaveSettings(ctx context.Context, workspaceID int64, externalAccountID string, s entity.MetaCAPISettings) error
+	DeliveryStatus(ctx context.Context, workspaceID, conversionID int64) (entity.MetaCAPIEvent, error)
+}
+
+type Rules interface {
+	List(ctx context.Context, workspaceID int64) ([]entity.Rule, error)
+	Create(ctx context.Context, actor entity.WorkspaceMember, r entity.Rule) (entity.Rule, error)
+	Update(ctx context.Context, actor entity.WorkspaceMember, ruleID int64, r entity.Rule) (entity.Rule, error)
+	Delete(ctx context.Context, workspaceID, ruleID int64) error
+	DryRun(ctx context.Context, workspaceID, ruleID int64) ([]entity.RuleMatch, error)
+	Executions(ctx context.Context, workspaceID, ruleID int64, limit int) ([]entity.RuleExecution, error)
+}
+
+type Alerts interface {
+	List(ctx context.Context, workspaceID int64, limit int) ([]entity.Alert, error)
+	Channels(ctx context.Context, workspaceID int64) ([]entity.AlertChannel, error)
+	CreateChannel(ctx context.Context, actor entity.WorkspaceMember, ch entity.AlertChannel) (entity.AlertChannel, error)
+	DeleteChannel(ctx context.Context, workspaceID, channelID int64) error
+}
+
+type Budgets interface {
+	List(ctx context.Context, workspaceID int64) ([]entity.Budget, error)
+	Create(ctx context.Context, actor entity.WorkspaceMember, b entity.Budget) (entity.Budget, error)
+	Update(ctx context.Context, workspaceID, budgetID int64, b entity.Budget) (entity.Budget, error)
+	Delete(ctx context.Context, workspaceID, budgetID int64) error
+	Pacing(ctx context.Context, workspaceID int64) ([]entity.BudgetPacing, error)
+}
+
+type Webhooks interface {
+	List(ctx context.Context, workspaceID int64) ([]entity.WebhookSubscription, error)
+	Create(ctx context.Context, actor entity.WorkspaceMember, s entity.WebhookSubscription) (entity.WebhookSubscription, error)
+	Delete(ctx context.Context, workspaceID, subscriptionID int64) error
+	Deliveries(ctx context.Context, workspaceID, subscriptionID int64, status string, limit int) ([]entity.WebhookDelivery, error)
+	Replay(ctx context.Context, workspaceID, subscriptionID, deliveryID int64) (int64, error)
+}
+
+type APIKeys interface {
+	mw.APIKeys
+	List(ctx context.Context, workspaceID int64) ([]entity.APIKey, error)
+	Create(ctx context.Context, actor entity.WorkspaceMember, name string) (entity.APIKey, error)
+	Revoke(ctx context.Context, workspaceID, keyID int64) error
+}
+
+type Workspaces interface {
+	mw.Workspaces
+	List(ctx context.Context, userID int64) ([]entity.Workspace, error)
+	Create(ctx context.Context, userID int64, name string) (entity.Workspace, error)
+	Members(ctx context.Context, workspaceID int64) ([]entity.WorkspaceMember, error)
+	SetRole(ctx context.Context, actor entity.WorkspaceMember, userID int64, role string) error
+	RemoveMember(ctx context.Context, actor entity.WorkspaceMember, userID int64) error
+	Invitations(ctx context.Context, workspaceID int64) ([]entity.WorkspaceInvitation, error)
+	Invite(ctx context.Context, actor entity.WorkspaceMember, email, role string) (entity.WorkspaceInvitation, error)
+	RevokeInvitation(ctx context.Context, workspaceID, invitationID int64) error
+	Accept(ctx context.Context, userID int64, token string) (entity.WorkspaceMember, error)
+}
+
+type Clients interface {
+	List(ctx context.Context, workspaceID int64) ([]entity.Client, error)
+	Create(ctx context.Context, workspaceID int64, cl entity.Client) (entity.Client, error)
+	Update(ctx context.Context, workspaceID, clientID int64, cl entity.Client) (entity.Client, error)
+	Delete(ctx context.Context, workspaceID, clientID int64) error
+	CreateLink(ctx context.Context, actor entity.WorkspaceMember, clientID int64, ttl time.Duration) (entity.ShareLink, error)
+	Links(ctx context.Context, workspaceID, clientID int64) ([]entity.ShareLink, error)
+	RevokeLink(ctx context.Context, workspaceID, clientID, linkID int64) error
+	AccessLog(ctx context.Context, workspaceID, clientID, linkID int64, limit int) ([]entity.ShareAccess, error)
+	SharedMetrics(ctx context.Context, token string, f entity.MetricsFilter, visitor entity.ShareAccess) ([]entity.DailyMetricDTO, error)
+}
+
+// RateLimits — политики token bucket по группам маршрутов; нулевой Limit — без ограничения.
+type RateLimits struct {
+	Click  ratelimit.Limit // POST /api/click — по IP
+	Auth   ratelimit.Limit // sign-up, sign-in, refresh, OAuth callback — по IP
+	SignIn ratelimit.Limit // sign-in — по email аккаунта (вдобавок к IP)
+	Shared ratelimit.Limit // публичные ссылки /api/shared/:token — по IP
+	API    ratelimit.Limit // приватные /api/* и /integrations/* — по пользователю
+	APIKey ratelimit.Limit // /api/click и приватные маршруты с X-API-Key — по ключу (вдобавок)
 }
 
 type Handler struct {
@@ -32,6 +131,28 @@ type Handler struct {
 	oauthCfg    *oauth2.Config
 
 	googleSync GoogleSync // +++
+	googleConv GoogleConversions
+	googleDisc GoogleDisconnect
+
+	// интеграции Meta
+	metaCAPI MetaCAPI
+
+	integrations IntegrationStatus
+
+	rules    Rules
+	alerts   Alerts
+	budgets  Budgets
+	webhooks Webhooks
+
+	workspaces Workspaces
+	clients    Clients
+	apiKeys    APIKeys
+
+	denylist mw.Denylist
+
+	rateStore      ratelimit.Store
+	rateLimits     RateLimits
+	trustedProxies []string
 }
 
 func NewHandler(
@@ -45,6 +166,10 @@ func NewHandler(
 	gadsClient ports.GoogleAdsClient,
 	oauthCfg *oauth2.Config,
 	googleSync GoogleSync, // +++
+	googleConv GoogleConversions,
+	metaCAPI MetaCAPI,
+	googleDisc GoogleDisconnect,
+	integrations IntegrationStatus,
 ) *Handler {
 	return &Handler{
 		userSvc:    userSvc,
@@ -59,20 +184,123 @@ func NewHandler(
 		oauthCfg:    oauthCfg,
 
 		googleSync: googleSync, // +++
+		googleConv: googleConv,
+		googleDisc: googleDisc,
+
+		metaCAPI: metaCAPI,
+
+		integrations: integrations,
 	}
 }
 
-func (h *Handler) Router(jwtSecret []byte) http.Handler {
+// WithRules включает /api/rules (sieve rules).
+func (h *Handler) WithRules(r Rules) *Handler {
+	h.rules = r
+	return h
+}
+
+// WithAlerts включает /api/alerts (алерты и каналы доставки).
+func (h *Handler) WithAlerts(a Alerts) *Handler {
+	h.alerts = a
+	return h
+}
+
+// WithBudgets включает /api/budgets (бюджеты и пейсинг).
+func (h *Handler) WithBudgets(b Budgets) *Handler {
+	h.budgets = b
+	return h
+}
+
+// WithWebhooks включает /api/webhooks (подписки на исходящие события).
+func (h *Handler) WithWebhooks(w Webhooks) *Handler {
+	h.webhooks = w
+	return h
+}
+
+// WithWorkspaces включает рабочие пространства: /api/workspaces, участники, приглашения
+// и проверку роли (authz-middleware) на приватных маршрутах.
+func (h *Handler) WithWorkspaces(w Workspaces) *Handler {
+	h.workspaces = w
+	return h
+}
+
+// WithClients включает /api/clients (клиенты агентства) и публичные ссылки /api/shared/:token.
+func (h *Handler) WithClients(cl Clients) *Handler {
+	h.clients = cl
+	return h
+}
+
+// WithAPIKeys включает /api/api-keys и аутентификацию по X-API-Key (клики и приватные маршруты).
+func (h *Handler) WithAPIKeys(k APIKeys) *Handler {
+	h.apiKeys = k
+	return h
+}
+
+// WithAccessDenylist включает проверку отозванных access-токенов в JWT-middleware.
+func (h *Handler) WithAccessDenylist(d mw.Denylist) *Handler {
+	h.denylist = d
+	return h
+}
+
+// WithRateLimits включает ограничение частоты запросов (заголовки RateLimit-*, 429 + Retry-After).
+func (h *Handler) WithRateLimits(store ratelimit.Store, limits RateLimits) *Handler {
+	h.rateStore, h.rateLimits = store, limits
+	return h
+}
+
+// WithTrustedProxies — прокси (IP или CIDR), чьему X-Forwarded-For верить при определении IP клиента.
+// Пустой список — не доверять никому: IP клиента — адрес соединения.
+func (h *Handler) WithTrustedProxies(proxies []string) *Handler {
+	h.trustedProxies = proxies
+	return h
+}
+
+func (h *Handler) Router(keys *jwtkeys.KeySet) http.Handler {
 	r := gin.New()
+	// иначе gin доверяет X-Forwarded-For от любого источника, и лимиты по IP обходятся подменой заголовка
+	if err := r.SetTrustedProxies(h.trustedProxies); err != nil {
+		log.Printf("trusted proxies: %v", err)
+	}
 	config := cors.DefaultConfig()
-    config.AllowOrigins = []string{"http://localhost:5173"} 
-    config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-    config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization"}
-    config.AllowCredentials = true
+	config.AllowOrigins = []string{"http://localhost:5173"}
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Authorization", mw.HeaderWorkspace, mw.HeaderAPIKey}
+	config.ExposeHeaders = []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"}
+	config.AllowCredentials = true
 
 	r.Use(cors.New(config))
 	r.Use(gin.Logger(), gin.Recovery())
-	jwtAuth := mw.NewJWTAuth(jwtSecret)
+	jwtAuth := mw.NewJWTAuthWithKeys(keys.Keyfunc, keys.Methods())
+	if h.denylist != nil {
+		jwtAuth.WithDenylist(h.denylist)
+	}
+	// role — минимальная роль в активном рабочем пространстве (без WithWorkspaces проверок нет)
+	role := func(min string) gin.HandlerFunc { return func(c *gin.Context) { c.Next() } }
+	if h.workspaces != nil {
+		role = mw.NewAuthz(h.workspaces).Require
+	}
+
+	// limit — token bucket по политике (без WithRateLimits ограничений нет)
+	limit := func(p mw.RatePolicy) gin.HandlerFunc { return func(c *gin.Context) { c.Next() } }
+	if h.rateStore != nil {
+		limit = mw.NewRateLimiter(h.rateStore).Limit
+	}
+	authLimit := limit(mw.RatePolicy{Name: "auth", Limit: h.rateLimits.Auth, Key: mw.KeyIP})
+	apiLimit := limit(mw.RatePolicy{Name: "api", Limit: h.rateLimits.API, Key: mw.KeyUser})
+	keyLimit := limit(mw.RatePolicy{Name: "api_key", Limit: h.rateLimits.APIKey, Key: mw.KeyAPIKey})
+
+	// authn — access-токен или, с WithAPIKeys, X-API-Key (от автора ключа в его пространстве)
+	authn, clickKey := jwtAuth.Middleware(), func(c *gin.Context) { c.Next() }
+	if h.apiKeys != nil {
+		keyAuth := mw.NewAPIKeyAuth(h.apiKeys)
+		authn, clickKey = keyAuth.OrJWT(authn), keyAuth.Optional()
+	}
+
+	// публичные ключи проверки access-токенов
+	r.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	})
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/swagger/doc.json")))
 	r.GET("/api/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/api/swagger/doc.json")))
@@ -81,29 +309,123 @@ func (h *Handler) Router(jwtSecret []byte) http.Handler {
 	{
 		auth := api.Group("/auth")
 		{
-			auth.POST("/sign-up", h.signUp)
-			auth.POST("/sign-in", h.signIn)
-			auth.POST("/refresh", h.refresh)
+			auth.POST("/sign-up", authLimit, h.signUp)
+			auth.POST("/sign-in", authLimit,
+				limit(mw.RatePolicy{Name: "sign_in", Limit: h.rateLimits.SignIn, Key: mw.KeyEmail}),
+				h.signIn)
+			auth.POST("/refresh", authLimit, h.refresh)
+			auth.GET("/sessions", jwtAuth.Middleware(), h.sessions)
+			auth.DELETE("/sessions/:session_id", jwtAuth.Middleware(), h.revokeSession)
+			auth.POST("/sign-out", jwtAuth.Middleware(), h.signOut)
+			auth.POST("/sign-out-everywhere", jwtAuth.Middleware(), h.signOutEverywhere)
+		}
+		api.POST("/click",
+			limit(mw.RatePolicy{Name: "click", Limit: h.rateLimits.Click, Key: mw.KeyIP}),
+			clickKey, keyLimit,
+			h.click)
+		if h.clients != nil {
+			// public, доступ по подписанному токену
+			api.GET("/shared/:token/metrics",
+				limit(mw.RatePolicy{Name: "shared", Limit: h.rateLimits.Shared, Key: mw.KeyIP}),
+				h.sharedMetrics)
 		}
-		api.POST("/click", h.click)
 
 		private := api.Group("/")
-		private.Use(jwtAuth.Middleware())
+		private.Use(authn, apiLimit, keyLimit, role(entity.RoleReadOnly))
 		{
-			private.POST("/conversion", h.conversion)
+			private.POST("/conversion", role(entity.RoleAnalyst), h.conversion)
 			private.GET("/metrics", h.metrics)
 			private.GET("/ads", h.ads)
+			private.POST("/ads/:ad_id/pause", role(entity.RoleAdmin), h.adPause)
+			private.POST("/ads/:ad_id/resume", role(entity.RoleAdmin), h.adResume)
+
+			if h.workspaces != nil {
+				private.GET("/workspaces", h.listWorkspaces)
+				private.POST("/workspaces", h.createWorkspace)
+				private.POST("/workspaces/invitations/accept", h.acceptInvitation)
+				private.GET("/workspace/members", h.listMembers)
+				private.PUT("/workspace/members/:user_id", role(entity.RoleAdmin), h.setMemberRole)
+				private.DELETE("/workspace/members/:user_id", h.removeMember) // права — в сервисе (выйти может любой)
+				private.GET("/workspace/invitations", role(entity.RoleAdmin), h.listInvitations)
+				private.POST("/workspace/invitations", role(entity.RoleAdmin), h.createInvitation)
+				private.DELETE("/workspace/invitations/:invitation_id", role(entity.RoleAdmin), h.revokeInvitation)
+			}
+
+			if h.clients != nil {
+				private.GET("/clients", h.listClients)
+				private.POST("/clients", role(entity.RoleAdmin), h.createClient)
+				private.PUT("/clients/:client_id", role(entity.RoleAdmin), h.updateClient)
+				private.DELETE("/clients/:client_id", role(entity.RoleAdmin), h.deleteClient)
+				private.GET("/clients/:client_id/share-links", h.listShareLinks)
+				private.POST("/clients/:client_id/share-links", role(entity.RoleAdmin), h.createShareLink)
+				private.DELETE("/clients/:client_id/share-links/:link_id", role(entity.RoleAdmin), h.revokeShareLink)
+				private.GET("/clients/:client_id/share-links/:link_id/access", role(entity.RoleAdmin), h.shareLinkAccess)
+			}
+
+			if h.rules != nil {
+				private.GET("/rules", h.listRules)
+				private.POST("/rules", role(entity.RoleAnalyst), h.createRule)
+				private.PUT("/rules/:rule_id", role(entity.RoleAnalyst), h.updateRule)
+				private.DELETE("/rules/:rule_id", role(entity.RoleAnalyst), h.deleteRule)
+				private.POST("/rules/:rule_id/dry-run", role(entity.RoleAnalyst), h.dryRunRule)
+				private.GET("/rules/:rule_id/executions", h.ruleExecutions)
+			}
+
+			if h.alerts != nil {
+				private.GET("/alerts", h.listAlerts)
+				private.GET("/alerts/channels", h.listAlertChannels)
+				private.POST("/alerts/channels", role(entity.RoleAnalyst), h.createAlertChannel)
+				private.DELETE("/alerts/channels/:channel_id", role(entity.RoleAnalyst), h.deleteAlertChannel)
+			}
+
+			if h.budgets != nil {
+				private.GET("/budgets", h.listBudgets)
+				private.POST("/budgets", role(entity.RoleAnalyst), h.createBudget)
+				private.GET("/budgets/pacing", h.budgetPacing)
+				private.PUT("/budgets/:budget_id", role(entity.RoleAnalyst), h.updateBudget)
+				private.DELETE("/budgets/:budget_id", role(entity.RoleAnalyst), h.deleteBudget)
+			}
+
+			if h.apiKeys != nil {
+				private.GET("/api-keys", role(entity.RoleAdmin), h.listAPIKeys)
+				private.POST("/api-keys", role(entity.RoleAdmin), h.createAPIKey)
+				private.DELETE("/api-keys/:key_id", role(entity.RoleAdmin), h.revokeAPIKey)
+			}
+
+			if h.webhooks != nil {
+				private.GET("/webhooks", h.listWebhooks)
+				private.POST("/webhooks", role(entity.RoleAdmin), h.createWebhook)
+				private.DELETE("/webhooks/:subscription_id", role(entity.RoleAdmin), h.deleteWebhook)
+				private.GET("/webhooks/:subscription_id/deliveries", h.webhookDeliveries)
+				private.POST("/webhooks/:subscription_id/replay", role(entity.RoleAdmin), h.replayWebhook)
+				private.POST("/webhooks/:subscription_id/deliveries/:delivery_id/replay", role(entity.RoleAdmin), h.replayWebhookDelivery)
+			}
 		}
 	}
 
-	r.POST("/integrations/google/connect", jwtAuth.Middleware(), h.googleConnect)
+	reader, admin := role(entity.RoleReadOnly), role(entity.RoleAdmin)
+
+	integrations := r.Group("/integrations")
 	// public
-	r.GET("/integrations/google/callback", h.googleCallback)
+	integrations.GET("/google/callback", authLimit, h.googleCallback)
 
 	// private
-	r.GET("/integrations/google/accounts", jwtAuth.Middleware(), h.googleAccounts)
-	r.POST("/integrations/google/link-accounts", jwtAuth.Middleware(), h.googleLinkAccounts)
-	r.POST("/integrations/google/sync", jwtAuth.Middleware(), h.googleSyncCosts) // +++
+	integ := integrations.Group("", authn, apiLimit, keyLimit)
+	integ.GET("/status", reader, h.integrationStatus)
+
+	integ.POST("/google/connect", admin, h.googleConnect)
+	integ.GET("/google/accounts", admin, h.googleAccounts)
+	integ.POST("/google/link-accounts", admin, h.googleLinkAccounts)
+	integ.GET("/google/identities", admin, h.googleIdentities)
+	integ.DELETE("/google/identities/:google_user_id", admin, h.googleDisconnectIdentity)
+	integ.DELETE("/google/accounts/:customer_id", admin, h.googleDisconnectAccount)
+	integ.DELETE("/google/connection", admin, h.googleDisconnectAll)
+	integ.POST("/google/sync", admin, h.googleSyncCosts) // +++
+	integ.PUT("/google/accounts/:customer_id/conversion-action", admin, h.googleSetConversionAction)
+	integ.GET("/google/conversions/:conversion_id/upload", reader, h.googleConversionUpload)
+
+	integ.PUT("/meta/accounts/:account_id/capi", admin, h.metaSaveCAPISettings)
+	integ.GET("/meta/conversions/:conversion_id/delivery", reader, h.metaConversionDelivery)
 
 	return r
 }
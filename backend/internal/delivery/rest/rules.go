@@ -0,0 +1,160 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// Тело POST /api/rules и PUT /api/rules/:rule_id:
+// { "name": "High CPA", "condition": "cpa > 40 AND conversions >= 5", "window_days": 3, "action": "pause" }
+// action: pause | notify | tag (тег — в action_param); enabled по умолчанию true.
+type ruleReq struct {
+	Name        string `json:"name"        binding:"required"`
+	Condition   string `json:"condition"   binding:"required"`
+	WindowDays  int    `json:"window_days" binding:"required"`
+	Action      string `json:"action"      binding:"required"`
+	ActionParam string `json:"action_param"`
+	Enabled     *bool  `json:"enabled"`
+}
+
+func (r ruleReq) entity() entity.Rule {
+	return entity.Rule{
+		Name:        r.Name,
+		Condition:   r.Condition,
+		WindowDays:  r.WindowDays,
+		Action:      r.Action,
+		ActionParam: r.ActionParam,
+		Enabled:     r.Enabled == nil || *r.Enabled,
+	}
+}
+
+// GET /api/rules
+func (h *Handler) listRules(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	rules, err := h.rules.List(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// POST /api/rules
+func (h *Handler) createRule(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	var req ruleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := h.rules.Create(c.Request.Context(), actor, req.entity())
+	if err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, rule)
+}
+
+// PUT /api/rules/:rule_id
+func (h *Handler) updateRule(c *gin.Context) {
+	actor, ruleID, ok := ruleParams(c)
+	if !ok {
+		return
+	}
+	var req ruleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule, err := h.rules.Update(c.Request.Context(), actor, ruleID, req.entity())
+	if err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rule)
+}
+
+// DELETE /api/rules/:rule_id
+func (h *Handler) deleteRule(c *gin.Context) {
+	actor, ruleID, ok := ruleParams(c)
+	if !ok {
+		return
+	}
+	if err := h.rules.Delete(c.Request.Context(), actor.WorkspaceID, ruleID); err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// POST /api/rules/:rule_id/dry-run
+// Какие объявления правило затронуло бы сейчас; действия не выполняются.
+func (h *Handler) dryRunRule(c *gin.Context) {
+	actor, ruleID, ok := ruleParams(c)
+	if !ok {
+		return
+	}
+	matches, err := h.rules.DryRun(c.Request.Context(), actor.WorkspaceID, ruleID)
+	if err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rule_id": ruleID, "matches": matches})
+}
+
+// GET /api/rules/:rule_id/executions?limit=50
+func (h *Handler) ruleExecutions(c *gin.Context) {
+	actor, ruleID, ok := ruleParams(c)
+	if !ok {
+		return
+	}
+	limit := mustAtoiDefault(c.Query("limit"), 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	list, err := h.rules.Executions(c.Request.Context(), actor.WorkspaceID, ruleID, limit)
+	if err != nil {
+		ruleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"executions": list})
+}
+
+// ruleParams — актор и rule_id из пути; при ошибке ответ уже записан.
+func ruleParams(c *gin.Context) (entity.WorkspaceMember, int64, bool) {
+	actor, ok := getActor(c)
+	if !ok {
+		return actor, 0, false
+	}
+	ruleID, err := strconv.ParseInt(c.Param("rule_id"), 10, 64)
+	if err != nil || ruleID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid rule_id"})
+		return actor, 0, false
+	}
+	return actor, ruleID, true
+}
+
+func ruleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrInvalidRule):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errs.ErrRuleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "rule_not_found"})
+	case errors.Is(err, errs.ErrWorkspaceForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "workspace_forbidden"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,155 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// Тело POST /api/webhooks:
+// { "url": "https://example.com/hooks/adsieve", "event_types": ["click.created", "sync.failed"], "secret": "..." }
+// secret необязателен — без него генерируется; возвращается только в ответе на создание.
+type webhookReq struct {
+	URL        string   `json:"url"         binding:"required"`
+	EventTypes []string `json:"event_types" binding:"required"`
+	Secret     string   `json:"secret"`
+}
+
+// GET /api/webhooks
+func (h *Handler) listWebhooks(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	subs, err := h.webhooks.List(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": subs, "event_types": entity.EventTypes})
+}
+
+// POST /api/webhooks
+func (h *Handler) createWebhook(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	var req webhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sub, err := h.webhooks.Create(c.Request.Context(), actor, entity.WebhookSubscription{
+		URL: req.URL, EventTypes: req.EventTypes, Secret: req.Secret,
+	})
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// DELETE /api/webhooks/:subscription_id
+func (h *Handler) deleteWebhook(c *gin.Context) {
+	actor, subID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	if err := h.webhooks.Delete(c.Request.Context(), actor.WorkspaceID, subID); err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/webhooks/:subscription_id/deliveries?status=dead&limit=50
+func (h *Handler) webhookDeliveries(c *gin.Context) {
+	actor, subID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", entity.WebhookStatusPending, entity.WebhookStatusSent, entity.WebhookStatusDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending|sent|dead"})
+		return
+	}
+	limit := mustAtoiDefault(c.Query("limit"), 50)
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	list, err := h.webhooks.Deliveries(c.Request.Context(), actor.WorkspaceID, subID, status, limit)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": list})
+}
+
+// POST /api/webhooks/:subscription_id/replay
+// Все dead-доставки подписки снова в очередь.
+func (h *Handler) replayWebhook(c *gin.Context) {
+	actor, subID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	n, err := h.webhooks.Replay(c.Request.Context(), actor.WorkspaceID, subID, 0)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"replayed": n})
+}
+
+// POST /api/webhooks/:subscription_id/deliveries/:delivery_id/replay
+func (h *Handler) replayWebhookDelivery(c *gin.Context) {
+	actor, subID, ok := webhookParams(c)
+	if !ok {
+		return
+	}
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil || deliveryID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid delivery_id"})
+		return
+	}
+	n, err := h.webhooks.Replay(c.Request.Context(), actor.WorkspaceID, subID, deliveryID)
+	if err != nil {
+		webhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"replayed": n})
+}
+
+// webhookParams — актор и subscription_id из пути; при ошибке ответ уже записан.
+func webhookParams(c *gin.Context) (entity.WorkspaceMember, int64, bool) {
+	actor, ok := getActor(c)
+	if !ok {
+		return actor, 0, false
+	}
+	subID, err := strconv.ParseInt(c.Param("subscription_id"), 10, 64)
+	if err != nil || subID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid subscription_id"})
+		return actor, 0, false
+	}
+	return actor, subID, true
+}
+
+func webhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errs.ErrWebhookNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook_not_found"})
+	case errors.Is(err, errs.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "dead_delivery_not_found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,234 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+// Роли (см. entity.Role*): read_only < analyst < admin < owner.
+// Активное пространство — заголовок X-Workspace-ID, без него — личное пространство пользователя.
+
+// Тело POST /api/workspaces: { "name": "Agency" }
+type workspaceReq struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// Тело POST /api/workspace/invitations: { "email": "new@example.com", "role": "analyst" }
+type invitationReq struct {
+	Email string `json:"email" binding:"required"`
+	Role  string `json:"role"  binding:"required"`
+}
+
+// Тело PUT /api/workspace/members/:user_id: { "role": "admin" }
+type memberRoleReq struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// Тело POST /api/workspaces/invitations/accept: { "token": "..." }
+type acceptInvitationReq struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// GET /api/workspaces — пространства пользователя с его ролью
+func (h *Handler) listWorkspaces(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	list, err := h.workspaces.List(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"workspaces": list})
+}
+
+// POST /api/workspaces — командное пространство, создатель — owner
+func (h *Handler) createWorkspace(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req workspaceReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	w, err := h.workspaces.Create(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, w)
+}
+
+// POST /api/workspaces/invitations/accept — вступление по токену из письма
+func (h *Handler) acceptInvitation(c *gin.Context) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	var req acceptInvitationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	m, err := h.workspaces.Accept(c.Request.Context(), userID, req.Token)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// GET /api/workspace/members
+func (h *Handler) listMembers(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	members, err := h.workspaces.Members(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// PUT /api/workspace/members/:user_id
+func (h *Handler) setMemberRole(c *gin.Context) {
+	actor, userID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	var req memberRoleReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.workspaces.SetRole(c.Request.Context(), actor, userID, req.Role); err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": req.Role})
+}
+
+// DELETE /api/workspace/members/:user_id — исключить участника; свой user_id — выйти из пространства
+func (h *Handler) removeMember(c *gin.Context) {
+	actor, userID, ok := memberParams(c)
+	if !ok {
+		return
+	}
+	if err := h.workspaces.RemoveMember(c.Request.Context(), actor, userID); err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GET /api/workspace/invitations — открытые приглашения
+func (h *Handler) listInvitations(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	list, err := h.workspaces.Invitations(c.Request.Context(), actor.WorkspaceID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"invitations": list})
+}
+
+// POST /api/workspace/invitations — токен в ответе показывается один раз
+func (h *Handler) createInvitation(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	var req invitationReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	inv, err := h.workspaces.Invite(c.Request.Context(), actor, req.Email, req.Role)
+	if err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, inv)
+}
+
+// DELETE /api/workspace/invitations/:invitation_id
+func (h *Handler) revokeInvitation(c *gin.Context) {
+	actor, ok := getActor(c)
+	if !ok {
+		return
+	}
+	invitationID, err := strconv.ParseInt(c.Param("invitation_id"), 10, 64)
+	if err != nil || invitationID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invitation_id"})
+		return
+	}
+	if err := h.workspaces.RevokeInvitation(c.Request.Context(), actor.WorkspaceID, invitationID); err != nil {
+		workspaceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// getActor — пользователь и его роль в активном пространстве (после authz-middleware);
+// при ошибке ответ уже записан.
+func getActor(c *gin.Context) (entity.WorkspaceMember, bool) {
+	userID, ok := getUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return entity.WorkspaceMember{}, false
+	}
+	workspaceID, ok := extractID(c, "workspace_id")
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{"error": "workspace_required"})
+		return entity.WorkspaceMember{}, false
+	}
+	return entity.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: c.GetString("workspace_role")}, true
+}
+
+// memberParams — актор и user_id участника из пути; при ошибке ответ уже записан.
+func memberParams(c *gin.Context) (entity.WorkspaceMember, int64, bool) {
+	actor, ok := getActor(c)
+	if !ok {
+		return actor, 0, false
+	}
+	userID, err := strconv.ParseInt(c.Param("user_id"), 10, 64)
+	if err != nil || userID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+		return actor, 0, false
+	}
+	return actor, userID, true
+}
+
+func workspaceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrInvalidWorkspace), errors.Is(err, errs.ErrInvalidInvitation):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, errs.ErrWorkspaceForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "workspace_forbidden"})
+	case errors.Is(err, errs.ErrMemberNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "member_not_found"})
+	case errors.Is(err, errs.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "invitation_not_found"})
+	case errors.Is(err, errs.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": "last_owner"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
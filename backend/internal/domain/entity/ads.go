@@ -27,3 +27,38 @@ type AdsFilter struct {
 	Offset   int
 	Sort     string
 }
+
+// Статусы объявления в ads.status
+const (
+	AdStatusActive = "active"
+	AdStatusPaused = "paused"
+)
+
+// AdTarget — объявление вместе с аккаунтом, через который его можно изменить на платформе
+type AdTarget struct {
+	AdID              int64
+	AccountID         int64
+	UserID            int64
+	Platform          string // facebook | google
+	ExternalAccountID string // customer_id Google / act_... Meta
+	AccessToken       string // токен Marketing API (только Meta)
+	Status            string
+}
+
+// AdStatusChange — запись аудита смены статуса (кто, что, чем закончилось)
+type AdStatusChange struct {
+	AdID       int64
+	UserID     int64
+	Platform   string
+	FromStatus string
+	ToStatus   string
+	Result     string // applied | failed
+	Error      string
+}
+
+// Ответ POST /api/ads/{ad_id}/pause|resume
+type AdStatusResult struct {
+	AdID    int64  `json:"ad_id"`
+	Status  string `json:"status"`
+	Changed bool   `json:"changed"` // false — объявление уже было в этом статусе
+}
@@ -0,0 +1,85 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Источник алерта
+const (
+	AlertKindAnomaly = "anomaly" // выброс метрики относительно базы
+	AlertKindRule    = "rule"    // действие notify у sieve rule
+	AlertKindBudget  = "budget"  // прогноз перерасхода бюджета
+)
+
+// Каналы доставки алертов
+const (
+	AlertChannelWebhook = "webhook" // POST JSON на URL пользователя
+	AlertChannelSlack   = "slack"   // Slack-совместимый incoming webhook ({"text": ...})
+	AlertChannelEmail   = "email"
+)
+
+// Статусы доставки алерта в канал
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+)
+
+// Alert — алерт по объявлению (таблица alerts); адресован рабочему пространству
+type Alert struct {
+	AlertID     int64     `json:"alert_id"`
+	WorkspaceID int64     `json:"-"`
+	AdID        int64     `json:"ad_id,omitempty"` // 0 — алерт не по объявлению (budget)
+	AdName      string    `json:"ad_name,omitempty"`
+	Kind        string    `json:"kind"`   // anomaly | rule | budget
+	Metric      string    `json:"metric"` // spend | clicks | ... ; для rule — "rule:<rule_id>", для budget — "budget:<budget_id>"
+	Day         string    `json:"day"`    // YYYY-MM-DD
+	Value       float64   `json:"value"`
+	Baseline    float64   `json:"baseline"`
+	ZScore      float64   `json:"z_score"`
+	PctChange   float64   `json:"pct_change"`
+	Direction   string    `json:"direction,omitempty"` // up | down
+	Message     string    `json:"message"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AlertChannel — куда рабочее пространство получает алерты
+type AlertChannel struct {
+	ChannelID   int64     `json:"channel_id"`
+	WorkspaceID int64     `json:"-"`
+	UserID      int64     `json:"-"`      // кто добавил
+	Kind        string    `json:"kind"`   // webhook | slack | email
+	Target      string    `json:"target"` // URL или email
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AlertDelivery — алерт, забранный на отправку в конкретный канал
+type AlertDelivery struct {
+	Alert    Alert
+	Channel  AlertChannel
+	Attempts int
+}
+
+// AdMetricDay — дневные метрики объявления вместе с его пространством (история для детектора)
+type AdMetricDay struct {
+	WorkspaceID int64
+	AdID        int64
+	Name        string
+	Day         time.Time
+	Clicks      int
+	Conversions int
+	Revenue     decimal.Decimal
+	Spend       decimal.Decimal
+}
+
+// AlertRunStats — итог одного прогона детектора / доставки
+type AlertRunStats struct {
+	Ads     int
+	Alerts  int
+	Sent    int
+	Retried int
+	Failed  int
+}
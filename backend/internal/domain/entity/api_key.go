@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+// APIKey — ключ серверной интеграции (заголовок X-API-Key). Действует от имени автора
+// в своём рабочем пространстве. Key есть только в ответе на создание.
+type APIKey struct {
+	KeyID       int64      `json:"key_id"`
+	WorkspaceID int64      `json:"-"`
+	UserID      int64      `json:"created_by"`
+	Name        string     `json:"name"`
+	Prefix      string     `json:"prefix"`
+	CreatedAt   time.Time  `json:"created_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty"`
+	Key         string     `json:"key,omitempty"`
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Период бюджета
+const (
+	BudgetPeriodMonthly = "monthly" // лимит на календарный месяц
+	BudgetPeriodDaily   = "daily"   // лимит в день; пейсинг — по месяцу (amount × дней в месяце)
+)
+
+// Статус пейсинга
+const (
+	PaceOnTrack = "on_track"
+	PaceOver    = "over"
+	PaceUnder   = "under"
+)
+
+// Budget — бюджет рекламного аккаунта или группы его объявлений (таблицы budgets, budget_ads)
+type Budget struct {
+	BudgetID    int64           `json:"budget_id"`
+	WorkspaceID int64           `json:"-"`
+	UserID      int64           `json:"-"` // кто создал
+	AccountID   int64           `json:"account_id"`
+	Name        string          `json:"name"`
+	Period      string          `json:"period"` // monthly | daily
+	Amount      decimal.Decimal `json:"amount"`
+	AlertPct    decimal.Decimal `json:"alert_pct"` // порог прогнозируемого перерасхода, %
+	AdIDs       []int64         `json:"ad_ids"`    // пусто — весь аккаунт
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// BudgetPacing — расход по бюджету с начала месяца и прогноз на конец месяца
+type BudgetPacing struct {
+	Budget
+	PeriodStart string          `json:"period_start"` // YYYY-MM-DD, первое число месяца
+	PeriodEnd   string          `json:"period_end"`   // YYYY-MM-DD, последнее число месяца
+	Limit       decimal.Decimal `json:"limit"`        // лимит на месяц
+	Spent       decimal.Decimal `json:"spent"`        // расход с начала месяца (ads_insights)
+	Expected    decimal.Decimal `json:"expected"`     // сколько должно быть потрачено к этому моменту при ровном темпе
+	Projected   decimal.Decimal `json:"projected"`    // прогноз на конец месяца при текущем темпе
+	PacePct     float64         `json:"pace_pct"`     // (projected - limit) / limit * 100
+	Status      string          `json:"status"`       // on_track | over | under
+	AlertDue    bool            `json:"alert_due"`    // pace_pct больше alert_pct
+}
+
+// BudgetRunStats — итог проверки бюджетов кроном
+type BudgetRunStats struct {
+	Budgets int
+	Over    int
+	Alerts  int
+}
@@ -12,6 +12,8 @@ type Click struct {
 	AdID      int64     `json:"ad_id" db:"ad_id"`
 	ClickedAt time.Time `json:"clicked_at" db:"clicked_at"`
 	ClickRef  uuid.UUID `json:"click_ref" db:"click_ref"`
+	Gclid     *string   `json:"gclid,omitempty" db:"gclid"`   // Google click id (для офлайн-конверсий)
+	Fbclid    *string   `json:"fbclid,omitempty" db:"fbclid"` // Meta click id (для Conversions API)
 }
 
 type ClickInput struct {
@@ -19,6 +21,8 @@ type ClickInput struct {
 	AdID      int64     `json:"ad_id"`
 	ClickedAt *int64    `json:"clicked_at"`
 	ClickRef  uuid.UUID `json:"click_ref"`
+	Gclid     *string   `json:"gclid,omitempty"`
+	Fbclid    *string   `json:"fbclid,omitempty"`
 }
 
 func (in ClickInput) ParsedClickedAt() time.Time {
@@ -0,0 +1,44 @@
+package entity
+
+import "time"
+
+// Client — клиент агентства: группа рекламных аккаунтов рабочего пространства
+type Client struct {
+	ClientID    int64     `json:"client_id"`
+	WorkspaceID int64     `json:"-"`
+	Name        string    `json:"name"`
+	AccountIDs  []int64   `json:"account_ids"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Итог обращения по публичной ссылке
+const (
+	ShareOutcomeOK      = "ok"
+	ShareOutcomeExpired = "expired"
+	ShareOutcomeRevoked = "revoked"
+)
+
+// ShareLink — публичная ссылка на отчёт клиента (только чтение, без логина).
+// Token и URL есть только в ответе на создание.
+type ShareLink struct {
+	LinkID       int64      `json:"link_id"`
+	ClientID     int64      `json:"client_id"`
+	ClientName   string     `json:"-"`
+	CreatedBy    int64      `json:"created_by"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	Accesses     int        `json:"accesses"`
+	LastAccessAt *time.Time `json:"last_access_at,omitempty"`
+	Token        string     `json:"token,omitempty"`
+	URL          string     `json:"url,omitempty"`
+}
+
+// ShareAccess — запись журнала обращений по ссылке
+type ShareAccess struct {
+	LinkID     int64     `json:"link_id"`
+	AccessedAt time.Time `json:"accessed_at"`
+	IP         string    `json:"ip"`
+	UserAgent  string    `json:"user_agent"`
+	Outcome    string    `json:"outcome"` // ok | expired | revoked
+}
@@ -16,11 +16,25 @@ type Conversion struct {
 	ClickRef     *uuid.UUID      `json:"click_ref,omitempty" db:"click_ref"`
 }
 
+// ConversionUploads — выгрузки в рекламные платформы, которые ставятся в очередь тем же
+// запросом, что и сама конверсия: конверсия без своей выгрузки не записывается.
+type ConversionUploads struct {
+	Gclid string         // непусто — в очередь Google (если аккаунт объявления настроен)
+	Meta  *MetaCAPIEvent // не nil — в очередь CAPI (если пиксель включён); пустой EventID — conv-<id>
+}
+
 type ConversionInput struct {
 	ClickID     string          `json:"click_id"   validate:"required"`
 	Revenue     decimal.Decimal `json:"revenue"    validate:"required"`
 	OrderID     *string         `json:"order_id,omitempty"`
 	ConvertedAt *int64          `json:"converted_at,omitempty"`
+
+	// Необязательные данные для Meta Conversions API (в открытом виде не сохраняются)
+	Email   string `json:"email,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Fbc     string `json:"fbc,omitempty"`
+	Fbp     string `json:"fbp,omitempty"`
+	EventID string `json:"event_id,omitempty"` // для дедупликации с браузерным пикселем
 }
 
 func (in ConversionInput) ParsedConvertedAt() time.Time {
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Статусы загрузки конверсии на рекламную платформу
+const (
+	UploadStatusPending    = "pending"
+	UploadStatusProcessing = "processing"
+	UploadStatusUploaded   = "uploaded"
+	UploadStatusFailed     = "failed"
+)
+
+// GoogleConversionUpload — строка очереди google_conversion_uploads
+// вместе с данными конверсии, нужными для uploadClickConversions.
+type GoogleConversionUpload struct {
+	ConversionID     int64           `json:"conversion_id"     db:"conversion_id"`
+	UserID           int64           `json:"user_id"           db:"user_id"`
+	CustomerID       string          `json:"customer_id"       db:"customer_id"`
+	ConversionAction string          `json:"conversion_action" db:"conversion_action"`
+	Gclid            string          `json:"gclid"             db:"gclid"`
+	Status           string          `json:"status"            db:"status"`
+	Attempts         int             `json:"attempts"          db:"attempts"`
+	LastError        *string         `json:"last_error,omitempty" db:"last_error"`
+	ConvertedAt      time.Time       `json:"converted_at"      db:"converted_at"`
+	Revenue          decimal.Decimal `json:"revenue"           db:"revenue"`
+	OrderID          *string         `json:"order_id,omitempty" db:"order_id"`
+}
+
+// ClickConversion — одна офлайн-конверсия для uploadClickConversions
+type ClickConversion struct {
+	Gclid            string
+	ConversionAction string // customers/<cid>/conversionActions/<id>
+	ConvertedAt      time.Time
+	Value            float64
+	CurrencyCode     string // пусто ⇒ валюта аккаунта
+	OrderID          string
+}
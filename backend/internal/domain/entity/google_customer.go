@@ -0,0 +1,22 @@
+package entity
+
+// GoogleCustomer — аккаунт Google Ads, доступный пользователю (напрямую или через MCC)
+type GoogleCustomer struct {
+	CustomerID      string `json:"customer_id"`
+	DescriptiveName string `json:"descriptive_name"`
+	CurrencyCode    string `json:"currency_code,omitempty"`
+	TimeZone        string `json:"time_zone,omitempty"`
+	Manager         bool   `json:"manager"`
+	// google_user_id логина, через токен которого виден аккаунт
+	GoogleUserID string `json:"google_user_id,omitempty"`
+	// MCC, через который аккаунт достижим (значение заголовка login-customer-id); пусто ⇒ напрямую
+	LoginCustomerID string           `json:"login_customer_id,omitempty"`
+	Children        []GoogleCustomer `json:"children,omitempty"`
+}
+
+// GoogleAccountLink — аккаунт, выбранный для привязки, и MCC, через который к нему ходить
+type GoogleAccountLink struct {
+	CustomerID      string
+	LoginCustomerID string
+	TokenOwner      string // google_user_id, чьим токеном синкается аккаунт
+}
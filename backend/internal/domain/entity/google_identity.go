@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// GoogleIdentity — Google-логин, подключённый пользователем AdSieve (строка google_user_tokens)
+type GoogleIdentity struct {
+	GoogleUserID   string    `json:"google_user_id"`
+	Scope          string    `json:"scope"`
+	NeedsConsent   bool      `json:"needs_consent"`
+	LinkedAccounts int       `json:"linked_accounts"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// GoogleRefreshToken — расшифрованный refresh-токен логина (нужен только для отзыва)
+type GoogleRefreshToken struct {
+	GoogleUserID string
+	RefreshToken string
+}
+
+// GoogleDisconnectResult — итог отключения аккаунта/логина/всей интеграции Google
+type GoogleDisconnectResult struct {
+	RevokedTokens int  `json:"revoked_tokens"`
+	RevokeFailed  int  `json:"revoke_failed"`
+	SpendPurged   bool `json:"spend_purged"`
+}
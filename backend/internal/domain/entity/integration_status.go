@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Состояние подключения рекламной платформы
+const (
+	IntegrationConnected      = "connected"
+	IntegrationNeedsConsent   = "needs_consent"
+	IntegrationNeverConnected = "never_connected"
+)
+
+// IntegrationStatus — строка ответа GET /integrations/status
+type IntegrationStatus struct {
+	Platform       string     `json:"platform"`
+	State          string     `json:"state"`
+	LinkedAccounts int        `json:"linked_accounts"`
+	LastSyncAt     *time.Time `json:"last_sync_at"`
+	LastError      *string    `json:"last_error"`
+	LastErrorAt    *time.Time `json:"last_error_at"`
+}
+
+// AccountsSyncSummary — сводка по привязанным аккаунтам платформы
+type AccountsSyncSummary struct {
+	LinkedAccounts int
+	LastSyncAt     *time.Time
+	LastError      *string
+	LastErrorAt    *time.Time
+}
@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// MetaCAPISettings — настройки Conversions API рекламного аккаунта Meta
+type MetaCAPISettings struct {
+	AccountID     int64  `json:"account_id"      db:"account_id"`
+	PixelID       string `json:"pixel_id"        db:"pixel_id"`
+	AccessToken   string `json:"-"               db:"access_token_enc"` // в открытом виде только в памяти
+	Currency      string `json:"currency"        db:"currency"`
+	TestEventCode string `json:"test_event_code,omitempty" db:"test_event_code"`
+	Enabled       bool   `json:"enabled"         db:"enabled"`
+}
+
+// MetaCAPIEvent — строка очереди meta_capi_events (+ данные конверсии и пикселя для отправки)
+type MetaCAPIEvent struct {
+	ConversionID int64           `json:"conversion_id" db:"conversion_id"`
+	AccountID    int64           `json:"account_id"    db:"account_id"`
+	UserID       int64           `json:"user_id"       db:"user_id"`
+	EventID      string          `json:"event_id"      db:"event_id"`
+	EmHash       *string         `json:"-"             db:"em_hash"`
+	PhHash       *string         `json:"-"             db:"ph_hash"`
+	Fbc          *string         `json:"-"             db:"fbc"`
+	Fbp          *string         `json:"-"             db:"fbp"`
+	Status       string          `json:"status"        db:"status"`
+	Attempts     int             `json:"attempts"      db:"attempts"`
+	LastError    *string         `json:"last_error,omitempty" db:"last_error"`
+	FbtraceID    *string         `json:"fbtrace_id,omitempty" db:"fbtrace_id"`
+	SentAt       *time.Time      `json:"sent_at,omitempty"    db:"sent_at"`
+	ConvertedAt  time.Time       `json:"converted_at"  db:"converted_at"`
+	Revenue      decimal.Decimal `json:"revenue"       db:"revenue"`
+	OrderID      *string         `json:"order_id,omitempty"   db:"order_id"`
+
+	Settings MetaCAPISettings `json:"-"`
+}
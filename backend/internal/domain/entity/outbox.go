@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent — событие из outbox_events, ожидающее публикации relay-воркером
+type OutboxEvent struct {
+	OutboxID      int64
+	EventID       string
+	EventType     string // click.created | conversion.created
+	AdID          int64  // ключ упорядочивания
+	Payload       json.RawMessage
+	CreatedAt     time.Time
+	Attempts      int
+	NextAttemptAt time.Time
+}
+
+// Envelope — событие в формате для sinks (тот же, что и тело исходящего webhook)
+func (e OutboxEvent) Envelope() ([]byte, error) {
+	return json.Marshal(struct {
+		ID        string          `json:"id"`
+		Type      string          `json:"type"`
+		CreatedAt time.Time       `json:"created_at"`
+		Data      json.RawMessage `json:"data"`
+	}{e.EventID, e.EventType, e.CreatedAt.UTC(), e.Payload})
+}
+
+// OutboxRunStats — итог прогона relay
+type OutboxRunStats struct {
+	Published int
+	Failed    int
+	Dead      int // попытки исчерпаны, очередь объявления идёт дальше без события
+}
@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Действия правила
+const (
+	RuleActionPause  = "pause"  // поставить объявление на паузу на платформе
+	RuleActionNotify = "notify" // уведомить владельца
+	RuleActionTag    = "tag"    // пометить объявление тегом (action_param)
+)
+
+// Итог срабатывания правила по объявлению (rule_executions.result)
+const (
+	RuleResultApplied = "applied"
+	RuleResultSkipped = "skipped"
+	RuleResultFailed  = "failed"
+)
+
+// Rule — «sieve rule»: условие над метриками объявления за последние WindowDays дней и действие.
+type Rule struct {
+	RuleID      int64     `json:"rule_id"`
+	WorkspaceID int64     `json:"-"`
+	UserID      int64     `json:"-"` // автор
+	AuthorRole  string    `json:"-"` // роль автора в пространстве на момент прогона (EnabledRules)
+	Name        string    `json:"name"`
+	Condition   string    `json:"condition"`   // DSL: "cpa > 40 AND conversions >= 5"
+	WindowDays  int       `json:"window_days"` // окно метрик, включая сегодня
+	Action      string    `json:"action"`      // pause | notify | tag
+	ActionParam string    `json:"action_param,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// AdWindowStats — метрики объявления, просуммированные за окно правила
+type AdWindowStats struct {
+	AdID        int64
+	Name        string
+	Status      string
+	Platform    string
+	Clicks      int
+	Conversions int
+	Revenue     decimal.Decimal
+	Spend       decimal.Decimal
+}
+
+// CPA = spend / conversions
+func (s AdWindowStats) CPA() *decimal.Decimal {
+	if s.Conversions == 0 {
+		return nil
+	}
+	v := s.Spend.Div(decimal.NewFromInt(int64(s.Conversions)))
+	return &v
+}
+
+// ROAS = revenue / spend
+func (s AdWindowStats) ROAS() *decimal.Decimal {
+	if s.Spend.IsZero() {
+		return nil
+	}
+	v := s.Revenue.Div(s.Spend)
+	return &v
+}
+
+// RuleMatch — объявление, на котором сработало (или сработало бы) условие правила
+type RuleMatch struct {
+	AdID     int64             `json:"ad_id"`
+	Name     string            `json:"name"`
+	Status   string            `json:"status"`
+	Platform string            `json:"platform"`
+	Metrics  map[string]string `json:"metrics"`
+}
+
+// RuleExecution — запись журнала выполнения правила
+type RuleExecution struct {
+	ExecutionID int64             `json:"execution_id"`
+	RuleID      int64             `json:"rule_id"`
+	AdID        int64             `json:"ad_id"`
+	Action      string            `json:"action"`
+	DryRun      bool              `json:"dry_run"`
+	Result      string            `json:"result"` // applied | skipped | failed
+	Error       string            `json:"error,omitempty"`
+	Metrics     map[string]string `json:"metrics"`
+	ExecutedAt  time.Time         `json:"executed_at"`
+}
+
+// RuleRunStats — итог одного прогона крона правил
+type RuleRunStats struct {
+	Rules   int
+	Matched int
+	Applied int
+	Skipped int
+	Failed  int
+}
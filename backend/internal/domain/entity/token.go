@@ -2,9 +2,24 @@ package entity
 
 import "time"
 
+// RefreshToken — один refresh-токен семейства (сессии). Сам токен не храним, только SHA-256.
 type RefreshToken struct {
-	TokenID      int64     `db:"token_id"`
-	UserID       int64     `db:"user_id"`
-	RefreshToken string    `db:"refresh_token"`
-	ExpiresAt    time.Time `db:"expires_at"`
+	TokenID   int64      `db:"token_id"`
+	UserID    int64      `db:"user_id"`
+	SessionID int64      `db:"session_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"` // токен уже обменян на новый
+}
+
+// Session — вход пользователя с одного устройства (семейство refresh-токенов).
+type Session struct {
+	SessionID  int64     `json:"session_id"   db:"session_id"`
+	UserID     int64     `json:"-"            db:"user_id"`
+	UserAgent  string    `json:"user_agent"   db:"user_agent"`
+	IP         string    `json:"ip"           db:"ip"`
+	CreatedAt  time.Time `json:"created_at"   db:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"   db:"expires_at"`
+	Current    bool      `json:"current"`
 }
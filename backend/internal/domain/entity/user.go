@@ -21,6 +21,10 @@ type User struct {
 type SignInput struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,gte=6"`
+
+	// откуда вход — для списка сессий
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
 func (i *SignInput) Validate() error {
@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы исходящих событий
+const (
+	EventClickCreated            = "click.created"
+	EventConversionCreated       = "conversion.created"
+	EventSyncFailed              = "sync.failed"
+	EventIntegrationNeedsConsent = "integration.needs_consent"
+)
+
+// EventTypes — все типы событий, на которые можно подписаться
+var EventTypes = []string{EventClickCreated, EventConversionCreated, EventSyncFailed, EventIntegrationNeedsConsent}
+
+// Статусы доставки webhook
+const (
+	WebhookStatusPending = "pending"
+	WebhookStatusSent    = "sent"
+	WebhookStatusDead    = "dead" // попытки исчерпаны, ждёт ручного replay
+)
+
+// WebhookSubscription — подписка интегратора (таблица webhook_subscriptions).
+// Secret отдаётся только при создании.
+type WebhookSubscription struct {
+	SubscriptionID int64     `json:"subscription_id"`
+	WorkspaceID    int64     `json:"-"`
+	UserID         int64     `json:"-"` // кто создал
+	URL            string    `json:"url"`
+	Secret         string    `json:"secret,omitempty"`
+	EventTypes     []string  `json:"event_types"`
+	Enabled        bool      `json:"enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// WebhookEvent — событие для подписчиков. Получатели — подписки пространства объявления AdID,
+// иначе пространства аккаунта AccountID, иначе всех пространств с аккаунтами, привязанными UserID.
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+	UserID    int64     `json:"-"`
+	AccountID int64     `json:"-"`
+	AdID      int64     `json:"-"`
+}
+
+// WebhookDelivery — доставка события в подписку (таблица webhook_deliveries)
+type WebhookDelivery struct {
+	DeliveryID     int64           `json:"delivery_id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode *int            `json:"last_status_code,omitempty"`
+	LastError      *string         `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	SentAt         *time.Time      `json:"sent_at,omitempty"`
+
+	// для отправки (ClaimWebhookDeliveries)
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookRunStats — итог прогона доставки
+type WebhookRunStats struct {
+	Sent    int
+	Retried int
+	Dead    int
+}
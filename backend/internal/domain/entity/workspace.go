@@ -0,0 +1,51 @@
+package entity
+
+import "time"
+
+// Роли участника рабочего пространства (по убыванию прав)
+const (
+	RoleOwner    = "owner"     // всё, включая назначение владельцев
+	RoleAdmin    = "admin"     // интеграции, пауза объявлений, webhooks, участники и приглашения
+	RoleAnalyst  = "analyst"   // правила, алерты, бюджеты, конверсии
+	RoleReadOnly = "read_only" // только чтение
+)
+
+var roleRank = map[string]int{RoleReadOnly: 1, RoleAnalyst: 2, RoleAdmin: 3, RoleOwner: 4}
+
+// ValidRole — известная роль
+func ValidRole(role string) bool { return roleRank[role] > 0 }
+
+// RoleAtLeast — role даёт права не ниже min
+func RoleAtLeast(role, min string) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[min]
+}
+
+// Workspace — рабочее пространство в списке пользователя (Role — его роль в нём)
+type Workspace struct {
+	WorkspaceID int64     `json:"workspace_id"`
+	Name        string    `json:"name"`
+	Personal    bool      `json:"personal"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkspaceMember — участник пространства; он же «актор» запроса после authz-middleware
+type WorkspaceMember struct {
+	WorkspaceID int64     `json:"workspace_id"`
+	UserID      int64     `json:"user_id"`
+	Email       string    `json:"email,omitempty"`
+	Role        string    `json:"role"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkspaceInvitation — приглашение по email. Token есть только в ответе на создание.
+type WorkspaceInvitation struct {
+	InvitationID int64     `json:"invitation_id"`
+	WorkspaceID  int64     `json:"workspace_id"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	InvitedBy    int64     `json:"invited_by"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	Token        string    `json:"token,omitempty"`
+}
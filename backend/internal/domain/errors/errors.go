@@ -3,15 +3,62 @@ package errs
 import "errors"
 
 var (
-	ErrUserNotFound        = errors.New("user with such credentials not found")
-	ErrInvalidCreds        = errors.New("invalid credentials")
-	ErrInvalidRefreshToken = errors.New("invalid refresh token")
-	ErrRefreshTokenExpired = errors.New("refresh token expired")
-	ErrEmailTaken          = errors.New("this email is already taken")
-	ErrDuplicateClick      = errors.New("click alredy registered")
-	ErrClickNotFound       = errors.New("click was not found")
-	ErrConversionExists    = errors.New("conversion already exists")
-	ErrConversionNotFound  = errors.New("conversion not found")
-	ErrInvalidRange        = errors.New("invalid date range")
-	ErrNoAdAccess          = errors.New("no access to requested ad_id")
+	ErrUserNotFound         = errors.New("user with such credentials not found")
+	ErrInvalidCreds         = errors.New("invalid credentials")
+	ErrInvalidRefreshToken  = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired  = errors.New("refresh token expired")
+	ErrRefreshTokenReused   = errors.New("refresh token reuse detected, session revoked")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrEmailTaken           = errors.New("this email is already taken")
+	ErrDuplicateClick       = errors.New("click alredy registered")
+	ErrClickNotFound        = errors.New("click was not found")
+	ErrConversionExists     = errors.New("conversion already exists")
+	ErrConversionNotFound   = errors.New("conversion not found")
+	ErrInvalidRange         = errors.New("invalid date range")
+	ErrNoAdAccess           = errors.New("no access to requested ad_id")
+	ErrAdNotFound           = errors.New("ad not found")
+	ErrAdStatusConflict     = errors.New("ad status was changed concurrently")
+	ErrRuleNotFound         = errors.New("rule not found")
+	ErrInvalidRule          = errors.New("invalid rule")
+	ErrAlertChannelNotFound = errors.New("alert channel not found")
+	ErrInvalidAlertChannel  = errors.New("invalid alert channel")
+	ErrBudgetNotFound       = errors.New("budget not found")
+	ErrInvalidBudget        = errors.New("invalid budget")
+	ErrWebhookNotFound      = errors.New("webhook subscription not found")
+	ErrInvalidWebhook       = errors.New("invalid webhook subscription")
+	ErrDeliveryNotFound     = errors.New("webhook delivery not found")
+	ErrWorkspaceNotFound    = errors.New("workspace not found")
+	ErrInvalidWorkspace     = errors.New("invalid workspace")
+	ErrWorkspaceForbidden   = errors.New("insufficient workspace role")
+	ErrMemberNotFound       = errors.New("workspace member not found")
+	ErrLastOwner            = errors.New("workspace must keep at least one owner")
+	ErrInvalidInvitation    = errors.New("invalid invitation")
+	ErrInvitationNotFound   = errors.New("invitation not found or expired")
+	ErrClientNotFound       = errors.New("client not found")
+	ErrInvalidClient        = errors.New("invalid client")
+	ErrShareLinkNotFound    = errors.New("share link not found")
+	ErrShareLinkExpired     = errors.New("share link expired")
+	ErrShareLinkRevoked     = errors.New("share link revoked")
+	ErrShareLinksDisabled   = errors.New("share links are not configured")
+	ErrAPIKeyNotFound       = errors.New("api key not found")
+	ErrInvalidAPIKey        = errors.New("invalid api key")
+)
+
+// Интеграции с рекламными платформами
+var (
+	ErrAccountNotLinked        = errors.New("ad account is not linked")
+	ErrConversionRejected      = errors.New("conversion rejected by ad platform")
+	ErrInvalidConversionAction = errors.New("invalid conversion action")
+	ErrInvalidPixelSettings    = errors.New("invalid pixel settings")
+	ErrGoogleIdentityNotFound  = errors.New("google identity is not connected")
+	ErrGoogleNotConnected      = errors.New("google ads is not connected")
+	ErrGoogleNeedsConsent      = errors.New("google re-consent required")
+	ErrAdPlatformUnsupported   = errors.New("ad platform does not support status changes")
+	ErrAdMutationRejected      = errors.New("ad change rejected by ad platform")
+
+	// ошибки Google Ads API (GoogleAdsFailure)
+	ErrGoogleQuotaExceeded         = errors.New("google ads quota exceeded")
+	ErrGooglePermissionDenied      = errors.New("google ads permission denied")
+	ErrGoogleCustomerNotEnabled    = errors.New("google ads customer is not enabled")
+	ErrGoogleInvalidDeveloperToken = errors.New("google ads developer token is invalid")
 )
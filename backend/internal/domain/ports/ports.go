@@ -3,6 +3,7 @@ package ports
 
 import (
 	"context"
+	"time"
 
 	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
 )
@@ -11,6 +12,10 @@ type User interface {
 	SignUp(ctx context.Context, inp entity.SignInput) (string, string, error)
 	SignIn(ctx context.Context, in entity.SignInput) (string, string, error)
 	Refresh(ctx context.Context, refreshToken string) (string, string, error)
+	Sessions(ctx context.Context, userID, currentSessionID int64) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID int64) error
+	SignOut(ctx context.Context, userID, sessionID int64, jti string, expiresAt time.Time) error
+	SignOutEverywhere(ctx context.Context, userID int64) error
 }
 
 type Click interface {
@@ -22,11 +27,17 @@ type Conversion interface {
 }
 
 type Metrics interface {
-	Get(ctx context.Context, userID int64, f entity.MetricsFilter) ([]entity.DailyMetricDTO, error)
+	Get(ctx context.Context, workspaceID int64, f entity.MetricsFilter) ([]entity.DailyMetricDTO, error)
 }
 
 type Ads interface {
-	List(ctx context.Context, userID int64, f entity.AdsFilter) (items []entity.AdDTO, total int, err error)
+	List(ctx context.Context, workspaceID int64, f entity.AdsFilter) (items []entity.AdDTO, total int, err error)
+	SetWorkspaceStatus(ctx context.Context, workspaceID, userID, adID int64, status string) (entity.AdStatusResult, error)
+}
+
+// Изменение объявления на рекламной платформе (своя реализация на каждую платформу)
+type AdMutator interface {
+	SetAdStatus(ctx context.Context, t entity.AdTarget, status string) error
 }
 
 // === Google integrations ===
@@ -41,10 +52,12 @@ type TokenVault interface {
     SaveGoogleRefreshToken(ctx context.Context, userID int64, googleUserID, refreshToken, scope string) error
     LoadGoogleRefreshToken(ctx context.Context, userID int64) (googleUserID, refreshTokenEnc, scope string, err error)
     MarkNeedsConsent(ctx context.Context, userID int64, googleUserID string) error
+    // подключённые Google-логины пользователя
+    ListGoogleIdentities(ctx context.Context, userID int64) ([]entity.GoogleIdentity, error)
 }
 
-// Клиент Google Ads для списка доступных аккаунтов и линковки
+// Клиент Google Ads для списка доступных аккаунтов (с иерархией MCC) и линковки
 type GoogleAdsClient interface {
-	ListAccessibleCustomers(ctx context.Context, userID int64) ([]string, error)
-	LinkAccounts(ctx context.Context, userID int64, customerIDs []string) error
+	ListAccessibleAccounts(ctx context.Context, userID int64) ([]entity.GoogleCustomer, error)
+	LinkAccounts(ctx context.Context, workspaceID, userID int64, customerIDs []string) error
 }
@@ -18,18 +18,22 @@ type AuthRepository interface {
 }
 
 type ConversionRepository interface {
-	Create(ctx context.Context, in entity.Conversion) (int64, error)
+	Create(ctx context.Context, in entity.Conversion, up entity.ConversionUploads) (int64, error)
 }
 
 type MetricsRepository interface {
 	List(ctx context.Context, adIDs []int64, from, to time.Time) ([]entity.AdDailyMetric, error)
 }
 
-type UserAdsRepo interface {
-	IDsByUser(ctx context.Context, userID int64) ([]int64, error)
-	Ensure(ctx context.Context, userID, adID int64) error
+// AdAccessRepo — объявления, доступные рабочему пространству (через аккаунт объявления).
+type AdAccessRepo interface {
+	IDsByWorkspace(ctx context.Context, workspaceID int64) ([]int64, error)
 }
 
 type AdsRepository interface {
-	ListByUser(ctx context.Context, userID int64, f entity.AdsFilter) (items []entity.Ad, total int, err error)
+	AdAccessRepo
+	ListByWorkspace(ctx context.Context, workspaceID int64, f entity.AdsFilter) (items []entity.Ad, total int, err error)
+	WorkspaceTarget(ctx context.Context, workspaceID, adID int64) (entity.AdTarget, error)
+	SwapStatus(ctx context.Context, adID int64, from, to string) (bool, error)
+	LogStatusChange(ctx context.Context, ch entity.AdStatusChange) error
 }
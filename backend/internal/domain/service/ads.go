@@ -3,24 +3,37 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
 	"strings"
 
 	"github.com/berezovskyivalerii/adsieve/internal/domain"
 	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+	"github.com/berezovskyivalerii/adsieve/internal/domain/ports"
 )
 
-// AdsService реализует бизнес-логику для GET /api/ads
+// AdsService реализует бизнес-логику для GET /api/ads и пауз/включений объявлений
 type AdsService struct {
-	repo domain.AdsRepository // ожидается метод: ListByUser(ctx, userID, f) ([]entity.Ad, int, error)
+	repo     domain.AdsRepository
+	mutators map[string]ports.AdMutator // platform → клиент платформы
 }
 
 func NewAdsService(repo domain.AdsRepository) *AdsService {
-	return &AdsService{repo: repo}
+	return &AdsService{repo: repo, mutators: map[string]ports.AdMutator{}}
 }
 
-// List возвращает объявления пользователя с учётом фильтров/пагинации.
+// WithMutator подключает изменение объявлений платформы platform (facebook | google).
+func (s *AdsService) WithMutator(platform string, m ports.AdMutator) *AdsService {
+	s.mutators[platform] = m
+	return s
+}
+
+// List возвращает объявления рабочего пространства с учётом фильтров/пагинации.
 // Маппит сущности БД в DTO для API-ответа.
-func (s *AdsService) List(ctx context.Context, userID int64, f entity.AdsFilter) ([]entity.AdDTO, int, error) {
+func (s *AdsService) List(ctx context.Context, workspaceID int64, f entity.AdsFilter) ([]entity.AdDTO, int, error) {
 	// лёгкая санитаризация sort (на случай, если хэндлер пропустил мусор)
 	switch strings.ToLower(f.Sort) {
 	case "name", "-name", "created_at", "-created_at":
@@ -28,7 +41,7 @@ func (s *AdsService) List(ctx context.Context, userID int64, f entity.AdsFilter)
 		f.Sort = "name"
 	}
 
-	ads, total, err := s.repo.ListByUser(ctx, userID, f)
+	ads, total, err := s.repo.ListByWorkspace(ctx, workspaceID, f)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -44,3 +57,59 @@ func (s *AdsService) List(ctx context.Context, userID int64, f entity.AdsFilter)
 	}
 	return out, total, nil
 }
+
+// SetWorkspaceStatus ставит объявление рабочего пространства на паузу / включает его на платформе
+// (вручную и правилами); userID пишется в аудит. ads.status меняется оптимистично до запроса
+// к платформе и откатывается, если платформа отказала; каждая попытка пишется в аудит.
+func (s *AdsService) SetWorkspaceStatus(ctx context.Context, workspaceID, userID, adID int64, status string) (entity.AdStatusResult, error) {
+	res := entity.AdStatusResult{AdID: adID, Status: status}
+	if status != entity.AdStatusActive && status != entity.AdStatusPaused {
+		return res, fmt.Errorf("unsupported ad status %q", status)
+	}
+
+	t, err := s.repo.WorkspaceTarget(ctx, workspaceID, adID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, errs.ErrAdNotFound
+	}
+	if err != nil {
+		return res, err
+	}
+	if t.Status == status {
+		return res, nil
+	}
+	m, ok := s.mutators[t.Platform]
+	if !ok {
+		return res, errs.ErrAdPlatformUnsupported
+	}
+
+	swapped, err := s.repo.SwapStatus(ctx, adID, t.Status, status)
+	if err != nil {
+		return res, err
+	}
+	if !swapped {
+		return res, errs.ErrAdStatusConflict
+	}
+
+	change := entity.AdStatusChange{
+		AdID: adID, UserID: userID, Platform: t.Platform,
+		FromStatus: t.Status, ToStatus: status, Result: "applied",
+	}
+	if mErr := m.SetAdStatus(ctx, t, status); mErr != nil {
+		change.Result, change.Error = "failed", mErr.Error()
+		if _, err := s.repo.SwapStatus(ctx, adID, status, t.Status); err != nil {
+			log.Printf("ads: revert status of ad %d: %v", adID, err)
+		}
+		s.audit(ctx, change)
+		return res, mErr
+	}
+	s.audit(ctx, change)
+	res.Changed = true
+	return res, nil
+}
+
+// audit — best effort: статус на платформе уже изменён (или откачен), ответ из-за аудита не роняем
+func (s *AdsService) audit(ctx context.Context, ch entity.AdStatusChange) {
+	if err := s.repo.LogStatusChange(ctx, ch); err != nil {
+		log.Printf("ads: audit status change of ad %d: %v", ch.AdID, err)
+	}
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/anomaly"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/safehttp"
+)
+
+type AlertsRepo interface {
+	MetricHistory(ctx context.Context, from, to time.Time) ([]entity.AdMetricDay, error)
+	CreateAlert(ctx context.Context, a entity.Alert) (int64, bool, error)
+	ListAlerts(ctx context.Context, workspaceID int64, limit int) ([]entity.Alert, error)
+	ListChannels(ctx context.Context, workspaceID int64) ([]entity.AlertChannel, error)
+	CreateChannel(ctx context.Context, ch entity.AlertChannel) (entity.AlertChannel, error)
+	DeleteChannel(ctx context.Context, workspaceID, channelID int64) (bool, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.AlertDelivery, error)
+	MarkDelivered(ctx context.Context, alertID, channelID int64) error
+	MarkDeliveryFailed(ctx context.Context, alertID, channelID int64, errText string, final bool, retryAt time.Time) error
+}
+
+// AlertSender отправляет алерт в канал одного вида (webhook, slack, email); target — URL или адрес.
+type AlertSender interface {
+	Send(ctx context.Context, target string, a entity.Alert) error
+}
+
+// DefaultAlertThresholds — пороги детектора по умолчанию (переопределяются ALERT_THRESHOLDS).
+func DefaultAlertThresholds() map[string]anomaly.Threshold {
+	return map[string]anomaly.Threshold{
+		"spend":       {Direction: anomaly.Up, Z: 3, Pct: 50, MinMean: 1},
+		"clicks":      {Direction: anomaly.Both, Z: 3, Pct: 50, MinMean: 10},
+		"conversions": {Direction: anomaly.Down, Z: 2, Pct: 50, MinMean: 1, OnZero: true},
+		"revenue":     {Direction: anomaly.Down, Z: 3, Pct: 50, MinMean: 1},
+		"cpa":         {Direction: anomaly.Up, Z: 3, Pct: 50, MinMean: 1},
+	}
+}
+
+const (
+	defaultBaselineDays = 14
+	minBaselineSamples  = 7
+
+	deliveryLease = 2 * time.Minute
+)
+
+// AlertsService — детектор аномалий по ad_daily_metrics, алерты и их доставка по каналам.
+type AlertsService struct {
+	repo         AlertsRepo
+	senders      map[string]AlertSender
+	maxAttempts  int
+	thresholds   map[string]anomaly.Threshold
+	baselineDays int
+	now          func() time.Time
+}
+
+// NewAlerts: senders — отправители по виду канала; каналы без отправителя считаются недоступными.
+func NewAlerts(repo AlertsRepo, senders map[string]AlertSender, maxAttempts int) *AlertsService {
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	return &AlertsService{
+		repo:         repo,
+		senders:      senders,
+		maxAttempts:  maxAttempts,
+		thresholds:   DefaultAlertThresholds(),
+		baselineDays: defaultBaselineDays,
+		now:          time.Now,
+	}
+}
+
+// WithThresholds переопределяет пороги отдельных метрик, остальные остаются по умолчанию.
+func (s *AlertsService) WithThresholds(th map[string]anomaly.Threshold) *AlertsService {
+	for metric, t := range th {
+		s.thresholds[metric] = t
+	}
+	return s
+}
+
+// WithBaselineDays — сколько предыдущих дней входит в базу.
+func (s *AlertsService) WithBaselineDays(days int) *AlertsService {
+	if days >= minBaselineSamples {
+		s.baselineDays = days
+	}
+	return s
+}
+
+func (s *AlertsService) List(ctx context.Context, workspaceID int64, limit int) ([]entity.Alert, error) {
+	return s.repo.ListAlerts(ctx, workspaceID, limit)
+}
+
+func (s *AlertsService) Channels(ctx context.Context, workspaceID int64) ([]entity.AlertChannel, error) {
+	return s.repo.ListChannels(ctx, workspaceID)
+}
+
+func (s *AlertsService) CreateChannel(ctx context.Context, actor entity.WorkspaceMember, ch entity.AlertChannel) (entity.AlertChannel, error) {
+	ch.WorkspaceID, ch.UserID = actor.WorkspaceID, actor.UserID
+	ch.Kind = strings.ToLower(strings.TrimSpace(ch.Kind))
+	ch.Target = strings.TrimSpace(ch.Target)
+	if err := validateChannel(ch); err != nil {
+		return entity.AlertChannel{}, err
+	}
+	if _, ok := s.senders[ch.Kind]; !ok {
+		return entity.AlertChannel{}, fmt.Errorf("%w: %s delivery is not configured", errs.ErrInvalidAlertChannel, ch.Kind)
+	}
+	return s.repo.CreateChannel(ctx, ch)
+}
+
+func (s *AlertsService) DeleteChannel(ctx context.Context, workspaceID, channelID int64) error {
+	ok, err := s.repo.DeleteChannel(ctx, workspaceID, channelID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrAlertChannelNotFound
+	}
+	return nil
+}
+
+// Detect ищет аномалии за день day (нулевой — вчера по UTC) относительно предыдущих baselineDays дней.
+// Повторный прогон за тот же день новых алертов не создаёт.
+func (s *AlertsService) Detect(ctx context.Context, day time.Time) (entity.AlertRunStats, error) {
+	var st entity.AlertRunStats
+	if day.IsZero() {
+		day = s.now().UTC().AddDate(0, 0, -1)
+	}
+	day = day.UTC().Truncate(24 * time.Hour)
+
+	history, err := s.repo.MetricHistory(ctx, day.AddDate(0, 0, -s.baselineDays), day)
+	if err != nil {
+		return st, err
+	}
+
+	// история упорядочена по ad_id, дням
+	for start := 0; start < len(history); {
+		end := start
+		for end < len(history) && history[end].AdID == history[start].AdID {
+			end++
+		}
+		st.Ads++
+		for _, a := range s.detectAd(history[start:end], day) {
+			_, created, err := s.repo.CreateAlert(ctx, a)
+			if err != nil {
+				return st, err
+			}
+			if created {
+				st.Alerts++
+			}
+		}
+		start = end
+	}
+	return st, nil
+}
+
+// detectAd — аномалии одного объявления; без строки за целевой день (синк не дошёл) ничего не проверяется.
+func (s *AlertsService) detectAd(days []entity.AdMetricDay, day time.Time) []entity.Alert {
+	last := days[len(days)-1]
+	if !last.Day.UTC().Truncate(24 * time.Hour).Equal(day) {
+		return nil
+	}
+	base := days[:len(days)-1]
+
+	var out []entity.Alert
+	for _, metric := range sortedMetrics(s.thresholds) {
+		value, ok := metricValue(last, metric)
+		if !ok {
+			continue
+		}
+		var baseline []float64
+		for _, d := range base {
+			if v, ok := metricValue(d, metric); ok {
+				baseline = append(baseline, v)
+			}
+		}
+		if len(baseline) < minBaselineSamples {
+			continue
+		}
+		r, hit := anomaly.Detect(baseline, value, s.thresholds[metric])
+		if !hit {
+			continue
+		}
+		out = append(out, entity.Alert{
+			WorkspaceID: last.WorkspaceID,
+			AdID:        last.AdID,
+			AdName:      last.Name,
+			Kind:        entity.AlertKindAnomaly,
+			Metric:      metric,
+			Day:         day.Format("2006-01-02"),
+			Value:       value,
+			Baseline:    r.Mean,
+			ZScore:      r.Z,
+			PctChange:   r.PctChange,
+			Direction:   r.Direction,
+			Message: fmt.Sprintf("%s of %q (ad %d) on %s is %s %.0f%%: %.2f vs %.2f average over %d days (z=%.1f)",
+				metric, last.Name, last.AdID, day.Format("2006-01-02"), r.Direction,
+				math.Abs(r.PctChange), value, r.Mean, len(baseline), r.Z),
+		})
+	}
+	return out
+}
+
+// Deliver отправляет до batch ожидающих доставок. Неудачная попытка повторяется
+// по расписанию retryBackoff; после maxAttempts доставка помечается failed.
+func (s *AlertsService) Deliver(ctx context.Context, batch int) (entity.AlertRunStats, error) {
+	var st entity.AlertRunStats
+	list, err := s.repo.ClaimDeliveries(ctx, batch, deliveryLease)
+	if err != nil {
+		return st, err
+	}
+	for _, d := range list {
+		sendErr := fmt.Errorf("no sender for channel kind %q", d.Channel.Kind)
+		if sender, ok := s.senders[d.Channel.Kind]; ok {
+			sendErr = sender.Send(ctx, d.Channel.Target, d.Alert)
+		}
+		if sendErr == nil {
+			if err := s.repo.MarkDelivered(ctx, d.Alert.AlertID, d.Channel.ChannelID); err != nil {
+				return st, err
+			}
+			st.Sent++
+			continue
+		}
+
+		final := d.Attempts >= s.maxAttempts
+		retryAt := s.now().Add(retryBackoff(d.Attempts))
+		if err := s.repo.MarkDeliveryFailed(ctx, d.Alert.AlertID, d.Channel.ChannelID, sendErr.Error(), final, retryAt); err != nil {
+			return st, err
+		}
+		if final {
+			st.Failed++
+			log.Printf("alerts: alert %d -> channel %d failed after %d attempts: %v",
+				d.Alert.AlertID, d.Channel.ChannelID, d.Attempts, sendErr)
+		} else {
+			st.Retried++
+		}
+	}
+	return st, nil
+}
+
+// NotifyRule — действие notify у sieve rule: алерт вида rule уходит по каналам пространства правила.
+func (s *AlertsService) NotifyRule(ctx context.Context, r entity.Rule, m entity.RuleMatch) error {
+	keys := make([]string, 0, len(m.Metrics))
+	for k := range m.Metrics {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+m.Metrics[k])
+	}
+
+	_, _, err := s.repo.CreateAlert(ctx, entity.Alert{
+		WorkspaceID: r.WorkspaceID,
+		AdID:        m.AdID,
+		AdName:      m.Name,
+		Kind:        entity.AlertKindRule,
+		Metric:      fmt.Sprintf("rule:%d", r.RuleID),
+		Day:         s.now().UTC().Format("2006-01-02"),
+		Message: fmt.Sprintf("rule %q matched %q (ad %d) over the last %d days: %s",
+			r.Name, m.Name, m.AdID, r.WindowDays, strings.Join(parts, ", ")),
+	})
+	return err
+}
+
+// validateChannel: webhook и slack — http(s) URL на публичный хост, email — один адрес.
+func validateChannel(ch entity.AlertChannel) error {
+	switch ch.Kind {
+	case entity.AlertChannelWebhook, entity.AlertChannelSlack:
+		if err := safehttp.ValidateURL(ch.Target); err != nil {
+			return fmt.Errorf("%w: target: %v", errs.ErrInvalidAlertChannel, err)
+		}
+	case entity.AlertChannelEmail:
+		addr, err := mail.ParseAddress(ch.Target)
+		if err != nil || addr.Address != ch.Target {
+			return fmt.Errorf("%w: target must be an email address", errs.ErrInvalidAlertChannel)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be webhook|slack|email", errs.ErrInvalidAlertChannel)
+	}
+	return nil
+}
+
+// metricValue — значение метрики за день; CPA определена только для дней с конверсиями.
+func metricValue(d entity.AdMetricDay, metric string) (float64, bool) {
+	switch metric {
+	case "spend":
+		return d.Spend.InexactFloat64(), true
+	case "clicks":
+		return float64(d.Clicks), true
+	case "conversions":
+		return float64(d.Conversions), true
+	case "revenue":
+		return d.Revenue.InexactFloat64(), true
+	case "cpa":
+		if d.Conversions == 0 {
+			return 0, false
+		}
+		return d.Spend.InexactFloat64() / float64(d.Conversions), true
+	}
+	return 0, false
+}
+
+func sortedMetrics(th map[string]anomaly.Threshold) []string {
+	out := make([]string, 0, len(th))
+	for m := range th {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// NotifyBudget — алерт о прогнозируемом перерасходе бюджета; один на бюджет за месяц.
+func (s *AlertsService) NotifyBudget(ctx context.Context, p entity.BudgetPacing) (bool, error) {
+	_, created, err := s.repo.CreateAlert(ctx, entity.Alert{
+		WorkspaceID: p.WorkspaceID,
+		Kind:        entity.AlertKindBudget,
+		Metric:      fmt.Sprintf("budget:%d", p.BudgetID),
+		Day:         p.PeriodStart,
+		Value:       p.Projected.InexactFloat64(),
+		Baseline:    p.Limit.InexactFloat64(),
+		PctChange:   p.PacePct,
+		Direction:   anomaly.Up,
+		Message: fmt.Sprintf("budget %q is projected to spend %s of %s by %s (%+.0f%%); spent %s so far",
+			p.Name, p.Projected.StringFixed(2), p.Limit.StringFixed(2), p.PeriodEnd, p.PacePct, p.Spent.StringFixed(2)),
+	})
+	return created, err
+}
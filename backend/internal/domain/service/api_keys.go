@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+type APIKeysRepo interface {
+	CreateAPIKey(ctx context.Context, k entity.APIKey, keyHash string) (entity.APIKey, error)
+	ListAPIKeys(ctx context.Context, workspaceID int64) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, workspaceID, keyID int64) (bool, error)
+	APIKeyByHash(ctx context.Context, keyHash string) (entity.APIKey, error)
+}
+
+// apiKeyPrefix — по нему ключ узнаётся в логах и сканерах утечек
+const apiKeyPrefix = "ask_"
+
+// APIKeysService — ключи серверных интеграций: выпуск, отзыв и проверка предъявленного ключа.
+type APIKeysService struct {
+	repo APIKeysRepo
+}
+
+func NewAPIKeys(repo APIKeysRepo) *APIKeysService { return &APIKeysService{repo: repo} }
+
+func (s *APIKeysService) List(ctx context.Context, workspaceID int64) ([]entity.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx, workspaceID)
+}
+
+// Create выпускает ключ в пространстве актора; сам ключ возвращается только здесь.
+func (s *APIKeysService) Create(ctx context.Context, actor entity.WorkspaceMember, name string) (entity.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return entity.APIKey{}, fmt.Errorf("%w: name is required (up to 100 chars)", errs.ErrInvalidAPIKey)
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return entity.APIKey{}, err
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	out, err := s.repo.CreateAPIKey(ctx, entity.APIKey{
+		WorkspaceID: actor.WorkspaceID, UserID: actor.UserID, Name: name, Prefix: key[:len(apiKeyPrefix)+6],
+	}, hashAPIKey(key))
+	if err != nil {
+		return entity.APIKey{}, err
+	}
+	out.Key = key
+	return out, nil
+}
+
+func (s *APIKeysService) Revoke(ctx context.Context, workspaceID, keyID int64) error {
+	ok, err := s.repo.RevokeAPIKey(ctx, workspaceID, keyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate — действующий ключ по предъявленному значению; неизвестный или отозванный —
+// errs.ErrInvalidAPIKey.
+func (s *APIKeysService) Authenticate(ctx context.Context, key string) (entity.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return entity.APIKey{}, errs.ErrInvalidAPIKey
+	}
+	k, err := s.repo.APIKeyByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.APIKey{}, errs.ErrInvalidAPIKey
+	}
+	return k, err
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
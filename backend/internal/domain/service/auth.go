@@ -3,8 +3,10 @@ package service
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"strconv"
 	"time"
@@ -12,7 +14,8 @@ import (
 	"github.com/berezovskyivalerii/adsieve/internal/domain"
 	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
 	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type PasswordHasher interface {
@@ -21,20 +24,61 @@ type PasswordHasher interface {
 }
 
 type SessionsRepository interface {
-	Create(ctx context.Context, token entity.RefreshToken) error
-	Get(ctx context.Context, token string) (entity.RefreshToken, error)
+	CreateSession(ctx context.Context, s entity.Session, tokenHash string) (int64, error)
+	Rotate(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (entity.RefreshToken, error)
+	ListSessions(ctx context.Context, userID int64) ([]entity.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID int64, reason string) error
+	RevokeAllSessions(ctx context.Context, userID int64, reason string) error
+}
+
+// AccessDenylist — отзыв ещё не истёкших access-токенов (проверяется в middleware.JWTAuth)
+type AccessDenylist interface {
+	Deny(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	CutOff(ctx context.Context, userID int64, ttl time.Duration) error
+}
+
+// TokenSigner подписывает access-токены (jwtkeys.KeySet: активный ключ, kid в заголовке)
+type TokenSigner interface {
+	Sign(claims jwt.Claims) (string, error)
+}
+
+const (
+	defaultAccessTTL  = 24 * time.Hour
+	defaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// accessClaims — claims access-токена; sid — сессия (семейство refresh-токенов), из которой он выдан
+type accessClaims struct {
+	jwt.RegisteredClaims
+	SessionID int64 `json:"sid,omitempty"`
 }
 
 type AuthService struct {
 	repo        domain.AuthRepository
 	sessionRepo SessionsRepository
 	hasher      PasswordHasher
-	ttl         time.Duration // Добавить ttl в конфиг
-	jwtKey      []byte
+	denylist    AccessDenylist
+	signer      TokenSigner
+	ttl         time.Duration // время жизни access-токена
+	refreshTTL  time.Duration
+}
+
+func NewAuthService(r domain.AuthRepository, sessionrepo SessionsRepository, denylist AccessDenylist, hasher PasswordHasher, signer TokenSigner) *AuthService {
+	return &AuthService{
+		repo: r, hasher: hasher, sessionRepo: sessionrepo, denylist: denylist, signer: signer,
+		ttl: defaultAccessTTL, refreshTTL: defaultRefreshTTL,
+	}
 }
 
-func NewAuthService(r domain.AuthRepository, sessionrepo SessionsRepository, hasher PasswordHasher, key []byte) *AuthService {
-	return &AuthService{repo: r, hasher: hasher, sessionRepo: sessionrepo, jwtKey: key, ttl: time.Hour * 24}
+// WithTTL задаёт время жизни access- и refresh-токенов (нулевое значение — по умолчанию 24h / 30d).
+func (s *AuthService) WithTTL(access, refresh time.Duration) *AuthService {
+	if access > 0 {
+		s.ttl = access
+	}
+	if refresh > 0 {
+		s.refreshTTL = refresh
+	}
+	return s
 }
 
 func (s *AuthService) SignUp(
@@ -58,7 +102,7 @@ func (s *AuthService) SignUp(
 		return "", "", err
 	}
 
-	return s.generateTokens(ctx, userID)
+	return s.startSession(ctx, userID, inp)
 }
 
 func (s *AuthService) SignIn(
@@ -74,41 +118,49 @@ func (s *AuthService) SignIn(
 		return "", "", errs.ErrInvalidCreds
 	}
 
-	access, refresh, err := s.generateTokens(ctx, user.UserID)
+	access, refresh, err := s.startSession(ctx, user.UserID, inp)
 	if err != nil {
 		return "", "", err
 	}
 	return access, refresh, nil
 }
 
-func (s *AuthService) generateTokens(ctx context.Context, userID int64) (string, string, error) {
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.StandardClaims{
-		Subject:   strconv.Itoa(int(userID)),
-		IssuedAt:  time.Now().Unix(),
-		ExpiresAt: time.Now().Add(s.ttl).Unix(),
-	})
-
-	accessToken, err := t.SignedString(s.jwtKey)
+// startSession открывает новую сессию (семейство refresh-токенов) для входа с устройства.
+func (s *AuthService) startSession(ctx context.Context, userID int64, inp entity.SignInput) (string, string, error) {
+	refreshToken, err := newRefreshToken()
 	if err != nil {
 		return "", "", err
 	}
-
-	refreshToken, err := newRefreshToken()
+	sessionID, err := s.sessionRepo.CreateSession(ctx, entity.Session{
+		UserID:    userID,
+		UserAgent: inp.UserAgent,
+		IP:        inp.IP,
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+	}, hashRefreshToken(refreshToken))
 	if err != nil {
 		return "", "", err
 	}
 
-	if err := s.sessionRepo.Create(ctx, entity.RefreshToken{
-		UserID:       userID,
-		RefreshToken: refreshToken,
-		ExpiresAt:    time.Now().Add(time.Hour * 24 * 30),
-	}); err != nil {
+	accessToken, err := s.accessToken(userID, sessionID)
+	if err != nil {
 		return "", "", err
 	}
-
 	return accessToken, refreshToken, nil
 }
 
+func (s *AuthService) accessToken(userID, sessionID int64) (string, error) {
+	now := time.Now()
+	return s.signer.Sign(accessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(), // jti — по нему токен можно отозвать до истечения
+			Subject:   strconv.Itoa(int(userID)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+		},
+		SessionID: sessionID,
+	})
+}
+
 func newRefreshToken() (string, error) {
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
@@ -117,8 +169,20 @@ func newRefreshToken() (string, error) {
 	return base64.URLEncoding.EncodeToString(b), nil
 }
 
+// hashRefreshToken — в БД лежит только SHA-256 токена (hex)
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Refresh обменивает refresh-токен на новую пару в рамках той же сессии.
+// Повторное предъявление уже обменянного токена отзывает эту сессию (errs.ErrRefreshTokenReused).
 func (s *AuthService) Refresh(ctx context.Context, oldRefresh string) (string, string, error) {
-	session, err := s.sessionRepo.Get(ctx, oldRefresh)
+	newRefresh, err := newRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+	rt, err := s.sessionRepo.Rotate(ctx, hashRefreshToken(oldRefresh), hashRefreshToken(newRefresh), time.Now().Add(s.refreshTTL))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", "", errs.ErrInvalidRefreshToken
@@ -126,9 +190,48 @@ func (s *AuthService) Refresh(ctx context.Context, oldRefresh string) (string, s
 		return "", "", err
 	}
 
-	if time.Now().After(session.ExpiresAt) {
-		return "", "", errs.ErrRefreshTokenExpired
+	access, err := s.accessToken(rt.UserID, rt.SessionID)
+	if err != nil {
+		return "", "", err
+	}
+	return access, newRefresh, nil
+}
+
+// Sessions — активные сессии пользователя; currentSessionID помечается как текущая.
+func (s *AuthService) Sessions(ctx context.Context, userID, currentSessionID int64) ([]entity.Session, error) {
+	list, err := s.sessionRepo.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Current = list[i].SessionID == currentSessionID
+	}
+	return list, nil
+}
+
+// RevokeSession — выход на конкретном устройстве: refresh-токены сессии больше не принимаются.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	return s.sessionRepo.RevokeSession(ctx, userID, sessionID, "user_revoked")
+}
+
+// SignOut — выход на текущем устройстве: отзывает сессию и сам access-токен (jti) до его истечения.
+func (s *AuthService) SignOut(ctx context.Context, userID, sessionID int64, jti string, expiresAt time.Time) error {
+	if sessionID != 0 {
+		if err := s.sessionRepo.RevokeSession(ctx, userID, sessionID, "sign_out"); err != nil &&
+			!errors.Is(err, errs.ErrSessionNotFound) {
+			return err
+		}
+	}
+	if jti == "" {
+		return nil
 	}
+	return s.denylist.Deny(ctx, jti, userID, expiresAt)
+}
 
-	return s.generateTokens(ctx, session.UserID)
+// SignOutEverywhere — выход на всех устройствах: все сессии и все выданные access-токены.
+func (s *AuthService) SignOutEverywhere(ctx context.Context, userID int64) error {
+	if err := s.sessionRepo.RevokeAllSessions(ctx, userID, "sign_out_everywhere"); err != nil {
+		return err
+	}
+	return s.denylist.CutOff(ctx, userID, s.ttl)
 }
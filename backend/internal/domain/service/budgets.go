@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+type BudgetsRepo interface {
+	ListBudgets(ctx context.Context, workspaceID int64) ([]entity.Budget, error)
+	AllBudgets(ctx context.Context) ([]entity.Budget, error)
+	CreateBudget(ctx context.Context, b entity.Budget) (entity.Budget, error)
+	UpdateBudget(ctx context.Context, b entity.Budget) (entity.Budget, error)
+	DeleteBudget(ctx context.Context, workspaceID, budgetID int64) (bool, error)
+	BudgetSpend(ctx context.Context, b entity.Budget, from, to time.Time) (decimal.Decimal, error)
+}
+
+// BudgetNotifier — алерт о прогнозируемом перерасходе; false — за этот месяц алерт уже был.
+type BudgetNotifier interface {
+	NotifyBudget(ctx context.Context, p entity.BudgetPacing) (bool, error)
+}
+
+// Отклонение прогноза от лимита (в %), в пределах которого темп считается нормальным
+const paceTolerance = 10
+
+// В первые сутки месяца прогноз по паре часов расхода слишком шумный для алертов
+const minAlertElapsedDays = 1
+
+// BudgetsService — бюджеты аккаунтов, пейсинг по расходу из ads_insights и алерты о перерасходе.
+type BudgetsService struct {
+	repo     BudgetsRepo
+	notifier BudgetNotifier
+	now      func() time.Time
+}
+
+func NewBudgets(repo BudgetsRepo) *BudgetsService {
+	return &BudgetsService{repo: repo, now: time.Now}
+}
+
+// WithNotifier подключает алерты о перерасходе (без него CheckAll только считает пейсинг).
+func (s *BudgetsService) WithNotifier(n BudgetNotifier) *BudgetsService {
+	s.notifier = n
+	return s
+}
+
+func (s *BudgetsService) List(ctx context.Context, workspaceID int64) ([]entity.Budget, error) {
+	return s.repo.ListBudgets(ctx, workspaceID)
+}
+
+func (s *BudgetsService) Create(ctx context.Context, actor entity.WorkspaceMember, b entity.Budget) (entity.Budget, error) {
+	b.WorkspaceID, b.UserID = actor.WorkspaceID, actor.UserID
+	if err := normalizeBudget(&b); err != nil {
+		return entity.Budget{}, err
+	}
+	out, err := s.repo.CreateBudget(ctx, b)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Budget{}, fmt.Errorf("%w: unknown account_id", errs.ErrInvalidBudget)
+	}
+	return out, err
+}
+
+func (s *BudgetsService) Update(ctx context.Context, workspaceID, budgetID int64, b entity.Budget) (entity.Budget, error) {
+	b.WorkspaceID, b.BudgetID = workspaceID, budgetID
+	if err := normalizeBudget(&b); err != nil {
+		return entity.Budget{}, err
+	}
+	out, err := s.repo.UpdateBudget(ctx, b)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Budget{}, errs.ErrBudgetNotFound
+	}
+	return out, err
+}
+
+func (s *BudgetsService) Delete(ctx context.Context, workspaceID, budgetID int64) error {
+	ok, err := s.repo.DeleteBudget(ctx, workspaceID, budgetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrBudgetNotFound
+	}
+	return nil
+}
+
+// Pacing — пейсинг всех бюджетов пространства на текущий момент месяца.
+func (s *BudgetsService) Pacing(ctx context.Context, workspaceID int64) ([]entity.BudgetPacing, error) {
+	budgets, err := s.repo.ListBudgets(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	out := make([]entity.BudgetPacing, 0, len(budgets))
+	for _, b := range budgets {
+		p, err := s.pacing(ctx, b, now)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// CheckAll считает пейсинг всех бюджетов и шлёт алерт, когда прогнозируемый перерасход
+// больше alert_pct бюджета. Ошибка одного бюджета не останавливает остальные.
+func (s *BudgetsService) CheckAll(ctx context.Context) (entity.BudgetRunStats, error) {
+	var st entity.BudgetRunStats
+	budgets, err := s.repo.AllBudgets(ctx)
+	if err != nil {
+		return st, err
+	}
+	now := s.now()
+	for _, b := range budgets {
+		st.Budgets++
+		p, err := s.pacing(ctx, b, now)
+		if err != nil {
+			log.Printf("budgets: budget %d (workspace %d): %v", b.BudgetID, b.WorkspaceID, err)
+			continue
+		}
+		if p.Status == entity.PaceOver {
+			st.Over++
+		}
+		if !p.AlertDue || s.notifier == nil {
+			continue
+		}
+		created, err := s.notifier.NotifyBudget(ctx, p)
+		if err != nil {
+			log.Printf("budgets: notify budget %d (workspace %d): %v", b.BudgetID, b.WorkspaceID, err)
+			continue
+		}
+		if created {
+			st.Alerts++
+		}
+	}
+	return st, nil
+}
+
+// pacing: расход с 1-го числа по сегодня и прогноз на конец месяца при текущем темпе.
+// Прошедшая часть месяца считается с точностью до часа — сегодняшний неполный день
+// не занижает прогноз. Дневной бюджет сравнивается с amount × дней в месяце.
+func (s *BudgetsService) pacing(ctx context.Context, b entity.Budget, now time.Time) (entity.BudgetPacing, error) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, -1)
+	days := end.Day()
+
+	limit := b.Amount
+	if b.Period == entity.BudgetPeriodDaily {
+		limit = b.Amount.Mul(decimal.NewFromInt(int64(days)))
+	}
+	spent, err := s.repo.BudgetSpend(ctx, b, start, now.Truncate(24*time.Hour))
+	if err != nil {
+		return entity.BudgetPacing{}, err
+	}
+
+	elapsed := now.Sub(start).Hours() / 24
+	if elapsed < 1.0/24 {
+		elapsed = 1.0 / 24
+	}
+	frac := decimal.NewFromFloat(elapsed / float64(days))
+
+	p := entity.BudgetPacing{
+		Budget:      b,
+		PeriodStart: start.Format("2006-01-02"),
+		PeriodEnd:   end.Format("2006-01-02"),
+		Limit:       limit,
+		Spent:       spent,
+		Expected:    limit.Mul(frac).Round(2),
+		Projected:   spent.Div(frac).Round(2),
+		Status:      entity.PaceOnTrack,
+	}
+	p.PacePct = p.Projected.Sub(limit).Div(limit).Mul(decimal.NewFromInt(100)).Round(2).InexactFloat64()
+	switch {
+	case p.PacePct > paceTolerance:
+		p.Status = entity.PaceOver
+	case p.PacePct < -paceTolerance:
+		p.Status = entity.PaceUnder
+	}
+	p.AlertDue = elapsed >= minAlertElapsedDays && p.PacePct > b.AlertPct.InexactFloat64()
+	return p, nil
+}
+
+// normalizeBudget проверяет бюджет перед сохранением; ошибки — errs.ErrInvalidBudget.
+func normalizeBudget(b *entity.Budget) error {
+	b.Name = strings.TrimSpace(b.Name)
+	b.Period = strings.ToLower(strings.TrimSpace(b.Period))
+	if b.Name == "" {
+		return fmt.Errorf("%w: name is required", errs.ErrInvalidBudget)
+	}
+	if b.Period == "" {
+		b.Period = entity.BudgetPeriodMonthly
+	}
+	if b.Period != entity.BudgetPeriodMonthly && b.Period != entity.BudgetPeriodDaily {
+		return fmt.Errorf("%w: period must be monthly|daily", errs.ErrInvalidBudget)
+	}
+	if !b.Amount.IsPositive() {
+		return fmt.Errorf("%w: amount must be positive", errs.ErrInvalidBudget)
+	}
+	b.Amount = b.Amount.Round(2)
+	if b.AlertPct.IsNegative() {
+		return fmt.Errorf("%w: alert_pct must not be negative", errs.ErrInvalidBudget)
+	}
+
+	seen := map[int64]bool{}
+	ids := make([]int64, 0, len(b.AdIDs))
+	for _, id := range b.AdIDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: invalid ad_id %d", errs.ErrInvalidBudget, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	b.AdIDs = ids
+	return nil
+}
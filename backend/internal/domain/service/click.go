@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 )
 
+// ClickService — регистрация кликов; click.created пишется в outbox репозиторием.
 type ClickService struct {
 	repo domain.ClickRepository
 }
@@ -20,6 +21,8 @@ func (s *ClickService) Click(ctx context.Context, in entity.ClickInput) (int64,
 		AdID:      in.AdID,
 		ClickedAt: in.ParsedClickedAt(),
 		ClickRef:  uuid.New(),
+		Gclid:     in.Gclid,
+		Fbclid:    in.Fbclid,
 	}
 	return s.repo.Click(ctx, click)
 }
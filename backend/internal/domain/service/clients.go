@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/sharetoken"
+)
+
+type ClientsRepo interface {
+	ListClients(ctx context.Context, workspaceID int64) ([]entity.Client, error)
+	CreateClient(ctx context.Context, c entity.Client) (entity.Client, error)
+	UpdateClient(ctx context.Context, c entity.Client) (entity.Client, error)
+	DeleteClient(ctx context.Context, workspaceID, clientID int64) (bool, error)
+	ClientAdIDs(ctx context.Context, clientID int64) ([]int64, error)
+	CreateLink(ctx context.Context, workspaceID int64, l entity.ShareLink) (entity.ShareLink, error)
+	Links(ctx context.Context, workspaceID, clientID int64) ([]entity.ShareLink, error)
+	Link(ctx context.Context, linkID int64) (entity.ShareLink, error)
+	RevokeLink(ctx context.Context, workspaceID, clientID, linkID int64) (bool, error)
+	LogAccess(ctx context.Context, a entity.ShareAccess) error
+	AccessLog(ctx context.Context, workspaceID, clientID, linkID int64, limit int) ([]entity.ShareAccess, error)
+}
+
+// AdMetrics — метрики по заданному набору объявлений (MetricsService.ForAds).
+type AdMetrics interface {
+	ForAds(ctx context.Context, adIDs []int64, f entity.MetricsFilter) ([]entity.DailyMetricDTO, error)
+}
+
+const (
+	defaultShareTTL = 7 * 24 * time.Hour
+	maxShareTTL     = 90 * 24 * time.Hour
+)
+
+// ClientsService — клиенты агентства (группы аккаунтов) и публичные ссылки на их отчёт.
+type ClientsService struct {
+	repo    ClientsRepo
+	metrics AdMetrics
+	secret  []byte // ключ подписи ссылок; пустой — ссылки выключены
+	linkURL string // к нему дописывается токен, напр. https://app/shared/
+	now     func() time.Time
+}
+
+func NewClients(repo ClientsRepo, metrics AdMetrics, secret []byte) *ClientsService {
+	return &ClientsService{repo: repo, metrics: metrics, secret: secret, now: time.Now}
+}
+
+// WithLinkURL — адрес страницы отчёта; к нему дописывается токен (без него в ответе только токен).
+func (s *ClientsService) WithLinkURL(base string) *ClientsService {
+	s.linkURL = base
+	return s
+}
+
+func (s *ClientsService) List(ctx context.Context, workspaceID int64) ([]entity.Client, error) {
+	return s.repo.ListClients(ctx, workspaceID)
+}
+
+func (s *ClientsService) Create(ctx context.Context, workspaceID int64, c entity.Client) (entity.Client, error) {
+	c.WorkspaceID = workspaceID
+	if err := normalizeClient(&c); err != nil {
+		return entity.Client{}, err
+	}
+	return s.repo.CreateClient(ctx, c)
+}
+
+func (s *ClientsService) Update(ctx context.Context, workspaceID, clientID int64, c entity.Client) (entity.Client, error) {
+	c.WorkspaceID, c.ClientID = workspaceID, clientID
+	if err := normalizeClient(&c); err != nil {
+		return entity.Client{}, err
+	}
+	out, err := s.repo.UpdateClient(ctx, c)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Client{}, errs.ErrClientNotFound
+	}
+	return out, err
+}
+
+func (s *ClientsService) Delete(ctx context.Context, workspaceID, clientID int64) error {
+	ok, err := s.repo.DeleteClient(ctx, workspaceID, clientID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrClientNotFound
+	}
+	return nil
+}
+
+func normalizeClient(c *entity.Client) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" || len(c.Name) > 200 {
+		return fmt.Errorf("%w: name must be 1..200 characters", errs.ErrInvalidClient)
+	}
+	seen := make(map[int64]struct{}, len(c.AccountIDs))
+	ids := make([]int64, 0, len(c.AccountIDs))
+	for _, id := range c.AccountIDs {
+		if id <= 0 {
+			return fmt.Errorf("%w: invalid account_id %d", errs.ErrInvalidClient, id)
+		}
+		if _, dup := seen[id]; !dup {
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	c.AccountIDs = ids
+	return nil
+}
+
+// CreateLink выпускает публичную ссылку на отчёт клиента. ttl = 0 — 7 дней, максимум 90.
+// Токен возвращается только здесь: в БД он не хранится.
+func (s *ClientsService) CreateLink(ctx context.Context, actor entity.WorkspaceMember, clientID int64, ttl time.Duration) (entity.ShareLink, error) {
+	if len(s.secret) == 0 {
+		return entity.ShareLink{}, errs.ErrShareLinksDisabled
+	}
+	if ttl == 0 {
+		ttl = defaultShareTTL
+	}
+	if ttl < time.Minute || ttl > maxShareTTL {
+		return entity.ShareLink{}, fmt.Errorf("%w: ttl must be between 1 minute and 90 days", errs.ErrInvalidClient)
+	}
+	// срок в токене — в секундах, храним его же
+	exp := s.now().Add(ttl).UTC().Truncate(time.Second)
+	l, err := s.repo.CreateLink(ctx, actor.WorkspaceID, entity.ShareLink{
+		ClientID:  clientID,
+		CreatedBy: actor.UserID,
+		ExpiresAt: exp,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.ShareLink{}, errs.ErrClientNotFound
+	}
+	if err != nil {
+		return entity.ShareLink{}, err
+	}
+	l.Token = sharetoken.Sign(s.secret, l.LinkID, exp)
+	if s.linkURL != "" {
+		l.URL = s.linkURL + url.PathEscape(l.Token)
+	}
+	return l, nil
+}
+
+func (s *ClientsService) Links(ctx context.Context, workspaceID, clientID int64) ([]entity.ShareLink, error) {
+	return s.repo.Links(ctx, workspaceID, clientID)
+}
+
+func (s *ClientsService) RevokeLink(ctx context.Context, workspaceID, clientID, linkID int64) error {
+	ok, err := s.repo.RevokeLink(ctx, workspaceID, clientID, linkID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrShareLinkNotFound
+	}
+	return nil
+}
+
+func (s *ClientsService) AccessLog(ctx context.Context, workspaceID, clientID, linkID int64, limit int) ([]entity.ShareAccess, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	return s.repo.AccessLog(ctx, workspaceID, clientID, linkID, limit)
+}
+
+// SharedMetrics — метрики клиента по публичной ссылке (без логина). Каждое обращение по
+// действительной подписи пишется в журнал, включая отказы по сроку и отзыву.
+func (s *ClientsService) SharedMetrics(ctx context.Context, token string, f entity.MetricsFilter, visitor entity.ShareAccess) ([]entity.DailyMetricDTO, error) {
+	if len(s.secret) == 0 {
+		return nil, errs.ErrShareLinkNotFound
+	}
+	linkID, exp, err := sharetoken.Parse(s.secret, token)
+	if err != nil {
+		return nil, errs.ErrShareLinkNotFound
+	}
+	l, err := s.repo.Link(ctx, linkID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errs.ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	visitor.LinkID = linkID
+	now := s.now()
+	switch {
+	case l.RevokedAt != nil:
+		visitor.Outcome = entity.ShareOutcomeRevoked
+	case !now.Before(exp) || !now.Before(l.ExpiresAt):
+		visitor.Outcome = entity.ShareOutcomeExpired
+	default:
+		visitor.Outcome = entity.ShareOutcomeOK
+	}
+	// журнал — не повод отказать в отчёте
+	if err := s.repo.LogAccess(ctx, visitor); err != nil {
+		log.Printf("share link %d: access log: %v", linkID, err)
+	}
+	switch visitor.Outcome {
+	case entity.ShareOutcomeRevoked:
+		return nil, errs.ErrShareLinkRevoked
+	case entity.ShareOutcomeExpired:
+		return nil, errs.ErrShareLinkExpired
+	}
+
+	adIDs, err := s.repo.ClientAdIDs(ctx, l.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	return s.metrics.ForAds(ctx, adIDs, f)
+}
@@ -8,15 +8,36 @@ import (
 	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
 )
 
+// MetaConversionQueue готовит событие для очереди пересылки в Meta Conversions API
+type MetaConversionQueue interface {
+	PrepareMeta(click entity.Click, in entity.ConversionInput) entity.MetaCAPIEvent
+}
+
+// ConversionService пишет конверсию вместе с её выгрузками в платформы одним запросом:
+// сбой постановки в очередь откатывает и конверсию, трекер повторит запрос.
 type ConversionService struct {
 	clickRepo      domain.ClickRepository
 	conversionRepo domain.ConversionRepository
+	googleUploads  bool                // false — загрузка в Google выключена
+	metaQueue      MetaConversionQueue // может быть nil — CAPI выключен
 }
 
 func NewConversionService(c domain.ClickRepository, conv domain.ConversionRepository) *ConversionService {
 	return &ConversionService{clickRepo: c, conversionRepo: conv}
 }
 
+// WithGoogleUploads ставит конверсии кликов с gclid в очередь офлайн-загрузки в Google Ads.
+func (s *ConversionService) WithGoogleUploads() *ConversionService {
+	s.googleUploads = true
+	return s
+}
+
+// WithMetaQueue ставит конверсии в очередь пересылки в Meta Conversions API.
+func (s *ConversionService) WithMetaQueue(mq MetaConversionQueue) *ConversionService {
+	s.metaQueue = mq
+	return s
+}
+
 func (s *ConversionService) Create(ctx context.Context, in entity.ConversionInput) (int64, error) {
 	click, err := s.clickRepo.ByClickID(ctx, in.ClickID) // Проверяем существует ли указаный клик
 	if err != nil {
@@ -33,9 +54,20 @@ func (s *ConversionService) Create(ctx context.Context, in entity.ConversionInpu
 		OrderID:     in.OrderID,
 		ClickRef:    &click.ClickRef, // связь с помощью поля ClickRef с таблицей clicks
 	}
-	id, err := s.conversionRepo.Create(ctx, conv)
+	var up entity.ConversionUploads
+	if s.googleUploads && click.Gclid != nil {
+		up.Gclid = *click.Gclid
+	}
+	if s.metaQueue != nil {
+		ev := s.metaQueue.PrepareMeta(click, in)
+		up.Meta = &ev
+	}
+	id, err := s.conversionRepo.Create(ctx, conv, up)
 	if err == errs.ErrConversionExists {
 		return 0, errs.ErrConversionExists
 	}
-	return id, err
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
 }
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+type GoogleConversionUploader interface {
+	UploadClickConversions(ctx context.Context, userID int64, customerID string, convs []entity.ClickConversion) ([]error, error)
+}
+
+type GoogleConversionUploadsRepo interface {
+	ClaimDue(ctx context.Context, limit int, stuckAfter time.Duration) ([]entity.GoogleConversionUpload, error)
+	MarkUploaded(ctx context.Context, conversionID int64) error
+	MarkRetry(ctx context.Context, conversionID int64, lastErr string, nextAttempt time.Time) error
+	MarkFailed(ctx context.Context, conversionID int64, lastErr string) error
+	ByConversionID(ctx context.Context, userID, conversionID int64) (entity.GoogleConversionUpload, error)
+}
+
+type ConversionActionRepo interface {
+	SetConversionAction(ctx context.Context, workspaceID int64, customerID, conversionAction string) error
+}
+
+// UploadStats — итог одного прогона воркера
+type UploadStats struct {
+	Claimed  int
+	Uploaded int
+	Retried  int
+	Failed   int
+}
+
+type GoogleConversionUploadService struct {
+	gads        GoogleConversionUploader
+	repo        GoogleConversionUploadsRepo
+	accounts    ConversionActionRepo
+	maxAttempts int
+	now         func() time.Time
+}
+
+func NewGoogleConversionUpload(
+	gads GoogleConversionUploader,
+	repo GoogleConversionUploadsRepo,
+	accounts ConversionActionRepo,
+	maxAttempts int,
+) *GoogleConversionUploadService {
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	return &GoogleConversionUploadService{
+		gads:        gads,
+		repo:        repo,
+		accounts:    accounts,
+		maxAttempts: maxAttempts,
+		now:         time.Now,
+	}
+}
+
+// SetConversionAction настраивает, в какой conversion action грузить конверсии аккаунта.
+// Принимает числовой ID или полный resource name; пустая строка отключает загрузку.
+// Аккаунт ищется в пространстве workspaceID.
+func (s *GoogleConversionUploadService) SetConversionAction(ctx context.Context, workspaceID int64, customerID, action string) error {
+	action = strings.TrimSpace(action)
+	cid := strings.ReplaceAll(customerID, "-", "")
+	switch {
+	case action == "":
+	case isDigits(action):
+		action = "customers/" + cid + "/conversionActions/" + action
+	case strings.HasPrefix(action, "customers/"+cid+"/conversionActions/"):
+	default:
+		return errs.ErrInvalidConversionAction
+	}
+	if err := s.accounts.SetConversionAction(ctx, workspaceID, customerID, action); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrAccountNotLinked
+		}
+		return err
+	}
+	return nil
+}
+
+// UploadStatus — статус загрузки конверсии в Google Ads.
+func (s *GoogleConversionUploadService) UploadStatus(ctx context.Context, userID, conversionID int64) (entity.GoogleConversionUpload, error) {
+	u, err := s.repo.ByConversionID(ctx, userID, conversionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, errs.ErrConversionNotFound
+	}
+	return u, err
+}
+
+// RunOnce забирает пачку конверсий из очереди и грузит их в Google Ads,
+// группируя по (user_id, customer_id) — один запрос на группу.
+func (s *GoogleConversionUploadService) RunOnce(ctx context.Context, batch int) (UploadStats, error) {
+	var st UploadStats
+	items, err := s.repo.ClaimDue(ctx, batch, 15*time.Minute)
+	if err != nil {
+		return st, err
+	}
+	st.Claimed = len(items)
+
+	type groupKey struct {
+		userID     int64
+		customerID string
+	}
+	groups := make(map[groupKey][]entity.GoogleConversionUpload)
+	var order []groupKey
+	for _, it := range items {
+		k := groupKey{it.UserID, it.CustomerID}
+		if _, ok := groups[k]; !ok {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], it)
+	}
+
+	for _, k := range order {
+		group := groups[k]
+		convs := make([]entity.ClickConversion, 0, len(group))
+		for _, it := range group {
+			cv := entity.ClickConversion{
+				Gclid:            it.Gclid,
+				ConversionAction: it.ConversionAction,
+				ConvertedAt:      it.ConvertedAt,
+				Value:            it.Revenue.InexactFloat64(),
+			}
+			if it.OrderID != nil {
+				cv.OrderID = *it.OrderID
+			}
+			convs = append(convs, cv)
+		}
+
+		results, err := s.gads.UploadClickConversions(ctx, k.userID, k.customerID, convs)
+		for i, it := range group {
+			itemErr := err
+			if err == nil && i < len(results) {
+				itemErr = results[i]
+			}
+			if itemErr == nil {
+				if err := s.repo.MarkUploaded(ctx, it.ConversionID); err != nil {
+					return st, err
+				}
+				st.Uploaded++
+				continue
+			}
+			retried, err := s.fail(ctx, it, itemErr)
+			if err != nil {
+				return st, err
+			}
+			if retried {
+				st.Retried++
+			} else {
+				st.Failed++
+			}
+		}
+	}
+	return st, nil
+}
+
+func (s *GoogleConversionUploadService) fail(ctx context.Context, it entity.GoogleConversionUpload, cause error) (retried bool, err error) {
+	msg := cause.Error()
+	quota := errors.Is(cause, errs.ErrGoogleQuotaExceeded)
+	// отказ по самой конверсии (битый gclid, дубль и т.п.) и закрытый для нас аккаунт повтором не лечатся;
+	// исчерпанная квота — не вина конверсии, попытки на ней не заканчиваются
+	permanent := errors.Is(cause, errs.ErrConversionRejected) ||
+		errors.Is(cause, errs.ErrGooglePermissionDenied) ||
+		errors.Is(cause, errs.ErrGoogleCustomerNotEnabled)
+	if permanent || (it.Attempts >= s.maxAttempts && !quota) {
+		if err := s.repo.MarkFailed(ctx, it.ConversionID, msg); err != nil {
+			return false, fmt.Errorf("conversion %d: %w", it.ConversionID, err)
+		}
+		return false, nil
+	}
+	next := s.now().Add(retryBackoff(it.Attempts))
+	if d := retryAfter(cause); d > 0 {
+		next = s.now().Add(d)
+	}
+	if err := s.repo.MarkRetry(ctx, it.ConversionID, msg, next); err != nil {
+		return false, fmt.Errorf("conversion %d: %w", it.ConversionID, err)
+	}
+	return true, nil
+}
+
+// Паузы между попытками доставки во внешние платформы (по номеру попытки)
+var retryBackoffs = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 6 * time.Hour}
+
+func retryBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > len(retryBackoffs) {
+		return retryBackoffs[len(retryBackoffs)-1]
+	}
+	return retryBackoffs[attempt-1]
+}
+
+// retryAfter — пауза, которую платформа сама попросила выдержать (quota retryDelay / Retry-After).
+func retryAfter(err error) time.Duration {
+	var ra interface{ RetryAfter() time.Duration }
+	if errors.As(err, &ra) {
+		return ra.RetryAfter()
+	}
+	return 0
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+type TokenRevoker interface {
+	Revoke(ctx context.Context, token string) error
+}
+
+type GoogleTokensRepo interface {
+	GoogleRefreshTokens(ctx context.Context, userID int64, googleUserID string) ([]entity.GoogleRefreshToken, error)
+	DeleteGoogleIdentity(ctx context.Context, userID int64, googleUserID string) error
+	DeleteAllGoogleIdentities(ctx context.Context, userID int64) error
+}
+
+type GoogleLinksRepo interface {
+	UnlinkGoogleAccount(ctx context.Context, workspaceID int64, customerID string) (linkedBy int64, tokenOwner string, err error)
+	CountLinkedByOwner(ctx context.Context, userID int64, owner string) (int, error)
+	PurgeGoogleSpend(ctx context.Context, workspaceID, userID int64, customerID string) error
+}
+
+// GoogleDisconnectService отвязывает Google-аккаунты и отзывает токены у Google.
+// Отзыв — best effort: локальные токены удаляются в любом случае, неудачи считаются в результате.
+type GoogleDisconnectService struct {
+	revoker  TokenRevoker
+	tokens   GoogleTokensRepo
+	accounts GoogleLinksRepo
+}
+
+func NewGoogleDisconnect(revoker TokenRevoker, tokens GoogleTokensRepo, accounts GoogleLinksRepo) *GoogleDisconnectService {
+	return &GoogleDisconnectService{revoker: revoker, tokens: tokens, accounts: accounts}
+}
+
+// DisconnectAccount отвязывает один аккаунт пространства actor. Если аккаунт привязал сам actor
+// и это был последний аккаунт, синкавшийся токеном его Google-логина, — логин тоже отключается
+// (токен отзывается и удаляется). Google-логины других участников не трогаются.
+func (s *GoogleDisconnectService) DisconnectAccount(ctx context.Context, actor entity.WorkspaceMember, customerID string, purge bool) (entity.GoogleDisconnectResult, error) {
+	var res entity.GoogleDisconnectResult
+	linkedBy, owner, err := s.accounts.UnlinkGoogleAccount(ctx, actor.WorkspaceID, customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return res, errs.ErrAccountNotLinked
+	}
+	if err != nil {
+		return res, err
+	}
+
+	if owner != "" && linkedBy == actor.UserID {
+		left, err := s.accounts.CountLinkedByOwner(ctx, actor.UserID, owner)
+		if err != nil {
+			return res, err
+		}
+		if left == 0 {
+			if err := s.revokeAndDelete(ctx, actor.UserID, owner, &res); err != nil && !errors.Is(err, errs.ErrGoogleIdentityNotFound) {
+				return res, err
+			}
+		}
+	}
+
+	if purge {
+		if err := s.accounts.PurgeGoogleSpend(ctx, actor.WorkspaceID, actor.UserID, customerID); err != nil {
+			return res, err
+		}
+		res.SpendPurged = true
+	}
+	return res, nil
+}
+
+// DisconnectIdentity отключает один Google-логин: отзывает его токен, удаляет его
+// и отвязывает аккаунты, которые через него синкались.
+func (s *GoogleDisconnectService) DisconnectIdentity(ctx context.Context, userID int64, googleUserID string) (entity.GoogleDisconnectResult, error) {
+	var res entity.GoogleDisconnectResult
+	err := s.revokeAndDelete(ctx, userID, googleUserID, &res)
+	return res, err
+}
+
+// DisconnectAll отключает Google-логины actor целиком. purge стирает траты только тех аккаунтов
+// пространства actor, которые привязал он сам.
+func (s *GoogleDisconnectService) DisconnectAll(ctx context.Context, actor entity.WorkspaceMember, purge bool) (entity.GoogleDisconnectResult, error) {
+	var res entity.GoogleDisconnectResult
+	toks, err := s.tokens.GoogleRefreshTokens(ctx, actor.UserID, "")
+	if err != nil {
+		return res, err
+	}
+	s.revokeAll(ctx, toks, &res)
+	if err := s.tokens.DeleteAllGoogleIdentities(ctx, actor.UserID); err != nil {
+		return res, err
+	}
+	if purge {
+		if err := s.accounts.PurgeGoogleSpend(ctx, actor.WorkspaceID, actor.UserID, ""); err != nil {
+			return res, err
+		}
+		res.SpendPurged = true
+	}
+	return res, nil
+}
+
+func (s *GoogleDisconnectService) revokeAndDelete(ctx context.Context, userID int64, googleUserID string, res *entity.GoogleDisconnectResult) error {
+	toks, err := s.tokens.GoogleRefreshTokens(ctx, userID, googleUserID)
+	if err != nil {
+		return err
+	}
+	s.revokeAll(ctx, toks, res)
+	return s.tokens.DeleteGoogleIdentity(ctx, userID, googleUserID)
+}
+
+func (s *GoogleDisconnectService) revokeAll(ctx context.Context, toks []entity.GoogleRefreshToken, res *entity.GoogleDisconnectResult) {
+	for _, t := range toks {
+		if err := s.revoker.Revoke(ctx, t.RefreshToken); err != nil {
+			log.Printf("google revoke (%s): %v", t.GoogleUserID, err)
+			res.RevokeFailed++
+			continue
+		}
+		res.RevokedTokens++
+	}
+}
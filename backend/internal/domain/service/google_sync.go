@@ -2,12 +2,14 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
-)
+	"log"
 
-type UserAdsRepo interface {
-	Ensure(ctx context.Context, userID, adID int64) error
-}
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
 
 type GoogleAdsCostStreamer interface {
 	SyncCostsForDate(ctx context.Context, userID int64, customerID, yyyymmdd string,
@@ -15,23 +17,34 @@ type GoogleAdsCostStreamer interface {
 }
 
 type GoogleAdAccountsRepo interface {
-	GetAccountID(ctx context.Context, userID int64, platform, externalID string) (int64, error)
+	GetAccountID(ctx context.Context, workspaceID int64, platform, externalID string) (accountID, linkedBy int64, err error)
 	UpsertAdIfMissing(ctx context.Context, accountID, adID int64) error
 	UpsertSpend(ctx context.Context, adID int64, date string, costMicros int64) error
+	RecordSyncResult(ctx context.Context, accountID int64, syncErr string) error
 }
 
 type GoogleSyncService struct {
-	gads GoogleAdsCostStreamer
-	repo GoogleAdAccountsRepo
-	uads UserAdsRepo
+	gads   GoogleAdsCostStreamer
+	repo   GoogleAdAccountsRepo
+	events EventPublisher // может быть nil
+}
+
+func NewGoogleSync(gads GoogleAdsCostStreamer, repo GoogleAdAccountsRepo) *GoogleSyncService {
+	return &GoogleSyncService{gads: gads, repo: repo}
 }
 
-func NewGoogleSync(gads GoogleAdsCostStreamer, repo GoogleAdAccountsRepo, uads UserAdsRepo) *GoogleSyncService {
-	return &GoogleSyncService{gads: gads, repo: repo, uads: uads}
+// WithEvents включает событие sync.failed для webhook-подписок пространства аккаунта.
+func (s *GoogleSyncService) WithEvents(p EventPublisher) *GoogleSyncService {
+	s.events = p
+	return s
 }
 
-func (s *GoogleSyncService) SyncCostsForDate(ctx context.Context, userID int64, customerID, date string) error {
-	accountID, err := s.repo.GetAccountID(ctx, userID, "google", customerID)
+// SyncCostsForDate синкает траты аккаунта пространства за день — токенами того, кто аккаунт привязал.
+func (s *GoogleSyncService) SyncCostsForDate(ctx context.Context, workspaceID int64, customerID, date string) error {
+	accountID, userID, err := s.repo.GetAccountID(ctx, workspaceID, "google", customerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errs.ErrAccountNotLinked
+	}
 	if err != nil {
 		return fmt.Errorf("lookup account_id: %w", err)
 	}
@@ -40,17 +53,36 @@ func (s *GoogleSyncService) SyncCostsForDate(ctx context.Context, userID int64,
 		if err := s.repo.UpsertAdIfMissing(ctx, accountID, adID); err != nil {
 			return fmt.Errorf("upsert ad %d: %w", adID, err)
 		}
-		// ключевая строка:
-		if err := s.uads.Ensure(ctx, userID, adID); err != nil {
-			return fmt.Errorf("link user->ad %d: %w", adID, err)
-		}
+		// доступ к объявлению следует из аккаунта — отдельной привязки не нужно
 		if err := s.repo.UpsertSpend(ctx, adID, d, costMicros); err != nil {
 			return fmt.Errorf("upsert spend ad %d %s: %w", adID, d, err)
 		}
 		return nil
 	}
 	if err := s.gads.SyncCostsForDate(ctx, userID, customerID, date, sink); err != nil {
+		// ошибку видно в GET /integrations/status
+		if recErr := s.repo.RecordSyncResult(ctx, accountID, err.Error()); recErr != nil {
+			log.Printf("record sync result (account %d): %v", accountID, recErr)
+		}
+		if s.events != nil {
+			pubErr := s.events.Publish(ctx, entity.WebhookEvent{
+				Type:      entity.EventSyncFailed,
+				AccountID: accountID,
+				Data: map[string]any{
+					"platform":    "google",
+					"customer_id": customerID,
+					"date":        date,
+					"error":       err.Error(),
+				},
+			})
+			if pubErr != nil {
+				log.Printf("publish %s (account %d): %v", entity.EventSyncFailed, accountID, pubErr)
+			}
+		}
 		return fmt.Errorf("google searchStream for %s %s: %w", customerID, date, err)
 	}
+	if err := s.repo.RecordSyncResult(ctx, accountID, ""); err != nil {
+		log.Printf("record sync result (account %d): %v", accountID, err)
+	}
 	return nil
 }
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+type IntegrationStatusRepo interface {
+	GoogleTokenCounts(ctx context.Context, workspaceID, userID int64) (active, total int, err error)
+	AccountsSummary(ctx context.Context, workspaceID int64, platform string) (entity.AccountsSyncSummary, error)
+}
+
+type IntegrationStatusService struct {
+	repo IntegrationStatusRepo
+}
+
+func NewIntegrationStatus(repo IntegrationStatusRepo) *IntegrationStatusService {
+	return &IntegrationStatusService{repo: repo}
+}
+
+// Status — состояние подключения по каждой платформе в рабочем пространстве actor.
+// Google: connected — есть хотя бы один рабочий логин; needs_consent — логины есть, но все ждут
+// повторного согласия. Meta подключается токеном аккаунта, поэтому connected = есть привязанные аккаунты.
+func (s *IntegrationStatusService) Status(ctx context.Context, actor entity.WorkspaceMember) ([]entity.IntegrationStatus, error) {
+	active, total, err := s.repo.GoogleTokenCounts(ctx, actor.WorkspaceID, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	google, err := s.platformStatus(ctx, actor.WorkspaceID, "google")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case active > 0:
+		google.State = entity.IntegrationConnected
+	case total > 0:
+		google.State = entity.IntegrationNeedsConsent
+	default:
+		google.State = entity.IntegrationNeverConnected
+	}
+
+	meta, err := s.platformStatus(ctx, actor.WorkspaceID, "facebook")
+	if err != nil {
+		return nil, err
+	}
+	meta.State = entity.IntegrationNeverConnected
+	if meta.LinkedAccounts > 0 {
+		meta.State = entity.IntegrationConnected
+	}
+	return []entity.IntegrationStatus{google, meta}, nil
+}
+
+func (s *IntegrationStatusService) platformStatus(ctx context.Context, workspaceID int64, platform string) (entity.IntegrationStatus, error) {
+	sum, err := s.repo.AccountsSummary(ctx, workspaceID, platform)
+	if err != nil {
+		return entity.IntegrationStatus{}, err
+	}
+	return entity.IntegrationStatus{
+		Platform:       platform,
+		LinkedAccounts: sum.LinkedAccounts,
+		LastSyncAt:     sum.LastSyncAt,
+		LastError:      sum.LastError,
+		LastErrorAt:    sum.LastErrorAt,
+	}, nil
+}
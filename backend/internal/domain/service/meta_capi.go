@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+type MetaCAPISender interface {
+	SendPurchase(ctx context.Context, ev entity.MetaCAPIEvent) (fbtraceID string, err error)
+}
+
+type MetaCAPIRepo interface {
+	SaveSettings(ctx context.Context, workspaceID int64, externalAccountID string, s entity.MetaCAPISettings) error
+	ClaimDue(ctx context.Context, limit int, stuckAfter time.Duration) ([]entity.MetaCAPIEvent, error)
+	MarkSent(ctx context.Context, conversionID int64, fbtraceID string) error
+	MarkRetry(ctx context.Context, conversionID int64, lastErr, fbtraceID string, nextAttempt time.Time) error
+	MarkFailed(ctx context.Context, conversionID int64, lastErr, fbtraceID string) error
+	ByConversionID(ctx context.Context, workspaceID, conversionID int64) (entity.MetaCAPIEvent, error)
+}
+
+type MetaCAPIService struct {
+	sender      MetaCAPISender
+	repo        MetaCAPIRepo
+	maxAttempts int
+	now         func() time.Time
+}
+
+func NewMetaCAPI(sender MetaCAPISender, repo MetaCAPIRepo, maxAttempts int) *MetaCAPIService {
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	return &MetaCAPIService{sender: sender, repo: repo, maxAttempts: maxAttempts, now: time.Now}
+}
+
+// SaveSettings включает/настраивает пересылку конверсий facebook-аккаунта в Conversions API.
+func (s *MetaCAPIService) SaveSettings(ctx context.Context, workspaceID int64, externalAccountID string, st entity.MetaCAPISettings) error {
+	st.PixelID = strings.TrimSpace(st.PixelID)
+	st.AccessToken = strings.TrimSpace(st.AccessToken)
+	st.Currency = strings.ToUpper(strings.TrimSpace(st.Currency))
+	if st.Currency == "" {
+		st.Currency = "USD"
+	}
+	if !isDigits(st.PixelID) || st.AccessToken == "" || len(st.Currency) != 3 {
+		return errs.ErrInvalidPixelSettings
+	}
+	if err := s.repo.SaveSettings(ctx, workspaceID, externalAccountID, st); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrAccountNotLinked
+		}
+		return err
+	}
+	return nil
+}
+
+// PrepareMeta готовит событие CAPI для записываемой конверсии: хэширует email/телефон,
+// восстанавливает fbc из fbclid клика и выбирает event_id для дедупликации с пикселем
+// (без order_id и event_id трекера репозиторий подставит conv-<conversion_id>).
+func (s *MetaCAPIService) PrepareMeta(click entity.Click, in entity.ConversionInput) entity.MetaCAPIEvent {
+	ev := entity.MetaCAPIEvent{
+		EventID: strings.TrimSpace(in.EventID),
+		EmHash:  hashPII(normalizeEmail(in.Email)),
+		PhHash:  hashPII(normalizePhone(in.Phone)),
+		Fbc:     stringOrNil(strings.TrimSpace(in.Fbc)),
+		Fbp:     stringOrNil(strings.TrimSpace(in.Fbp)),
+	}
+	if ev.Fbc == nil && click.Fbclid != nil && *click.Fbclid != "" {
+		// формат fbc: fb.<subdomain_index>.<creation_time_ms>.<fbclid>
+		fbc := fmt.Sprintf("fb.1.%d.%s", click.ClickedAt.UnixMilli(), *click.Fbclid)
+		ev.Fbc = &fbc
+	}
+	if ev.EventID == "" && in.OrderID != nil && *in.OrderID != "" {
+		ev.EventID = *in.OrderID
+	}
+	return ev
+}
+
+// DeliveryStatus — статус пересылки конверсии в CAPI.
+func (s *MetaCAPIService) DeliveryStatus(ctx context.Context, workspaceID, conversionID int64) (entity.MetaCAPIEvent, error) {
+	ev, err := s.repo.ByConversionID(ctx, workspaceID, conversionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ev, errs.ErrConversionNotFound
+	}
+	return ev, err
+}
+
+// RunOnce забирает пачку событий из очереди и отправляет их в CAPI по одному,
+// чтобы отказ по одному событию не ронял остальные.
+func (s *MetaCAPIService) RunOnce(ctx context.Context, batch int) (UploadStats, error) {
+	var st UploadStats
+	items, err := s.repo.ClaimDue(ctx, batch, 15*time.Minute)
+	if err != nil {
+		return st, err
+	}
+	st.Claimed = len(items)
+
+	for _, ev := range items {
+		trace, sendErr := s.sender.SendPurchase(ctx, ev)
+		switch {
+		case sendErr == nil:
+			if err := s.repo.MarkSent(ctx, ev.ConversionID, trace); err != nil {
+				return st, err
+			}
+			st.Uploaded++
+		case errors.Is(sendErr, errs.ErrConversionRejected) || ev.Attempts >= s.maxAttempts:
+			if err := s.repo.MarkFailed(ctx, ev.ConversionID, sendErr.Error(), trace); err != nil {
+				return st, err
+			}
+			st.Failed++
+		default:
+			next := s.now().Add(retryBackoff(ev.Attempts))
+			if err := s.repo.MarkRetry(ctx, ev.ConversionID, sendErr.Error(), trace, next); err != nil {
+				return st, err
+			}
+			st.Retried++
+		}
+	}
+	return st, nil
+}
+
+// ===== helpers =====
+
+// Нормализация по правилам Meta: email — trim + lower; телефон — только цифры с кодом страны.
+func normalizeEmail(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
+
+func normalizePhone(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return strings.TrimLeft(b.String(), "0")
+}
+
+func hashPII(v string) *string {
+	if v == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(v))
+	h := hex.EncodeToString(sum[:])
+	return &h
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
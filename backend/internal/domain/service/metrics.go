@@ -14,24 +14,32 @@ const maxRange = 90 * 24 * time.Hour
 
 type MetricsService struct {
 	metricsRepo domain.MetricsRepository
-	userAdsRepo domain.UserAdsRepo
+	access      domain.AdAccessRepo
 }
 
-func NewMetricsService(m domain.MetricsRepository, a domain.UserAdsRepo) *MetricsService {
-	return &MetricsService{metricsRepo: m, userAdsRepo: a}
+func NewMetricsService(m domain.MetricsRepository, a domain.AdAccessRepo) *MetricsService {
+	return &MetricsService{metricsRepo: m, access: a}
 }
 
-func (s *MetricsService) Get(ctx context.Context, userID int64, f entity.MetricsFilter) ([]entity.DailyMetricDTO, error) {
+func (s *MetricsService) Get(ctx context.Context, workspaceID int64, f entity.MetricsFilter) ([]entity.DailyMetricDTO, error) {
 	/* 1. Диапазон */
 	if f.To.Before(f.From) || f.To.Sub(f.From) > maxRange {
 		return nil, errs.ErrInvalidRange
 	}
 
-	/* 2. Доступные объявления пользователя */
-	allowed, err := s.userAdsRepo.IDsByUser(ctx, userID)
+	/* 2. Доступные объявления рабочего пространства */
+	allowed, err := s.access.IDsByWorkspace(ctx, workspaceID)
 	if err != nil {
 		return nil, err
 	}
+	return s.ForAds(ctx, allowed, f)
+}
+
+// ForAds — метрики в пределах allowed (объявления пространства, клиента по публичной ссылке и т.п.).
+func (s *MetricsService) ForAds(ctx context.Context, allowed []int64, f entity.MetricsFilter) ([]entity.DailyMetricDTO, error) {
+	if f.To.Before(f.From) || f.To.Sub(f.From) > maxRange {
+		return nil, errs.ErrInvalidRange
+	}
 	if len(allowed) == 0 {
 		return nil, errs.ErrNoAdAccess
 	}
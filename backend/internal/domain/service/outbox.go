@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+)
+
+type OutboxRepo interface {
+	Pending(ctx context.Context, limit int) ([]entity.OutboxEvent, error)
+	MarkPublished(ctx context.Context, outboxID int64) error
+	MarkFailed(ctx context.Context, outboxID int64, errText string, dead bool, retryAt time.Time) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OutboxSink — куда relay публикует события (webhook-подписки, брокер, stdout).
+// Публикация может повториться: получатель дедуплицирует по event id.
+type OutboxSink interface {
+	Publish(ctx context.Context, e entity.OutboxEvent) error
+}
+
+// OutboxRelay публикует события outbox во все sinks: at-least-once, по порядку внутри объявления.
+// Событие считается опубликованным, когда его приняли все sinks; сбой любого — повтор целиком.
+type OutboxRelay struct {
+	repo        OutboxRepo
+	sinks       []OutboxSink
+	maxAttempts int
+	now         func() time.Time
+}
+
+// NewOutboxRelay: после maxAttempts неудачных попыток событие становится dead.
+func NewOutboxRelay(repo OutboxRepo, maxAttempts int, sinks ...OutboxSink) *OutboxRelay {
+	if maxAttempts <= 0 {
+		maxAttempts = 10
+	}
+	return &OutboxRelay{repo: repo, sinks: sinks, maxAttempts: maxAttempts, now: time.Now}
+}
+
+// RunOnce публикует до batch голов очередей (по одному готовому событию на объявление),
+// поэтому следующее событие объявления выйдет только после того, как текущее опубликовано
+// или признано dead.
+func (r *OutboxRelay) RunOnce(ctx context.Context, batch int) (entity.OutboxRunStats, error) {
+	var st entity.OutboxRunStats
+	events, err := r.repo.Pending(ctx, batch)
+	if err != nil {
+		return st, err
+	}
+	now := r.now()
+	for _, e := range events {
+		if pubErr := r.publish(ctx, e); pubErr != nil {
+			dead := e.Attempts+1 >= r.maxAttempts
+			if dead {
+				st.Dead++
+				log.Printf("outbox: event %d (%s, ad %d) dead after %d attempts: %v", e.OutboxID, e.EventType, e.AdID, e.Attempts+1, pubErr)
+			} else {
+				st.Failed++
+				log.Printf("outbox: event %d (%s, ad %d) attempt %d: %v", e.OutboxID, e.EventType, e.AdID, e.Attempts+1, pubErr)
+			}
+			if err := r.repo.MarkFailed(ctx, e.OutboxID, pubErr.Error(), dead, now.Add(retryBackoff(e.Attempts+1))); err != nil {
+				return st, err
+			}
+			continue
+		}
+		if err := r.repo.MarkPublished(ctx, e.OutboxID); err != nil {
+			return st, err
+		}
+		st.Published++
+	}
+	return st, nil
+}
+
+// Purge — чистка опубликованных событий старше retention.
+func (r *OutboxRelay) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+	return r.repo.Purge(ctx, r.now().Add(-retention))
+}
+
+func (r *OutboxRelay) publish(ctx context.Context, e entity.OutboxEvent) error {
+	for _, s := range r.sinks {
+		if err := s.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OutboxSink — события outbox в очередь webhook-подписок пространства объявления.
+// Повторная публикация не дублирует доставку (уникальность по event id).
+func (s *WebhooksService) OutboxSink() OutboxSink { return webhookOutboxSink{s} }
+
+type webhookOutboxSink struct{ w *WebhooksService }
+
+func (k webhookOutboxSink) Publish(ctx context.Context, e entity.OutboxEvent) error {
+	err := k.w.Publish(ctx, entity.WebhookEvent{
+		ID:        e.EventID,
+		Type:      e.EventType,
+		CreatedAt: e.CreatedAt.UTC(),
+		Data:      json.RawMessage(e.Payload),
+		AdID:      e.AdID,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook sink: %w", err)
+	}
+	return nil
+}
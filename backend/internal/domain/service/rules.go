@@ -0,0 +1,323 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ruledsl"
+)
+
+type RulesRepo interface {
+	ListRules(ctx context.Context, workspaceID int64) ([]entity.Rule, error)
+	EnabledRules(ctx context.Context) ([]entity.Rule, error)
+	Rule(ctx context.Context, workspaceID, ruleID int64) (entity.Rule, error)
+	CreateRule(ctx context.Context, r entity.Rule) (entity.Rule, error)
+	UpdateRule(ctx context.Context, r entity.Rule) (entity.Rule, error)
+	DeleteRule(ctx context.Context, workspaceID, ruleID int64) (bool, error)
+	AdWindowStats(ctx context.Context, workspaceID int64, from, to time.Time) ([]entity.AdWindowStats, error)
+	FiredSince(ctx context.Context, ruleID int64, since time.Time) (map[int64]bool, error)
+	LogExecution(ctx context.Context, e entity.RuleExecution) error
+	Executions(ctx context.Context, workspaceID, ruleID int64, limit int) ([]entity.RuleExecution, error)
+	TagAd(ctx context.Context, adID int64, tag string) error
+}
+
+// AdStatusSetter — пауза объявления пространства на платформе (AdsService.SetWorkspaceStatus)
+type AdStatusSetter interface {
+	SetWorkspaceStatus(ctx context.Context, workspaceID, userID, adID int64, status string) (entity.AdStatusResult, error)
+}
+
+// RuleNotifier доставляет уведомление о срабатывании правила в каналы пространства
+type RuleNotifier interface {
+	NotifyRule(ctx context.Context, r entity.Rule, m entity.RuleMatch) error
+}
+
+// Правило не срабатывает повторно по тому же объявлению чаще раза в сутки
+const ruleCooldown = 24 * time.Hour
+
+const maxRuleWindowDays = 90
+
+// RulesService — CRUD «sieve rules», dry-run и прогон правил кроном.
+type RulesService struct {
+	repo     RulesRepo
+	ads      AdStatusSetter
+	notifier RuleNotifier
+	now      func() time.Time
+}
+
+func NewRules(repo RulesRepo, ads AdStatusSetter) *RulesService {
+	return &RulesService{repo: repo, ads: ads, notifier: logNotifier{}, now: time.Now}
+}
+
+// WithNotifier подключает доставку уведомлений (по умолчанию — только лог).
+func (s *RulesService) WithNotifier(n RuleNotifier) *RulesService {
+	s.notifier = n
+	return s
+}
+
+func (s *RulesService) List(ctx context.Context, workspaceID int64) ([]entity.Rule, error) {
+	return s.repo.ListRules(ctx, workspaceID)
+}
+
+// Create — правило пространства; правило с паузой может завести только admin
+// (ставить объявления на паузу вручную analyst тоже не может).
+func (s *RulesService) Create(ctx context.Context, actor entity.WorkspaceMember, r entity.Rule) (entity.Rule, error) {
+	r.WorkspaceID, r.UserID = actor.WorkspaceID, actor.UserID
+	if err := normalizeRule(&r); err != nil {
+		return entity.Rule{}, err
+	}
+	if !mayRunAction(actor.Role, r.Action) {
+		return entity.Rule{}, errs.ErrWorkspaceForbidden
+	}
+	return s.repo.CreateRule(ctx, r)
+}
+
+// Update перезаписывает правило; автором (от чьего имени правило выполняется) становится actor.
+func (s *RulesService) Update(ctx context.Context, actor entity.WorkspaceMember, ruleID int64, r entity.Rule) (entity.Rule, error) {
+	r.WorkspaceID, r.UserID, r.RuleID = actor.WorkspaceID, actor.UserID, ruleID
+	if err := normalizeRule(&r); err != nil {
+		return entity.Rule{}, err
+	}
+	if !mayRunAction(actor.Role, r.Action) {
+		return entity.Rule{}, errs.ErrWorkspaceForbidden
+	}
+	out, err := s.repo.UpdateRule(ctx, r)
+	if errors.Is(err, sql.ErrNoRows) {
+		return entity.Rule{}, errs.ErrRuleNotFound
+	}
+	return out, err
+}
+
+func (s *RulesService) Delete(ctx context.Context, workspaceID, ruleID int64) error {
+	ok, err := s.repo.DeleteRule(ctx, workspaceID, ruleID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrRuleNotFound
+	}
+	return nil
+}
+
+func (s *RulesService) Executions(ctx context.Context, workspaceID, ruleID int64, limit int) ([]entity.RuleExecution, error) {
+	if _, err := s.rule(ctx, workspaceID, ruleID); err != nil {
+		return nil, err
+	}
+	return s.repo.Executions(ctx, workspaceID, ruleID, limit)
+}
+
+// DryRun показывает, на каких объявлениях правило сработало бы сейчас; действий не выполняет.
+func (s *RulesService) DryRun(ctx context.Context, workspaceID, ruleID int64) ([]entity.RuleMatch, error) {
+	r, err := s.rule(ctx, workspaceID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	return s.match(ctx, r)
+}
+
+// RunOnce проверяет все включённые правила и выполняет их действия от имени автора
+// с его текущей ролью в пространстве (правила удалённых и понизившихся до read_only авторов
+// репозиторий не отдаёт). В dryRun действия не выполняются, а совпадения пишутся в журнал с dry_run=true.
+// Ошибка одного правила не останавливает остальные.
+func (s *RulesService) RunOnce(ctx context.Context, dryRun bool) (entity.RuleRunStats, error) {
+	var st entity.RuleRunStats
+	rules, err := s.repo.EnabledRules(ctx)
+	if err != nil {
+		return st, err
+	}
+	for _, r := range rules {
+		st.Rules++
+		if err := s.run(ctx, r, dryRun, &st); err != nil {
+			log.Printf("rules: rule %d (workspace %d): %v", r.RuleID, r.WorkspaceID, err)
+		}
+	}
+	return st, nil
+}
+
+func (s *RulesService) run(ctx context.Context, r entity.Rule, dryRun bool, st *entity.RuleRunStats) error {
+	matches, err := s.match(ctx, r)
+	if err != nil {
+		return err
+	}
+	fired, err := s.repo.FiredSince(ctx, r.RuleID, s.now().Add(-ruleCooldown))
+	if err != nil {
+		return err
+	}
+	for _, m := range matches {
+		if fired[m.AdID] {
+			continue
+		}
+		st.Matched++
+		e := entity.RuleExecution{
+			RuleID: r.RuleID, AdID: m.AdID, Action: r.Action, DryRun: dryRun,
+			Result: entity.RuleResultApplied, Metrics: m.Metrics,
+		}
+		if !dryRun {
+			e.Result, e.Error = s.apply(ctx, r, m)
+		}
+		switch e.Result {
+		case entity.RuleResultApplied:
+			st.Applied++
+		case entity.RuleResultSkipped:
+			st.Skipped++
+		default:
+			st.Failed++
+		}
+		if err := s.repo.LogExecution(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply выполняет действие правила по объявлению: результат и текст ошибки для журнала.
+func (s *RulesService) apply(ctx context.Context, r entity.Rule, m entity.RuleMatch) (string, string) {
+	if !mayRunAction(r.AuthorRole, r.Action) {
+		return entity.RuleResultFailed, fmt.Sprintf("rule author (user %d) is %s in the workspace, %s requires admin",
+			r.UserID, r.AuthorRole, r.Action)
+	}
+	var err error
+	switch r.Action {
+	case entity.RuleActionPause:
+		var res entity.AdStatusResult
+		res, err = s.ads.SetWorkspaceStatus(ctx, r.WorkspaceID, r.UserID, m.AdID, entity.AdStatusPaused)
+		if err == nil && !res.Changed {
+			return entity.RuleResultSkipped, "ad is already paused"
+		}
+	case entity.RuleActionNotify:
+		err = s.notifier.NotifyRule(ctx, r, m)
+	case entity.RuleActionTag:
+		err = s.repo.TagAd(ctx, m.AdID, r.ActionParam)
+	default:
+		err = fmt.Errorf("unknown action %q", r.Action)
+	}
+	if err != nil {
+		return entity.RuleResultFailed, err.Error()
+	}
+	return entity.RuleResultApplied, ""
+}
+
+// match — объявления пространства, метрики которых за окно правила удовлетворяют условию.
+// Для pause уже остановленные объявления не считаются совпадением.
+func (s *RulesService) match(ctx context.Context, r entity.Rule) ([]entity.RuleMatch, error) {
+	expr, err := ruledsl.Parse(r.Condition)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errs.ErrInvalidRule, err)
+	}
+	to := s.now().UTC().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -(r.WindowDays - 1))
+	stats, err := s.repo.AdWindowStats(ctx, r.WorkspaceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []entity.RuleMatch{}
+	for _, st := range stats {
+		if r.Action == entity.RuleActionPause && st.Status == entity.AdStatusPaused {
+			continue
+		}
+		if !expr.Eval(ruleValues(st)) {
+			continue
+		}
+		out = append(out, entity.RuleMatch{
+			AdID:     st.AdID,
+			Name:     st.Name,
+			Status:   st.Status,
+			Platform: st.Platform,
+			Metrics:  ruleMetrics(st),
+		})
+	}
+	return out, nil
+}
+
+func (s *RulesService) rule(ctx context.Context, workspaceID, ruleID int64) (entity.Rule, error) {
+	r, err := s.repo.Rule(ctx, workspaceID, ruleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, errs.ErrRuleNotFound
+	}
+	return r, err
+}
+
+// mayRunAction — пауза требует роли admin (как POST /ads/:ad_id/pause), notify и tag — analyst.
+func mayRunAction(role, action string) bool {
+	if action == entity.RuleActionPause {
+		return entity.RoleAtLeast(role, entity.RoleAdmin)
+	}
+	return entity.RoleAtLeast(role, entity.RoleAnalyst)
+}
+
+func ruleValues(st entity.AdWindowStats) ruledsl.Values {
+	clicks := decimal.NewFromInt(int64(st.Clicks))
+	conversions := decimal.NewFromInt(int64(st.Conversions))
+	revenue, spend := st.Revenue, st.Spend
+	return ruledsl.Values{
+		"clicks":      &clicks,
+		"conversions": &conversions,
+		"revenue":     &revenue,
+		"spend":       &spend,
+		"cpa":         st.CPA(),
+		"roas":        st.ROAS(),
+	}
+}
+
+// ruleMetrics — снимок метрик для ответа dry-run и журнала (формат как в GET /metrics)
+func ruleMetrics(st entity.AdWindowStats) map[string]string {
+	m := map[string]string{
+		"clicks":      fmt.Sprint(st.Clicks),
+		"conversions": fmt.Sprint(st.Conversions),
+		"revenue":     st.Revenue.StringFixed(2),
+		"spend":       st.Spend.StringFixed(2),
+	}
+	if cpa := st.CPA(); cpa != nil {
+		m["cpa"] = cpa.StringFixed(2)
+	}
+	if roas := st.ROAS(); roas != nil {
+		m["roas"] = roas.StringFixed(4)
+	}
+	return m
+}
+
+// normalizeRule проверяет правило перед сохранением; ошибки — errs.ErrInvalidRule.
+func normalizeRule(r *entity.Rule) error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Action = strings.ToLower(strings.TrimSpace(r.Action))
+	r.ActionParam = strings.TrimSpace(r.ActionParam)
+	if r.Name == "" {
+		return fmt.Errorf("%w: name is required", errs.ErrInvalidRule)
+	}
+	expr, err := ruledsl.Parse(r.Condition)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidRule, err)
+	}
+	r.Condition = expr.String()
+	if r.WindowDays < 1 || r.WindowDays > maxRuleWindowDays {
+		return fmt.Errorf("%w: window_days must be 1..%d", errs.ErrInvalidRule, maxRuleWindowDays)
+	}
+	switch r.Action {
+	case entity.RuleActionPause, entity.RuleActionNotify:
+		r.ActionParam = ""
+	case entity.RuleActionTag:
+		if r.ActionParam == "" {
+			return fmt.Errorf("%w: tag action requires action_param", errs.ErrInvalidRule)
+		}
+	default:
+		return fmt.Errorf("%w: action must be pause|notify|tag", errs.ErrInvalidRule)
+	}
+	return nil
+}
+
+// logNotifier — уведомления только в лог, пока не подключена доставка
+type logNotifier struct{}
+
+func (logNotifier) NotifyRule(ctx context.Context, r entity.Rule, m entity.RuleMatch) error {
+	log.Printf("rules: rule %q (workspace %d) matched ad %d %q: %v", r.Name, r.WorkspaceID, m.AdID, m.Name, m.Metrics)
+	return nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+	"github.com/berezovskyivalerii/adsieve/internal/shared/safehttp"
+)
+
+type WebhooksRepo interface {
+	ListSubscriptions(ctx context.Context, workspaceID int64) ([]entity.WebhookSubscription, error)
+	CreateSubscription(ctx context.Context, s entity.WebhookSubscription) (entity.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, workspaceID, subscriptionID int64) (bool, error)
+	Enqueue(ctx context.Context, e entity.WebhookEvent, payload []byte) (int64, error)
+	ListDeliveries(ctx context.Context, workspaceID, subscriptionID int64, status string, limit int) ([]entity.WebhookDelivery, error)
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entity.WebhookDelivery, error)
+	MarkSent(ctx context.Context, deliveryID int64, statusCode int) error
+	MarkFailed(ctx context.Context, deliveryID int64, statusCode int, errText string, dead bool, retryAt time.Time) error
+	Replay(ctx context.Context, workspaceID, subscriptionID, deliveryID int64) (int64, error)
+}
+
+// WebhookSender отправляет доставку на URL подписки; statusCode 0 — ответа не было.
+type WebhookSender interface {
+	Send(ctx context.Context, d entity.WebhookDelivery) (int, error)
+}
+
+// EventPublisher — публикация исходящих событий (click.created, sync.failed, ...) подписчикам.
+type EventPublisher interface {
+	Publish(ctx context.Context, e entity.WebhookEvent) error
+}
+
+const webhookLease = 2 * time.Minute
+
+// WebhooksService — подписки на события, публикация в очередь и доставка с повторами.
+type WebhooksService struct {
+	repo        WebhooksRepo
+	sender      WebhookSender
+	maxAttempts int
+	now         func() time.Time
+}
+
+// NewWebhooks: после maxAttempts неудачных попыток доставка уходит в dead-letter.
+func NewWebhooks(repo WebhooksRepo, sender WebhookSender, maxAttempts int) *WebhooksService {
+	if maxAttempts <= 0 {
+		maxAttempts = 6
+	}
+	return &WebhooksService{repo: repo, sender: sender, maxAttempts: maxAttempts, now: time.Now}
+}
+
+func (s *WebhooksService) List(ctx context.Context, workspaceID int64) ([]entity.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx, workspaceID)
+}
+
+// Create — новая подписка; без секрета генерируется случайный. Секрет виден только в ответе Create.
+func (s *WebhooksService) Create(ctx context.Context, actor entity.WorkspaceMember, sub entity.WebhookSubscription) (entity.WebhookSubscription, error) {
+	sub.WorkspaceID, sub.UserID = actor.WorkspaceID, actor.UserID
+	if err := normalizeSubscription(&sub); err != nil {
+		return entity.WebhookSubscription{}, err
+	}
+	if sub.Secret == "" {
+		b := make([]byte, 24)
+		if _, err := rand.Read(b); err != nil {
+			return entity.WebhookSubscription{}, err
+		}
+		sub.Secret = "whsec_" + hex.EncodeToString(b)
+	}
+	return s.repo.CreateSubscription(ctx, sub)
+}
+
+func (s *WebhooksService) Delete(ctx context.Context, workspaceID, subscriptionID int64) error {
+	ok, err := s.repo.DeleteSubscription(ctx, workspaceID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *WebhooksService) Deliveries(ctx context.Context, workspaceID, subscriptionID int64, status string, limit int) ([]entity.WebhookDelivery, error) {
+	if err := s.owned(ctx, workspaceID, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListDeliveries(ctx, workspaceID, subscriptionID, status, limit)
+}
+
+// Replay возвращает в очередь dead-доставки подписки: одну (deliveryID > 0) или все.
+func (s *WebhooksService) Replay(ctx context.Context, workspaceID, subscriptionID, deliveryID int64) (int64, error) {
+	if err := s.owned(ctx, workspaceID, subscriptionID); err != nil {
+		return 0, err
+	}
+	n, err := s.repo.Replay(ctx, workspaceID, subscriptionID, deliveryID)
+	if err != nil {
+		return 0, err
+	}
+	if deliveryID > 0 && n == 0 {
+		return 0, errs.ErrDeliveryNotFound
+	}
+	return n, nil
+}
+
+// Publish ставит событие в очередь подписок пространств-получателей; подписок нет — ничего не делает.
+func (s *WebhooksService) Publish(ctx context.Context, e entity.WebhookEvent) error {
+	if e.ID == "" {
+		e.ID = uuid.NewString()
+	}
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = s.now().UTC()
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("webhook %s payload: %w", e.Type, err)
+	}
+	_, err = s.repo.Enqueue(ctx, e, payload)
+	return err
+}
+
+// Deliver отправляет до batch доставок. Неудача повторяется по расписанию retryBackoff,
+// после maxAttempts доставка помечается dead и ждёт ручного replay.
+func (s *WebhooksService) Deliver(ctx context.Context, batch int) (entity.WebhookRunStats, error) {
+	var st entity.WebhookRunStats
+	list, err := s.repo.ClaimDeliveries(ctx, batch, webhookLease)
+	if err != nil {
+		return st, err
+	}
+	for _, d := range list {
+		code, sendErr := s.sender.Send(ctx, d)
+		if sendErr == nil {
+			if err := s.repo.MarkSent(ctx, d.DeliveryID, code); err != nil {
+				return st, err
+			}
+			st.Sent++
+			continue
+		}
+
+		dead := d.Attempts >= s.maxAttempts
+		if err := s.repo.MarkFailed(ctx, d.DeliveryID, code, sendErr.Error(), dead, s.now().Add(retryBackoff(d.Attempts))); err != nil {
+			return st, err
+		}
+		if dead {
+			st.Dead++
+			log.Printf("webhooks: delivery %d (%s) dead after %d attempts: %v", d.DeliveryID, d.EventType, d.Attempts, sendErr)
+		} else {
+			st.Retried++
+		}
+	}
+	return st, nil
+}
+
+func (s *WebhooksService) owned(ctx context.Context, workspaceID, subscriptionID int64) error {
+	subs, err := s.repo.ListSubscriptions(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		if sub.SubscriptionID == subscriptionID {
+			return nil
+		}
+	}
+	return errs.ErrWebhookNotFound
+}
+
+// normalizeSubscription: http(s) URL на публичный хост и непустой набор известных типов событий.
+// Имя хоста проверяется ещё раз при каждой отправке — по адресу, в который оно резолвится.
+func normalizeSubscription(sub *entity.WebhookSubscription) error {
+	sub.URL = strings.TrimSpace(sub.URL)
+	if err := safehttp.ValidateURL(sub.URL); err != nil {
+		return fmt.Errorf("%w: %v", errs.ErrInvalidWebhook, err)
+	}
+	known := map[string]bool{}
+	for _, t := range entity.EventTypes {
+		known[t] = true
+	}
+	seen := map[string]bool{}
+	types := make([]string, 0, len(sub.EventTypes))
+	for _, t := range sub.EventTypes {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !known[t] {
+			return fmt.Errorf("%w: unknown event type %q (want one of %s)", errs.ErrInvalidWebhook, t, strings.Join(entity.EventTypes, ", "))
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	if len(types) == 0 {
+		return fmt.Errorf("%w: event_types is required", errs.ErrInvalidWebhook)
+	}
+	sort.Strings(types)
+	sub.EventTypes = types
+	sub.Secret = strings.TrimSpace(sub.Secret)
+	return nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/berezovskyivalerii/adsieve/internal/domain/entity"
+	errs "github.com/berezovskyivalerii/adsieve/internal/domain/errors"
+)
+
+type WorkspacesRepo interface {
+	Membership(ctx context.Context, userID, workspaceID int64) (entity.WorkspaceMember, error)
+	DefaultMembership(ctx context.Context, userID int64) (entity.WorkspaceMember, error)
+	ListForUser(ctx context.Context, userID int64) ([]entity.Workspace, error)
+	Create(ctx context.Context, userID int64, name string) (entity.Workspace, error)
+	Members(ctx context.Context, workspaceID int64) ([]entity.WorkspaceMember, error)
+	CountOwners(ctx context.Context, workspaceID int64) (int, error)
+	SetRole(ctx context.Context, workspaceID, userID int64, role string) (bool, error)
+	RemoveMember(ctx context.Context, workspaceID, userID int64) (bool, error)
+	CreateInvitation(ctx context.Context, inv entity.WorkspaceInvitation, tokenHash string) (entity.WorkspaceInvitation, error)
+	Invitations(ctx context.Context, workspaceID int64) ([]entity.WorkspaceInvitation, error)
+	DeleteInvitation(ctx context.Context, workspaceID, invitationID int64) (bool, error)
+	AcceptInvitation(ctx context.Context, tokenHash string, userID int64) (entity.WorkspaceMember, error)
+}
+
+// InvitationMailer отправляет ссылку-приглашение на email.
+type InvitationMailer interface {
+	SendInvitation(ctx context.Context, to, acceptURL string) error
+}
+
+const defaultInviteTTL = 7 * 24 * time.Hour
+
+// WorkspacesService — рабочие пространства, роли участников и приглашения по email-токену.
+type WorkspacesService struct {
+	repo      WorkspacesRepo
+	mailer    InvitationMailer // может быть nil — токен только в ответе API
+	inviteURL string           // к нему дописывается токен, напр. https://app/invite?token=
+	inviteTTL time.Duration
+	now       func() time.Time
+}
+
+func NewWorkspaces(repo WorkspacesRepo) *WorkspacesService {
+	return &WorkspacesService{repo: repo, inviteTTL: defaultInviteTTL, now: time.Now}
+}
+
+// WithMailer включает отправку приглашений письмом; acceptURL — ссылка, к которой дописывается токен.
+func (s *WorkspacesService) WithMailer(m InvitationMailer, acceptURL string) *WorkspacesService {
+	s.mailer, s.inviteURL = m, acceptURL
+	return s
+}
+
+// WithInviteTTL — срок действия приглашения (по умолчанию 7 дней).
+func (s *WorkspacesService) WithInviteTTL(ttl time.Duration) *WorkspacesService {
+	if ttl > 0 {
+		s.inviteTTL = ttl
+	}
+	return s
+}
+
+// Resolve — членство пользователя в пространстве; workspaceID = 0 — пространство по умолчанию.
+// Не участник — errs.ErrWorkspaceNotFound (существование чужих пространств не раскрываем).
+func (s *WorkspacesService) Resolve(ctx context.Context, userID, workspaceID int64) (entity.WorkspaceMember, error) {
+	var (
+		m   entity.WorkspaceMember
+		err error
+	)
+	if workspaceID == 0 {
+		m, err = s.repo.DefaultMembership(ctx, userID)
+	} else {
+		m, err = s.repo.Membership(ctx, userID, workspaceID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return m, errs.ErrWorkspaceNotFound
+	}
+	return m, err
+}
+
+func (s *WorkspacesService) List(ctx context.Context, userID int64) ([]entity.Workspace, error) {
+	return s.repo.ListForUser(ctx, userID)
+}
+
+// Create — командное пространство, создатель — владелец.
+func (s *WorkspacesService) Create(ctx context.Context, userID int64, name string) (entity.Workspace, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 200 {
+		return entity.Workspace{}, fmt.Errorf("%w: name must be 1..200 characters", errs.ErrInvalidWorkspace)
+	}
+	return s.repo.Create(ctx, userID, name)
+}
+
+func (s *WorkspacesService) Members(ctx context.Context, workspaceID int64) ([]entity.WorkspaceMember, error) {
+	return s.repo.Members(ctx, workspaceID)
+}
+
+// SetRole меняет роль участника. Назначать и трогать владельцев может только владелец;
+// последнего владельца понизить нельзя.
+func (s *WorkspacesService) SetRole(ctx context.Context, actor entity.WorkspaceMember, userID int64, role string) error {
+	if !entity.ValidRole(role) {
+		return fmt.Errorf("%w: unknown role %q", errs.ErrInvalidWorkspace, role)
+	}
+	target, err := s.target(ctx, actor, userID)
+	if err != nil {
+		return err
+	}
+	if role == entity.RoleOwner && actor.Role != entity.RoleOwner {
+		return errs.ErrWorkspaceForbidden
+	}
+	if target.Role == entity.RoleOwner && role != entity.RoleOwner {
+		if err := s.keepOwner(ctx, actor.WorkspaceID); err != nil {
+			return err
+		}
+	}
+	ok, err := s.repo.SetRole(ctx, actor.WorkspaceID, userID, role)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrMemberNotFound
+	}
+	return nil
+}
+
+// RemoveMember исключает участника (или самого себя — выход из пространства).
+func (s *WorkspacesService) RemoveMember(ctx context.Context, actor entity.WorkspaceMember, userID int64) error {
+	var (
+		target entity.WorkspaceMember
+		err    error
+	)
+	if userID == actor.UserID {
+		target = actor
+	} else if target, err = s.target(ctx, actor, userID); err != nil {
+		return err
+	}
+	if target.Role == entity.RoleOwner {
+		if err := s.keepOwner(ctx, actor.WorkspaceID); err != nil {
+			return err
+		}
+	}
+	ok, err := s.repo.RemoveMember(ctx, actor.WorkspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrMemberNotFound
+	}
+	return nil
+}
+
+// target — участник, которым actor (admin+) вправе управлять.
+func (s *WorkspacesService) target(ctx context.Context, actor entity.WorkspaceMember, userID int64) (entity.WorkspaceMember, error) {
+	if !entity.RoleAtLeast(actor.Role, entity.RoleAdmin) {
+		return entity.WorkspaceMember{}, errs.ErrWorkspaceForbidden
+	}
+	m, err := s.repo.Membership(ctx, userID, actor.WorkspaceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m, errs.ErrMemberNotFound
+	}
+	if err != nil {
+		return m, err
+	}
+	if m.Role == entity.RoleOwner && actor.Role != entity.RoleOwner {
+		return m, errs.ErrWorkspaceForbidden
+	}
+	return m, nil
+}
+
+func (s *WorkspacesService) keepOwner(ctx context.Context, workspaceID int64) error {
+	n, err := s.repo.CountOwners(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if n <= 1 {
+		return errs.ErrLastOwner
+	}
+	return nil
+}
+
+func (s *WorkspacesService) Invitations(ctx context.Context, workspaceID int64) ([]entity.WorkspaceInvitation, error) {
+	return s.repo.Invitations(ctx, workspaceID)
+}
+
+// Invite создаёт приглашение и (если настроена почта) отправляет ссылку. Токен возвращается
+// один раз — в БД только его хэш. Роль owner приглашением не выдаётся.
+func (s *WorkspacesService) Invite(ctx context.Context, actor entity.WorkspaceMember, email, role string) (entity.WorkspaceInvitation, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return entity.WorkspaceInvitation{}, fmt.Errorf("%w: invalid email", errs.ErrInvalidInvitation)
+	}
+	if !entity.ValidRole(role) || role == entity.RoleOwner {
+		return entity.WorkspaceInvitation{}, fmt.Errorf("%w: role must be admin, analyst or read_only", errs.ErrInvalidInvitation)
+	}
+	token, err := newRefreshToken()
+	if err != nil {
+		return entity.WorkspaceInvitation{}, err
+	}
+	inv, err := s.repo.CreateInvitation(ctx, entity.WorkspaceInvitation{
+		WorkspaceID: actor.WorkspaceID,
+		Email:       strings.ToLower(addr.Address),
+		Role:        role,
+		InvitedBy:   actor.UserID,
+		ExpiresAt:   s.now().Add(s.inviteTTL).UTC(),
+	}, hashRefreshToken(token))
+	if err != nil {
+		return inv, err
+	}
+	inv.Token = token
+
+	// приглашение уже создано: сбой почты не отменяет его — ссылку можно передать вручную
+	if s.mailer != nil {
+		link := s.inviteURL + url.QueryEscape(token)
+		if err := s.mailer.SendInvitation(ctx, inv.Email, link); err != nil {
+			log.Printf("workspace %d: invitation %d email: %v", inv.WorkspaceID, inv.InvitationID, err)
+		}
+	}
+	return inv, nil
+}
+
+func (s *WorkspacesService) RevokeInvitation(ctx context.Context, workspaceID, invitationID int64) error {
+	ok, err := s.repo.DeleteInvitation(ctx, workspaceID, invitationID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errs.ErrInvitationNotFound
+	}
+	return nil
+}
+
+// Accept — вступление по токену. Токен должен быть действующим и выданным на email пользователя.
+func (s *WorkspacesService) Accept(ctx context.Context, userID int64, token string) (entity.WorkspaceMember, error) {
+	if strings.TrimSpace(token) == "" {
+		return entity.WorkspaceMember{}, errs.ErrInvitationNotFound
+	}
+	m, err := s.repo.AcceptInvitation(ctx, hashRefreshToken(token), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m, errs.ErrInvitationNotFound
+	}
+	return m, err
+}
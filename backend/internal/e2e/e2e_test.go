@@ -113,7 +113,9 @@ func openDB(t *testing.T) *sql.DB {
 	return db
 }
 
-func ensureUserAds(t *testing.T, db *sql.DB, email string, adID int64) {
+// ensureAdAccess делает пользователя участником (analyst — нужен для конверсий) пространства аккаунта объявления
+// и возвращает id этого пространства.
+func ensureAdAccess(t *testing.T, db *sql.DB, email string, adID int64) int64 {
 	t.Helper()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -123,10 +125,21 @@ func ensureUserAds(t *testing.T, db *sql.DB, email string, adID int64) {
 		t.Fatalf("get user_id: %v", err)
 	}
 
-	_, err := db.ExecContext(ctx, `INSERT INTO user_ads (user_id, ad_id) VALUES ($1,$2) ON CONFLICT DO NOTHING`, uid, adID)
+	var wsID int64
+	err := db.QueryRowContext(ctx, `
+		SELECT aa.workspace_id FROM ads a JOIN ad_accounts aa ON aa.account_id = a.account_id WHERE a.ad_id = $1`,
+		adID).Scan(&wsID)
 	if err != nil {
-		t.Fatalf("insert user_ads: %v", err)
+		t.Fatalf("get workspace_id: %v", err)
 	}
+
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1,$2,'analyst') ON CONFLICT DO NOTHING`,
+		wsID, uid)
+	if err != nil {
+		t.Fatalf("insert workspace_members: %v", err)
+	}
+	return wsID
 }
 
 func runAggregatorSQL(t *testing.T, db *sql.DB) {
@@ -168,8 +181,9 @@ func TestE2E_Click_Conversion_Aggregate_Metrics(t *testing.T) {
 	}
 	authz := map[string]string{"Authorization": "Bearer " + si.AccessToken}
 
-	// 2) Связываем пользователя с нужным объявлением (authorization в /metrics)
-	ensureUserAds(t, db, email, testAdID)
+	// 2) Даём пользователю доступ к пространству объявления (authorization в /metrics)
+	wsID := ensureAdAccess(t, db, email, testAdID)
+	authz["X-Workspace-ID"] = strconv.FormatInt(wsID, 10)
 
 	// 3) Отправляем клик
 	clickID := "click_" + strconv.FormatInt(time.Now().Unix(), 10)
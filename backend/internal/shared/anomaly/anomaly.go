@@ -0,0 +1,125 @@
+// Package anomaly — поиск выбросов дневной метрики относительно скользящей базы:
+// значение дня сравнивается со средним предыдущих дней по z-score и по изменению в процентах.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Направления, в которых отклонение считается аномалией
+const (
+	Up   = "up"
+	Down = "down"
+	Both = "both"
+)
+
+// z-score при нулевом разбросе базы (все дни одинаковые), чтобы не отдавать Inf в JSON
+const maxZ = 99
+
+// Threshold — пороги одной метрики. Срабатывает, когда |z| >= Z и |изменение| >= Pct (в %)
+// в нужном направлении, а среднее базы не меньше MinMean (мелкие базы шумят).
+// OnZero — падение до нуля при такой базе срабатывает независимо от z (конверсии «пропали»).
+type Threshold struct {
+	Direction string
+	Z         float64
+	Pct       float64
+	MinMean   float64
+	OnZero    bool
+}
+
+// Result — насколько значение отличается от базы
+type Result struct {
+	Mean      float64
+	Std       float64
+	Z         float64
+	PctChange float64 // (value - mean) / mean * 100
+	Direction string  // up | down
+}
+
+// Detect сравнивает value с базой; baseline — значения предыдущих дней.
+func Detect(baseline []float64, value float64, th Threshold) (Result, bool) {
+	n := len(baseline)
+	if n == 0 {
+		return Result{}, false
+	}
+	var sum float64
+	for _, v := range baseline {
+		sum += v
+	}
+	mean := sum / float64(n)
+	var sq float64
+	for _, v := range baseline {
+		sq += (v - mean) * (v - mean)
+	}
+	std := 0.0
+	if n > 1 {
+		std = math.Sqrt(sq / float64(n-1))
+	}
+
+	r := Result{Mean: mean, Std: std, Direction: Up}
+	diff := value - mean
+	if diff < 0 {
+		r.Direction = Down
+	}
+	switch {
+	case std > 0:
+		r.Z = math.Max(-maxZ, math.Min(maxZ, diff/std))
+	case diff > 0:
+		r.Z = maxZ
+	case diff < 0:
+		r.Z = -maxZ
+	}
+	if mean != 0 {
+		r.PctChange = diff / mean * 100
+	}
+
+	if diff == 0 || mean < th.MinMean || mean == 0 {
+		return r, false
+	}
+	if th.Direction != Both && th.Direction != r.Direction {
+		return r, false
+	}
+	if th.OnZero && value == 0 {
+		return r, true
+	}
+	return r, math.Abs(r.Z) >= th.Z && math.Abs(r.PctChange) >= th.Pct
+}
+
+// ParseThresholds разбирает переопределения порогов вида
+// "spend:up:3:100,conversions:down:2:80:1" (метрика:направление:z:pct[:min_mean]).
+func ParseThresholds(s string) (map[string]Threshold, error) {
+	out := map[string]Threshold{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		f := strings.Split(part, ":")
+		if len(f) != 4 && len(f) != 5 {
+			return nil, fmt.Errorf("anomaly: bad threshold %q (want metric:direction:z:pct[:min_mean])", part)
+		}
+		th := Threshold{Direction: strings.ToLower(f[1])}
+		switch th.Direction {
+		case Up, Down, Both:
+		default:
+			return nil, fmt.Errorf("anomaly: bad direction %q in %q", f[1], part)
+		}
+		var err error
+		if th.Z, err = strconv.ParseFloat(f[2], 64); err != nil {
+			return nil, fmt.Errorf("anomaly: bad z in %q: %w", part, err)
+		}
+		if th.Pct, err = strconv.ParseFloat(f[3], 64); err != nil {
+			return nil, fmt.Errorf("anomaly: bad pct in %q: %w", part, err)
+		}
+		if len(f) == 5 {
+			if th.MinMean, err = strconv.ParseFloat(f[4], 64); err != nil {
+				return nil, fmt.Errorf("anomaly: bad min_mean in %q: %w", part, err)
+			}
+		}
+		out[strings.ToLower(f[0])] = th
+	}
+	return out, nil
+}
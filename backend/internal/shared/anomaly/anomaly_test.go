@@ -0,0 +1,68 @@
+package anomaly_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/shared/anomaly"
+)
+
+var spendBase = []float64{48, 52, 50, 49, 51, 50, 50}
+
+func TestDetect_SpendSpike(t *testing.T) {
+	th := anomaly.Threshold{Direction: anomaly.Up, Z: 3, Pct: 50, MinMean: 1}
+
+	r, ok := anomaly.Detect(spendBase, 100, th)
+	require.True(t, ok)
+	require.Equal(t, anomaly.Up, r.Direction)
+	require.InDelta(t, 50, r.Mean, 1e-9)
+	require.InDelta(t, 100, r.PctChange, 1e-9)
+	require.Greater(t, r.Z, 3.0)
+
+	// в пределах шума
+	_, ok = anomaly.Detect(spendBase, 53, th)
+	require.False(t, ok)
+
+	// падение не интересует порог "up"
+	_, ok = anomaly.Detect(spendBase, 5, th)
+	require.False(t, ok)
+}
+
+func TestDetect_ZeroConversions(t *testing.T) {
+	base := []float64{1, 6, 2, 5, 0, 4, 3}
+	th := anomaly.Threshold{Direction: anomaly.Down, Z: 3, Pct: 80, MinMean: 1, OnZero: true}
+
+	r, ok := anomaly.Detect(base, 0, th)
+	require.True(t, ok, "drop to zero fires even with a noisy baseline")
+	require.Equal(t, anomaly.Down, r.Direction)
+	require.InDelta(t, -100, r.PctChange, 1e-9)
+
+	// база слишком мала, чтобы пропажа что-то значила
+	_, ok = anomaly.Detect([]float64{0, 0, 1, 0, 0, 0, 0}, 0, th)
+	require.False(t, ok)
+}
+
+func TestDetect_FlatBaseline(t *testing.T) {
+	r, ok := anomaly.Detect([]float64{10, 10, 10}, 30, anomaly.Threshold{Direction: anomaly.Both, Z: 3, Pct: 50})
+	require.True(t, ok)
+	require.Equal(t, 99.0, r.Z)
+
+	_, ok = anomaly.Detect([]float64{10, 10, 10}, 10, anomaly.Threshold{Direction: anomaly.Both})
+	require.False(t, ok)
+
+	_, ok = anomaly.Detect(nil, 10, anomaly.Threshold{Direction: anomaly.Both})
+	require.False(t, ok)
+}
+
+func TestParseThresholds(t *testing.T) {
+	got, err := anomaly.ParseThresholds("spend:up:3:100, conversions:down:2:80:1")
+	require.NoError(t, err)
+	require.Equal(t, anomaly.Threshold{Direction: anomaly.Up, Z: 3, Pct: 100}, got["spend"])
+	require.Equal(t, anomaly.Threshold{Direction: anomaly.Down, Z: 2, Pct: 80, MinMean: 1}, got["conversions"])
+
+	for _, bad := range []string{"spend:up:3", "spend:sideways:3:100", "spend:up:x:100", "spend:up:3:100:y"} {
+		_, err := anomaly.ParseThresholds(bad)
+		require.Error(t, err, bad)
+	}
+}
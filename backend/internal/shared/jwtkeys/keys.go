@@ -0,0 +1,239 @@
+// Package jwtkeys — ключи подписи и проверки JWT: активный ключ с kid,
+// набор ключей для проверки (ротация) и публикация публичных ключей в JWKS.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key — один ключ набора. Без private — только для проверки (выведенный из оборота ключ).
+type Key struct {
+	ID      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+	public  crypto.PublicKey
+}
+
+// KeySet подписывает активным ключом (kid в заголовке) и проверяет любым ключом набора.
+// Токены без kid проверяются общим HS256-секретом, если он задан (JWT_SECRET до перехода
+// на асимметричные ключи): выданные раньше токены доживают свой срок.
+type KeySet struct {
+	active *Key
+	keys   map[string]*Key
+	hmac   []byte
+}
+
+// NewHMAC — прежний режим: подпись и проверка HS256 общим секретом, без kid.
+func NewHMAC(secret []byte) *KeySet {
+	return &KeySet{keys: map[string]*Key{}, hmac: secret}
+}
+
+// New собирает набор из ключей; activeID должен быть ключом с приватной частью.
+// legacySecret (может быть nil) — HS256-секрет для токенов без kid.
+func New(keys []*Key, activeID string, legacySecret []byte) (*KeySet, error) {
+	ks := &KeySet{keys: make(map[string]*Key, len(keys)), hmac: legacySecret}
+	for _, k := range keys {
+		if _, dup := ks.keys[k.ID]; dup {
+			return nil, fmt.Errorf("jwtkeys: duplicate kid %q", k.ID)
+		}
+		ks.keys[k.ID] = k
+	}
+	active, ok := ks.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("jwtkeys: active kid %q is not in the set", activeID)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("jwtkeys: active kid %q has no private key", activeID)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+// ParsePEM разбирает ключ из PEM: приватный (PKCS#8 RSA/Ed25519 или PKCS#1 RSA)
+// или публичный (PKIX) — последний годится только для проверки.
+func ParsePEM(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwtkeys: %s: no PEM block", id)
+	}
+	var raw any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		raw, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		raw, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		raw, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("jwtkeys: %s: unsupported PEM type %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwtkeys: %s: %w", id, err)
+	}
+	return newKey(id, raw)
+}
+
+func newKey(id string, raw any) (*Key, error) {
+	if id == "" || strings.ContainsAny(id, ":,") {
+		return nil, fmt.Errorf("jwtkeys: bad kid %q", id)
+	}
+	k := &Key{ID: id}
+	switch v := raw.(type) {
+	case *rsa.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodRS256, v, &v.PublicKey
+	case *rsa.PublicKey:
+		k.method, k.public = jwt.SigningMethodRS256, v
+	case ed25519.PrivateKey:
+		k.method, k.private, k.public = jwt.SigningMethodEdDSA, v, v.Public()
+	case ed25519.PublicKey:
+		k.method, k.public = jwt.SigningMethodEdDSA, v
+	default:
+		return nil, fmt.Errorf("jwtkeys: %s: unsupported key type %T", id, raw)
+	}
+	return k, nil
+}
+
+// Sign подписывает claims активным ключом (или HS256-секретом в режиме NewHMAC).
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	if s.active == nil {
+		if len(s.hmac) == 0 {
+			return "", errors.New("jwtkeys: no signing key")
+		}
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.hmac)
+	}
+	t := jwt.NewWithClaims(s.active.method, claims)
+	t.Header["kid"] = s.active.ID
+	return t.SignedString(s.active.private)
+}
+
+// Keyfunc — для jwt.Parse: ключ по kid с проверкой алгоритма.
+func (s *KeySet) Keyfunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok || len(s.hmac) == 0 {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return s.hmac, nil
+	}
+	k, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("jwtkeys: unknown kid %q", kid)
+	}
+	if t.Method.Alg() != k.method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return k.public, nil
+}
+
+// Methods — допустимые алгоритмы (для jwt.WithValidMethods).
+func (s *KeySet) Methods() []string {
+	seen := map[string]bool{}
+	if len(s.hmac) > 0 {
+		seen[jwt.SigningMethodHS256.Alg()] = true
+	}
+	for _, k := range s.keys {
+		seen[k.method.Alg()] = true
+	}
+	out := make([]string, 0, len(seen))
+	for alg := range seen {
+		out = append(out, alg)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// JWK — публичный ключ в формате RFC 7517.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS — публичные ключи набора для /.well-known/jwks.json (HS256-секрет не публикуется).
+func (s *KeySet) JWKS() JWKSet {
+	out := JWKSet{Keys: []JWK{}}
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	b64 := base64.RawURLEncoding.EncodeToString
+	for _, id := range ids {
+		k := s.keys[id]
+		j := JWK{Kid: k.ID, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			j.Kty, j.N, j.E = "RSA", b64(pub.N.Bytes()), b64(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			j.Kty, j.Crv, j.X = "OKP", "Ed25519", b64(pub)
+		}
+		out.Keys = append(out.Keys, j)
+	}
+	return out
+}
+
+// FromEnv читает JWT_KEYS="kid1:/path/key1.pem,kid2:/path/key2.pem" и JWT_ACTIVE_KID
+// (по умолчанию — первый ключ списка). JWT_SECRET, если задан, проверяет старые
+// HS256-токены без kid. Без JWT_KEYS — прежний режим HS256 с JWT_SECRET.
+func FromEnv() (*KeySet, error) {
+	secret := []byte(os.Getenv("JWT_SECRET"))
+	spec := strings.TrimSpace(os.Getenv("JWT_KEYS"))
+	if spec == "" {
+		if len(secret) == 0 {
+			return nil, errors.New("jwtkeys: set JWT_KEYS or JWT_SECRET")
+		}
+		return NewHMAC(secret), nil
+	}
+
+	var keys []*Key
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, path, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("jwtkeys: JWT_KEYS entry must be <kid>:<pem path>")
+		}
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return nil, fmt.Errorf("jwtkeys: %s: %w", id, err)
+		}
+		k, err := ParsePEM(strings.TrimSpace(id), data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwtkeys: JWT_KEYS is empty")
+	}
+	active := os.Getenv("JWT_ACTIVE_KID")
+	if active == "" {
+		active = keys[0].ID
+	}
+	return New(keys, active, secret)
+}
@@ -0,0 +1,122 @@
+package jwtkeys_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/shared/jwtkeys"
+)
+
+func pemFile(t *testing.T, typ string, der []byte) string {
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600))
+	return path
+}
+
+func claims() jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{Subject: "42", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))}
+}
+
+func parse(ks *jwtkeys.KeySet, raw string) error {
+	_, err := jwt.ParseWithClaims(raw, &jwt.RegisteredClaims{}, ks.Keyfunc, jwt.WithValidMethods(ks.Methods()))
+	return err
+}
+
+func TestKeySet_RotationWithRetiredPublicKey(t *testing.T) {
+	_, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	edDER, _ := x509.MarshalPKCS8PrivateKey(edPriv)
+	oldKey, err := jwtkeys.ParsePEM("old", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: edDER}))
+	require.NoError(t, err)
+	oldSet, err := jwtkeys.New([]*jwtkeys.Key{oldKey}, "old", nil)
+	require.NoError(t, err)
+	oldToken, err := oldSet.Sign(claims())
+	require.NoError(t, err)
+
+	// новый активный RSA-ключ; от старого остался только публичный
+	pubDER, _ := x509.MarshalPKIXPublicKey(edPriv.Public())
+	retired, err := jwtkeys.ParsePEM("old", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}))
+	require.NoError(t, err)
+	newKey, err := jwtkeys.ParsePEM("new", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaPriv)}))
+	require.NoError(t, err)
+	ks, err := jwtkeys.New([]*jwtkeys.Key{retired, newKey}, "new", nil)
+	require.NoError(t, err)
+
+	newToken, err := ks.Sign(claims())
+	require.NoError(t, err)
+	tok, _, err := jwt.NewParser().ParseUnverified(newToken, &jwt.RegisteredClaims{})
+	require.NoError(t, err)
+	require.Equal(t, "new", tok.Header["kid"])
+	require.Equal(t, "RS256", tok.Method.Alg())
+
+	require.NoError(t, parse(ks, newToken))
+	require.NoError(t, parse(ks, oldToken))
+
+	// ключ без приватной части не может быть активным
+	_, err = jwtkeys.New([]*jwtkeys.Key{retired}, "old", nil)
+	require.Error(t, err)
+
+	jwks := ks.JWKS()
+	require.Len(t, jwks.Keys, 2)
+	require.Equal(t, "new", jwks.Keys[0].Kid)
+	require.Equal(t, "RSA", jwks.Keys[0].Kty)
+	require.Equal(t, "AQAB", jwks.Keys[0].E)
+	require.Equal(t, "OKP", jwks.Keys[1].Kty)
+	require.Equal(t, "Ed25519", jwks.Keys[1].Crv)
+}
+
+func TestKeySet_LegacyHMAC(t *testing.T) {
+	secret := []byte("legacy-secret")
+	legacy, err := jwtkeys.NewHMAC(secret).Sign(claims())
+	require.NoError(t, err)
+
+	_, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edPriv)
+	k, err := jwtkeys.ParsePEM("k1", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	require.NoError(t, err)
+
+	// с JWT_SECRET старые HS256-токены без kid ещё принимаются, без него — нет
+	withLegacy, err := jwtkeys.New([]*jwtkeys.Key{k}, "k1", secret)
+	require.NoError(t, err)
+	require.NoError(t, parse(withLegacy, legacy))
+
+	strict, err := jwtkeys.New([]*jwtkeys.Key{k}, "k1", nil)
+	require.NoError(t, err)
+	require.Error(t, parse(strict, legacy))
+
+	// HS256 с чужим kid (подмена алгоритма) не проходит
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	forged.Header["kid"] = "k1"
+	raw, _ := forged.SignedString(secret)
+	require.Error(t, parse(withLegacy, raw))
+}
+
+func TestFromEnv(t *testing.T) {
+	_, edPriv, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKCS8PrivateKey(edPriv)
+	path := pemFile(t, "PRIVATE KEY", der)
+
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("JWT_KEYS", "2025-01:"+path)
+	t.Setenv("JWT_ACTIVE_KID", "")
+	ks, err := jwtkeys.FromEnv()
+	require.NoError(t, err)
+	require.Equal(t, []string{"EdDSA"}, ks.Methods())
+
+	t.Setenv("JWT_KEYS", "")
+	_, err = jwtkeys.FromEnv()
+	require.Error(t, err)
+}
@@ -0,0 +1,152 @@
+// Package ratelimit — token bucket: до Burst запросов подряд, дальше Burst за Per
+// с равномерным пополнением. Хранилище вёдер подключаемое (Store): в памяти процесса
+// или общее для нескольких инстансов API (Postgres, см. adapter/postgres.RateLimitRepo).
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit — ёмкость ведра и за сколько оно наполняется с нуля. Нулевой Limit — без ограничения.
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+func (l Limit) Enabled() bool { return l.Burst > 0 && l.Per > 0 }
+
+// Rate — пополнение, токенов в секунду
+func (l Limit) Rate() float64 { return float64(l.Burst) / l.Per.Seconds() }
+
+func (l Limit) String() string { return strconv.Itoa(l.Burst) + "/" + l.Per.String() }
+
+// Parse разбирает "600/1m" (600 запросов в минуту). "" и "off" — без ограничения.
+func Parse(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" {
+		return Limit{}, nil
+	}
+	n, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q: want <burst>/<duration>", s)
+	}
+	burst, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: invalid burst", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("rate limit %q: invalid duration", s)
+	}
+	return Limit{Burst: burst, Per: d}, nil
+}
+
+// Result — решение по запросу и состояние ведра для заголовков RateLimit-*.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // до полного ведра
+	RetryAfter time.Duration // до следующего токена; только при отказе
+}
+
+// Store списывает токен из ведра key (создавая полное ведро при первом обращении).
+type Store interface {
+	Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error)
+}
+
+// Refill — токенов в ведре к моменту now, если в updated их было tokens.
+func Refill(l Limit, tokens float64, updated, now time.Time) float64 {
+	if elapsed := now.Sub(updated).Seconds(); elapsed > 0 {
+		tokens += elapsed * l.Rate()
+	}
+	return math.Min(tokens, float64(l.Burst))
+}
+
+// ResultOf — Result по остатку токенов после решения.
+func ResultOf(l Limit, tokens float64, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(l.Burst) - tokens) / l.Rate()),
+	}
+	if !allowed {
+		r.RetryAfter = secondsToDuration((1 - tokens) / l.Rate())
+	}
+	return r
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+// Memory — вёдра в памяти процесса: лимит считается на каждый инстанс API отдельно.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	takes   int
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // после этого момента ведро полное — запись можно выбросить
+}
+
+// Раз в столько списаний из памяти убираются наполнившиеся вёдра
+const sweepEvery = 1024
+
+func NewMemory() *Memory { return &Memory{buckets: map[string]*bucket{}} }
+
+func (m *Memory) Take(_ context.Context, key string, l Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.takes++
+	if m.takes%sweepEvery == 0 {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	tokens := Refill(l, b.tokens, b.updated, now)
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	b.tokens = tokens
+	if now.After(b.updated) {
+		b.updated = now
+	}
+	res := ResultOf(l, tokens, allowed)
+	b.full = b.updated.Add(res.Reset)
+	return res, nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+	for k, b := range m.buckets {
+		if !now.Before(b.full) {
+			delete(m.buckets, k)
+		}
+	}
+}
+
+// Len — число вёдер в памяти.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.buckets)
+}
@@ -0,0 +1,82 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ratelimit"
+)
+
+func TestParse(t *testing.T) {
+	l, err := ratelimit.Parse("600/1m")
+	require.NoError(t, err)
+	require.Equal(t, ratelimit.Limit{Burst: 600, Per: time.Minute}, l)
+	require.Equal(t, 10.0, l.Rate())
+
+	for _, off := range []string{"", "off"} {
+		l, err := ratelimit.Parse(off)
+		require.NoError(t, err)
+		require.False(t, l.Enabled())
+	}
+	for _, bad := range []string{"600", "0/1m", "x/1m", "10/", "10/-1s"} {
+		_, err := ratelimit.Parse(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestMemory_BurstThenRefill(t *testing.T) {
+	ctx := context.Background()
+	m := ratelimit.NewMemory()
+	l := ratelimit.Limit{Burst: 3, Per: 3 * time.Second} // 1 токен в секунду
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		r, err := m.Take(ctx, "ip:1.2.3.4", l, now)
+		require.NoError(t, err)
+		require.True(t, r.Allowed)
+		require.Equal(t, 3, r.Limit)
+		require.Equal(t, i, r.Remaining)
+	}
+
+	r, err := m.Take(ctx, "ip:1.2.3.4", l, now)
+	require.NoError(t, err)
+	require.False(t, r.Allowed)
+	require.Equal(t, 0, r.Remaining)
+	require.Equal(t, time.Second, r.RetryAfter)
+	require.Equal(t, 3*time.Second, r.Reset)
+
+	// другое ведро не затронуто
+	r, _ = m.Take(ctx, "ip:5.6.7.8", l, now)
+	require.True(t, r.Allowed)
+
+	// через полсекунды токена ещё нет, через секунду — есть
+	r, _ = m.Take(ctx, "ip:1.2.3.4", l, now.Add(500*time.Millisecond))
+	require.False(t, r.Allowed)
+	require.Equal(t, 500*time.Millisecond, r.RetryAfter)
+	r, _ = m.Take(ctx, "ip:1.2.3.4", l, now.Add(time.Second))
+	require.True(t, r.Allowed)
+	require.Equal(t, 0, r.Remaining)
+
+	// за долгий простой ведро не переполняется
+	r, _ = m.Take(ctx, "ip:1.2.3.4", l, now.Add(time.Hour))
+	require.True(t, r.Allowed)
+	require.Equal(t, 2, r.Remaining)
+}
+
+func TestMemory_SweepsFullBuckets(t *testing.T) {
+	ctx := context.Background()
+	m := ratelimit.NewMemory()
+	l := ratelimit.Limit{Burst: 5, Per: time.Second}
+	now := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	_, _ = m.Take(ctx, "user:1", l, now)
+	require.Equal(t, 1, m.Len())
+	// ведро user:1 давно полное — очистка по счётчику списаний его убирает
+	for i := 0; i < 1023; i++ {
+		_, _ = m.Take(ctx, "user:2", l, now.Add(time.Minute))
+	}
+	require.Equal(t, 1, m.Len())
+}
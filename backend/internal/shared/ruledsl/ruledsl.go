@@ -0,0 +1,302 @@
+// Package ruledsl — условия «sieve rules» над метриками объявления:
+//
+//	cpa > 40 AND conversions >= 5
+//	roas < 1 OR (spend >= 100 AND conversions = 0)
+//
+// Поля — то, что считает MetricsService (clicks, conversions, revenue, spend, cpa, roas),
+// операторы сравнения > >= < <= = != и связки AND / OR (AND связывает сильнее), скобки.
+package ruledsl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// Fields — поля, доступные в условиях.
+var Fields = map[string]bool{
+	"clicks":      true,
+	"conversions": true,
+	"revenue":     true,
+	"spend":       true,
+	"cpa":         true,
+	"roas":        true,
+}
+
+// Values — значения полей одного объявления; nil — метрика не определена
+// (cpa без конверсий, roas без трат), любое сравнение с ней ложно.
+type Values map[string]*decimal.Decimal
+
+// Expr — разобранное условие.
+type Expr interface {
+	Eval(v Values) bool
+	String() string
+}
+
+type cmp struct {
+	field string
+	op    string
+	value decimal.Decimal
+}
+
+func (c cmp) Eval(v Values) bool {
+	x := v[c.field]
+	if x == nil {
+		return false
+	}
+	d := x.Cmp(c.value)
+	switch c.op {
+	case ">":
+		return d > 0
+	case ">=":
+		return d >= 0
+	case "<":
+		return d < 0
+	case "<=":
+		return d <= 0
+	case "=":
+		return d == 0
+	case "!=":
+		return d != 0
+	}
+	return false
+}
+
+func (c cmp) String() string { return c.field + " " + c.op + " " + c.value.String() }
+
+type and []Expr
+
+func (a and) Eval(v Values) bool {
+	for _, e := range a {
+		if !e.Eval(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a and) String() string { return join(a, " AND ") }
+
+type or []Expr
+
+func (o or) Eval(v Values) bool {
+	for _, e := range o {
+		if e.Eval(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o or) String() string { return join(o, " OR ") }
+
+func join(list []Expr, sep string) string {
+	parts := make([]string, len(list))
+	for i, e := range list {
+		s := e.String()
+		if _, isOr := e.(or); isOr {
+			s = "(" + s + ")"
+		}
+		parts[i] = s
+	}
+	return strings.Join(parts, sep)
+}
+
+// Parse разбирает условие; ошибка указывает позицию и что ожидалось.
+func Parse(src string) (Expr, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(toks) == 0 {
+		return nil, fmt.Errorf("ruledsl: empty condition")
+	}
+	p := &parser{toks: toks}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t != nil {
+		return nil, fmt.Errorf("ruledsl: unexpected %q at %d", t.text, t.pos)
+	}
+	return e, nil
+}
+
+// FieldList — поля через запятую (для сообщений об ошибках).
+func FieldList() string {
+	names := make([]string, 0, len(Fields))
+	for f := range Fields {
+		names = append(names, f)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+type tokKind int
+
+const (
+	tokIdent tokKind = iota
+	tokNumber
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var out []token
+	rs := []rune(src)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			out = append(out, token{tokLParen, "(", i})
+			i++
+		case r == ')':
+			out = append(out, token{tokRParen, ")", i})
+			i++
+		case strings.ContainsRune("<>=!", r):
+			n := 1
+			if i+1 < len(rs) && rs[i+1] == '=' {
+				n = 2
+			}
+			op := string(rs[i : i+n])
+			switch op {
+			case "==":
+				op = "=" // привычная запись равенства
+			case "!":
+				return nil, fmt.Errorf("ruledsl: unexpected %q at %d", op, i)
+			}
+			out = append(out, token{tokOp, op, i})
+			i += n
+		case unicode.IsDigit(r) || r == '.' || r == '-':
+			j := i + 1
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			out = append(out, token{tokNumber, string(rs[i:j]), i})
+			i = j
+		case unicode.IsLetter(r) || r == '_':
+			j := i + 1
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			out = append(out, token{tokIdent, string(rs[i:j]), i})
+			i = j
+		default:
+			return nil, fmt.Errorf("ruledsl: unexpected %q at %d", string(r), i)
+		}
+	}
+	return out, nil
+}
+
+type parser struct {
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() *token {
+	if p.i >= len(p.toks) {
+		return nil
+	}
+	return &p.toks[p.i]
+}
+
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	if t != nil && t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (Expr, error) {
+	var list or
+	for {
+		e, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if !p.keyword("or") {
+			break
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *parser) and() (Expr, error) {
+	var list and
+	for {
+		e, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+		if !p.keyword("and") {
+			break
+		}
+	}
+	if len(list) == 1 {
+		return list[0], nil
+	}
+	return list, nil
+}
+
+func (p *parser) term() (Expr, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, fmt.Errorf("ruledsl: unexpected end of condition")
+	}
+	if t.kind == tokLParen {
+		p.i++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.peek(); c == nil || c.kind != tokRParen {
+			return nil, fmt.Errorf("ruledsl: missing ) for ( at %d", t.pos)
+		}
+		p.i++
+		return e, nil
+	}
+	if t.kind != tokIdent {
+		return nil, fmt.Errorf("ruledsl: expected field at %d, got %q", t.pos, t.text)
+	}
+	field := strings.ToLower(t.text)
+	if !Fields[field] {
+		return nil, fmt.Errorf("ruledsl: unknown field %q at %d (use: %s)", t.text, t.pos, FieldList())
+	}
+	p.i++
+
+	op := p.peek()
+	if op == nil || op.kind != tokOp {
+		return nil, fmt.Errorf("ruledsl: expected comparison after %q", t.text)
+	}
+	p.i++
+
+	num := p.peek()
+	if num == nil || num.kind != tokNumber {
+		return nil, fmt.Errorf("ruledsl: expected number after %q %s", t.text, op.text)
+	}
+	v, err := decimal.NewFromString(num.text)
+	if err != nil {
+		return nil, fmt.Errorf("ruledsl: bad number %q at %d", num.text, num.pos)
+	}
+	p.i++
+	return cmp{field: field, op: op.text, value: v}, nil
+}
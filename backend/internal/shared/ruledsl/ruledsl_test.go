@@ -0,0 +1,75 @@
+package ruledsl_test
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/shared/ruledsl"
+)
+
+func num(s string) *decimal.Decimal {
+	d := decimal.RequireFromString(s)
+	return &d
+}
+
+func TestParse_Eval(t *testing.T) {
+	vals := ruledsl.Values{
+		"clicks":      num("120"),
+		"conversions": num("6"),
+		"revenue":     num("180"),
+		"spend":       num("300"),
+		"cpa":         num("50"),
+		"roas":        num("0.6"),
+	}
+	cases := []struct {
+		cond string
+		want bool
+	}{
+		{"cpa > 40 AND conversions >= 5", true},
+		{"cpa > 40 and conversions >= 10", false},
+		{"roas < 1", true},
+		{"ROAS >= 1 OR spend == 300", true},
+		{"roas >= 1 OR (spend > 100 AND conversions = 0)", false},
+		{"(roas < 1 OR clicks < 10) AND cpa <= 50", true},
+		{"conversions != 6", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.cond, func(t *testing.T) {
+			e, err := ruledsl.Parse(tc.cond)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, e.Eval(vals))
+		})
+	}
+}
+
+func TestEval_UndefinedMetricIsFalse(t *testing.T) {
+	e, err := ruledsl.Parse("cpa > 40 OR cpa <= 40")
+	require.NoError(t, err)
+	require.False(t, e.Eval(ruledsl.Values{"cpa": nil, "conversions": num("0")}))
+}
+
+func TestParse_String(t *testing.T) {
+	e, err := ruledsl.Parse("(roas < 1 or clicks<10) and cpa<=50")
+	require.NoError(t, err)
+	require.Equal(t, "(roas < 1 OR clicks < 10) AND cpa <= 50", e.String())
+}
+
+func TestParse_Errors(t *testing.T) {
+	for _, cond := range []string{
+		"",
+		"ctr > 1",
+		"cpa >",
+		"cpa 40",
+		"cpa > 40 AND",
+		"(cpa > 40",
+		"cpa > 40)",
+		"cpa ! 40",
+		"cpa > 4.0.1",
+		"cpa > 40 $",
+	} {
+		_, err := ruledsl.Parse(cond)
+		require.Error(t, err, cond)
+	}
+}
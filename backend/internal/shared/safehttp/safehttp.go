@@ -0,0 +1,112 @@
+// Package safehttp — HTTP-клиент для запросов на URL, которые задаёт пользователь
+// (webhook-подписки, каналы алертов).
+//
+// Адрес проверяется в момент соединения (net.Dialer.Control), уже после DNS: имя,
+// которое резолвится в loopback, приватную сеть, link-local (в т.ч. 169.254.169.254)
+// и прочие не-публичные адреса, отклоняется — переименование DNS между проверкой
+// и запросом не помогает. Редиректы не выполняются, прокси из окружения не используется.
+package safehttp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL       = errors.New("url must be an http(s) URL")
+	ErrForbiddenAddress = errors.New("destination address is not public")
+)
+
+// не-публичные диапазоны, которые не покрывают методы netip.Addr
+var reserved = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 — оборачивает любой IPv4
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("fec0::/10"),
+}
+
+// IsPublic — адрес маршрутизируется в интернете (не loopback, не приватный, не link-local и т.п.).
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range reserved {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewClient — клиент, который соединяется только с публичными адресами и не ходит по редиректам.
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, control)
+}
+
+// NewLocalClient — то же без фильтра адресов: локальная разработка и тесты.
+func NewLocalClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, nil)
+}
+
+func newClient(timeout time.Duration, ctl func(string, string, syscall.RawConn) error) *http.Client {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second, Control: ctl}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          20,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ExpectContinueTimeout: time.Second,
+		},
+		// 3xx возвращается вызывающему как есть: Location мог бы увести во внутреннюю сеть
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// control вызывается для каждого адреса, в который резолвилось имя, прямо перед connect.
+func control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !IsPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
+
+// ValidateURL — ранняя проверка при сохранении: http(s) URL с хостом, хост не localhost
+// и не литерал не-публичного IP. Имена не резолвятся — это делает dial-time проверка.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenAddress
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !IsPublic(ip) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
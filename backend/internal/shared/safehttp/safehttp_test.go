@@ -0,0 +1,85 @@
+package safehttp_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/shared/safehttp"
+)
+
+func TestIsPublic(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"8.8.8.8":            true,
+		"2a00:1450::1":       true,
+		"127.0.0.1":          false,
+		"10.1.2.3":           false,
+		"172.20.0.5":         false, // docker-сеть: vault:8200, postgres
+		"192.168.1.1":        false,
+		"169.254.169.254":    false, // метаданные облака
+		"100.100.100.200":    false,
+		"0.0.0.0":            false,
+		"::1":                false,
+		"fe80::1":            false,
+		"fd00:ec2::254":      false,
+		"::ffff:127.0.0.1":   false,
+		"::ffff:169.254.1.1": false,
+		"64:ff9b::a9fe:a9fe": false,
+		"224.0.0.1":          false,
+	} {
+		require.Equal(t, want, safehttp.IsPublic(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	require.NoError(t, safehttp.ValidateURL("https://hooks.example.com/x"))
+	require.NoError(t, safehttp.ValidateURL("http://vault:8200/v1/secret")) // имя проверит dial
+	require.ErrorIs(t, safehttp.ValidateURL("ftp://example.com"), safehttp.ErrInvalidURL)
+	require.ErrorIs(t, safehttp.ValidateURL("https:///path"), safehttp.ErrInvalidURL)
+	for _, u := range []string{
+		"http://localhost:8080/",
+		"http://api.localhost/",
+		"http://127.0.0.1/",
+		"http://[::1]:9000/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.7/hook",
+	} {
+		require.ErrorIs(t, safehttp.ValidateURL(u), safehttp.ErrForbiddenAddress, u)
+	}
+}
+
+func TestNewClient_RejectsLoopbackAtDial(t *testing.T) {
+	var hit bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hit = true }))
+	defer srv.Close()
+
+	// имя, а не литерал: проверяется адрес после резолва
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost:"+port+"/", nil)
+	_, err := safehttp.NewClient(0).Do(req)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, safehttp.ErrForbiddenAddress), err.Error())
+	require.False(t, hit)
+}
+
+func TestNewClient_DoesNotFollowRedirects(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("redirect followed")
+	}))
+	defer internal.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	resp, err := safehttp.NewLocalClient(0).Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+}
@@ -0,0 +1,54 @@
+// Package sharetoken — подписанные токены публичных ссылок на отчёт (без логина).
+//
+// Формат: <link_id>.<exp_unix>.<sig>, sig = base64url(HMAC-SHA256(secret, "<link_id>.<exp_unix>")).
+// Подпись защищает от перебора link_id; отзыв и срок проверяются ещё и по БД.
+package sharetoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalid = errors.New("invalid share token")
+
+// Sign выпускает токен ссылки linkID, действующей до exp.
+func Sign(secret []byte, linkID int64, exp time.Time) string {
+	payload := strconv.FormatInt(linkID, 10) + "." + strconv.FormatInt(exp.Unix(), 10)
+	return payload + "." + sign(secret, payload)
+}
+
+// Parse проверяет подпись и возвращает link_id и срок действия (истечение не проверяет).
+func Parse(secret []byte, token string) (int64, time.Time, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return 0, time.Time{}, ErrInvalid
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(sign(secret, payload))) {
+		return 0, time.Time{}, ErrInvalid
+	}
+	idPart, expPart, ok := strings.Cut(payload, ".")
+	if !ok {
+		return 0, time.Time{}, ErrInvalid
+	}
+	linkID, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || linkID <= 0 {
+		return 0, time.Time{}, ErrInvalid
+	}
+	exp, err := strconv.ParseInt(expPart, 10, 64)
+	if err != nil {
+		return 0, time.Time{}, ErrInvalid
+	}
+	return linkID, time.Unix(exp, 0).UTC(), nil
+}
+
+func sign(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
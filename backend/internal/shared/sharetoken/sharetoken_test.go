@@ -0,0 +1,42 @@
+package sharetoken_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/berezovskyivalerii/adsieve/internal/shared/sharetoken"
+)
+
+var secret = []byte("share-secret")
+
+func TestSignParse_RoundTrip(t *testing.T) {
+	exp := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	tok := sharetoken.Sign(secret, 17, exp)
+	require.True(t, strings.HasPrefix(tok, "17.1743508800."))
+
+	id, gotExp, err := sharetoken.Parse(secret, tok)
+	require.NoError(t, err)
+	require.Equal(t, int64(17), id)
+	require.True(t, exp.Equal(gotExp))
+}
+
+func TestParse_Rejects(t *testing.T) {
+	exp := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	tok := sharetoken.Sign(secret, 17, exp)
+	sig := tok[strings.LastIndexByte(tok, '.')+1:]
+
+	for name, bad := range map[string]string{
+		"other secret": sharetoken.Sign([]byte("other"), 17, exp),
+		"other link":   "18.1743508800." + sig, // подпись от ссылки 17
+		"extended exp": "17.1900000000." + sig,
+		"no signature": "17.1743508800",
+		"garbage":      "not-a-token",
+		"empty":        "",
+	} {
+		_, _, err := sharetoken.Parse(secret, bad)
+		require.ErrorIs(t, err, sharetoken.ErrInvalid, name)
+	}
+}
@@ -0,0 +1,34 @@
+-- +goose Up
+
+-- 1) gclid из посадочной ссылки — нужен для офлайн-загрузки конверсий в Google Ads
+ALTER TABLE clicks
+  ADD COLUMN IF NOT EXISTS gclid TEXT;
+
+-- 2) Действие-конверсия в Google Ads, в которое грузим конверсии аккаунта
+--    (resource name вида customers/<cid>/conversionActions/<id>)
+ALTER TABLE ad_accounts
+  ADD COLUMN IF NOT EXISTS conversion_action TEXT;
+
+-- 3) Очередь загрузки конверсий (одна строка на конверсию)
+CREATE TABLE IF NOT EXISTS google_conversion_uploads (
+  conversion_id     BIGINT PRIMARY KEY REFERENCES conversions (conversion_id) ON DELETE CASCADE,
+  user_id           BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  customer_id       TEXT        NOT NULL,
+  conversion_action TEXT        NOT NULL,
+  gclid             TEXT        NOT NULL,
+  status            TEXT        NOT NULL DEFAULT 'pending', -- pending | processing | uploaded | failed
+  attempts          INT         NOT NULL DEFAULT 0,
+  last_error        TEXT,
+  next_attempt_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  uploaded_at       TIMESTAMPTZ,
+  created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_gconv_uploads_due
+  ON google_conversion_uploads (status, next_attempt_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_gconv_uploads_due;
+DROP TABLE IF EXISTS google_conversion_uploads;
+ALTER TABLE ad_accounts DROP COLUMN IF EXISTS conversion_action;
+ALTER TABLE clicks DROP COLUMN IF EXISTS gclid;
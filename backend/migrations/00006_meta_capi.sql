@@ -0,0 +1,46 @@
+-- +goose Up
+
+-- 1) fbclid из посадочной ссылки (для параметра fbc в Conversions API)
+ALTER TABLE clicks
+  ADD COLUMN IF NOT EXISTS fbclid TEXT;
+
+-- 2) Настройки Conversions API на рекламный аккаунт Meta
+CREATE TABLE IF NOT EXISTS meta_capi_settings (
+  account_id       BIGINT PRIMARY KEY REFERENCES ad_accounts (account_id) ON DELETE CASCADE,
+  pixel_id         TEXT        NOT NULL,
+  access_token_enc TEXT        NOT NULL,  -- system user token, в шифре
+  currency         TEXT        NOT NULL DEFAULT 'USD',
+  test_event_code  TEXT,
+  enabled          BOOLEAN     NOT NULL DEFAULT TRUE,
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 3) Очередь пересылки конверсий в CAPI (одна строка на конверсию)
+--    email/phone храним только в виде SHA-256, как их и требует Meta
+CREATE TABLE IF NOT EXISTS meta_capi_events (
+  conversion_id   BIGINT PRIMARY KEY REFERENCES conversions (conversion_id) ON DELETE CASCADE,
+  account_id      BIGINT      NOT NULL REFERENCES ad_accounts (account_id) ON DELETE CASCADE,
+  user_id         BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  event_id        TEXT        NOT NULL,
+  em_hash         TEXT,
+  ph_hash         TEXT,
+  fbc             TEXT,
+  fbp             TEXT,
+  status          TEXT        NOT NULL DEFAULT 'pending', -- pending | processing | uploaded | failed
+  attempts        INT         NOT NULL DEFAULT 0,
+  last_error      TEXT,
+  fbtrace_id      TEXT,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  sent_at         TIMESTAMPTZ,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_meta_capi_events_due
+  ON meta_capi_events (status, next_attempt_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_meta_capi_events_due;
+DROP TABLE IF EXISTS meta_capi_events;
+DROP TABLE IF EXISTS meta_capi_settings;
+ALTER TABLE clicks DROP COLUMN IF EXISTS fbclid;
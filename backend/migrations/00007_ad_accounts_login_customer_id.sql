@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- MCC, через который привязан аккаунт Google Ads: значение заголовка login-customer-id
+-- для запросов по этому аккаунту. NULL ⇒ аккаунт доступен напрямую
+-- (или привязан до появления колонки — тогда действует GOOGLE_LOGIN_CUSTOMER_ID).
+ALTER TABLE ad_accounts
+  ADD COLUMN IF NOT EXISTS login_customer_id TEXT;
+
+-- +goose Down
+ALTER TABLE ad_accounts DROP COLUMN IF EXISTS login_customer_id;
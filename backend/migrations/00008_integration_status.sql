@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- Итог последнего синка аккаунта — для GET /integrations/status
+ALTER TABLE ad_accounts
+  ADD COLUMN IF NOT EXISTS last_synced_at     TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS last_sync_error    TEXT,
+  ADD COLUMN IF NOT EXISTS last_sync_error_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE ad_accounts
+  DROP COLUMN IF EXISTS last_sync_error_at,
+  DROP COLUMN IF EXISTS last_sync_error,
+  DROP COLUMN IF EXISTS last_synced_at;
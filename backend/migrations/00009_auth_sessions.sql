@@ -0,0 +1,53 @@
+-- +goose Up
+
+-- 1) Сессия = семейство refresh-токенов одного входа (устройства).
+--    Ротация идёт внутри семейства; повторное использование уже ротированного
+--    токена отзывает только это семейство.
+CREATE TABLE IF NOT EXISTS auth_sessions (
+  session_id    BIGSERIAL PRIMARY KEY,
+  user_id       BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  user_agent    TEXT        NOT NULL DEFAULT '',
+  ip            TEXT        NOT NULL DEFAULT '',
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  last_used_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  expires_at    TIMESTAMPTZ NOT NULL,
+  revoked_at    TIMESTAMPTZ,
+  revoke_reason TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_user ON auth_sessions (user_id) WHERE revoked_at IS NULL;
+
+-- 2) refresh_tokens: вместо самого токена — SHA-256 (hex), привязка к сессии, отметка ротации
+ALTER TABLE refresh_tokens
+  ADD COLUMN IF NOT EXISTS session_id BIGINT REFERENCES auth_sessions (session_id) ON DELETE CASCADE,
+  ADD COLUMN IF NOT EXISTS token_hash TEXT,
+  ADD COLUMN IF NOT EXISTS used_at    TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- 3) Перенос: каждый живой токен становится своей сессией, никого не разлогиниваем
+DELETE FROM refresh_tokens WHERE expires_at <= NOW();
+INSERT INTO auth_sessions (session_id, user_id, expires_at)
+SELECT token_id, user_id, expires_at FROM refresh_tokens;
+SELECT setval(pg_get_serial_sequence('auth_sessions', 'session_id'),
+              GREATEST((SELECT COALESCE(MAX(session_id), 0) FROM auth_sessions), 1));
+UPDATE refresh_tokens
+SET session_id = token_id,
+    token_hash = encode(sha256(convert_to(refresh_token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens
+  DROP COLUMN refresh_token,
+  ALTER COLUMN session_id SET NOT NULL,
+  ALTER COLUMN token_hash SET NOT NULL,
+  ADD CONSTRAINT refresh_tokens_token_hash_key UNIQUE (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session ON refresh_tokens (session_id);
+
+-- +goose Down
+-- хэши обратно в токены не превратить — все refresh-токены сбрасываются
+DELETE FROM refresh_tokens;
+ALTER TABLE refresh_tokens
+  DROP CONSTRAINT IF EXISTS refresh_tokens_token_hash_key,
+  DROP COLUMN IF EXISTS created_at,
+  DROP COLUMN IF EXISTS used_at,
+  DROP COLUMN IF EXISTS token_hash,
+  DROP COLUMN IF EXISTS session_id,
+  ADD COLUMN refresh_token TEXT NOT NULL UNIQUE;
+DROP TABLE IF EXISTS auth_sessions;
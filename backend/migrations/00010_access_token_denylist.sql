@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- Отозванные access-токены (выход). Строка нужна, только пока токен не истёк сам.
+CREATE TABLE IF NOT EXISTS revoked_access_tokens (
+  jti        TEXT        PRIMARY KEY,
+  user_id    BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_access_tokens_exp ON revoked_access_tokens (expires_at);
+
+-- Выход отовсюду: access-токены пользователя, выданные раньше not_before, недействительны.
+-- После expires_at (not_before + TTL access-токена) строка ничего не отсекает.
+CREATE TABLE IF NOT EXISTS access_token_cutoffs (
+  user_id    BIGINT      PRIMARY KEY REFERENCES users (user_id) ON DELETE CASCADE,
+  not_before TIMESTAMPTZ NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS access_token_cutoffs;
+DROP TABLE IF EXISTS revoked_access_tokens;
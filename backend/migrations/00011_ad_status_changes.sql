@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- Аудит пауз/включений объявлений из AdSieve: кто, что и чем закончилось на платформе
+CREATE TABLE IF NOT EXISTS ad_status_changes (
+  change_id   BIGSERIAL PRIMARY KEY,
+  ad_id       BIGINT      NOT NULL REFERENCES ads (ad_id) ON DELETE CASCADE,
+  user_id     BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  platform    TEXT        NOT NULL,
+  from_status TEXT        NOT NULL,
+  to_status   TEXT        NOT NULL,
+  result      TEXT        NOT NULL CHECK (result IN ('applied', 'failed')),
+  error       TEXT,
+  created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_ad_status_changes_ad ON ad_status_changes (ad_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS ad_status_changes;
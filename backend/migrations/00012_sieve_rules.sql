@@ -0,0 +1,43 @@
+-- +goose Up
+
+-- «Sieve rules»: условие (DSL над метриками) за окно в днях и действие над объявлением
+CREATE TABLE IF NOT EXISTS sieve_rules (
+  rule_id      BIGSERIAL PRIMARY KEY,
+  user_id      BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  name         TEXT        NOT NULL,
+  condition    TEXT        NOT NULL,
+  window_days  INT         NOT NULL CHECK (window_days BETWEEN 1 AND 90),
+  action       TEXT        NOT NULL CHECK (action IN ('pause', 'notify', 'tag')),
+  action_param TEXT        NOT NULL DEFAULT '',
+  enabled      BOOLEAN     NOT NULL DEFAULT TRUE,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_sieve_rules_user ON sieve_rules (user_id);
+
+-- Журнал срабатываний (и dry-run) с метриками на момент проверки
+CREATE TABLE IF NOT EXISTS rule_executions (
+  execution_id BIGSERIAL PRIMARY KEY,
+  rule_id      BIGINT      NOT NULL REFERENCES sieve_rules (rule_id) ON DELETE CASCADE,
+  ad_id        BIGINT      NOT NULL REFERENCES ads (ad_id) ON DELETE CASCADE,
+  action       TEXT        NOT NULL,
+  dry_run      BOOLEAN     NOT NULL DEFAULT FALSE,
+  result       TEXT        NOT NULL CHECK (result IN ('applied', 'skipped', 'failed')),
+  error        TEXT,
+  metrics      JSONB       NOT NULL DEFAULT '{}',
+  executed_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_rule_executions_rule ON rule_executions (rule_id, executed_at DESC);
+
+-- Теги объявлений (действие tag)
+CREATE TABLE IF NOT EXISTS ad_tags (
+  ad_id      BIGINT      NOT NULL REFERENCES ads (ad_id) ON DELETE CASCADE,
+  tag        TEXT        NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (ad_id, tag)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS ad_tags;
+DROP TABLE IF EXISTS rule_executions;
+DROP TABLE IF EXISTS sieve_rules;
@@ -0,0 +1,50 @@
+-- +goose Up
+
+-- Каналы доставки алертов пользователя
+CREATE TABLE IF NOT EXISTS alert_channels (
+  channel_id BIGSERIAL PRIMARY KEY,
+  user_id    BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  kind       TEXT        NOT NULL CHECK (kind IN ('webhook', 'slack', 'email')),
+  target     TEXT        NOT NULL,
+  enabled    BOOLEAN     NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, kind, target)
+);
+
+-- Алерты: выбросы метрик (anomaly) и уведомления sieve rules (rule).
+-- Один алерт на объявление/метрику/день — повторный прогон детектора не дублирует.
+CREATE TABLE IF NOT EXISTS alerts (
+  alert_id    BIGSERIAL PRIMARY KEY,
+  user_id     BIGINT           NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  ad_id       BIGINT           NOT NULL REFERENCES ads (ad_id) ON DELETE CASCADE,
+  kind        TEXT             NOT NULL CHECK (kind IN ('anomaly', 'rule')),
+  metric      TEXT             NOT NULL,
+  metric_date DATE             NOT NULL,
+  value       DOUBLE PRECISION NOT NULL DEFAULT 0,
+  baseline    DOUBLE PRECISION NOT NULL DEFAULT 0,
+  z_score     DOUBLE PRECISION NOT NULL DEFAULT 0,
+  pct_change  DOUBLE PRECISION NOT NULL DEFAULT 0,
+  direction   TEXT             NOT NULL DEFAULT '',
+  message     TEXT             NOT NULL,
+  created_at  TIMESTAMPTZ      NOT NULL DEFAULT NOW(),
+  UNIQUE (ad_id, kind, metric, metric_date)
+);
+CREATE INDEX IF NOT EXISTS idx_alerts_user_created ON alerts (user_id, created_at DESC);
+
+-- Очередь доставки: алерт × канал
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+  alert_id        BIGINT      NOT NULL REFERENCES alerts (alert_id) ON DELETE CASCADE,
+  channel_id      BIGINT      NOT NULL REFERENCES alert_channels (channel_id) ON DELETE CASCADE,
+  status          TEXT        NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'failed')),
+  attempts        INT         NOT NULL DEFAULT 0,
+  last_error      TEXT,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  sent_at         TIMESTAMPTZ,
+  PRIMARY KEY (alert_id, channel_id)
+);
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_due ON alert_deliveries (next_attempt_at) WHERE status = 'pending';
+
+-- +goose Down
+DROP TABLE IF EXISTS alert_deliveries;
+DROP TABLE IF EXISTS alerts;
+DROP TABLE IF EXISTS alert_channels;
@@ -0,0 +1,39 @@
+-- +goose Up
+
+-- Бюджеты рекламных аккаунтов: месячный лимит или дневной (пейсинг — по месяцу).
+-- alert_pct — алерт, когда прогноз на конец месяца превышает лимит больше чем на столько %.
+CREATE TABLE IF NOT EXISTS budgets (
+  budget_id  BIGSERIAL PRIMARY KEY,
+  user_id    BIGINT        NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  account_id BIGINT        NOT NULL REFERENCES ad_accounts (account_id) ON DELETE CASCADE,
+  name       TEXT          NOT NULL,
+  period     TEXT          NOT NULL CHECK (period IN ('monthly', 'daily')),
+  amount     NUMERIC(15,2) NOT NULL CHECK (amount > 0),
+  alert_pct  NUMERIC(7,2)  NOT NULL DEFAULT 10 CHECK (alert_pct >= 0),
+  created_at TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_budgets_user ON budgets (user_id);
+
+-- Группа объявлений бюджета; пусто — бюджет на весь аккаунт
+CREATE TABLE IF NOT EXISTS budget_ads (
+  budget_id BIGINT NOT NULL REFERENCES budgets (budget_id) ON DELETE CASCADE,
+  ad_id     BIGINT NOT NULL REFERENCES ads (ad_id) ON DELETE CASCADE,
+  PRIMARY KEY (budget_id, ad_id)
+);
+
+-- Алерты по бюджету не привязаны к объявлению: metric = 'budget:<budget_id>',
+-- metric_date — начало месяца (один алерт на бюджет за месяц).
+ALTER TABLE alerts ALTER COLUMN ad_id DROP NOT NULL;
+ALTER TABLE alerts DROP CONSTRAINT IF EXISTS alerts_kind_check;
+ALTER TABLE alerts ADD CONSTRAINT alerts_kind_check CHECK (kind IN ('anomaly', 'rule', 'budget'));
+CREATE UNIQUE INDEX IF NOT EXISTS uq_alerts_without_ad ON alerts (user_id, kind, metric, metric_date) WHERE ad_id IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS uq_alerts_without_ad;
+DELETE FROM alerts WHERE ad_id IS NULL;
+ALTER TABLE alerts DROP CONSTRAINT IF EXISTS alerts_kind_check;
+ALTER TABLE alerts ADD CONSTRAINT alerts_kind_check CHECK (kind IN ('anomaly', 'rule'));
+ALTER TABLE alerts ALTER COLUMN ad_id SET NOT NULL;
+DROP TABLE IF EXISTS budget_ads;
+DROP TABLE IF EXISTS budgets;
@@ -0,0 +1,37 @@
+-- +goose Up
+
+-- Подписки интеграторов на события: URL, секрет HMAC-подписи и типы событий
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  subscription_id BIGSERIAL PRIMARY KEY,
+  user_id         BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  url             TEXT        NOT NULL,
+  secret          TEXT        NOT NULL,
+  event_types     TEXT[]      NOT NULL,
+  enabled         BOOLEAN     NOT NULL DEFAULT TRUE,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_user ON webhook_subscriptions (user_id) WHERE enabled;
+
+-- Очередь доставки: событие × подписка. После исчерпания попыток — dead (dead-letter),
+-- такие доставки можно переотправить вручную (replay).
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  delivery_id      BIGSERIAL PRIMARY KEY,
+  subscription_id  BIGINT      NOT NULL REFERENCES webhook_subscriptions (subscription_id) ON DELETE CASCADE,
+  event_id         UUID        NOT NULL,
+  event_type       TEXT        NOT NULL,
+  payload          JSONB       NOT NULL,
+  status           TEXT        NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'dead')),
+  attempts         INT         NOT NULL DEFAULT 0,
+  last_status_code INT,
+  last_error       TEXT,
+  next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  sent_at          TIMESTAMPTZ,
+  UNIQUE (subscription_id, event_id)
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_sub ON webhook_deliveries (subscription_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
@@ -0,0 +1,21 @@
+-- +goose Up
+
+-- Transactional outbox: событие пишется тем же запросом, что и клик/конверсия,
+-- relay (cmd/cron/relay_outbox) публикует его в sinks не меньше одного раза
+-- и по порядку outbox_id внутри одного объявления.
+CREATE TABLE IF NOT EXISTS outbox_events (
+  outbox_id       BIGSERIAL PRIMARY KEY,
+  event_id        UUID        NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+  event_type      TEXT        NOT NULL,
+  ad_id           BIGINT      NOT NULL,
+  payload         JSONB       NOT NULL,
+  created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  attempts        INT         NOT NULL DEFAULT 0,
+  last_error      TEXT,
+  next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  published_at    TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox_events (outbox_id) WHERE published_at IS NULL;
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox_events;
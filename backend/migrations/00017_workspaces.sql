@@ -0,0 +1,72 @@
+-- +goose Up
+
+-- Рабочие пространства: данные (рекламные аккаунты) принадлежат пространству,
+-- пользователи работают в нём с ролью. У каждого пользователя есть личное пространство.
+CREATE TABLE IF NOT EXISTS workspaces (
+  workspace_id     BIGSERIAL PRIMARY KEY,
+  name             TEXT        NOT NULL,
+  personal_user_id BIGINT      UNIQUE REFERENCES users (user_id) ON DELETE CASCADE, -- NULL — командное
+  created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+  workspace_id BIGINT      NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+  user_id      BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  role         TEXT        NOT NULL CHECK (role IN ('owner', 'admin', 'analyst', 'read_only')),
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (workspace_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user ON workspace_members (user_id);
+
+-- Приглашения по email: в БД только SHA-256 токена, принять может пользователь с этим email
+CREATE TABLE IF NOT EXISTS workspace_invitations (
+  invitation_id BIGSERIAL PRIMARY KEY,
+  workspace_id  BIGINT      NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+  email         TEXT        NOT NULL,
+  role          TEXT        NOT NULL CHECK (role IN ('admin', 'analyst', 'read_only')),
+  token_hash    TEXT        NOT NULL UNIQUE,
+  invited_by    BIGINT      REFERENCES users (user_id) ON DELETE SET NULL,
+  expires_at    TIMESTAMPTZ NOT NULL,
+  accepted_at   TIMESTAMPTZ,
+  accepted_by   BIGINT      REFERENCES users (user_id) ON DELETE SET NULL,
+  created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+-- одно открытое приглашение на email в пространстве (повторное — перевыпуск токена)
+CREATE UNIQUE INDEX IF NOT EXISTS uq_workspace_invitations_pending
+  ON workspace_invitations (workspace_id, lower(email)) WHERE accepted_at IS NULL;
+
+-- личные пространства существующих пользователей
+INSERT INTO workspaces (name, personal_user_id)
+SELECT email, user_id FROM users
+ON CONFLICT (personal_user_id) DO NOTHING;
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT workspace_id, personal_user_id, 'owner' FROM workspaces WHERE personal_user_id IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- аккаунты переезжают в личное пространство того, кто их привязал;
+-- ad_accounts.user_id остаётся — чьими токенами синкается аккаунт
+ALTER TABLE ad_accounts ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces (workspace_id) ON DELETE CASCADE;
+UPDATE ad_accounts aa SET workspace_id = w.workspace_id
+FROM workspaces w
+WHERE w.personal_user_id = aa.user_id AND aa.workspace_id IS NULL;
+ALTER TABLE ad_accounts ALTER COLUMN workspace_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_ad_accounts_workspace ON ad_accounts (workspace_id);
+
+-- доступ к объявлениям для метрик — тоже в разрезе пространства
+ALTER TABLE user_ads ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces (workspace_id) ON DELETE CASCADE;
+UPDATE user_ads ua SET workspace_id = COALESCE(
+  (SELECT aa.workspace_id FROM ads a JOIN ad_accounts aa ON aa.account_id = a.account_id WHERE a.ad_id = ua.ad_id),
+  (SELECT w.workspace_id FROM workspaces w WHERE w.personal_user_id = ua.user_id))
+WHERE ua.workspace_id IS NULL;
+ALTER TABLE user_ads ALTER COLUMN workspace_id SET NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_user_ads_workspace ON user_ads (workspace_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_user_ads_workspace;
+ALTER TABLE user_ads DROP COLUMN IF EXISTS workspace_id;
+DROP INDEX IF EXISTS idx_ad_accounts_workspace;
+ALTER TABLE ad_accounts DROP COLUMN IF EXISTS workspace_id;
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
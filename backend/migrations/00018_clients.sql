@@ -0,0 +1,43 @@
+-- +goose Up
+
+-- Клиенты агентства: группа рекламных аккаунтов рабочего пространства
+CREATE TABLE IF NOT EXISTS clients (
+  client_id    BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT      NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+  name         TEXT        NOT NULL,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  UNIQUE (workspace_id, name)
+);
+
+ALTER TABLE ad_accounts ADD COLUMN IF NOT EXISTS client_id BIGINT REFERENCES clients (client_id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_ad_accounts_client ON ad_accounts (client_id) WHERE client_id IS NOT NULL;
+
+-- Публичные ссылки на отчёт клиента: только чтение, со сроком, отзываемые.
+-- Сам токен не хранится — он подписан (см. shared/sharetoken) и содержит link_id и срок.
+CREATE TABLE IF NOT EXISTS share_links (
+  link_id    BIGSERIAL PRIMARY KEY,
+  client_id  BIGINT      NOT NULL REFERENCES clients (client_id) ON DELETE CASCADE,
+  created_by BIGINT      REFERENCES users (user_id) ON DELETE SET NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_share_links_client ON share_links (client_id);
+
+-- Журнал обращений по ссылке (включая отказы: истекла / отозвана)
+CREATE TABLE IF NOT EXISTS share_link_access (
+  access_id   BIGSERIAL PRIMARY KEY,
+  link_id     BIGINT      NOT NULL REFERENCES share_links (link_id) ON DELETE CASCADE,
+  accessed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  ip          TEXT        NOT NULL DEFAULT '',
+  user_agent  TEXT        NOT NULL DEFAULT '',
+  outcome     TEXT        NOT NULL CHECK (outcome IN ('ok', 'expired', 'revoked'))
+);
+CREATE INDEX IF NOT EXISTS idx_share_link_access_link ON share_link_access (link_id, accessed_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS share_link_access;
+DROP TABLE IF EXISTS share_links;
+DROP INDEX IF EXISTS idx_ad_accounts_client;
+ALTER TABLE ad_accounts DROP COLUMN IF EXISTS client_id;
+DROP TABLE IF EXISTS clients;
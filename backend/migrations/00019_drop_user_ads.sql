@@ -0,0 +1,81 @@
+-- +goose Up
+
+-- Доступ к объявлениям теперь выводится из аккаунта: ads → ad_accounts.workspace_id → workspace_members.
+-- user_ads вёлся вручную (Ensure при синке) и расходился с /api/ads.
+--
+-- Сверка выдач из user_ads, которых новая модель не покрывает (пользователь не участник
+-- пространства аккаунта объявления). Членство даёт доступ ко ВСЕМ объявлениям пространства,
+-- поэтому read_only выдаётся только тем, у кого в user_ads уже были все объявления всех
+-- аккаунтов пространства, — доступ при этом не расширяется. Остальные выдачи (к части
+-- объявлений) новой моделью не выражаются: они пропадают и сохраняются в user_ads_dropped
+-- для ручного разбора (выдать членство или оставить как есть).
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT g.workspace_id, g.user_id, 'read_only'
+FROM (SELECT DISTINCT aa.workspace_id, ua.user_id
+      FROM user_ads ua
+      JOIN ads a ON a.ad_id = ua.ad_id
+      JOIN ad_accounts aa ON aa.account_id = a.account_id) g
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM ads a
+  JOIN ad_accounts aa ON aa.account_id = a.account_id
+  WHERE aa.workspace_id = g.workspace_id
+    AND NOT EXISTS (SELECT 1 FROM user_ads ua WHERE ua.user_id = g.user_id AND ua.ad_id = a.ad_id))
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS user_ads_dropped (
+  user_id      BIGINT NOT NULL,
+  ad_id        BIGINT NOT NULL,
+  workspace_id BIGINT NOT NULL,
+  dropped_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, ad_id)
+);
+INSERT INTO user_ads_dropped (user_id, ad_id, workspace_id)
+SELECT ua.user_id, ua.ad_id, aa.workspace_id
+FROM user_ads ua
+JOIN ads a ON a.ad_id = ua.ad_id
+JOIN ad_accounts aa ON aa.account_id = a.account_id
+WHERE NOT EXISTS (SELECT 1 FROM workspace_members m WHERE m.workspace_id = aa.workspace_id AND m.user_id = ua.user_id)
+ON CONFLICT DO NOTHING;
+
+-- +goose StatementBegin
+DO $$
+DECLARE n BIGINT;
+BEGIN
+  SELECT COUNT(*) INTO n FROM user_ads_dropped;
+  IF n > 0 THEN
+    RAISE NOTICE 'user_ads: % grant(s) not covered by workspace membership were dropped, see user_ads_dropped', n;
+  END IF;
+END
+$$;
+-- +goose StatementEnd
+
+DROP TABLE IF EXISTS user_ads;
+
+-- +goose Down
+-- Членства, выданные при сверке, не откатываются. Таблица восстанавливается из владения аккаунтами
+-- и отброшенных выдач.
+CREATE TABLE IF NOT EXISTS user_ads (
+  user_id      BIGINT NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  ad_id        BIGINT NOT NULL REFERENCES ads (ad_id) ON DELETE CASCADE,
+  workspace_id BIGINT NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+  PRIMARY KEY (user_id, ad_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_ads_user_id ON user_ads (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_ads_ad_id ON user_ads (ad_id);
+CREATE INDEX IF NOT EXISTS idx_user_ads_workspace ON user_ads (workspace_id);
+
+INSERT INTO user_ads (user_id, ad_id, workspace_id)
+SELECT aa.user_id, a.ad_id, aa.workspace_id
+FROM ads a
+JOIN ad_accounts aa ON aa.account_id = a.account_id
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_ads (user_id, ad_id, workspace_id)
+SELECT d.user_id, d.ad_id, d.workspace_id
+FROM user_ads_dropped d
+JOIN ads a ON a.ad_id = d.ad_id
+JOIN users u ON u.user_id = d.user_id
+JOIN workspaces w ON w.workspace_id = d.workspace_id
+ON CONFLICT DO NOTHING;
+DROP TABLE IF EXISTS user_ads_dropped;
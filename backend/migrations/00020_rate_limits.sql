@@ -0,0 +1,14 @@
+-- +goose Up
+
+-- Вёдра token bucket, общие для всех инстансов API (RATE_LIMIT_STORE=postgres).
+-- bucket_key — политика и принципал (напр. auth:ip:203.0.113.5); allowed — решение по последнему запросу.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+  bucket_key TEXT PRIMARY KEY,
+  tokens     DOUBLE PRECISION NOT NULL,
+  allowed    BOOLEAN          NOT NULL,
+  updated_at TIMESTAMPTZ      NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated ON rate_limit_buckets (updated_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS rate_limit_buckets;
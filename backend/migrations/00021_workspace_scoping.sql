@@ -0,0 +1,76 @@
+-- +goose Up
+
+-- Правила, бюджеты, алерты, каналы алертов и webhook-подписки принадлежат рабочему пространству,
+-- как и аккаунты, над которыми они работают. user_id остаётся — кто создал.
+ALTER TABLE sieve_rules           ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces (workspace_id) ON DELETE CASCADE;
+ALTER TABLE budgets               ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces (workspace_id) ON DELETE CASCADE;
+ALTER TABLE alert_channels        ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces (workspace_id) ON DELETE CASCADE;
+ALTER TABLE alerts                ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces (workspace_id) ON DELETE CASCADE;
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS workspace_id BIGINT REFERENCES workspaces (workspace_id) ON DELETE CASCADE;
+
+-- бюджет и алерт по объявлению — в пространство аккаунта; остальное — в личное пространство автора
+UPDATE budgets b SET workspace_id = aa.workspace_id
+FROM ad_accounts aa
+WHERE aa.account_id = b.account_id AND b.workspace_id IS NULL;
+
+UPDATE alerts al SET workspace_id = COALESCE(
+  (SELECT aa.workspace_id FROM ads a JOIN ad_accounts aa ON aa.account_id = a.account_id WHERE a.ad_id = al.ad_id),
+  (SELECT w.workspace_id FROM workspaces w WHERE w.personal_user_id = al.user_id))
+WHERE al.workspace_id IS NULL;
+
+UPDATE sieve_rules r SET workspace_id = w.workspace_id
+FROM workspaces w WHERE w.personal_user_id = r.user_id AND r.workspace_id IS NULL;
+UPDATE alert_channels c SET workspace_id = w.workspace_id
+FROM workspaces w WHERE w.personal_user_id = c.user_id AND c.workspace_id IS NULL;
+UPDATE webhook_subscriptions s SET workspace_id = w.workspace_id
+FROM workspaces w WHERE w.personal_user_id = s.user_id AND s.workspace_id IS NULL;
+
+ALTER TABLE sieve_rules           ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE budgets               ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE alert_channels        ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE alerts                ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE webhook_subscriptions ALTER COLUMN workspace_id SET NOT NULL;
+
+-- алерт адресован пространству, а не пользователю
+ALTER TABLE alerts ALTER COLUMN user_id DROP NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_sieve_rules_workspace ON sieve_rules (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_budgets_workspace ON budgets (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_alerts_workspace_created ON alerts (workspace_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_workspace ON webhook_subscriptions (workspace_id) WHERE enabled;
+
+-- уникальность канала и алерта без объявления — в пределах пространства
+ALTER TABLE alert_channels DROP CONSTRAINT IF EXISTS alert_channels_user_id_kind_target_key;
+ALTER TABLE alert_channels ADD CONSTRAINT alert_channels_workspace_id_kind_target_key UNIQUE (workspace_id, kind, target);
+DROP INDEX IF EXISTS uq_alerts_without_ad;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_alerts_without_ad ON alerts (workspace_id, kind, metric, metric_date) WHERE ad_id IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS uq_alerts_without_ad;
+ALTER TABLE alert_channels DROP CONSTRAINT IF EXISTS alert_channels_workspace_id_kind_target_key;
+
+-- алерты без автора — владельцу личного пространства, остальные не восстановить
+UPDATE alerts al SET user_id = w.personal_user_id
+FROM workspaces w WHERE w.workspace_id = al.workspace_id AND al.user_id IS NULL;
+DELETE FROM alerts WHERE user_id IS NULL;
+ALTER TABLE alerts ALTER COLUMN user_id SET NOT NULL;
+
+-- каналы с одинаковым target у одного автора из разных пространств схлопываются
+DELETE FROM alert_channels c USING alert_channels d
+WHERE c.user_id = d.user_id AND c.kind = d.kind AND c.target = d.target AND c.channel_id > d.channel_id;
+ALTER TABLE alert_channels ADD CONSTRAINT alert_channels_user_id_kind_target_key UNIQUE (user_id, kind, target);
+DELETE FROM alerts a USING alerts b
+WHERE a.ad_id IS NULL AND b.ad_id IS NULL AND a.user_id = b.user_id AND a.kind = b.kind
+  AND a.metric = b.metric AND a.metric_date = b.metric_date AND a.alert_id > b.alert_id;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_alerts_without_ad ON alerts (user_id, kind, metric, metric_date) WHERE ad_id IS NULL;
+
+DROP INDEX IF EXISTS idx_webhook_subscriptions_workspace;
+DROP INDEX IF EXISTS idx_alerts_workspace_created;
+DROP INDEX IF EXISTS idx_budgets_workspace;
+DROP INDEX IF EXISTS idx_sieve_rules_workspace;
+
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE alerts                DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE alert_channels        DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE budgets               DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE sieve_rules           DROP COLUMN IF EXISTS workspace_id;
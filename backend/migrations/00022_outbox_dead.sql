@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- Событие, которое не приняли за OUTBOX_MAX_ATTEMPTS попыток, становится dead и больше
+-- не держит очередь своего объявления; следующие события объявления идут дальше.
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'pending'
+  CHECK (status IN ('pending', 'published', 'dead'));
+UPDATE outbox_events SET status = 'published' WHERE published_at IS NOT NULL;
+
+-- relay берёт голову очереди каждого объявления: DISTINCT ON (ad_id) ... ORDER BY ad_id, outbox_id
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending_ad ON outbox_events (ad_id, outbox_id) WHERE status = 'pending';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_outbox_pending_ad;
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox_events (outbox_id) WHERE published_at IS NULL;
+-- dead-события снова ждут публикации
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS status;
@@ -0,0 +1,18 @@
+-- +goose Up
+
+-- API-ключи серверных интеграций (трекер, бэкенд клиента). Ключ действует от имени автора
+-- в своём рабочем пространстве и с его ролью там. Сам ключ не хранится — только SHA-256.
+CREATE TABLE IF NOT EXISTS api_keys (
+  key_id       BIGSERIAL PRIMARY KEY,
+  workspace_id BIGINT      NOT NULL REFERENCES workspaces (workspace_id) ON DELETE CASCADE,
+  user_id      BIGINT      NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+  name         TEXT        NOT NULL,
+  prefix       TEXT        NOT NULL, -- начало ключа, чтобы узнать его в списке
+  key_hash     TEXT        NOT NULL UNIQUE,
+  created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  revoked_at   TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_workspace ON api_keys (workspace_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Секрет HMAC-подписи хранится зашифрованным (привязан к subscription_id).
+-- Открытый secret остаётся только у старых подписок, пока их не перенесёт reencrypt.
+ALTER TABLE webhook_subscriptions ADD COLUMN IF NOT EXISTS secret_enc TEXT;
+ALTER TABLE webhook_subscriptions ALTER COLUMN secret DROP NOT NULL;
+ALTER TABLE webhook_subscriptions ADD CONSTRAINT webhook_subscriptions_secret_present
+  CHECK (secret IS NOT NULL OR secret_enc IS NOT NULL);
+
+-- +goose Down
+-- расшифровать секреты в SQL нельзя: подписки только с зашифрованным секретом удаляются
+ALTER TABLE webhook_subscriptions DROP CONSTRAINT IF EXISTS webhook_subscriptions_secret_present;
+DELETE FROM webhook_subscriptions WHERE secret IS NULL;
+ALTER TABLE webhook_subscriptions ALTER COLUMN secret SET NOT NULL;
+ALTER TABLE webhook_subscriptions DROP COLUMN IF EXISTS secret_enc;
@@ -17,29 +17,19 @@ export function getAds() {
 }
 
 /**
- * Поставить объявление на паузу (пока не реализовано в API, но заглушка есть)
- * PDF Спека: POST /ads/{ad_id}/pause
- * Простая API дока: нет
- * * !!! ВАЖНО: В твоей API-документации НЕТ эндпоинта для паузы. 
- * Я реализую логику вызова, но если эндпоинта нет, он будет возвращать 404.
- * Если он появится, код УЖЕ готов.
+ * Поставить объявление на паузу (на рекламной платформе)
+ * POST /api/ads/{ad_id}/pause → { ad_id, status, changed }
  */
 export function pauseAd(ad_id) {
-  // return API.post(`/api/ads/${ad_id}/pause`);
-  // Временно возвращаем Promise.resolve(), т.к. ручки нет в доке
-  console.warn("API for pauseAd not specified in docs, faking success.");
-  return Promise.resolve();
+  return API.post(`/api/ads/${ad_id}/pause`);
 }
 
 /**
  * Возобновить объявление
- * PDF Спека: POST /ads/{ad_id}/resume
- * Простая API дока: нет
+ * POST /api/ads/{ad_id}/resume → { ad_id, status, changed }
  */
 export function resumeAd(ad_id) {
-  // return API.post(`/api/ads/${ad_id}/resume`);
-  console.warn("API for resumeAd not specified in docs, faking success.");
-  return Promise.resolve();
+  return API.post(`/api/ads/${ad_id}/resume`);
 }
 
 
@@ -72,4 +62,4 @@ export function linkGoogleAccounts(customer_ids) {
 export function syncGoogle(customer_id, date) {
   // date YYYY-MM-DD
   return API.post("/integrations/google/sync", { customer_id, date });
-}
\ No newline at end of file
+}
\ No newline at end of file